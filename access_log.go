@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"shopping/accesslog"
+	"shopping/render"
+	"shopping/repository"
+	"time"
+)
+
+type AccessLogEntry struct {
+	RequestID  string    `json:"request_id"`
+	Username   string    `json:"username"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Status     int32     `json:"status"`
+	DurationMs int32     `json:"duration_ms"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Time       time.Time `json:"time"`
+}
+
+// withAccessLogRoute records the route that served the request in the
+// access log
+func withAccessLogRoute(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accesslog.SetRoute(r.Context(), route)
+		next(w, r)
+	}
+}
+
+// handleListRequests returns the last requests of the access log, the ones
+// of the last second can still be buffered by the instances.
+func (app *App) handleListRequests(w http.ResponseWriter, r *http.Request) {
+	if app.AccessLogRepository == nil {
+		http.Error(w, "the access log is disabled, set ACCESS_LOG=true", http.StatusNotFound)
+		return
+	}
+
+	filter := repository.AccessLogFilter{Username: r.URL.Query().Get("username")}
+
+	var err error
+	filter.Limit, err = intQueryParam(r, "limit", 100, 1000)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("min_status") != "" {
+		filter.MinStatus, err = intQueryParam(r, "min_status", 0, 599)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if since := r.URL.Query().Get("since"); since != "" {
+		filter.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "'since' must be a RFC 3339 time like 2025-01-01T10:00:00Z", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := app.AccessLogRepository.List(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]AccessLogEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, AccessLogEntry{
+			RequestID:  row.RequestID,
+			Username:   row.Username,
+			Method:     row.Method,
+			Route:      row.Route,
+			Status:     row.Status,
+			DurationMs: row.DurationMs,
+			IP:         row.Ip,
+			UserAgent:  row.UserAgent,
+			Time:       row.CreatedAt.Time,
+		})
+	}
+
+	render.JSON(w, http.StatusOK, entries)
+}
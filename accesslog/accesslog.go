@@ -0,0 +1,235 @@
+package accesslog
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"shopping/clientip"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"shopping/requestid"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Stats are published in /debug/vars as access_log: the entries recorded,
+// the ones left out by the sampling, the ones dropped because the buffer was
+// full and the ones deleted by the retention
+var Stats = expvar.NewMap("access_log")
+
+const maxUserAgentLength = 255
+
+type Options struct {
+	// SampleRate keeps 1 of every N requests answered with a 1xx, 2xx or
+	// 3xx, the errors are always kept. 1 keeps every request
+	SampleRate int
+	// Retention is how long the entries are kept, forever when it's 0
+	Retention time.Duration
+	// FlushInterval is the time between the writes of the buffered entries
+	FlushInterval time.Duration
+	// BufferSize is the number of entries waiting to be written, the next
+	// ones are dropped
+	BufferSize int
+}
+
+// Recorder writes a summary of the requests to the access log, the entries
+// are buffered and written in batches so the responses don't wait for the
+// database.
+type Recorder struct {
+	repo    repository.AccessLogRepository
+	opts    Options
+	entries chan db_queries.InsertAccessLogEntriesParams
+	// the successful requests seen, for the sampling
+	seen atomic.Uint64
+}
+
+func NewRecorder(repo repository.AccessLogRepository, opts Options) *Recorder {
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = 1
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+
+	return &Recorder{
+		repo:    repo,
+		opts:    opts,
+		entries: make(chan db_queries.InsertAccessLogEntriesParams, opts.BufferSize),
+	}
+}
+
+// details are filled while the request is served, the middleware only sees
+// its copy of the request
+type details struct {
+	route    string
+	username string
+}
+
+type contextKey struct{}
+
+// SetRoute records the pattern of the route that served the request, like
+// /v1/lists/{id}. The path isn't recorded as it can have tokens
+func SetRoute(ctx context.Context, route string) {
+	if d, ok := ctx.Value(contextKey{}).(*details); ok {
+		d.route = route
+	}
+}
+
+// SetUser records the user of the request
+func SetUser(ctx context.Context, username string) {
+	if d, ok := ctx.Value(contextKey{}).(*details); ok {
+		d.username = username
+	}
+}
+
+// Middleware records the requests, it must wrap the recovery of the panics
+// so they are recorded with their 500
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		d := &details{}
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKey{}, d)))
+
+		if !rec.sampled(rw.status) {
+			Stats.Add("sampled_out", 1)
+			return
+		}
+
+		userAgent := r.UserAgent()
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+
+		rec.Record(db_queries.InsertAccessLogEntriesParams{
+			RequestID:  requestid.FromContext(r.Context()),
+			Username:   d.username,
+			Method:     r.Method,
+			Route:      d.route,
+			Status:     int32(rw.status),
+			DurationMs: int32(time.Since(start).Milliseconds()),
+			Ip:         clientip.FromRequest(r),
+			UserAgent:  userAgent,
+			CreatedAt:  pgtype.Timestamptz{Time: start.UTC(), Valid: true},
+		})
+	})
+}
+
+// sampled tells if the request is kept, the errors always are
+func (rec *Recorder) sampled(status int) bool {
+	if status >= http.StatusBadRequest || rec.opts.SampleRate == 1 {
+		return true
+	}
+
+	return rec.seen.Add(1)%uint64(rec.opts.SampleRate) == 1
+}
+
+// Record buffers the entry, it's dropped when the buffer is full
+func (rec *Recorder) Record(entry db_queries.InsertAccessLogEntriesParams) {
+	select {
+	case rec.entries <- entry:
+	default:
+		Stats.Add("dropped", 1)
+	}
+}
+
+// Run writes the buffered entries and deletes the old ones until the context
+// is done, the entries still buffered are written before it returns
+func (rec *Recorder) Run(ctx context.Context) {
+	flush := time.NewTicker(rec.opts.FlushInterval)
+	defer flush.Stop()
+
+	var prune <-chan time.Time
+	if rec.opts.Retention > 0 {
+		// the retention is counted in hours or days, pruning more often
+		// than the hour doesn't delete much more
+		ticker := time.NewTicker(min(rec.opts.Retention, time.Hour))
+		defer ticker.Stop()
+		prune = ticker.C
+		rec.Prune()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			rec.Flush()
+			return
+		case <-flush.C:
+			rec.Flush()
+		case <-prune:
+			rec.Prune()
+		}
+	}
+}
+
+// Flush writes the buffered entries
+func (rec *Recorder) Flush() {
+	batch := []db_queries.InsertAccessLogEntriesParams{}
+	for done := false; !done; {
+		select {
+		case entry := <-rec.entries:
+			batch = append(batch, entry)
+		default:
+			done = true
+		}
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	err := rec.repo.RecordAll(batch)
+	if err != nil {
+		Stats.Add("dropped", int64(len(batch)))
+		return
+	}
+	Stats.Add("recorded", int64(len(batch)))
+}
+
+// Prune deletes the entries older than the retention
+func (rec *Recorder) Prune() {
+	if rec.opts.Retention <= 0 {
+		return
+	}
+
+	deleted, err := rec.repo.DeleteBefore(time.Now().Add(-rec.opts.Retention))
+	if err != nil {
+		return
+	}
+	if deleted > 0 {
+		Stats.Add("pruned", deleted)
+		log.Debug().Msgf("accesslog: %d entries older than %s deleted", deleted, rec.opts.Retention)
+	}
+}
+
+// responseWriter keeps the status of the response
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap lets http.ResponseController flush the event streams
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
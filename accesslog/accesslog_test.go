@@ -0,0 +1,79 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"shopping/requestid"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMiddleware(t *testing.T) {
+	repo := repository.NewMockAccessLogRepository(gomock.NewController(t))
+	recorder := NewRecorder(repo, Options{SampleRate: 2})
+
+	handler := requestid.Middleware(recorder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r.Context(), "/v1/lists/{id}")
+		SetUser(r.Context(), "user")
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "list not found", http.StatusNotFound)
+		}
+	})))
+
+	// 1 of the 2 successful requests and every error are kept
+	for _, target := range []string{"/v1/lists/1", "/v1/lists/2", "/v1/lists/3?fail=1"} {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("User-Agent", "shopping-ios/2.1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var recorded []db_queries.InsertAccessLogEntriesParams
+	repo.EXPECT().RecordAll(gomock.Any()).DoAndReturn(func(entries []db_queries.InsertAccessLogEntriesParams) error {
+		recorded = entries
+		return nil
+	})
+	recorder.Flush()
+
+	assert.Len(t, recorded, 2)
+	assert.Equal(t, []int32{200, 404}, []int32{recorded[0].Status, recorded[1].Status})
+	for _, entry := range recorded {
+		assert.Equal(t, "/v1/lists/{id}", entry.Route)
+		assert.Equal(t, "user", entry.Username)
+		assert.Equal(t, "203.0.113.7", entry.Ip)
+		assert.Equal(t, "shopping-ios/2.1", entry.UserAgent)
+		assert.NotEmpty(t, entry.RequestID)
+	}
+
+	// nothing is written when the buffer is empty
+	recorder.Flush()
+}
+
+func TestRecordDropsWhenTheBufferIsFull(t *testing.T) {
+	repo := repository.NewMockAccessLogRepository(gomock.NewController(t))
+	recorder := NewRecorder(repo, Options{BufferSize: 1})
+
+	recorder.Record(db_queries.InsertAccessLogEntriesParams{RequestID: "first"})
+	recorder.Record(db_queries.InsertAccessLogEntriesParams{RequestID: "second"})
+
+	repo.EXPECT().RecordAll([]db_queries.InsertAccessLogEntriesParams{{RequestID: "first"}}).Return(nil)
+	recorder.Flush()
+}
+
+func TestPrune(t *testing.T) {
+	repo := repository.NewMockAccessLogRepository(gomock.NewController(t))
+	repo.EXPECT().DeleteBefore(gomock.Any()).DoAndReturn(func(before time.Time) (int64, error) {
+		assert.WithinDuration(t, time.Now().Add(-72*time.Hour), before, time.Second)
+		return 3, nil
+	})
+
+	NewRecorder(repo, Options{Retention: 72 * time.Hour}).Prune()
+
+	// without retention the entries are kept
+	NewRecorder(repo, Options{}).Prune()
+}
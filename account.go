@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/export"
+	"shopping/portable"
+	"shopping/quota"
+	"shopping/render"
+	"shopping/repository"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+const onConflictSkip = "skip"
+
+const (
+	importStatusCreated = "created"
+	importStatusRenamed = "renamed"
+	importStatusMerged  = "merged"
+)
+
+const (
+	preferencesImported  = "imported"
+	preferencesUnchanged = "unchanged"
+	preferencesKept      = "kept"
+	preferencesMissing   = "missing"
+)
+
+// notMovedData is what an account move never brings, it's sent in every
+// import report so the users know what to do again.
+var notMovedData = []string{
+	"share links: they are signed by the source deployment, create them again",
+	"history and stats: the completions belong to the source deployment",
+}
+
+type AccountImportReport struct {
+	Source         string           `json:"source"`
+	SourceUsername string           `json:"source_username"`
+	Lists          []ImportedList   `json:"lists"`
+	Conflicts      []ImportConflict `json:"conflicts"`
+	// imported, unchanged, kept (the user already changed them) or missing
+	Preferences string   `json:"preferences"`
+	NotMoved    []string `json:"not_moved"`
+}
+
+type ImportedList struct {
+	SourceID string `json:"source_id"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"` // created, renamed or merged
+}
+
+type ImportConflict struct {
+	SourceID   string `json:"source_id"`
+	Name       string `json:"name"`
+	ExistingID string `json:"existing_id"`
+	Reason     string `json:"reason"`
+}
+
+// handleExportAccountBundle returns the signed bundle with the lists and the
+// preferences of the user, it's imported in the destination deployment.
+func (app *App) handleExportAccountBundle(w http.ResponseWriter, r *http.Request) {
+	secret, ok := app.accountMoveSecret(w)
+	if !ok {
+		return
+	}
+
+	user := currentUser(r)
+
+	lists, err := app.ShoppingListRepository.GetShoppingListsByOwner(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	prefs, err := app.UserPreferencesRepository.GetUserPreferences(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	account := &portable.Account{
+		Format:     portable.AccountFormatName,
+		Version:    portable.AccountVersion,
+		ExportedAt: time.Now().UTC(),
+		Source:     app.publicURL(r),
+		Username:   user.Username,
+		Preferences: &portable.Preferences{
+			Timezone:       prefs.Timezone,
+			Locale:         prefs.Locale,
+			FirstDayOfWeek: strings.ToLower(time.Weekday(prefs.FirstDayOfWeek).String()),
+		},
+		Lists: make([]portable.List, 0, len(lists)),
+	}
+
+	for i := range lists {
+		account.Lists = append(account.Lists, portableList(&lists[i]))
+	}
+
+	signed, err := portable.SignAccount(secret, account)
+	if err != nil {
+		http.Error(w, "failed to sign the account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(user.Username+"-account", "json")))
+	w.Header().Set("Cache-Control", "no-store")
+
+	render.JSON(w, http.StatusOK, signed)
+}
+
+// handleImportAccountBundle imports a bundle of another deployment in the
+// account of the current user, everything is imported in one transaction.
+// The lists whose name is taken are reported as conflicts unless the
+// `on_conflict` query param says to rename or merge them.
+func (app *App) handleImportAccountBundle(w http.ResponseWriter, r *http.Request) {
+	secret, ok := app.accountMoveSecret(w)
+	if !ok {
+		return
+	}
+
+	mode := r.URL.Query().Get("on_conflict")
+	if mode == "" {
+		mode = onConflictSkip
+	}
+
+	if mode != onConflictSkip && mode != onConflictRename && mode != onConflictMerge {
+		http.Error(w, "'on_conflict' must be skip, rename or merge", http.StatusBadRequest)
+		return
+	}
+
+	var signed portable.SignedAccount
+	err := json.NewDecoder(r.Body).Decode(&signed)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	account, err := portable.OpenAccount(secret, &signed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var prefs *db_queries.SaveUserPreferencesParams
+	user := currentUser(r)
+	if account.Preferences != nil {
+		prefs, err = preferencesParams(user.Username, account.Preferences)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	var report AccountImportReport
+	var changed []string
+	err = app.UnitOfWork.Do(func(repos repository.Repositories) error {
+		report = AccountImportReport{
+			Source:         account.Source,
+			SourceUsername: account.Username,
+			Lists:          []ImportedList{},
+			Conflicts:      []ImportConflict{},
+			Preferences:    preferencesMissing,
+			NotMoved:       notMovedData,
+		}
+
+		lists := newAccountImport(repos, user.Username, mode)
+		lists.limits = app.userQuotas(user.Username, user.TenantID)
+		for _, list := range account.Lists {
+			err := lists.add(list)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := lists.createPending()
+		if err != nil {
+			return err
+		}
+		report.Lists = lists.lists
+		report.Conflicts = lists.conflicts
+		changed = lists.changed
+
+		if prefs != nil {
+			report.Preferences, err = importPreferences(repos, *prefs)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if quotaError(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, id := range changed {
+		app.listChanged(id)
+	}
+
+	if report.Preferences == preferencesImported {
+		app.invalidateStats(user.Username)
+	}
+
+	render.JSON(w, http.StatusOK, report)
+}
+
+func (app *App) accountMoveSecret(w http.ResponseWriter) ([]byte, bool) {
+	if app.Config == nil {
+		http.Error(w, "account moves are disabled in this deployment", http.StatusNotFound)
+		return nil, false
+	}
+
+	secret := app.secret("ACCOUNT_MOVE_SECRET", app.Config.AccountMoveSecret)
+	if secret == "" {
+		http.Error(w, "account moves are disabled in this deployment", http.StatusNotFound)
+		return nil, false
+	}
+
+	return []byte(secret), true
+}
+
+// accountImport resolves the name conflicts of the lists of a bundle one by
+// one and creates the new lists together at the end, so a bundle with
+// hundreds of lists doesn't send an insert for each of them. The lists
+// waiting to be created count as taken names for the next ones.
+type accountImport struct {
+	repos repository.Repositories
+	owner string
+	mode  string
+	// the quotas of the owner, none when they are zero
+	limits quota.Limits
+
+	lists     []ImportedList
+	conflicts []ImportConflict
+	changed   []string
+
+	pending []repository.NewShoppingList
+	// the index in pending of the lowercase names
+	pendingNames map[string]int
+	// the reported lists and conflicts of a pending list, by their index,
+	// they get its id once it's created
+	pendingLists     map[int]int
+	pendingConflicts map[int]int
+}
+
+func newAccountImport(repos repository.Repositories, owner string, mode string) *accountImport {
+	return &accountImport{
+		repos:            repos,
+		owner:            owner,
+		mode:             mode,
+		lists:            []ImportedList{},
+		conflicts:        []ImportConflict{},
+		changed:          []string{},
+		pendingNames:     map[string]int{},
+		pendingLists:     map[int]int{},
+		pendingConflicts: map[int]int{},
+	}
+}
+
+func (imp *accountImport) add(list portable.List) error {
+	similar, err := imp.repos.ShoppingLists.FindListNameConflicts(imp.owner, list.Name)
+	if err != nil {
+		return err
+	}
+
+	var existing *db_queries.FindListNameConflictsRow
+	taken := make([]string, 0, len(similar)+len(imp.pending))
+	for i, row := range similar {
+		taken = append(taken, row.Name)
+		if existing == nil && strings.EqualFold(row.Name, list.Name) {
+			existing = &similar[i]
+		}
+	}
+	for _, pending := range imp.pending {
+		taken = append(taken, pending.Name)
+	}
+	pendingIndex, pendingTaken := imp.pendingNames[strings.ToLower(list.Name)]
+
+	name := list.Name
+	status := importStatusCreated
+	if existing != nil || pendingTaken {
+		switch imp.mode {
+		case onConflictRename:
+			name = suggestListNames(list.Name, taken, 1)[0]
+			status = importStatusRenamed
+		case onConflictMerge:
+			if existing != nil {
+				merged, err := mergeImportedList(imp.repos, imp.limits, imp.owner, existing.ID.String(), list)
+				if err != nil {
+					return err
+				}
+				imp.lists = append(imp.lists, *merged)
+				imp.changed = append(imp.changed, merged.ID)
+				return nil
+			}
+
+			pending := &imp.pending[pendingIndex]
+			pending.Items = append(pending.Items, missingItems(pending.Items, list.ItemNames())...)
+			imp.pendingLists[len(imp.lists)] = pendingIndex
+			imp.lists = append(imp.lists, ImportedList{SourceID: list.Metadata.SourceID, Name: pending.Name, Status: importStatusMerged})
+			return nil
+		default:
+			conflict := ImportConflict{SourceID: list.Metadata.SourceID, Name: list.Name}
+			if existing != nil {
+				conflict.ExistingID = existing.ID.String()
+				conflict.Reason = fmt.Sprintf("a list named '%s' already exists", existing.Name)
+			} else {
+				imp.pendingConflicts[len(imp.conflicts)] = pendingIndex
+				conflict.Reason = fmt.Sprintf("a list named '%s' already exists", imp.pending[pendingIndex].Name)
+			}
+			imp.conflicts = append(imp.conflicts, conflict)
+			return nil
+		}
+	}
+
+	imp.pendingNames[strings.ToLower(name)] = len(imp.pending)
+	imp.pendingLists[len(imp.lists)] = len(imp.pending)
+	imp.pending = append(imp.pending, repository.NewShoppingList{
+		Owner: imp.owner,
+		Name:  name,
+		Items: list.ItemNames(),
+		Tags:  list.Tags,
+	})
+	imp.lists = append(imp.lists, ImportedList{SourceID: list.Metadata.SourceID, Name: name, Status: status})
+
+	return nil
+}
+
+// createPending creates the pending lists and records their events, the
+// reported lists and conflicts get their ids
+func (imp *accountImport) createPending() error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+
+	created, err := imp.repos.ShoppingLists.CreateShoppingLists(imp.pending)
+	if err != nil {
+		return err
+	}
+
+	for i := range created {
+		// the lists are counted once, after the last one
+		err = checkListQuotas(imp.repos, imp.limits, &created[i], i == len(created)-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	for listIndex, pendingIndex := range imp.pendingLists {
+		imp.lists[listIndex].ID = created[pendingIndex].ID.String()
+	}
+	for conflictIndex, pendingIndex := range imp.pendingConflicts {
+		imp.conflicts[conflictIndex].ExistingID = created[pendingIndex].ID.String()
+	}
+	for i := range created {
+		imp.changed = append(imp.changed, created[i].ID.String())
+	}
+
+	return recordListEvents(imp.repos, imp.owner, eventListCreated, created)
+}
+
+func mergeImportedList(repos repository.Repositories, limits quota.Limits, owner string, id string, list portable.List) (*ImportedList, error) {
+	current, err := repos.ShoppingLists.GetShoppingListByIDForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := &ImportedList{
+		SourceID: list.Metadata.SourceID,
+		ID:       id,
+		Name:     current.Name,
+		Status:   importStatusMerged,
+	}
+
+	missing := missingItems(current.Items, list.ItemNames())
+	if len(missing) == 0 {
+		return imported, nil
+	}
+
+	merged, err := repos.ShoppingLists.AppendItemsToShoppingList(id, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkListQuotas(repos, limits, merged, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, recordListEvent(repos, owner, eventListItemAdded, id, merged)
+}
+
+// importPreferences saves the preferences unless the user already changed
+// theirs in this deployment.
+func importPreferences(repos repository.Repositories, prefs db_queries.SaveUserPreferencesParams) (string, error) {
+	current, err := repos.UserPreferences.GetUserPreferences(prefs.Username)
+	if err != nil {
+		return "", err
+	}
+
+	if samePreferences(current, prefs) {
+		return preferencesUnchanged, nil
+	}
+
+	defaults := repository.DefaultUserPreferences(prefs.Username)
+	if !samePreferences(current, db_queries.SaveUserPreferencesParams{
+		Timezone:       defaults.Timezone,
+		Locale:         defaults.Locale,
+		FirstDayOfWeek: defaults.FirstDayOfWeek,
+	}) {
+		return preferencesKept, nil
+	}
+
+	_, err = repos.UserPreferences.SaveUserPreferences(prefs)
+	if err != nil {
+		return "", err
+	}
+
+	return preferencesImported, nil
+}
+
+func samePreferences(current *db_queries.UserPreference, prefs db_queries.SaveUserPreferencesParams) bool {
+	return current.Timezone == prefs.Timezone && current.Locale == prefs.Locale && current.FirstDayOfWeek == prefs.FirstDayOfWeek
+}
+
+// preferencesParams validates the preferences of a bundle with the same
+// rules of the preferences endpoint.
+func preferencesParams(username string, prefs *portable.Preferences) (*db_queries.SaveUserPreferencesParams, error) {
+	loc, err := parseTimezone(prefs.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := language.Parse(prefs.Locale)
+	if err != nil {
+		return nil, errors.New("'preferences.locale' must be a BCP 47 language tag like en-US")
+	}
+
+	day, ok := parseWeekday(prefs.FirstDayOfWeek)
+	if !ok {
+		return nil, errors.New("'preferences.first_day_of_week' must be a day name like monday")
+	}
+
+	return &db_queries.SaveUserPreferencesParams{
+		Username:       username,
+		Timezone:       loc.String(),
+		Locale:         tag.String(),
+		FirstDayOfWeek: int16(day),
+	}, nil
+}
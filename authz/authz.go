@@ -0,0 +1,146 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionListCreate Action = "lists:create"
+	ActionListRead   Action = "lists:read"
+	ActionListUpdate Action = "lists:update"
+	ActionListDelete Action = "lists:delete"
+	// reading the soft deleted lists, only for admins by default
+	ActionListReadDeleted Action = "lists:read_deleted"
+
+	ActionListComplete Action = "lists:complete"
+	ActionListExport   Action = "lists:export"
+	ActionListShare    Action = "lists:share"
+	ActionStatsRead    Action = "stats:read"
+	ActionItemsSuggest Action = "items:suggest"
+	ActionListSearch   Action = "lists:search"
+	ActionListRemind   Action = "lists:remind"
+	// creating and changing the stores of the user and their aisles
+	ActionStoresManage Action = "stores:manage"
+	// looking up the products of the barcodes
+	ActionProductsLookup Action = "products:lookup"
+
+	ActionPreferencesRead   Action = "preferences:read"
+	ActionPreferencesUpdate Action = "preferences:update"
+
+	// exporting and importing the signed bundles that move an account
+	// between deployments
+	ActionAccountMove Action = "account:move"
+
+	// exporting everything stored about the own account and deleting it
+	ActionAccountData Action = "account:data"
+
+	// changing the own password, only the users of the database have one
+	ActionAccountPassword Action = "account:password"
+
+	// signing the URLs that give access to a route without a session, the
+	// route is still authorized for the user when the URL is used
+	ActionSignedURLCreate Action = "signed_urls:create"
+
+	// the runtime metrics of /debug/vars, only for admins by default
+	ActionMetricsRead Action = "metrics:read"
+
+	// deleting the data of every user of a sandbox deployment, only for
+	// admins by default
+	ActionSandboxReset Action = "sandbox:reset"
+
+	// rebuilding the search index, only for admins by default
+	ActionSearchRebuild Action = "search:rebuild"
+
+	// running the consistency checks of the derived data and reading their
+	// reports, only for admins by default
+	ActionConsistencyCheck Action = "consistency:check"
+
+	// the build, config summary and migration version of the instance,
+	// only for admins by default
+	ActionRuntimeRead Action = "runtime:read"
+
+	// flushing the caches of the instance, only for admins by default
+	ActionCacheFlush Action = "cache:flush"
+
+	// reading the access log of the API, only for admins by default
+	ActionRequestsRead Action = "requests:read"
+
+	// backing up the data of every user and restoring it into an empty
+	// database, only for admins by default
+	ActionBackupsManage Action = "backups:manage"
+
+	// listing and rotating the keys of the share links and the signed URLs,
+	// only for admins by default
+	ActionSigningKeysManage Action = "signing_keys:manage"
+
+	// reading and changing the quotas of the users and the tenants, only
+	// for admins by default
+	ActionQuotasManage Action = "quotas:manage"
+)
+
+type Subject struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type Resource struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+// Request is the context handed to the policy engine, it's the same shape
+// for every engine so policies can be moved between them.
+type Request struct {
+	Subject  Subject  `json:"subject"`
+	Action   Action   `json:"action"`
+	Resource Resource `json:"resource"`
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req Request) (bool, error)
+}
+
+const (
+	EngineBuiltin = "builtin"
+	EngineOPA     = "opa"
+)
+
+type Options struct {
+	Engine     string
+	PolicyFile string
+	OPAUrl     string
+}
+
+var ErrUnknownEngine = errors.New("authz: unknown policy engine")
+
+// New returns the authorizer selected by the engine name, when no engine is
+// configured we fallback to the builtin engine with the default policy.
+func New(opts Options) (Authorizer, error) {
+	switch opts.Engine {
+	case "", EngineBuiltin:
+		if opts.PolicyFile == "" {
+			return NewPolicyAuthorizer(DefaultPolicy()), nil
+		}
+
+		policy, err := LoadPolicyFile(opts.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPolicyAuthorizer(policy), nil
+	case EngineOPA:
+		if opts.OPAUrl == "" {
+			return nil, errors.New("authz: the opa engine requires an url")
+		}
+
+		return NewOPAAuthorizer(opts.OPAUrl, &http.Client{Timeout: 2 * time.Second}), nil
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownEngine, opts.Engine)
+	}
+}
@@ -0,0 +1,67 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// OPAAuthorizer asks an OPA server for the decision, the url must point to
+// the rule document, e.g. http://localhost:8181/v1/data/shopping/allow
+type OPAAuthorizer struct {
+	url    string
+	client *http.Client
+}
+
+func NewOPAAuthorizer(url string, client *http.Client) *OPAAuthorizer {
+	return &OPAAuthorizer{
+		url:    url,
+		client: client,
+	}
+}
+
+type opaRequest struct {
+	Input Request `json:"input"`
+}
+
+type opaResponse struct {
+	Result *bool `json:"result"`
+}
+
+func (a *OPAAuthorizer) Authorize(ctx context.Context, req Request) (bool, error) {
+	body, err := json.Marshal(opaRequest{Input: req})
+	if err != nil {
+		return false, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return false, fmt.Errorf("authz: opa request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("authz: opa responded with status %d", resp.StatusCode)
+	}
+
+	var data opaResponse
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		return false, fmt.Errorf("authz: invalid opa response: %w", err)
+	}
+
+	// an undefined result means that no rule matched, so we deny
+	if data.Result == nil {
+		return false, nil
+	}
+
+	return *data.Result, nil
+}
@@ -0,0 +1,118 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+type Effect string
+
+const (
+	EffectPermit Effect = "permit"
+	EffectForbid Effect = "forbid"
+)
+
+// Rule follows the cedar semantics: a request is allowed only when at least
+// one permit rule matches and no forbid rule matches.
+type Rule struct {
+	Effect  Effect   `json:"effect"`
+	Roles   []string `json:"roles"`
+	Actions []Action `json:"actions"`
+}
+
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// DefaultPolicy keeps the behaviour we had before the policy engine: admins
+// can do everything and regular users can only read, create, complete,
+// export, share and set reminders of lists, see their own stats, get item
+// suggestions, look up products, manage their stores and preferences and
+// change the password of, move, export and delete their account and sign
+// URLs.
+func DefaultPolicy() Policy {
+	return Policy{
+		Rules: []Rule{
+			{Effect: EffectPermit, Roles: []string{"admin"}, Actions: []Action{"*"}},
+			{Effect: EffectPermit, Roles: []string{"user"}, Actions: []Action{
+				ActionListRead,
+				ActionListCreate,
+				ActionListComplete,
+				ActionListExport,
+				ActionListShare,
+				ActionListRemind,
+				ActionStatsRead,
+				ActionItemsSuggest,
+				ActionProductsLookup,
+				ActionStoresManage,
+				ActionListSearch,
+				ActionPreferencesRead,
+				ActionPreferencesUpdate,
+				ActionAccountMove,
+				ActionAccountData,
+				ActionAccountPassword,
+				ActionSignedURLCreate,
+			}},
+		},
+	}
+}
+
+func LoadPolicyFile(path string) (Policy, error) {
+	var policy Policy
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("authz: cannot read the policy file '%s': %w", path, err)
+	}
+
+	err = json.Unmarshal(data, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("authz: invalid policy file '%s': %w", path, err)
+	}
+
+	for i, rule := range policy.Rules {
+		if rule.Effect != EffectPermit && rule.Effect != EffectForbid {
+			return policy, fmt.Errorf("authz: rule %d has an invalid effect '%s'", i, rule.Effect)
+		}
+	}
+
+	return policy, nil
+}
+
+type PolicyAuthorizer struct {
+	policy Policy
+}
+
+func NewPolicyAuthorizer(policy Policy) *PolicyAuthorizer {
+	return &PolicyAuthorizer{
+		policy: policy,
+	}
+}
+
+func (a *PolicyAuthorizer) Authorize(ctx context.Context, req Request) (bool, error) {
+	permitted := false
+
+	for _, rule := range a.policy.Rules {
+		if !rule.matches(req) {
+			continue
+		}
+
+		if rule.Effect == EffectForbid {
+			return false, nil
+		}
+
+		permitted = true
+	}
+
+	return permitted, nil
+}
+
+func (r Rule) matches(req Request) bool {
+	roleMatch := slices.Contains(r.Roles, "*") || slices.Contains(r.Roles, req.Subject.Role)
+	actionMatch := slices.Contains(r.Actions, "*") || slices.Contains(r.Actions, req.Action)
+
+	return roleMatch && actionMatch
+}
@@ -0,0 +1,66 @@
+package authz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	authorizer := NewPolicyAuthorizer(DefaultPolicy())
+
+	tests := []struct {
+		role    string
+		action  Action
+		allowed bool
+	}{
+		{"admin", ActionListDelete, true},
+		{"admin", ActionListUpdate, true},
+		{"user", ActionListRead, true},
+		{"user", ActionListCreate, true},
+		{"user", ActionListUpdate, false},
+		{"user", ActionListDelete, false},
+		{"admin", ActionListReadDeleted, true},
+		{"user", ActionListReadDeleted, false},
+		{"unknown", ActionListRead, false},
+	}
+
+	for _, tt := range tests {
+		allowed, err := authorizer.Authorize(context.Background(), Request{
+			Subject: Subject{Username: "test", Role: tt.role},
+			Action:  tt.action,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, tt.allowed, allowed, "role '%s' action '%s'", tt.role, tt.action)
+	}
+}
+
+func TestForbidOverridesPermit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(path, []byte(`{
+		"rules": [
+			{"effect": "permit", "roles": ["editor"], "actions": ["*"]},
+			{"effect": "forbid", "roles": ["editor"], "actions": ["lists:delete"]}
+		]
+	}`), 0o600)
+	assert.NoError(t, err)
+
+	authorizer, err := New(Options{Engine: EngineBuiltin, PolicyFile: path})
+	assert.NoError(t, err)
+
+	allowed, _ := authorizer.Authorize(context.Background(), Request{
+		Subject: Subject{Role: "editor"},
+		Action:  ActionListUpdate,
+	})
+	assert.True(t, allowed)
+
+	allowed, _ = authorizer.Authorize(context.Background(), Request{
+		Subject: Subject{Role: "editor"},
+		Action:  ActionListDelete,
+	})
+	assert.False(t, allowed)
+}
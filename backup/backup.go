@@ -0,0 +1,123 @@
+// Package backup dumps the data of the users to a compressed JSON snapshot
+// and restores it into an empty database.
+package backup
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatName = "shopping-backup"
+	// Version must be increased when the layout of the snapshot changes, the
+	// columns of the tables follow the migration version instead
+	Version = 1
+
+	ContentType = "application/gzip"
+
+	// MaxBytes limits the uncompressed size of the snapshots that are
+	// restored, a small file can't expand forever
+	MaxBytes = 1 << 30
+)
+
+var (
+	ErrInvalid            = errors.New("backup: invalid snapshot")
+	ErrUnsupportedVersion = errors.New("backup: unsupported version")
+)
+
+// Table is a table of the snapshot, the rows are the JSON objects of
+// row_to_json so they are restored without knowing their columns
+type Table struct {
+	Name string            `json:"name"`
+	Rows []json.RawMessage `json:"rows"`
+}
+
+// Snapshot is the content of a backup, the tables are in the order they are
+// restored
+type Snapshot struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// MigrationVersion is the schema of the rows, they are only restored
+	// in a database at the same version
+	MigrationVersion uint    `json:"migration_version"`
+	Tables           []Table `json:"tables"`
+}
+
+// Rows is the number of rows of every table
+func (s *Snapshot) Rows() int {
+	total := 0
+	for _, table := range s.Tables {
+		total += len(table.Rows)
+	}
+
+	return total
+}
+
+func (s *Snapshot) Validate() error {
+	if s.Format != FormatName {
+		return fmt.Errorf("%w: 'format' must be '%s'", ErrInvalid, FormatName)
+	}
+
+	if s.Version < 1 || s.Version > Version {
+		return fmt.Errorf("%w %d, the supported versions are 1 to %d", ErrUnsupportedVersion, s.Version, Version)
+	}
+
+	seen := map[string]bool{}
+	for _, table := range s.Tables {
+		if _, ok := lookupTable(table.Name); !ok {
+			return fmt.Errorf("%w: unknown table '%s'", ErrInvalid, table.Name)
+		}
+		if seen[table.Name] {
+			return fmt.Errorf("%w: the table '%s' is repeated", ErrInvalid, table.Name)
+		}
+		seen[table.Name] = true
+	}
+
+	return nil
+}
+
+// Encode writes the snapshot as gzipped JSON
+func Encode(w io.Writer, snapshot *Snapshot) error {
+	zw := gzip.NewWriter(w)
+	err := json.NewEncoder(zw).Encode(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Decode reads and validates a snapshot written by Encode
+func Decode(r io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if len(data) > MaxBytes {
+		return nil, fmt.Errorf("%w: it's larger than %d bytes", ErrInvalid, MaxBytes)
+	}
+
+	var snapshot Snapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	err = snapshot.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
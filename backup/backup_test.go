@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	snapshot := &Snapshot{
+		Format:           FormatName,
+		Version:          Version,
+		CreatedAt:        time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC),
+		MigrationVersion: 22,
+		Tables: []Table{
+			{Name: "users", Rows: []json.RawMessage{json.RawMessage(`{"username":"alice","role":"user"}`)}},
+			{Name: "shopping_lists", Rows: []json.RawMessage{json.RawMessage(`{"name":"Groceries","items":["milk"]}`), json.RawMessage(`{"name":"Party","items":[]}`)}},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(&buf, snapshot))
+
+	decoded, err := Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, decoded)
+	assert.Equal(t, 3, decoded.Rows())
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	encode := func(snapshot Snapshot) *bytes.Buffer {
+		var buf bytes.Buffer
+		assert.NoError(t, Encode(&buf, &snapshot))
+		return &buf
+	}
+
+	_, err := Decode(bytes.NewBufferString(`{"format":"shopping-backup"}`))
+	assert.ErrorIs(t, err, ErrInvalid, "not gzipped")
+
+	var plain bytes.Buffer
+	zw := gzip.NewWriter(&plain)
+	zw.Write([]byte(`not json`))
+	zw.Close()
+	_, err = Decode(&plain)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Decode(encode(Snapshot{Format: "shopping-list", Version: 1}))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Decode(encode(Snapshot{Format: FormatName, Version: Version + 1}))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	// the table names end in the SQL, only the known ones are accepted
+	_, err = Decode(encode(Snapshot{Format: FormatName, Version: Version, Tables: []Table{{Name: "users; DROP TABLE users"}}}))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Decode(encode(Snapshot{Format: FormatName, Version: Version, Tables: []Table{{Name: "users"}, {Name: "users"}}}))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Decode(encode(Snapshot{Format: FormatName, Version: Version, Tables: []Table{{Name: "sessions"}}}))
+	assert.ErrorIs(t, err, ErrInvalid, "the sessions aren't restored")
+}
+
+func TestJSONArray(t *testing.T) {
+	assert.Equal(t, `[]`, jsonArray(nil))
+	assert.Equal(t, `[{"a":1},{"b":2}]`, jsonArray([]json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`{"b":2}`)}))
+}
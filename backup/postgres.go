@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrNotEmpty = errors.New("backup: the database isn't empty")
+
+// restoreBatchSize is the number of rows of each insert
+const restoreBatchSize = 500
+
+type table struct {
+	name string
+	// onConflict is set for the tables filled by the migrations, their rows
+	// are merged with the ones of the snapshot instead of being refused
+	onConflict string
+}
+
+// tables are the data of the users, in the order of their foreign keys. The
+// sessions, the outbox, the pending notifications and the access log are
+// transient, the search documents are rebuilt by the trigger of the lists
+// and the signing keys are secrets, so they aren't part of the snapshots
+var tables = []table{
+	{name: "tenants", onConflict: "ON CONFLICT (id) DO UPDATE SET slug = EXCLUDED.slug, name = EXCLUDED.name, created_at = EXCLUDED.created_at"},
+	{name: "users"},
+	{name: "account_deletions"},
+	{name: "user_preferences"},
+	{name: "notification_preferences"},
+	{name: "common_items", onConflict: "ON CONFLICT (name) DO NOTHING"},
+	{name: "shopping_lists"},
+	{name: "list_completions"},
+	{name: "purchase_history"},
+	{name: "audit_events"},
+	{name: "reminders"},
+	{name: "stores"},
+	{name: "shopping_list_stores"},
+	{name: "store_prices"},
+	{name: "item_photos"},
+	{name: "quotas"},
+}
+
+func lookupTable(name string) (table, bool) {
+	for _, t := range tables {
+		if t.name == name {
+			return t, true
+		}
+	}
+
+	return table{}, false
+}
+
+// DB is the database of the backups, a *pgxpool.Pool
+type DB interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Dump reads every table in a single repeatable read transaction, so the
+// snapshot is consistent while the API keeps writing
+func Dump(ctx context.Context, db DB, migrationVersion uint) (*Snapshot, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("backup: error to begin the transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	snapshot := &Snapshot{
+		Format:           FormatName,
+		Version:          Version,
+		CreatedAt:        time.Now().UTC(),
+		MigrationVersion: migrationVersion,
+		Tables:           make([]Table, 0, len(tables)),
+	}
+	for _, t := range tables {
+		rows, err := tx.Query(ctx, fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t", t.name))
+		if err != nil {
+			return nil, fmt.Errorf("backup: error to read the table %s: %w", t.name, err)
+		}
+
+		dumped, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (json.RawMessage, error) {
+			var data string
+			err := row.Scan(&data)
+			return json.RawMessage(data), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("backup: error to read the table %s: %w", t.name, err)
+		}
+
+		snapshot.Tables = append(snapshot.Tables, Table{Name: t.name, Rows: dumped})
+	}
+
+	return snapshot, nil
+}
+
+// Restore inserts the rows of the snapshot in a single transaction, nothing
+// is restored when it fails. The tables of the users must be empty, it
+// returns ErrNotEmpty otherwise. progress is called after each batch with
+// the rows restored so far of the table
+func Restore(ctx context.Context, db DB, snapshot *Snapshot, progress func(table string, restored int)) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("backup: error to begin the transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	err = checkEmpty(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	// the snapshot was validated, its tables are known
+	for _, data := range snapshot.Tables {
+		t, _ := lookupTable(data.Name)
+		query := fmt.Sprintf("INSERT INTO %s SELECT * FROM json_populate_recordset(NULL::%s, $1::json) %s", t.name, t.name, t.onConflict)
+
+		for start := 0; start < len(data.Rows); start += restoreBatchSize {
+			end := min(start+restoreBatchSize, len(data.Rows))
+
+			_, err := tx.Exec(ctx, query, jsonArray(data.Rows[start:end]))
+			if err != nil {
+				return fmt.Errorf("backup: error to restore the table %s: %w", t.name, err)
+			}
+
+			if progress != nil {
+				progress(t.name, end)
+			}
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("backup: error to commit the restore: %w", err)
+	}
+
+	return nil
+}
+
+// CheckEmpty returns ErrNotEmpty when a table of the users has rows, the
+// tables filled by the migrations can have them
+func CheckEmpty(ctx context.Context, db DB) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("backup: error to begin the transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	return checkEmpty(ctx, tx)
+}
+
+func checkEmpty(ctx context.Context, tx pgx.Tx) error {
+	for _, t := range tables {
+		if t.onConflict != "" {
+			continue
+		}
+
+		var exists bool
+		err := tx.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", t.name)).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("backup: error to read the table %s: %w", t.name, err)
+		}
+		if exists {
+			return fmt.Errorf("%w: the table %s has rows", ErrNotEmpty, t.name)
+		}
+	}
+
+	return nil
+}
+
+func jsonArray(rows []json.RawMessage) string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(row)
+	}
+	buf.WriteByte(']')
+
+	return buf.String()
+}
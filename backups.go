@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"shopping/backup"
+	"shopping/blob"
+	"shopping/render"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BackupStatus is the state of the last backup or restore made by this
+// instance
+type BackupStatus struct {
+	Running    bool       `json:"running"`
+	Key        string     `json:"key,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Rows are the rows backed up, or the rows of the backup to restore
+	Rows      int                 `json:"rows"`
+	SizeBytes int                 `json:"size_bytes,omitempty"`
+	Restored  int                 `json:"restored,omitempty"`
+	Tables    []BackupTableStatus `json:"tables,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// BackupTableStatus is the progress of a table of a restore
+type BackupTableStatus struct {
+	Name     string `json:"name"`
+	Rows     int    `json:"rows"`
+	Restored int    `json:"restored"`
+}
+
+type RestoreRequest struct {
+	Key string `json:"key"`
+}
+
+// backupJob runs a single backup or restore at a time
+type backupJob struct {
+	mu     sync.Mutex
+	status BackupStatus
+}
+
+func (bj *backupJob) Status() BackupStatus {
+	bj.mu.Lock()
+	defer bj.mu.Unlock()
+
+	status := bj.status
+	status.Tables = slices.Clone(bj.status.Tables)
+	return status
+}
+
+func (bj *backupJob) update(fn func(status *BackupStatus)) {
+	bj.mu.Lock()
+	defer bj.mu.Unlock()
+
+	fn(&bj.status)
+}
+
+// start runs the job in the background with the initial status, it returns
+// false when another one is running
+func (bj *backupJob) start(status BackupStatus, run func(ctx context.Context) error) (BackupStatus, bool) {
+	bj.mu.Lock()
+	defer bj.mu.Unlock()
+
+	if bj.status.Running {
+		running := bj.status
+		running.Tables = slices.Clone(bj.status.Tables)
+		return running, false
+	}
+
+	startedAt := time.Now().UTC()
+	status.Running = true
+	status.StartedAt = &startedAt
+	bj.status = status
+
+	go func() {
+		err := run(context.Background())
+
+		bj.update(func(status *BackupStatus) {
+			finishedAt := time.Now().UTC()
+			status.Running = false
+			status.FinishedAt = &finishedAt
+			if err != nil {
+				status.Error = err.Error()
+			}
+		})
+	}()
+
+	status.Tables = slices.Clone(status.Tables)
+	return status, true
+}
+
+// migrationVersion is the version of the schema of the rows, 0 in the tests
+func (app *App) migrationVersion(ctx context.Context) (uint, error) {
+	if app.Migrator == nil {
+		return 0, nil
+	}
+
+	version, dirty, err := app.Migrator.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("the migration %d failed, fix the schema and run `shopping migrate force`", version)
+	}
+
+	return version, nil
+}
+
+// startBackup uploads a new backup in the background, the backups are never
+// overwritten as their key has the time
+func (app *App) startBackup() (BackupStatus, bool) {
+	key := app.Config.BackupPrefix + "shopping-" + time.Now().UTC().Format("20060102T150405Z") + ".json.gz"
+
+	return app.backups.start(BackupStatus{Key: key}, func(ctx context.Context) error {
+		version, err := app.migrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := backup.Dump(ctx, app.BackupDB, version)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		err = backup.Encode(&buf, snapshot)
+		if err != nil {
+			return err
+		}
+
+		err = app.BackupStore.Put(ctx, key, backup.ContentType, buf.Bytes())
+		if err != nil {
+			log.Err(err).Msgf("backups: error to upload the backup %s", key)
+			return err
+		}
+
+		app.backups.update(func(status *BackupStatus) {
+			status.Rows = snapshot.Rows()
+			status.SizeBytes = buf.Len()
+		})
+		log.Info().Msgf("> backup %s uploaded, %d rows in %d bytes", key, snapshot.Rows(), buf.Len())
+
+		return nil
+	})
+}
+
+// runBackups starts a backup on every interval until the context is done,
+// only the instances that dispatch the outbox run it
+func (app *App) runBackups(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if status, started := app.startBackup(); !started {
+				log.Warn().Msgf("backups: the backup %s is still running, the next one is skipped", status.Key)
+			}
+		}
+	}
+}
+
+func (app *App) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
+	if app.BackupStore == nil {
+		http.Error(w, "the backups are disabled, set BACKUP_S3_BUCKET", http.StatusNotFound)
+		return
+	}
+
+	status, started := app.startBackup()
+	if !started {
+		render.JSON(w, http.StatusConflict, status)
+		return
+	}
+
+	render.JSON(w, http.StatusAccepted, status)
+}
+
+func (app *App) handleBackupStatus(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, http.StatusOK, app.backups.Status())
+}
+
+// handleRestore checks the backup and the database before restoring in the
+// background, the progress is read with GET /v1/admin/restore
+func (app *App) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if app.BackupStore == nil {
+		http.Error(w, "the backups are disabled, set BACKUP_S3_BUCKET", http.StatusNotFound)
+		return
+	}
+
+	var data RestoreRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil || strings.TrimSpace(data.Key) == "" {
+		http.Error(w, "'key' is required, it's the key of the backup in the bucket", http.StatusBadRequest)
+		return
+	}
+
+	if status := app.restores.Status(); status.Running {
+		render.JSON(w, http.StatusConflict, status)
+		return
+	}
+
+	snapshot, ok := app.readBackup(w, r, data.Key)
+	if !ok {
+		return
+	}
+
+	status := BackupStatus{Key: data.Key, Rows: snapshot.Rows()}
+	for _, table := range snapshot.Tables {
+		status.Tables = append(status.Tables, BackupTableStatus{Name: table.Name, Rows: len(table.Rows)})
+	}
+
+	status, started := app.restores.start(status, func(ctx context.Context) error {
+		err := backup.Restore(ctx, app.BackupDB, snapshot, func(table string, restored int) {
+			app.restores.update(func(status *BackupStatus) {
+				status.Restored = 0
+				for i := range status.Tables {
+					if status.Tables[i].Name == table {
+						status.Tables[i].Restored = restored
+					}
+					status.Restored += status.Tables[i].Restored
+				}
+			})
+		})
+		if err != nil {
+			log.Err(err).Msgf("backups: error to restore the backup %s", data.Key)
+			return err
+		}
+
+		app.restored()
+		log.Info().Msgf("> backup %s restored, %d rows", data.Key, snapshot.Rows())
+
+		return nil
+	})
+	if !started {
+		render.JSON(w, http.StatusConflict, status)
+		return
+	}
+
+	render.JSON(w, http.StatusAccepted, status)
+}
+
+// readBackup downloads the backup and checks it can be restored, it writes
+// the error response itself and returns false otherwise
+func (app *App) readBackup(w http.ResponseWriter, r *http.Request, key string) (*backup.Snapshot, bool) {
+	reader, err := app.BackupStore.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+		http.Error(w, "backup not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Err(err).Msgf("backups: error to download the backup %s", key)
+		http.Error(w, "the backup can't be downloaded", http.StatusBadGateway)
+		return nil, false
+	}
+	defer reader.Close()
+
+	snapshot, err := backup.Decode(reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	version, err := app.migrationVersion(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	// the rows have the columns of their migration
+	if snapshot.MigrationVersion != version {
+		http.Error(w, fmt.Sprintf("the backup was made at the migration %d and the database is at %d, migrate the database to %d, restore and migrate up", snapshot.MigrationVersion, version, snapshot.MigrationVersion), http.StatusConflict)
+		return nil, false
+	}
+
+	err = backup.CheckEmpty(r.Context(), app.BackupDB)
+	if errors.Is(err, backup.ErrNotEmpty) {
+		http.Error(w, "the backups are only restored into an empty database: "+err.Error(), http.StatusConflict)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return snapshot, true
+}
+
+// restored drops what this instance derived from the previous data, the
+// other instances drop their cached lists with the notifications of the
+// inserts
+func (app *App) restored() {
+	app.purgeListsCache()
+	app.StatsCache.Purge()
+	if app.PriceComparisons != nil {
+		app.PriceComparisons.Purge()
+	}
+
+	// the lists were inserted without going through the outbox
+	if app.SearchIndex != nil {
+		_, err := app.SearchIndex.Rebuild(context.Background(), app.searchDocuments)
+		if err != nil {
+			log.Err(err).Msg("error to rebuild the search index after the restore")
+		}
+	}
+}
+
+func (app *App) handleRestoreStatus(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, http.StatusOK, app.restores.Status())
+}
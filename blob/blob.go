@@ -0,0 +1,80 @@
+// Package blob stores the files of the users, like the photos of the items,
+// on the local disk or in an S3 compatible bucket.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	StoreDisk = "disk"
+	StoreS3   = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("blob: not found")
+	ErrInvalidKey = errors.New("blob: invalid key")
+)
+
+// Store keeps the blobs by key, the keys are paths like photos/<id>.jpg
+type Store interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// Get returns ErrNotFound when the key doesn't exist, the caller closes
+	// the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete doesn't fail when the key doesn't exist
+	Delete(ctx context.Context, key string) error
+}
+
+type Options struct {
+	Store string
+	// Dir is the directory of the disk store
+	Dir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	// S3SecretKey is read on every request, the secret providers can
+	// rotate it
+	S3SecretKey func() string
+	// S3PathStyle puts the bucket in the path instead of the host, most
+	// S3 compatible servers like MinIO need it
+	S3PathStyle bool
+	Client      *http.Client
+}
+
+func New(opts Options) (Store, error) {
+	switch opts.Store {
+	case "", StoreDisk:
+		return &Disk{Dir: opts.Dir}, nil
+	case StoreS3:
+		return &S3{
+			Endpoint:  strings.TrimSuffix(opts.S3Endpoint, "/"),
+			Region:    opts.S3Region,
+			Bucket:    opts.S3Bucket,
+			AccessKey: opts.S3AccessKey,
+			SecretKey: opts.S3SecretKey,
+			PathStyle: opts.S3PathStyle,
+			Client:    opts.Client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("blob: unknown store '%s'", opts.Store)
+	}
+}
+
+// cleanKey rejects the keys that could leave the directory or the bucket
+// prefix, like ../secrets
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidKey, key)
+	}
+
+	return key, nil
+}
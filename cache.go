@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/render"
+	"shopping/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// listsCacheStats are published in /debug/vars as lists_cache: the hits and
+// misses of the lists cache, the lookups answered by the ids that were not
+// found (missing_hits), the lists evicted to make room and the flushes and
+// invalidations of the admins
+var listsCacheStats = expvar.NewMap("lists_cache")
+
+// CachedList is an entry of the lists cache, the response of the list with
+// its JSON and ETag computed once when it's cached so the reads don't marshal
+// it again. Version is the updated_at of the list in microseconds
+type CachedList struct {
+	List    ShoppingListResponse
+	Version int64
+	JSON    []byte
+	ETag    string
+}
+
+func newCachedList(row db_queries.ShoppingList) *CachedList {
+	list := newShoppingListResponse(row)
+	// the response only has strings and times, it can't fail
+	data, _ := json.Marshal(list)
+
+	return &CachedList{
+		List:    list,
+		Version: row.UpdatedAt.Time.UnixMicro(),
+		JSON:    data,
+		ETag:    fmt.Sprintf(`"%x"`, sha256.Sum256(data)),
+	}
+}
+
+// cachedList returns the list from the cache, the concurrent misses of a
+// hot list wait for the same query instead of sending one each. The ids that
+// were not found are remembered for LISTS_CACHE_MISSING_TTL.
+func (app *App) cachedList(id string) (*CachedList, error) {
+	if list, ok := app.ListsCache.Get(id); ok {
+		listsCacheStats.Add("hits", 1)
+		return list, nil
+	}
+	if app.MissingLists != nil && app.MissingLists.Contains(id) {
+		listsCacheStats.Add("missing_hits", 1)
+		return nil, fmt.Errorf("the shopping list with id %s was not found recently: %w", id, repository.ErrNotFound)
+	}
+	listsCacheStats.Add("misses", 1)
+
+	value, err, _ := app.listLoads.Do(id, func() (any, error) {
+		list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+		if err != nil {
+			if app.MissingLists != nil && errors.Is(err, repository.ErrNotFound) {
+				app.MissingLists.Add(id, struct{}{})
+			}
+			return nil, err
+		}
+
+		cached := newCachedList(*list)
+		app.cacheList(id, cached)
+		return cached, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*CachedList), nil
+}
+
+// cacheList adds the list to the cache and counts the list it evicted, the
+// entry of a newer version of the list is kept
+func (app *App) cacheList(id string, list *CachedList) {
+	if cached, ok := app.ListsCache.Peek(id); ok && cached.Version > list.Version {
+		return
+	}
+
+	if app.ListsCache.Add(id, list) {
+		listsCacheStats.Add("evictions", 1)
+	}
+}
+
+// resetListsCache empties the cache when the listener (re)connects, the
+// notifications sent while it was down are lost, and loads the recently
+// updated lists again when LISTS_CACHE_WARM is set
+func (app *App) resetListsCache() {
+	app.purgeListsCache()
+
+	warm := 0
+	if settings := app.settings(); settings != nil {
+		warm = min(settings.ListsCacheWarm, listsCacheSize)
+	}
+	if warm == 0 {
+		return
+	}
+
+	lists, err := app.ShoppingListRepository.GetRecentlyUpdatedShoppingLists(warm)
+	if err != nil {
+		log.Err(err).Msg("error to warm the lists cache")
+		return
+	}
+
+	// the oldest first, so the most recently updated are the last evicted
+	for i := len(lists) - 1; i >= 0; i-- {
+		app.cacheList(lists[i].ID.String(), newCachedList(lists[i]))
+	}
+	log.Info().Msgf("the lists cache was warmed with %d lists", len(lists))
+}
+
+// purgeListsCache drops the cached lists and the ids that were not found
+func (app *App) purgeListsCache() {
+	app.ListsCache.Purge()
+	if app.MissingLists != nil {
+		app.MissingLists.Purge()
+	}
+}
+
+// FlushedCaches is the number of entries dropped from each cache
+type FlushedCaches struct {
+	Lists        int `json:"lists"`
+	MissingLists int `json:"missing_lists"`
+	Stats        int `json:"stats"`
+	Prices       int `json:"price_comparisons"`
+}
+
+// handleFlushCaches empties the caches of this instance, `cache=lists`,
+// `cache=stats` or `cache=prices` only flushes one of them. The other
+// instances keep theirs.
+func (app *App) handleFlushCaches(w http.ResponseWriter, r *http.Request) {
+	cache := r.URL.Query().Get("cache")
+	switch cache {
+	case "", "lists", "stats", "prices":
+	default:
+		http.Error(w, "'cache' must be lists, stats or prices", http.StatusBadRequest)
+		return
+	}
+
+	var flushed FlushedCaches
+	if cache == "" || cache == "lists" {
+		flushed.Lists = app.ListsCache.Len()
+		if app.MissingLists != nil {
+			flushed.MissingLists = app.MissingLists.Len()
+		}
+		app.purgeListsCache()
+	}
+	if cache == "" || cache == "stats" {
+		flushed.Stats = app.StatsCache.Len()
+		app.StatsCache.Purge()
+	}
+	if (cache == "" || cache == "prices") && app.PriceComparisons != nil {
+		flushed.Prices = app.PriceComparisons.Len()
+		app.PriceComparisons.Purge()
+	}
+
+	listsCacheStats.Add("flushes", 1)
+	log.Info().Msgf("the caches were flushed by %s: %d lists, %d missing lists, %d stats and %d price comparisons", currentUser(r).Username, flushed.Lists, flushed.MissingLists, flushed.Stats, flushed.Prices)
+
+	render.JSON(w, http.StatusOK, flushed)
+}
+
+// handleInvalidateCachedList drops one list from the cache of this instance,
+// the next read loads it from the database. It answers 204 even when the
+// list wasn't cached.
+func (app *App) handleInvalidateCachedList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	app.listLoads.Forget(id)
+	app.ListsCache.Remove(id)
+	if app.MissingLists != nil {
+		app.MissingLists.Remove(id)
+	}
+
+	listsCacheStats.Add("invalidations", 1)
+	w.WriteHeader(http.StatusNoContent)
+}
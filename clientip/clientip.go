@@ -0,0 +1,171 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Header is set by the proxies with the addresses the request went through,
+// the client first
+const Header = "X-Forwarded-For"
+
+type contextKey struct{}
+
+// ParsePrefixes reads a list of CIDRs, like 10.0.0.0/8, the addresses are
+// single hosts
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not an IP address or a CIDR", value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an IP address or a CIDR", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolver finds the address of the client behind the trusted proxies, the
+// X-Forwarded-For of the other peers is ignored as anyone can send it
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{trusted: trusted}, nil
+}
+
+// Resolve reads X-Forwarded-For from the right while the addresses are
+// trusted proxies, the first one that isn't is the client
+func (res *Resolver) Resolve(r *http.Request) string {
+	peer := peerIP(r)
+
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !contains(res.trusted, addr) {
+		return peer
+	}
+
+	forwarded := []string{}
+	for _, header := range r.Header.Values(Header) {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+
+	client := peer
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// the proxies write valid addresses, what's left of it comes
+			// from the client
+			break
+		}
+
+		client = addr.Unmap().String()
+		if !contains(res.trusted, addr) {
+			break
+		}
+	}
+
+	return client
+}
+
+// Middleware keeps the address of the client in the context of the request
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := res.Resolve(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, ip)))
+	})
+}
+
+// FromRequest returns the address resolved by Middleware, the address of the
+// peer out of it
+func FromRequest(r *http.Request) string {
+	if ip, ok := r.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+
+	return peerIP(r)
+}
+
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Filter allows the addresses of the allow list, every address when it's
+// empty, except the ones of the deny list
+type Filter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func NewFilter(allow []string, deny []string) (*Filter, error) {
+	allowed, err := ParsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := ParsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Filter{allow: allowed, deny: denied}, nil
+}
+
+// Allowed tells if the address can go through, the addresses that can't be
+// parsed only pass when there are no lists
+func (f *Filter) Allowed(ip string) bool {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	if contains(f.deny, addr) {
+		return false
+	}
+
+	return len(f.allow) == 0 || contains(f.allow, addr)
+}
@@ -0,0 +1,81 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{name: "without proxy", peer: "203.0.113.7:4000", want: "203.0.113.7"},
+		{name: "ignores the header of an untrusted peer", peer: "203.0.113.7:4000", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "behind a trusted proxy", peer: "10.0.0.2:4000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "skips the trusted proxies", peer: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, 192.168.1.1", "10.1.2.3"}, want: "198.51.100.1"},
+		{name: "ignores what the client prepends", peer: "10.0.0.2:4000", forwarded: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "stops at an invalid address", peer: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, not-an-ip, 10.0.0.3"}, want: "10.0.0.3"},
+		{name: "only trusted proxies", peer: "10.0.0.2:4000", forwarded: []string{"10.0.0.3"}, want: "10.0.0.3"},
+		{name: "trusted proxy without header", peer: "10.0.0.2:4000", want: "10.0.0.2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.peer
+			for _, header := range tc.forwarded {
+				req.Header.Add(Header, header)
+			}
+
+			assert.Equal(t, tc.want, resolver.Resolve(req))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.2"})
+	assert.NoError(t, err)
+
+	var seen string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromRequest(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.2:4000"
+	req.Header.Set(Header, "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "198.51.100.1", seen)
+
+	// out of the middleware it's the peer
+	assert.Equal(t, "10.0.0.2", FromRequest(req))
+}
+
+func TestFilter(t *testing.T) {
+	open, err := NewFilter(nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, open.Allowed("203.0.113.7"))
+
+	filter, err := NewFilter([]string{"10.0.0.0/8", "2001:db8::/32"}, []string{"10.6.6.6"})
+	assert.NoError(t, err)
+	assert.True(t, filter.Allowed("10.1.2.3"))
+	assert.True(t, filter.Allowed("2001:db8::1"))
+	assert.False(t, filter.Allowed("10.6.6.6"))
+	assert.False(t, filter.Allowed("203.0.113.7"))
+	assert.False(t, filter.Allowed("not-an-ip"))
+
+	denyOnly, err := NewFilter(nil, []string{"203.0.113.0/24"})
+	assert.NoError(t, err)
+	assert.False(t, denyOnly.Allowed("203.0.113.7"))
+	assert.True(t, denyOnly.Allowed("198.51.100.1"))
+
+	_, err = NewFilter([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"shopping/keyring"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) int
+}
+
+// commands of the binary, `shopping` without a command runs the server
+func commands() []command {
+	return []command{
+		{Name: "serve", Summary: "Run the API server", Run: runServe},
+		{Name: "migrate", Summary: "Apply or revert the database migrations", Run: runMigrate},
+		{Name: "seed", Summary: "Fill the database with the demo data", Run: runSeed},
+		{Name: "create-tenant", Summary: "Create a tenant in the database", Run: runCreateTenant},
+		{Name: "create-admin", Summary: "Create an admin user in the database", Run: runCreateAdmin},
+		{Name: "rotate-keys", Summary: "Generate new signing secrets for the config", Run: runRotateKeys},
+		{Name: "config", Summary: "Validate the config and print the effective values", Run: runConfig},
+		{Name: "routes", Summary: "List the routes of the API", Run: runRoutes},
+		{Name: "smoke", Summary: "Check a live deployment", Run: runSmoke},
+	}
+}
+
+func runCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		return runServe(args)
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	for _, cmd := range commands() {
+		if cmd.Name == name {
+			return cmd.Run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: shopping <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nRun 'shopping <command> -h' for the flags of a command.")
+}
+
+// runRoutes prints the routes as the server registers them, it doesn't need
+// the config. The static files and the docs are added by the server only
+func runRoutes(args []string) int {
+	flags := flag.NewFlagSet("routes", flag.ContinueOnError)
+	auth := flags.String("auth", "", "only the routes of an auth level: public, session or permission")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if *auth != "" && *auth != authPublic && *auth != authSession && *auth != authPermission {
+		fmt.Fprintln(os.Stderr, "-auth must be public, session or permission")
+		return 2
+	}
+
+	app := &App{}
+	mux := NewRouteMux()
+	app.registerRoutes(mux, app.routes())
+
+	routes := mux.Routes()
+	if *auth != "" {
+		routes = slices.DeleteFunc(routes, func(route RegisteredRoute) bool {
+			return route.Auth != *auth
+		})
+	}
+
+	printRoutes(os.Stdout, routes)
+	return 0
+}
+
+func printRoutes(w io.Writer, routes []RegisteredRoute) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tAUTH\tMIDDLEWARES\tSUMMARY")
+	for _, route := range routes {
+		auth := route.Auth
+		if route.Action != "" {
+			auth = route.Action
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", route.Method, route.Pattern, auth, strings.Join(route.Middlewares, ","), route.Summary)
+	}
+	tw.Flush()
+}
+
+// rotatedSecrets are the secrets of the config that sign data, a new value
+// invalidates what was signed with the old one. The Keys are lists of
+// <id>:<secret> instead, the new key is put first and the previous ones keep
+// verifying
+var rotatedSecrets = []struct {
+	Name        string
+	Invalidates string
+	Keys        bool
+}{
+	{Name: "SHARE_LINK_KEYS", Keys: true},
+	{Name: "SIGNED_URL_KEYS", Keys: true},
+	{Name: "SHARE_LINK_SECRET", Invalidates: "the share links, add a key to SHARE_LINK_KEYS instead to keep them"},
+	{Name: "ACCOUNT_MOVE_SECRET", Invalidates: "the account bundles not imported yet, set it in every deployment"},
+	{Name: "OUTBOX_WEBHOOK_SECRET", Invalidates: "the signatures checked by the webhook receivers, update them too"},
+}
+
+// runRotateKeys prints new random secrets to put in the config, the server
+// doesn't store them.
+func runRotateKeys(args []string) int {
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	only := flags.String("only", "", "generate only this secret, like SHARE_LINK_SECRET")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	found := false
+	for _, secret := range rotatedSecrets {
+		if *only != "" && secret.Name != *only {
+			continue
+		}
+		found = true
+
+		if secret.Keys {
+			now := time.Now()
+			key, err := keyring.Generate(now, now)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "rotate-keys: %s\n", err)
+				return 1
+			}
+
+			fmt.Fprintf(os.Stderr, "# put the new key first in %s and remove the previous ones once what they signed has expired\n", secret.Name)
+			fmt.Fprintf(os.Stdout, "%s=%s:%s\n", secret.Name, key.ID, key.Secret)
+			continue
+		}
+
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rotate-keys: %s\n", err)
+			return 1
+		}
+
+		fmt.Fprintf(os.Stderr, "# changing %s invalidates %s\n", secret.Name, secret.Invalidates)
+		fmt.Fprintf(os.Stdout, "%s=%s\n", secret.Name, base64.RawURLEncoding.EncodeToString(key))
+	}
+
+	if !found {
+		fmt.Fprintf(os.Stderr, "rotate-keys: unknown secret '%s'\n", *only)
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"shopping/config"
+	"shopping/loadshed"
+	"shopping/logging"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runConfig runs `shopping config`, it validates the config and prints the
+// effective values with their source, the secrets are redacted.
+func runConfig(args []string) int {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: invalid config:\n%s\n", err)
+		return 1
+	}
+
+	err = cfg.Print(os.Stdout)
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// logEffectiveConfig logs the config the server starts with, the secrets
+// are redacted
+func logEffectiveConfig(cfg *config.Config) {
+	event := log.Info()
+	for _, setting := range cfg.Effective() {
+		event = event.Str(setting.Key, setting.Value+" ("+setting.Source+")")
+	}
+	event.Msg("> effective config")
+}
+
+// settings returns the current config, app.Config keeps the one the server
+// started with
+func (app *App) settings() *config.Config {
+	if app.Settings == nil {
+		return app.Config
+	}
+
+	return app.Settings.Get()
+}
+
+// applyReloadedConfig applies the reloaded keys that aren't read on every
+// request
+func applyReloadedConfig(limiter *loadshed.Limiter) func(*config.Config) {
+	return func(cfg *config.Config) {
+		logging.SetLevel(cfg.LogLevel)
+		limiter.SetLimits(cfg.MaxConcurrentRequests, cfg.MaxQueuedRequests, cfg.RequestQueueTimeout)
+	}
+}
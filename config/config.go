@@ -1,54 +1,630 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"shopping/secrets"
+
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
+// Config has a field for each key, the keys tagged reload are applied while
+// the server runs when the config is reloaded, see Holder.
 type Config struct {
-	DBUrl  string
-	AppEnv string // development, qa, production
-	Port   int
+	DBUrl  string `key:"DATABASE_URL" secret:"url"`
+	AppEnv string `key:"APP_ENV"` // development, qa, production
+	Port   int    `key:"PORT"`
+
+	// the logs are written to stderr, stdout or a file, LogSampleRate keeps
+	// 1 of every N debug and info messages
+	LogLevel      string `key:"LOG_LEVEL" reload:"true"` // trace, debug, info, warn, error
+	LogFormat     string `key:"LOG_FORMAT"`              // json, pretty
+	LogOutput     string `key:"LOG_OUTPUT"`
+	LogSampleRate int    `key:"LOG_SAMPLE_RATE"`
+	// the statements are logged with their arguments, only from this level
+	DBLogLevel string `key:"DB_LOG_LEVEL"` // none, error, warn, info, debug, trace
+
+	CORSOrigins []string `key:"CORS_ALLOWED_ORIGINS" reload:"true"`
+
+	// the client address is read from X-Forwarded-For only when the peer is
+	// one of the TrustedProxies, the admin routes only answer to the
+	// addresses of AdminAllowIPs (any when it's empty) out of AdminDenyIPs.
+	// They are IP addresses or CIDRs
+	TrustedProxies []string `key:"TRUSTED_PROXIES"`
+	AdminAllowIPs  []string `key:"ADMIN_ALLOW_IPS" reload:"true"`
+	AdminDenyIPs   []string `key:"ADMIN_DENY_IPS" reload:"true"`
+
+	// override the TLS settings of DATABASE_URL, the pins are the base64
+	// SHA-256 hashes of the accepted server public keys
+	DBSSLMode     string   `key:"DB_SSL_MODE"` // disable, require, verify-ca, verify-full
+	DBSSLRootCert string   `key:"DB_SSL_ROOT_CERT"`
+	DBSSLCert     string   `key:"DB_SSL_CERT"`
+	DBSSLKey      string   `key:"DB_SSL_KEY"`
+	DBSSLPins     []string `key:"DB_SSL_PINS"`
+
+	// the transient database errors are retried with a backoff
+	DBRetryMaxAttempts int           `key:"DB_RETRY_MAX_ATTEMPTS"`
+	DBRetryBaseDelay   time.Duration `key:"DB_RETRY_BASE_DELAY"`
+
+	// deadlines of the repository operations and the statement_timeout of
+	// the connections, the server stops the statements that run longer
+	DBReadTimeout        time.Duration `key:"DB_READ_TIMEOUT"`
+	DBWriteTimeout       time.Duration `key:"DB_WRITE_TIMEOUT"`
+	DBTransactionTimeout time.Duration `key:"DB_TRANSACTION_TIMEOUT"`
+	DBStatementTimeout   time.Duration `key:"DB_STATEMENT_TIMEOUT"`
+
+	// how pgx sends the queries: cache_statement, cache_describe,
+	// describe_exec, exec or simple_protocol. Behind pgbouncer in transaction
+	// mode the connections can change between statements, so nothing can be
+	// prepared or cached: use exec or simple_protocol. The size is the
+	// capacity of the statement or description cache of each connection.
+	DBQueryExecMode      string `key:"DB_QUERY_EXEC_MODE"`
+	DBStatementCacheSize int    `key:"DB_STATEMENT_CACHE_SIZE"`
+
+	AuthzEngine     string `key:"AUTHZ_ENGINE"` // builtin, opa
+	AuthzPolicyFile string `key:"AUTHZ_POLICY_FILE"`
+	OPAUrl          string `key:"OPA_URL"`
+
+	// rejects lists with the same name for the same user unless the
+	// request says how to handle the conflict
+	UniqueListNames bool `key:"UNIQUE_LIST_NAMES" reload:"true"`
+
+	// the number of recently updated lists loaded in the cache at startup,
+	// 0 disables it
+	ListsCacheWarm int `key:"LISTS_CACHE_WARM"`
+	// the cached lists expire after ListsCacheTTL (never when it's 0), the
+	// ids that were not found are remembered for ListsCacheMissingTTL, 0
+	// disables it
+	ListsCacheTTL        time.Duration `key:"LISTS_CACHE_TTL"`
+	ListsCacheMissingTTL time.Duration `key:"LISTS_CACHE_MISSING_TTL"`
+
+	// used to build and sign the public share links, a random secret is
+	// generated when empty so the links stop working after a restart. The
+	// ShareLinkKeys, like <id>:<secret>, sign before the ShareLinkSecret and
+	// the secret only verifies the links signed before them
+	PublicURL       string        `key:"PUBLIC_URL"`
+	ShareLinkSecret string        `key:"SHARE_LINK_SECRET" secret:"true"`
+	ShareLinkKeys   []string      `key:"SHARE_LINK_KEYS" secret:"true"`
+	ShareLinkTTL    time.Duration `key:"SHARE_LINK_TTL"`
+
+	// the instances reload the signing keys rotated by the admins every
+	// SigningKeysRefreshInterval, a new key only signs after two intervals
+	// so every instance can verify it by then
+	SigningKeysRefreshInterval time.Duration `key:"SIGNING_KEYS_REFRESH_INTERVAL"`
+
+	// the browsers can keep the session in an HttpOnly cookie instead of a
+	// bearer token, the login asks for it. The requests that change data
+	// send the CSRF token of the session in the X-CSRF-Token header
+	SessionCookies        bool   `key:"SESSION_COOKIES"`
+	SessionCookieSecure   bool   `key:"SESSION_COOKIE_SECURE"`
+	SessionCookieSameSite string `key:"SESSION_COOKIE_SAMESITE"` // lax, strict, none
+	SessionCookieDomain   string `key:"SESSION_COOKIE_DOMAIN"`
+
+	// the new passwords have at least PasswordMinLength characters, aren't
+	// common passwords, of the built-in list or of the file, and have the
+	// estimated strength of PasswordMinScore, from 0 (not checked) to 4
+	PasswordMinLength     int    `key:"PASSWORD_MIN_LENGTH"`
+	PasswordMinScore      int    `key:"PASSWORD_MIN_SCORE"`
+	PasswordBlocklistFile string `key:"PASSWORD_BLOCKLIST_FILE"`
+
+	// the signed URLs give access to a path without a session, they are
+	// signed with the first of the SignedURLKeys, like <id>:<secret>, and the
+	// others still verify the URLs signed before a rotation. A random key is
+	// generated when empty so the URLs stop working after a restart
+	SignedURLKeys   []string      `key:"SIGNED_URL_KEYS" secret:"true"`
+	SignedURLTTL    time.Duration `key:"SIGNED_URL_TTL"`
+	SignedURLMaxTTL time.Duration `key:"SIGNED_URL_MAX_TTL"`
+
+	// signs and verifies the account move bundles, the deployments that
+	// exchange accounts must share it. Account moves are disabled when empty
+	AccountMoveSecret string `key:"ACCOUNT_MOVE_SECRET" secret:"true"`
+
+	// serves the static files from this directory instead of the ones
+	// built in the binary
+	StaticDir string `key:"STATIC_DIR"`
+
+	SwaggerAccess   string `key:"SWAGGER_ACCESS"` // open, basic_auth, disabled
+	SwaggerUser     string `key:"SWAGGER_USER"`
+	SwaggerPassword string `key:"SWAGGER_PASSWORD" secret:"true"`
+
+	// the outbox dispatcher can be disabled in some instances, the events
+	// are sent by the others
+	OutboxDispatcher    bool          `key:"OUTBOX_DISPATCHER"`
+	OutboxPollInterval  time.Duration `key:"OUTBOX_POLL_INTERVAL"`
+	OutboxWebhookURLs   []string      `key:"OUTBOX_WEBHOOK_URLS"`
+	OutboxWebhookSecret string        `key:"OUTBOX_WEBHOOK_SECRET" secret:"true"`
+
+	// sandbox deployments delete the data of every user and seed the demo
+	// data again on every interval and on demand, the interval can be 0
+	Sandbox              bool          `key:"SANDBOX"`
+	SandboxResetInterval time.Duration `key:"SANDBOX_RESET_INTERVAL"`
+
+	// the last change of a list can be undone during UndoWindow with
+	// POST /v1/lists/{id}/undo, 0 disables it
+	UndoWindow time.Duration `key:"UNDO_WINDOW"`
+
+	// DELETE /v1/me erases the data of the user AccountDeletionGrace later,
+	// the deletion can be canceled until then
+	AccountDeletionGrace time.Duration `key:"ACCOUNT_DELETION_GRACE"`
+
+	// the products of the barcodes scanned by the apps are looked up in
+	// ProductsProvider (openfoodfacts or none) and kept for ProductsCacheTTL
+	ProductsProvider string        `key:"PRODUCTS_PROVIDER"`
+	OpenFoodFactsURL string        `key:"OPENFOODFACTS_URL"`
+	ProductsCacheTTL time.Duration `key:"PRODUCTS_CACHE_TTL"`
+
+	// the files of the users, like the photos of the items, are kept in
+	// BlobStore: disk under BlobDir, or s3 in an S3 compatible bucket. The
+	// photos are up to PhotoMaxBytes, 0 disables them, and their signed
+	// download URLs expire after PhotoURLTTL
+	BlobStore         string        `key:"BLOB_STORE"`
+	BlobDir           string        `key:"BLOB_DIR"`
+	S3Endpoint        string        `key:"S3_ENDPOINT"`
+	S3Region          string        `key:"S3_REGION"`
+	S3Bucket          string        `key:"S3_BUCKET"`
+	S3AccessKeyID     string        `key:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string        `key:"S3_SECRET_ACCESS_KEY" secret:"true"`
+	S3PathStyle       bool          `key:"S3_PATH_STYLE"`
+	PhotoMaxBytes     int64         `key:"PHOTO_MAX_BYTES"`
+	PhotoURLTTL       time.Duration `key:"PHOTO_URL_TTL"`
+
+	// the backups are uploaded under BackupPrefix in BackupS3Bucket, with
+	// the S3 endpoint and keys of the blob store, every BackupInterval (0
+	// only on demand). An empty bucket disables them
+	BackupS3Bucket string        `key:"BACKUP_S3_BUCKET"`
+	BackupPrefix   string        `key:"BACKUP_PREFIX"`
+	BackupInterval time.Duration `key:"BACKUP_INTERVAL"`
+
+	// the deployment serves several organizations, the tenant of a request
+	// is the subdomain of TenantBaseDomain or the TenantHeader. The users,
+	// sessions and lists of a tenant are only seen by its requests
+	MultiTenancy     bool   `key:"MULTI_TENANCY"`
+	TenantHeader     string `key:"TENANT_HEADER"`
+	TenantBaseDomain string `key:"TENANT_BASE_DOMAIN"`
+
+	// a summary of each request is stored for GET /v1/admin/requests, 1 of
+	// every AccessLogSampleRate successful requests and every error. The
+	// entries are deleted after AccessLogRetention, never when it's 0
+	AccessLog           bool          `key:"ACCESS_LOG"`
+	AccessLogSampleRate int           `key:"ACCESS_LOG_SAMPLE_RATE"`
+	AccessLogRetention  time.Duration `key:"ACCESS_LOG_RETENTION"`
+
+	// the owners of the lists are notified of the items added by other
+	// users, by email when SMTPAddr is set and by push when FCMProjectID is
+	NotificationsEnabled bool   `key:"NOTIFICATIONS"`
+	SMTPAddr             string `key:"SMTP_ADDR"` // host:port
+	SMTPFrom             string `key:"SMTP_FROM"`
+	SMTPUsername         string `key:"SMTP_USERNAME"`
+	SMTPPassword         string `key:"SMTP_PASSWORD" secret:"true"`
+	FCMProjectID         string `key:"FCM_PROJECT_ID"`
+	// an OAuth 2 token of a service account, it expires so it must be
+	// refreshed by the secrets provider
+	FCMAccessToken string `key:"FCM_ACCESS_TOKEN" secret:"true"`
+
+	// requests served at the same time, the others wait in a queue for up to
+	// RequestQueueTimeout and are rejected with a 503 when it's full
+	MaxConcurrentRequests int           `key:"MAX_CONCURRENT_REQUESTS" reload:"true"`
+	MaxQueuedRequests     int           `key:"MAX_QUEUED_REQUESTS" reload:"true"`
+	RequestQueueTimeout   time.Duration `key:"REQUEST_QUEUE_TIMEOUT" reload:"true"`
+	// the requests that take longer are answered with a 504, some routes
+	// have their own timeout
+	RequestTimeout time.Duration `key:"REQUEST_TIMEOUT" reload:"true"`
+
+	// the quotas of the users unless the admins set others for the user or
+	// its tenant, 0 is no limit. The requests are counted by each instance
+	QuotaMaxLists          int `key:"QUOTA_MAX_LISTS" reload:"true"`
+	QuotaMaxItems          int `key:"QUOTA_MAX_ITEMS" reload:"true"`
+	QuotaRequestsPerMinute int `key:"QUOTA_REQUESTS_PER_MINUTE" reload:"true"`
+
+	// the lists are searched with the full-text index of the database or
+	// with an external engine, kept up to date by the outbox
+	SearchBackend     string `key:"SEARCH_BACKEND"` // postgres, meilisearch
+	MeilisearchURL    string `key:"MEILISEARCH_URL"`
+	MeilisearchAPIKey string `key:"MEILISEARCH_API_KEY" secret:"true"`
+	MeilisearchIndex  string `key:"MEILISEARCH_INDEX"`
+
+	// the search index and the caches are compared with the tables on every
+	// interval, 0 disables it. The discrepancies are only reported unless
+	// ConsistencyRepair is true
+	ConsistencyCheckInterval time.Duration `key:"CONSISTENCY_CHECK_INTERVAL"`
+	ConsistencyRepair        bool          `key:"CONSISTENCY_REPAIR" reload:"true"`
+
+	// the string values can be references to secrets, like
+	// vault:secret/data/shopping#database_url or awssm:shopping#database_url,
+	// or be read from the file of <KEY>_FILE. They are read again on every
+	// SecretsRefreshInterval, 0 disables it
+	VaultAddr              string        `key:"VAULT_ADDR"`
+	VaultToken             string        `key:"VAULT_TOKEN" secret:"true"`
+	AWSRegion              string        `key:"AWS_REGION"`
+	SecretsRefreshInterval time.Duration `key:"SECRETS_REFRESH_INTERVAL"`
+
+	// where each key was read from, see Effective
+	sources map[string]string
+	// the references of the keys read from a secret provider
+	secretRefs     map[string]string
+	secretResolver *secrets.Resolver
+}
+
+const (
+	SwaggerAccessOpen      = "open"
+	SwaggerAccessBasicAuth = "basic_auth"
+	SwaggerAccessDisabled  = "disabled"
+)
+
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+const (
+	LogFormatJSON   = "json"
+	LogFormatPretty = "pretty"
+)
+
+const (
+	AppEnvDevelopment = "development"
+	AppEnvQA          = "qa"
+	AppEnvProduction  = "production"
+)
+
+// where the value of a key comes from, by precedence
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceSecret  = "secret"
+	SourceDefault = "default"
+)
+
+// SetupConfig loads the config and stops the program when it's not valid,
+// flags can be nil when the command doesn't have the config flags.
+func SetupConfig(flags *Flags) *Config {
+	config, err := Load(flags)
+	if err != nil {
+		log.Fatal().Msgf("invalid config:\n%s", err)
+	}
+
+	return config
 }
 
-func SetupConfig() *Config {
+// Load reads the config from, by precedence: the flags, the env vars (the
+// .env file is loaded in the env), the config file and the defaults. It
+// returns every problem of the config at once.
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
 	err := godotenv.Load()
 	if err != nil {
 		log.Warn().Msg("Error to load the .env file")
 	}
 
+	v := viper.New()
+
 	// AutomaticEnv is a powerful helper especially when combined with SetEnvPrefix.
 	// When called, Viper will check for an environment variable any time a viper.Get request is made.
 	// It will apply the following rules. It will check for an environment variable with a name
 	// matching the key uppercased and prefixed with the EnvPrefix if set.
-	viper.AutomaticEnv()
+	v.AutomaticEnv()
 
-	dbUrl := mustGetString("DATABASE_URL")
-	port := mustGetInt("PORT")
-	appEnv := mustGetString("APP_ENV")
+	fileKeys, err := readConfigFile(v, flags.configFile())
+	if err != nil {
+		return nil, err
+	}
 
-	return &Config{
-		DBUrl:  dbUrl,
-		Port:   port,
-		AppEnv: appEnv,
+	flagKeys, err := flags.apply(v)
+	if err != nil {
+		return nil, err
 	}
+
+	v.SetDefault("LOG_LEVEL", "debug")
+	v.SetDefault("LOG_OUTPUT", "stderr")
+	v.SetDefault("LOG_SAMPLE_RATE", 1)
+	v.SetDefault("DB_LOG_LEVEL", "none")
+	v.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:9000,http://localhost:9002,http://localhost:3000")
+	v.SetDefault("DB_RETRY_MAX_ATTEMPTS", 3)
+	v.SetDefault("DB_RETRY_BASE_DELAY", "50ms")
+	v.SetDefault("DB_READ_TIMEOUT", "3s")
+	v.SetDefault("DB_WRITE_TIMEOUT", "5s")
+	v.SetDefault("DB_TRANSACTION_TIMEOUT", "10s")
+	v.SetDefault("DB_STATEMENT_TIMEOUT", "5s")
+	v.SetDefault("DB_QUERY_EXEC_MODE", "cache_statement")
+	v.SetDefault("DB_STATEMENT_CACHE_SIZE", 512)
+	v.SetDefault("AUTHZ_ENGINE", "builtin")
+	v.SetDefault("SHARE_LINK_TTL", "168h")
+	v.SetDefault("SESSION_COOKIE_SAMESITE", SameSiteLax)
+	v.SetDefault("PASSWORD_MIN_LENGTH", 12)
+	v.SetDefault("SIGNING_KEYS_REFRESH_INTERVAL", "1m")
+	v.SetDefault("SIGNED_URL_TTL", "15m")
+	v.SetDefault("SIGNED_URL_MAX_TTL", "24h")
+	v.SetDefault("LISTS_CACHE_TTL", "10m")
+	v.SetDefault("LISTS_CACHE_MISSING_TTL", "10s")
+	v.SetDefault("OUTBOX_DISPATCHER", true)
+	v.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	v.SetDefault("SANDBOX_RESET_INTERVAL", "1h")
+	v.SetDefault("UNDO_WINDOW", "10m")
+	v.SetDefault("ACCOUNT_DELETION_GRACE", "720h")
+	v.SetDefault("PRODUCTS_PROVIDER", "openfoodfacts")
+	v.SetDefault("OPENFOODFACTS_URL", "https://world.openfoodfacts.org")
+	v.SetDefault("PRODUCTS_CACHE_TTL", "24h")
+	v.SetDefault("BLOB_STORE", "disk")
+	v.SetDefault("BLOB_DIR", "data/blobs")
+	v.SetDefault("S3_REGION", "us-east-1")
+	v.SetDefault("S3_PATH_STYLE", true)
+	v.SetDefault("PHOTO_MAX_BYTES", 5<<20)
+	v.SetDefault("PHOTO_URL_TTL", "15m")
+	v.SetDefault("BACKUP_PREFIX", "backups/")
+	v.SetDefault("TENANT_HEADER", "X-Tenant")
+	v.SetDefault("ACCESS_LOG_SAMPLE_RATE", 1)
+	v.SetDefault("ACCESS_LOG_RETENTION", "168h")
+	v.SetDefault("MAX_CONCURRENT_REQUESTS", 100)
+	v.SetDefault("MAX_QUEUED_REQUESTS", 100)
+	v.SetDefault("REQUEST_QUEUE_TIMEOUT", "1s")
+	v.SetDefault("REQUEST_TIMEOUT", "30s")
+	v.SetDefault("QUOTA_MAX_LISTS", 500)
+	v.SetDefault("QUOTA_MAX_ITEMS", 1000)
+	v.SetDefault("QUOTA_REQUESTS_PER_MINUTE", 600)
+	v.SetDefault("SEARCH_BACKEND", "postgres")
+	v.SetDefault("MEILISEARCH_INDEX", "shopping_lists")
+	v.SetDefault("CONSISTENCY_CHECK_INTERVAL", "1h")
+	v.SetDefault("CONSISTENCY_REPAIR", true)
+	v.SetDefault("SECRETS_REFRESH_INTERVAL", "5m")
+
+	// the docs are open while developing and hidden in production unless
+	// configured otherwise
+	if v.GetString("APP_ENV") == AppEnvProduction {
+		v.SetDefault("SWAGGER_ACCESS", SwaggerAccessDisabled)
+	} else {
+		v.SetDefault("SWAGGER_ACCESS", SwaggerAccessOpen)
+	}
+
+	// the development server is usually served over plain http
+	v.SetDefault("SESSION_COOKIE_SECURE", v.GetString("APP_ENV") != AppEnvDevelopment)
+
+	// the logs are read by people while developing and by the log
+	// collectors in the other envs
+	if v.GetString("APP_ENV") == AppEnvDevelopment {
+		v.SetDefault("LOG_FORMAT", LogFormatPretty)
+	} else {
+		v.SetDefault("LOG_FORMAT", LogFormatJSON)
+	}
+
+	config := &Config{
+		DBUrl:  v.GetString("DATABASE_URL"),
+		Port:   v.GetInt("PORT"),
+		AppEnv: v.GetString("APP_ENV"),
+
+		LogLevel:      v.GetString("LOG_LEVEL"),
+		LogFormat:     v.GetString("LOG_FORMAT"),
+		LogOutput:     v.GetString("LOG_OUTPUT"),
+		LogSampleRate: v.GetInt("LOG_SAMPLE_RATE"),
+		DBLogLevel:    v.GetString("DB_LOG_LEVEL"),
+
+		CORSOrigins: getList(v, "CORS_ALLOWED_ORIGINS"),
+
+		TrustedProxies: getList(v, "TRUSTED_PROXIES"),
+		AdminAllowIPs:  getList(v, "ADMIN_ALLOW_IPS"),
+		AdminDenyIPs:   getList(v, "ADMIN_DENY_IPS"),
+
+		DBSSLMode:     v.GetString("DB_SSL_MODE"),
+		DBSSLRootCert: v.GetString("DB_SSL_ROOT_CERT"),
+		DBSSLCert:     v.GetString("DB_SSL_CERT"),
+		DBSSLKey:      v.GetString("DB_SSL_KEY"),
+		DBSSLPins:     getList(v, "DB_SSL_PINS"),
+
+		DBRetryMaxAttempts: v.GetInt("DB_RETRY_MAX_ATTEMPTS"),
+		DBRetryBaseDelay:   v.GetDuration("DB_RETRY_BASE_DELAY"),
+
+		DBReadTimeout:        v.GetDuration("DB_READ_TIMEOUT"),
+		DBWriteTimeout:       v.GetDuration("DB_WRITE_TIMEOUT"),
+		DBTransactionTimeout: v.GetDuration("DB_TRANSACTION_TIMEOUT"),
+		DBStatementTimeout:   v.GetDuration("DB_STATEMENT_TIMEOUT"),
+
+		DBQueryExecMode:      v.GetString("DB_QUERY_EXEC_MODE"),
+		DBStatementCacheSize: v.GetInt("DB_STATEMENT_CACHE_SIZE"),
+
+		AuthzEngine:     v.GetString("AUTHZ_ENGINE"),
+		AuthzPolicyFile: v.GetString("AUTHZ_POLICY_FILE"),
+		OPAUrl:          v.GetString("OPA_URL"),
+
+		UniqueListNames: v.GetBool("UNIQUE_LIST_NAMES"),
+
+		ListsCacheWarm:       v.GetInt("LISTS_CACHE_WARM"),
+		ListsCacheTTL:        v.GetDuration("LISTS_CACHE_TTL"),
+		ListsCacheMissingTTL: v.GetDuration("LISTS_CACHE_MISSING_TTL"),
+
+		PublicURL:       v.GetString("PUBLIC_URL"),
+		ShareLinkSecret: v.GetString("SHARE_LINK_SECRET"),
+		ShareLinkKeys:   getList(v, "SHARE_LINK_KEYS"),
+		ShareLinkTTL:    v.GetDuration("SHARE_LINK_TTL"),
+
+		SigningKeysRefreshInterval: v.GetDuration("SIGNING_KEYS_REFRESH_INTERVAL"),
+
+		SessionCookies:        v.GetBool("SESSION_COOKIES"),
+		SessionCookieSecure:   v.GetBool("SESSION_COOKIE_SECURE"),
+		SessionCookieSameSite: v.GetString("SESSION_COOKIE_SAMESITE"),
+		SessionCookieDomain:   v.GetString("SESSION_COOKIE_DOMAIN"),
+
+		PasswordMinLength:     v.GetInt("PASSWORD_MIN_LENGTH"),
+		PasswordMinScore:      v.GetInt("PASSWORD_MIN_SCORE"),
+		PasswordBlocklistFile: v.GetString("PASSWORD_BLOCKLIST_FILE"),
+
+		SignedURLKeys:   getList(v, "SIGNED_URL_KEYS"),
+		SignedURLTTL:    v.GetDuration("SIGNED_URL_TTL"),
+		SignedURLMaxTTL: v.GetDuration("SIGNED_URL_MAX_TTL"),
+
+		AccountMoveSecret: v.GetString("ACCOUNT_MOVE_SECRET"),
+
+		StaticDir: v.GetString("STATIC_DIR"),
+
+		SwaggerAccess:   v.GetString("SWAGGER_ACCESS"),
+		SwaggerUser:     v.GetString("SWAGGER_USER"),
+		SwaggerPassword: v.GetString("SWAGGER_PASSWORD"),
+
+		OutboxDispatcher:    v.GetBool("OUTBOX_DISPATCHER"),
+		OutboxPollInterval:  v.GetDuration("OUTBOX_POLL_INTERVAL"),
+		OutboxWebhookURLs:   getList(v, "OUTBOX_WEBHOOK_URLS"),
+		OutboxWebhookSecret: v.GetString("OUTBOX_WEBHOOK_SECRET"),
+
+		Sandbox:              v.GetBool("SANDBOX"),
+		SandboxResetInterval: v.GetDuration("SANDBOX_RESET_INTERVAL"),
+
+		UndoWindow: v.GetDuration("UNDO_WINDOW"),
+
+		AccountDeletionGrace: v.GetDuration("ACCOUNT_DELETION_GRACE"),
+
+		ProductsProvider: v.GetString("PRODUCTS_PROVIDER"),
+		OpenFoodFactsURL: v.GetString("OPENFOODFACTS_URL"),
+		ProductsCacheTTL: v.GetDuration("PRODUCTS_CACHE_TTL"),
+
+		BlobStore:         v.GetString("BLOB_STORE"),
+		BlobDir:           v.GetString("BLOB_DIR"),
+		S3Endpoint:        v.GetString("S3_ENDPOINT"),
+		S3Region:          v.GetString("S3_REGION"),
+		S3Bucket:          v.GetString("S3_BUCKET"),
+		S3AccessKeyID:     v.GetString("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: v.GetString("S3_SECRET_ACCESS_KEY"),
+		S3PathStyle:       v.GetBool("S3_PATH_STYLE"),
+		PhotoMaxBytes:     v.GetInt64("PHOTO_MAX_BYTES"),
+		PhotoURLTTL:       v.GetDuration("PHOTO_URL_TTL"),
+
+		BackupS3Bucket: v.GetString("BACKUP_S3_BUCKET"),
+		BackupPrefix:   v.GetString("BACKUP_PREFIX"),
+		BackupInterval: v.GetDuration("BACKUP_INTERVAL"),
+
+		MultiTenancy:     v.GetBool("MULTI_TENANCY"),
+		TenantHeader:     v.GetString("TENANT_HEADER"),
+		TenantBaseDomain: v.GetString("TENANT_BASE_DOMAIN"),
+
+		AccessLog:           v.GetBool("ACCESS_LOG"),
+		AccessLogSampleRate: v.GetInt("ACCESS_LOG_SAMPLE_RATE"),
+		AccessLogRetention:  v.GetDuration("ACCESS_LOG_RETENTION"),
+
+		NotificationsEnabled: v.GetBool("NOTIFICATIONS"),
+		SMTPAddr:             v.GetString("SMTP_ADDR"),
+		SMTPFrom:             v.GetString("SMTP_FROM"),
+		SMTPUsername:         v.GetString("SMTP_USERNAME"),
+		SMTPPassword:         v.GetString("SMTP_PASSWORD"),
+		FCMProjectID:         v.GetString("FCM_PROJECT_ID"),
+		FCMAccessToken:       v.GetString("FCM_ACCESS_TOKEN"),
+
+		MaxConcurrentRequests: v.GetInt("MAX_CONCURRENT_REQUESTS"),
+		MaxQueuedRequests:     v.GetInt("MAX_QUEUED_REQUESTS"),
+		RequestQueueTimeout:   v.GetDuration("REQUEST_QUEUE_TIMEOUT"),
+		RequestTimeout:        v.GetDuration("REQUEST_TIMEOUT"),
+
+		QuotaMaxLists:          v.GetInt("QUOTA_MAX_LISTS"),
+		QuotaMaxItems:          v.GetInt("QUOTA_MAX_ITEMS"),
+		QuotaRequestsPerMinute: v.GetInt("QUOTA_REQUESTS_PER_MINUTE"),
+
+		SearchBackend:     v.GetString("SEARCH_BACKEND"),
+		MeilisearchURL:    v.GetString("MEILISEARCH_URL"),
+		MeilisearchAPIKey: v.GetString("MEILISEARCH_API_KEY"),
+		MeilisearchIndex:  v.GetString("MEILISEARCH_INDEX"),
+
+		ConsistencyCheckInterval: v.GetDuration("CONSISTENCY_CHECK_INTERVAL"),
+		ConsistencyRepair:        v.GetBool("CONSISTENCY_REPAIR"),
+
+		VaultAddr:              v.GetString("VAULT_ADDR"),
+		VaultToken:             v.GetString("VAULT_TOKEN"),
+		AWSRegion:              v.GetString("AWS_REGION"),
+		SecretsRefreshInterval: v.GetDuration("SECRETS_REFRESH_INTERVAL"),
+
+		sources:    map[string]string{},
+		secretRefs: map[string]string{},
+	}
+
+	for _, key := range Keys() {
+		_, inEnv := os.LookupEnv(key)
+		switch {
+		case flagKeys[key]:
+			config.sources[key] = SourceFlag
+		case inEnv:
+			config.sources[key] = SourceEnv
+		case fileKeys[key]:
+			config.sources[key] = SourceFile
+		default:
+			config.sources[key] = SourceDefault
+		}
+	}
+
+	err = config.resolveSecrets(v)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
 }
 
-func mustGetString(key string) string {
-	v := viper.GetString(key)
-	if v == "" {
-		log.Fatal().Msgf("required config key '%s' is missing or empty", key)
+// readConfigFile merges the file in v, the format is taken from the
+// extension: .yaml, .yml, .toml or .json. The keys are the names of the env
+// vars, in any case. It returns the keys of the file.
+func readConfigFile(v *viper.Viper, path string) (map[string]bool, error) {
+	keys := map[string]bool{}
+	if path == "" {
+		return keys, nil
+	}
+
+	file := viper.New()
+	file.SetConfigFile(path)
+	err := file.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the config file %s: %w", path, err)
+	}
+
+	for _, key := range file.AllKeys() {
+		key = strings.ToUpper(key)
+		if !isKnownKey(key) {
+			return nil, fmt.Errorf("unknown key '%s' in the config file %s", key, path)
+		}
+		keys[key] = true
+	}
+
+	err = v.MergeConfigMap(file.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// getList reads a list that is a comma separated string in the env vars and
+// can also be a list in the config file
+func getList(v *viper.Viper, key string) []string {
+	if values, ok := v.Get(key).([]any); ok {
+		list := []string{}
+		for _, value := range values {
+			list = append(list, splitList(fmt.Sprint(value))...)
+		}
+
+		return list
 	}
 
-	return v
+	return splitList(v.GetString(key))
 }
 
-func mustGetInt(key string) int {
-	v := viper.GetInt(key)
-	if v == 0 {
-		log.Fatal().Msgf("required config value for key '%s' is missing or empty", key)
+// splitList splits a comma separated value, viper splits the env values on
+// spaces
+func splitList(value string) []string {
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
+		}
 	}
 
-	return viper.GetInt(key)
+	return list
 }
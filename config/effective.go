@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const redacted = "[redacted]"
+
+// Setting is a key of the effective config
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // flag, env, file, default
+}
+
+// Keys are the keys of the config, in the order of the fields
+func Keys() []string {
+	keys := []string{}
+	t := reflect.TypeOf(Config{})
+	for i := range t.NumField() {
+		if key := t.Field(i).Tag.Get("key"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// isKnownKey tells if the key is a key of the config or the <KEY>_FILE of
+// one
+func isKnownKey(key string) bool {
+	return slices.Contains(Keys(), key) || slices.Contains(Keys(), strings.TrimSuffix(key, "_FILE"))
+}
+
+// Effective returns the value of every key, the secrets are redacted and
+// only the password of DATABASE_URL is hidden.
+func (c *Config) Effective() []Setting {
+	settings := []Setting{}
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := field.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+
+		value := formatValue(v.Field(i).Interface())
+		switch field.Tag.Get("secret") {
+		case "url":
+			value = redactConnString(value)
+		case "true":
+			if value != "" {
+				value = redacted
+			}
+		}
+
+		source := c.sources[key]
+		if source == "" {
+			source = SourceDefault
+		}
+
+		settings = append(settings, Setting{Key: key, Value: value, Source: source})
+	}
+
+	return settings
+}
+
+// SecretValues returns the values of the secrets and of the keys read from a
+// secret provider, the password of DATABASE_URL is left to the database
+func (c *Config) SecretValues() []string {
+	values := []string{}
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := field.Tag.Get("key")
+		if key == "" || field.Tag.Get("secret") == "url" {
+			continue
+		}
+
+		_, fromProvider := c.secretRefs[key]
+		if field.Tag.Get("secret") == "true" || fromProvider {
+			values = append(values, formatValue(v.Field(i).Interface()))
+			// each of the keys of a list can be logged alone
+			if list, ok := v.Field(i).Interface().([]string); ok && len(list) > 1 {
+				values = append(values, list...)
+			}
+		}
+	}
+
+	return values
+}
+
+// Print writes the effective config as a table
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, setting := range c.Effective() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+	}
+
+	return tw.Flush()
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'])*'|\S+)`)
+
+// redactConnString hides the password of a connection string, in the URL
+// and in the key=value forms, like url.URL.Redacted does
+func redactConnString(conn string) string {
+	u, err := url.Parse(conn)
+	if err == nil && u.Scheme != "" {
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", "xxxxx")
+			u.RawQuery = query.Encode()
+		}
+
+		return u.Redacted()
+	}
+
+	return dsnPassword.ReplaceAllString(conn, "${1}xxxxx")
+}
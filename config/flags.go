@@ -0,0 +1,85 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Flags are the config flags of the commands, they win over the env vars
+// and the config file. Set has the values of --set by key.
+type Flags struct {
+	File        string
+	Port        int
+	AppEnv      string
+	DatabaseURL string
+	Set         map[string]string
+}
+
+// AddFlags defines the config flags in the flag set of a command
+func AddFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{Set: map[string]string{}}
+
+	fs.StringVar(&flags.File, "config", "", "config file, .yaml, .toml or .json (default $CONFIG_FILE)")
+	fs.IntVar(&flags.Port, "port", 0, "port of the server, overrides PORT")
+	fs.StringVar(&flags.AppEnv, "app-env", "", "development, qa or production, overrides APP_ENV")
+	fs.StringVar(&flags.DatabaseURL, "database-url", "", "overrides DATABASE_URL")
+	fs.Func("set", "overrides any config key as `KEY=VALUE`, can be repeated", func(value string) error {
+		key, v, ok := strings.Cut(value, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("'%s' must be KEY=VALUE", value)
+		}
+		if !isKnownKey(key) {
+			return fmt.Errorf("unknown config key '%s'", key)
+		}
+
+		flags.Set[key] = v
+		return nil
+	})
+
+	return flags
+}
+
+func (f *Flags) configFile() string {
+	if f.File != "" {
+		return f.File
+	}
+
+	return os.Getenv("CONFIG_FILE")
+}
+
+// apply sets the values of the flags in v, they take precedence over
+// everything else. It returns the keys that were set.
+func (f *Flags) apply(v *viper.Viper) (map[string]bool, error) {
+	values := map[string]string{}
+	for key, value := range f.Set {
+		if !isKnownKey(key) {
+			return nil, fmt.Errorf("unknown config key '%s'", key)
+		}
+		values[key] = value
+	}
+
+	// the dedicated flags win over --set
+	if f.Port != 0 {
+		values["PORT"] = strconv.Itoa(f.Port)
+	}
+	if f.AppEnv != "" {
+		values["APP_ENV"] = f.AppEnv
+	}
+	if f.DatabaseURL != "" {
+		values["DATABASE_URL"] = f.DatabaseURL
+	}
+
+	keys := map[string]bool{}
+	for key, value := range values {
+		v.Set(key, value)
+		keys[key] = true
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Holder keeps the current config of the server. Reload reads the config
+// again and applies the keys tagged reload, the other keys need a restart and
+// keep their value. The readers always see a whole config.
+type Holder struct {
+	flags   *Flags
+	current atomic.Pointer[Config]
+
+	mu       sync.Mutex
+	onReload []func(*Config)
+}
+
+// NewHolder keeps the config loaded with the flags, they are used again on
+// every reload
+func NewHolder(config *Config, flags *Flags) *Holder {
+	h := &Holder{flags: flags}
+	h.current.Store(config)
+
+	return h
+}
+
+// Get returns the current config, it must not be changed
+func (h *Holder) Get() *Config {
+	return h.current.Load()
+}
+
+// OnReload calls fn with the new config after every reload that changed a
+// key
+func (h *Holder) OnReload(fn func(*Config)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.onReload = append(h.onReload, fn)
+}
+
+// Reload reads the config again and returns the reloadable keys that
+// changed. The current config stays when the new one isn't valid.
+func (h *Holder) Reload() ([]string, error) {
+	next, err := Load(h.flags)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.Get()
+	reloaded := *current
+	reloaded.sources = maps.Clone(current.sources)
+
+	changed := []string{}
+	t := reflect.TypeOf(reloaded)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := field.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+
+		value := next.field(key).Interface()
+		if reflect.DeepEqual(current.field(key).Interface(), value) {
+			continue
+		}
+
+		// the secrets are refreshed by their store
+		if _, ok := current.secretRefs[key]; ok {
+			continue
+		}
+
+		if field.Tag.Get("reload") != "true" {
+			log.Warn().Msgf("config: %s changed, restart the server to apply it", key)
+			continue
+		}
+
+		reloaded.field(key).Set(reflect.ValueOf(value))
+		reloaded.sources[key] = next.sources[key]
+		changed = append(changed, key)
+	}
+
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	h.current.Store(&reloaded)
+	for _, fn := range h.onReload {
+		fn(&reloaded)
+	}
+
+	return changed, nil
+}
+
+// Watch reloads the config on SIGHUP and when the config file changes, until
+// the context is done. The file isn't watched when it can't be.
+func (h *Holder) Watch(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var fileEvents <-chan fsnotify.Event
+	var fileErrors <-chan error
+	file := h.flags.configFile()
+	if file != "" {
+		watcher, err := watchFile(file)
+		if err != nil {
+			log.Err(err).Msgf("config: unable to watch %s, it's only reloaded on SIGHUP", file)
+		} else {
+			defer watcher.Close()
+			fileEvents = watcher.Events
+			fileErrors = watcher.Errors
+		}
+		file, _ = filepath.Abs(file)
+	}
+
+	// a save is often several events, they are reloaded once
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			h.reloadAndLog("SIGHUP")
+		case event := <-fileEvents:
+			if filepath.Clean(event.Name) == file && !event.Has(fsnotify.Chmod) {
+				debounce.Reset(100 * time.Millisecond)
+			}
+		case err := <-fileErrors:
+			log.Err(err).Msg("config: error watching the config file")
+		case <-debounce.C:
+			h.reloadAndLog(filepath.Base(file) + " changed")
+		}
+	}
+}
+
+// watchFile watches the directory of the file, the editors and the config
+// maps of kubernetes replace the file instead of writing it
+func watchFile(file string) (*fsnotify.Watcher, error) {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = watcher.Add(dir)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+func (h *Holder) reloadAndLog(reason string) {
+	changed, err := h.Reload()
+	if err != nil {
+		log.Error().Msgf("config: the reload after %s failed, the current config stays:\n%s", reason, err)
+		return
+	}
+
+	log.Info().Strs("changed", changed).Msgf("config: reloaded after %s", reason)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"shopping/secrets"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// the keys that configure the providers, they can only be read from files
+var providerKeys = []string{"VAULT_ADDR", "VAULT_TOKEN", "AWS_REGION"}
+
+// resolveSecrets replaces the values read from <KEY>_FILE or that are
+// references to a secret provider. The providers are configured first, so
+// only their keys can't come from Vault or AWS.
+func (c *Config) resolveSecrets(v *viper.Viper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.secretResolver = secrets.NewResolver()
+	for _, key := range providerKeys {
+		err := c.resolveSecret(ctx, v, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.VaultAddr != "" {
+		c.secretResolver.Register(secrets.SchemeVault, secrets.NewVault(c.VaultAddr, c.VaultToken, &http.Client{Timeout: 5 * time.Second}))
+	}
+	if c.AWSRegion != "" {
+		c.secretResolver.Register(secrets.SchemeAWS, secrets.NewAWSSecretsManager(c.AWSRegion, &http.Client{Timeout: 5 * time.Second}))
+	}
+
+	for _, key := range Keys() {
+		if !slices.Contains(providerKeys, key) {
+			err := c.resolveSecret(ctx, v, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) resolveSecret(ctx context.Context, v *viper.Viper, key string) error {
+	field := c.field(key)
+	if field.Kind() != reflect.String {
+		return nil
+	}
+
+	ref := ""
+	if file := v.GetString(key + "_FILE"); file != "" {
+		ref = secrets.SchemeFile + ":" + file
+	} else if secrets.IsReference(field.String()) {
+		ref = field.String()
+	}
+	if ref == "" {
+		return nil
+	}
+
+	value, err := c.secretResolver.Resolve(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	field.SetString(value)
+	c.secretRefs[key] = ref
+	c.sources[key] = SourceSecret
+
+	return nil
+}
+
+// field returns the field of the key, it can be set
+func (c *Config) field(key string) reflect.Value {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		if t.Field(i).Tag.Get("key") == key {
+			return v.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+// SecretStore keeps the current value of the keys read from a secret
+// provider, they change when the store is refreshed
+func (c *Config) SecretStore() *secrets.Store {
+	values := map[string]string{}
+	for key := range c.secretRefs {
+		values[key] = c.field(key).String()
+	}
+
+	resolver := c.secretResolver
+	if resolver == nil {
+		resolver = secrets.NewResolver()
+	}
+
+	return secrets.NewStore(resolver, c.secretRefs, values)
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"shopping/clientip"
+	"shopping/keyring"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Validate checks the whole config, the error lists every problem found
+func (c *Config) Validate() error {
+	errs := []error{}
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.DBUrl == "" {
+		fail("required config key 'DATABASE_URL' is missing or empty")
+	} else if _, err := pgx.ParseConfig(c.DBUrl); err != nil {
+		fail("'DATABASE_URL' is not a valid connection string")
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		fail("'PORT' must be between 1 and 65535, got %d", c.Port)
+	}
+
+	switch c.AppEnv {
+	case AppEnvDevelopment, AppEnvQA, AppEnvProduction:
+	case "":
+		fail("required config key 'APP_ENV' is missing or empty")
+	default:
+		fail("'APP_ENV' must be development, qa or production, got '%s'", c.AppEnv)
+	}
+
+	switch c.LogLevel {
+	case "", "trace", "debug", "info", "warn", "error":
+	default:
+		fail("'LOG_LEVEL' must be trace, debug, info, warn or error, got '%s'", c.LogLevel)
+	}
+
+	switch c.LogFormat {
+	case "", LogFormatJSON, LogFormatPretty:
+	default:
+		fail("'LOG_FORMAT' must be json or pretty, got '%s'", c.LogFormat)
+	}
+
+	if c.LogSampleRate < 0 {
+		fail("'LOG_SAMPLE_RATE' can't be negative")
+	}
+
+	if c.ListsCacheWarm < 0 {
+		fail("'LISTS_CACHE_WARM' can't be negative")
+	}
+
+	if c.ListsCacheTTL < 0 {
+		fail("'LISTS_CACHE_TTL' can't be negative")
+	}
+
+	if c.ListsCacheMissingTTL < 0 {
+		fail("'LISTS_CACHE_MISSING_TTL' can't be negative")
+	}
+
+	switch c.DBLogLevel {
+	case "", "none", "error", "warn", "info", "debug", "trace":
+	default:
+		fail("'DB_LOG_LEVEL' must be none, error, warn, info, debug or trace, got '%s'", c.DBLogLevel)
+	}
+
+	switch c.DBQueryExecMode {
+	case "", "describe_exec", "exec", "simple_protocol":
+	case "cache_statement", "cache_describe":
+		if c.DBStatementCacheSize <= 0 {
+			fail("'DB_STATEMENT_CACHE_SIZE' must be positive with the '%s' mode of 'DB_QUERY_EXEC_MODE'", c.DBQueryExecMode)
+		}
+	default:
+		fail("'DB_QUERY_EXEC_MODE' must be cache_statement, cache_describe, describe_exec, exec or simple_protocol, got '%s'", c.DBQueryExecMode)
+	}
+
+	switch c.SwaggerAccess {
+	case SwaggerAccessOpen, SwaggerAccessDisabled:
+	case SwaggerAccessBasicAuth:
+		if c.SwaggerUser == "" || c.SwaggerPassword == "" {
+			fail("'SWAGGER_USER' and 'SWAGGER_PASSWORD' are required when 'SWAGGER_ACCESS' is basic_auth")
+		}
+	default:
+		fail("'SWAGGER_ACCESS' must be open, basic_auth or disabled, got '%s'", c.SwaggerAccess)
+	}
+
+	switch c.DBSSLMode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		fail("'DB_SSL_MODE' must be disable, require, verify-ca or verify-full, got '%s'", c.DBSSLMode)
+	}
+
+	if (c.DBSSLCert == "") != (c.DBSSLKey == "") {
+		fail("'DB_SSL_CERT' and 'DB_SSL_KEY' must be set together")
+	}
+
+	if c.DBRetryMaxAttempts < 1 {
+		fail("'DB_RETRY_MAX_ATTEMPTS' must be at least 1, got %d", c.DBRetryMaxAttempts)
+	}
+
+	positive := map[string]time.Duration{
+		"DB_READ_TIMEOUT":               c.DBReadTimeout,
+		"DB_WRITE_TIMEOUT":              c.DBWriteTimeout,
+		"DB_TRANSACTION_TIMEOUT":        c.DBTransactionTimeout,
+		"DB_STATEMENT_TIMEOUT":          c.DBStatementTimeout,
+		"SHARE_LINK_TTL":                c.ShareLinkTTL,
+		"SIGNING_KEYS_REFRESH_INTERVAL": c.SigningKeysRefreshInterval,
+		"OUTBOX_POLL_INTERVAL":          c.OutboxPollInterval,
+	}
+	for _, key := range Keys() {
+		if d, ok := positive[key]; ok && d <= 0 {
+			fail("'%s' must be a positive duration like 3s, got '%s'", key, d)
+		}
+	}
+
+	if c.SandboxResetInterval < 0 {
+		fail("'SANDBOX_RESET_INTERVAL' can't be negative")
+	}
+	if c.ConsistencyCheckInterval < 0 {
+		fail("'CONSISTENCY_CHECK_INTERVAL' can't be negative")
+	}
+	if c.SecretsRefreshInterval < 0 {
+		fail("'SECRETS_REFRESH_INTERVAL' can't be negative")
+	}
+	switch c.SessionCookieSameSite {
+	case "", SameSiteLax, SameSiteStrict:
+	case SameSiteNone:
+		// the browsers refuse the cookies of any site that aren't secure
+		if !c.SessionCookieSecure {
+			fail("'SESSION_COOKIE_SAMESITE' none requires 'SESSION_COOKIE_SECURE'")
+		}
+	default:
+		fail("'SESSION_COOKIE_SAMESITE' must be lax, strict or none, got '%s'", c.SessionCookieSameSite)
+	}
+	if c.PasswordMinLength < 8 || c.PasswordMinLength > 256 {
+		fail("'PASSWORD_MIN_LENGTH' must be between 8 and 256, got %d", c.PasswordMinLength)
+	}
+	if c.PasswordMinScore < 0 || c.PasswordMinScore > 4 {
+		fail("'PASSWORD_MIN_SCORE' must be between 0 and 4, got %d", c.PasswordMinScore)
+	}
+	if _, err := keyring.Parse(c.ShareLinkKeys); err != nil {
+		fail("'SHARE_LINK_KEYS' is not valid: %s", err)
+	}
+	if _, err := keyring.Parse(c.SignedURLKeys); err != nil {
+		fail("'SIGNED_URL_KEYS' is not valid: %s", err)
+	}
+	if c.SignedURLTTL <= 0 || c.SignedURLMaxTTL < c.SignedURLTTL {
+		fail("'SIGNED_URL_TTL' must be positive and at most 'SIGNED_URL_MAX_TTL'")
+	}
+	if c.UndoWindow < 0 {
+		fail("'UNDO_WINDOW' can't be negative")
+	}
+	if c.AccountDeletionGrace < 0 {
+		fail("'ACCOUNT_DELETION_GRACE' can't be negative")
+	}
+	if c.AccessLog && c.AccessLogSampleRate < 1 {
+		fail("'ACCESS_LOG_SAMPLE_RATE' must be at least 1, got %d", c.AccessLogSampleRate)
+	}
+	if c.AccessLogRetention < 0 {
+		fail("'ACCESS_LOG_RETENTION' can't be negative")
+	}
+	if c.RequestTimeout < 0 {
+		fail("'REQUEST_TIMEOUT' can't be negative")
+	}
+	if c.QuotaMaxLists < 0 || c.QuotaMaxItems < 0 || c.QuotaRequestsPerMinute < 0 {
+		fail("the quotas can't be negative, 0 is no limit")
+	}
+
+	if strings.Contains(c.TenantBaseDomain, "/") {
+		fail("'TENANT_BASE_DOMAIN' must be a domain like shopping.example.com, got '%s'", c.TenantBaseDomain)
+	}
+
+	if c.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.SMTPAddr); err != nil {
+			fail("'SMTP_ADDR' must be a host:port like smtp.example.com:587, got '%s'", c.SMTPAddr)
+		}
+		if c.SMTPFrom == "" {
+			fail("'SMTP_FROM' is required when 'SMTP_ADDR' is set")
+		}
+	}
+
+	if c.MaxConcurrentRequests <= 0 || c.MaxQueuedRequests < 0 {
+		fail("'MAX_CONCURRENT_REQUESTS' must be positive and 'MAX_QUEUED_REQUESTS' can't be negative")
+	}
+
+	switch c.AuthzEngine {
+	case "", "builtin":
+	case "opa":
+		if c.OPAUrl == "" {
+			fail("'OPA_URL' is required when 'AUTHZ_ENGINE' is opa")
+		}
+	default:
+		fail("'AUTHZ_ENGINE' must be builtin or opa, got '%s'", c.AuthzEngine)
+	}
+
+	switch c.SearchBackend {
+	case "", "postgres":
+	case "meilisearch":
+		if c.MeilisearchURL == "" {
+			fail("'MEILISEARCH_URL' is required when 'SEARCH_BACKEND' is meilisearch")
+		}
+	default:
+		fail("'SEARCH_BACKEND' must be postgres or meilisearch, got '%s'", c.SearchBackend)
+	}
+
+	switch c.ProductsProvider {
+	case "", "openfoodfacts", "none":
+	default:
+		fail("'PRODUCTS_PROVIDER' must be openfoodfacts or none, got '%s'", c.ProductsProvider)
+	}
+	if c.ProductsCacheTTL < 0 {
+		fail("'PRODUCTS_CACHE_TTL' can't be negative")
+	}
+
+	switch c.BlobStore {
+	case "", "disk":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			fail("'S3_ENDPOINT', 'S3_BUCKET', 'S3_ACCESS_KEY_ID' and 'S3_SECRET_ACCESS_KEY' are required when 'BLOB_STORE' is s3")
+		}
+	default:
+		fail("'BLOB_STORE' must be disk or s3, got '%s'", c.BlobStore)
+	}
+	if c.PhotoMaxBytes < 0 {
+		fail("'PHOTO_MAX_BYTES' can't be negative")
+	}
+	if c.PhotoMaxBytes > 0 && c.PhotoURLTTL <= 0 {
+		fail("'PHOTO_URL_TTL' must be a positive duration like 15m when the photos are enabled")
+	}
+	if c.BackupS3Bucket != "" && (c.S3Endpoint == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "") {
+		fail("'S3_ENDPOINT', 'S3_ACCESS_KEY_ID' and 'S3_SECRET_ACCESS_KEY' are required when 'BACKUP_S3_BUCKET' is set")
+	}
+	if c.BackupInterval < 0 {
+		fail("'BACKUP_INTERVAL' can't be negative")
+	}
+	if c.BackupInterval > 0 && c.BackupS3Bucket == "" {
+		fail("'BACKUP_S3_BUCKET' is required when 'BACKUP_INTERVAL' is set")
+	}
+
+	urls := map[string]string{
+		"PUBLIC_URL":        c.PublicURL,
+		"OPENFOODFACTS_URL": c.OpenFoodFactsURL,
+		"S3_ENDPOINT":       c.S3Endpoint,
+		"OPA_URL":           c.OPAUrl,
+		"MEILISEARCH_URL":   c.MeilisearchURL,
+		"VAULT_ADDR":        c.VaultAddr,
+	}
+	for _, key := range Keys() {
+		if value := urls[key]; value != "" && !isHTTPURL(value) {
+			fail("'%s' must be an http or https URL, got '%s'", key, value)
+		}
+	}
+	for _, value := range c.CORSOrigins {
+		if !isHTTPURL(value) {
+			fail("'CORS_ALLOWED_ORIGINS' must have http or https origins, got '%s'", value)
+		}
+	}
+	if _, err := clientip.ParsePrefixes(c.TrustedProxies); err != nil {
+		fail("'TRUSTED_PROXIES' must have IP addresses or CIDRs: %s", err)
+	}
+	if _, err := clientip.NewFilter(c.AdminAllowIPs, c.AdminDenyIPs); err != nil {
+		fail("'ADMIN_ALLOW_IPS' and 'ADMIN_DENY_IPS' must have IP addresses or CIDRs: %s", err)
+	}
+	for _, value := range c.OutboxWebhookURLs {
+		if !isHTTPURL(value) {
+			fail("'OUTBOX_WEBHOOK_URLS' must have http or https URLs, got '%s'", value)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"shopping/consistency"
+	"shopping/render"
+	"shopping/repository"
+	"strconv"
+)
+
+// consistencyChecks compare the derived data with the tables it comes from.
+// The caches are checked by every instance, each one has its own.
+func (app *App) consistencyChecks() []consistency.Check {
+	return []consistency.Check{
+		{Name: "search_index", Run: app.checkSearchIndex},
+		{Name: "lists_cache", Run: app.checkListsCache},
+		{Name: "stats_cache", Run: app.checkStatsCache},
+	}
+}
+
+func (app *App) checkSearchIndex(ctx context.Context, repair bool) (consistency.Result, error) {
+	drift, err := app.SearchIndex.Verify(ctx, app.searchDocuments, repair)
+
+	result := consistency.Result{Checked: drift.Checked}
+	for _, id := range drift.Missing {
+		result.AddDetail("missing list " + id)
+	}
+	for _, id := range drift.Stale {
+		result.AddDetail("stale list " + id)
+	}
+	for _, id := range drift.Outdated {
+		result.AddDetail("outdated list " + id)
+	}
+	if repair && err == nil {
+		result.Repaired = result.Discrepancies
+	}
+
+	return result, err
+}
+
+// checkListsCache drops the cached lists that don't match the database, the
+// lists changed while they are compared are dropped too and read again.
+func (app *App) checkListsCache(ctx context.Context, repair bool) (consistency.Result, error) {
+	result := consistency.Result{}
+	for _, id := range app.ListsCache.Keys() {
+		cached, ok := app.ListsCache.Peek(id)
+		if !ok {
+			continue
+		}
+		result.Checked++
+
+		list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return result, err
+		}
+
+		if err == nil && bytes.Equal(cached.JSON, newCachedList(*list).JSON) {
+			continue
+		}
+
+		result.AddDetail("list " + id)
+		if repair {
+			app.ListsCache.Remove(id)
+			result.Repaired++
+		}
+	}
+
+	return result, nil
+}
+
+// checkStatsCache computes the cached stats again and drops the ones that
+// changed, the stats are only invalidated when a list is completed.
+func (app *App) checkStatsCache(ctx context.Context, repair bool) (consistency.Result, error) {
+	result := consistency.Result{}
+	for _, key := range app.StatsCache.Keys() {
+		cached, ok := app.StatsCache.Peek(key)
+		if !ok {
+			continue
+		}
+		result.Checked++
+
+		username, kind, n, ok := parseStatsKey(key)
+		compute := statsFuncs[kind]
+		if !ok || compute == nil {
+			return result, fmt.Errorf("invalid key of the stats cache: %s", key)
+		}
+
+		stats, err := compute(app, username, n)
+		if err != nil {
+			return result, err
+		}
+
+		if reflect.DeepEqual(cached, stats) {
+			continue
+		}
+
+		result.AddDetail("stats " + key)
+		if repair {
+			app.StatsCache.Remove(key)
+			result.Repaired++
+		}
+	}
+
+	return result, nil
+}
+
+func (app *App) handleConsistencyReport(w http.ResponseWriter, r *http.Request) {
+	report := app.Consistency.Last()
+	if report == nil {
+		http.Error(w, "the consistency checks didn't run yet", http.StatusNotFound)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, report)
+}
+
+// handleRunConsistencyChecks runs the checks now, they only report the
+// discrepancies unless `repair=true`
+func (app *App) handleRunConsistencyChecks(w http.ResponseWriter, r *http.Request) {
+	repair := false
+	if raw := r.URL.Query().Get("repair"); raw != "" {
+		var err error
+		repair, err = strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "'repair' must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	render.JSON(w, http.StatusOK, app.Consistency.RunOnce(r.Context(), repair))
+}
@@ -0,0 +1,142 @@
+package consistency
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxDetails is the number of discrepancies listed in a result, the rest
+// are only counted
+const maxDetails = 20
+
+// Stats are published in /debug/vars as consistency: runs is the number of
+// runs since the start and, by check, the discrepancies found, the ones
+// repaired and the errors, like "search_index.discrepancies".
+var Stats = expvar.NewMap("consistency")
+
+// Check compares a derived copy of the data, like an index or a cache, with
+// its source. Run returns the discrepancies and fixes them when repair is
+// true.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context, repair bool) (Result, error)
+}
+
+type Result struct {
+	Check         string   `json:"check"`
+	Checked       int      `json:"checked"`
+	Discrepancies int      `json:"discrepancies"`
+	Repaired      int      `json:"repaired"`
+	Details       []string `json:"details"`
+	Error         string   `json:"error,omitempty"`
+	Duration      string   `json:"duration"`
+}
+
+// AddDetail counts a discrepancy and keeps its description while there is
+// room
+func (r *Result) AddDetail(detail string) {
+	r.Discrepancies++
+	if len(r.Details) < maxDetails {
+		r.Details = append(r.Details, detail)
+	}
+}
+
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Repair     bool      `json:"repair"`
+	Results    []Result  `json:"results"`
+}
+
+// Discrepancies is the total of every check
+func (r Report) Discrepancies() int {
+	total := 0
+	for _, result := range r.Results {
+		total += result.Discrepancies
+	}
+
+	return total
+}
+
+// Checker runs the checks one after the other and keeps the last report,
+// the runs don't overlap.
+type Checker struct {
+	checks []Check
+
+	runMu sync.Mutex
+	mu    sync.Mutex
+	last  *Report
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		checks: checks,
+	}
+}
+
+// RunOnce runs every check now, the error of a check is recorded in its
+// result and the others still run
+func (c *Checker) RunOnce(ctx context.Context, repair bool) Report {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	report := Report{StartedAt: time.Now().UTC(), Repair: repair, Results: []Result{}}
+	for _, check := range c.checks {
+		start := time.Now()
+		result, err := check.Run(ctx, repair)
+		result.Check = check.Name
+		result.Duration = time.Since(start).String()
+		if result.Details == nil {
+			result.Details = []string{}
+		}
+
+		if err != nil {
+			log.Err(err).Msgf("consistency: the check %s failed", check.Name)
+			result.Error = err.Error()
+			Stats.Add(check.Name+".errors", 1)
+		}
+		if result.Discrepancies > 0 {
+			log.Warn().Msgf("consistency: %s has %d discrepancies, %d repaired", check.Name, result.Discrepancies, result.Repaired)
+		}
+		Stats.Add(check.Name+".discrepancies", int64(result.Discrepancies))
+		Stats.Add(check.Name+".repaired", int64(result.Repaired))
+
+		report.Results = append(report.Results, result)
+	}
+	report.FinishedAt = time.Now().UTC()
+	Stats.Add("runs", 1)
+
+	c.mu.Lock()
+	c.last = &report
+	c.mu.Unlock()
+
+	return report
+}
+
+// Last returns the report of the last run, nil before the first one
+func (c *Checker) Last() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.last
+}
+
+// Run runs the checks on every interval until the context is done, repair is
+// asked on every run since it can be reloaded
+func (c *Checker) Run(ctx context.Context, interval time.Duration, repair func() bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.RunOnce(ctx, repair())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package consistency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	repairs := 0
+	checker := NewChecker(
+		Check{Name: "test_drift", Run: func(ctx context.Context, repair bool) (Result, error) {
+			result := Result{Checked: 30}
+			for i := range 25 {
+				result.AddDetail(fmt.Sprintf("entry %d", i))
+			}
+			if repair {
+				repairs++
+				result.Repaired = result.Discrepancies
+			}
+			return result, nil
+		}},
+		Check{Name: "test_broken", Run: func(ctx context.Context, repair bool) (Result, error) {
+			return Result{}, errors.New("source unavailable")
+		}},
+	)
+
+	assert.Nil(t, checker.Last())
+
+	report := checker.RunOnce(context.Background(), true)
+
+	assert.Equal(t, 1, repairs)
+	assert.Equal(t, 25, report.Discrepancies())
+	if assert.Len(t, report.Results, 2) {
+		drift := report.Results[0]
+		assert.Equal(t, "test_drift", drift.Check)
+		assert.Equal(t, 25, drift.Repaired)
+		assert.Len(t, drift.Details, maxDetails)
+
+		broken := report.Results[1]
+		assert.Equal(t, "source unavailable", broken.Error)
+		assert.Equal(t, []string{}, broken.Details)
+	}
+	assert.Equal(t, &report, checker.Last())
+
+	assert.Equal(t, "25", Stats.Get("test_drift.discrepancies").String())
+	assert.Equal(t, "1", Stats.Get("test_broken.errors").String())
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"shopping/logging"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// password replaces the password of DATABASE_URL in the new connections
+// when it's set, the open connections keep working with the previous one
+var password atomic.Pointer[string]
+
+// SetPassword changes the password of the new connections, it's called when
+// the DATABASE_URL read from a secret provider changes
+func SetPassword(connString string) error {
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return err
+	}
+
+	password.Store(&config.Password)
+	logging.AddSecret(config.Password)
+
+	return nil
+}
+
+func beforeConnect(ctx context.Context, config *pgx.ConnConfig) error {
+	if p := password.Load(); p != nil {
+		config.Password = *p
+	}
+
+	return nil
+}
@@ -2,11 +2,16 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"shopping/config"
+	"shopping/logging"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -19,11 +24,45 @@ func NewDB(config *config.Config) (*pgxpool.Pool, error) {
 		return nil, err
 	}
 
+	err = applyTLS(&dbConfig.ConnConfig.Config, TLSOptions{
+		Mode:     config.DBSSLMode,
+		RootCert: config.DBSSLRootCert,
+		Cert:     config.DBSSLCert,
+		Key:      config.DBSSLKey,
+		Pins:     config.DBSSLPins,
+	})
+	if err != nil {
+		log.Err(err).Msg("there was an error creating the database TLS configuration")
+		return nil, err
+	}
+
+	// the server stops the statements that outlive the deadline of the
+	// client, so they don't keep running on a connection nobody waits for
+	dbConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.DBStatementTimeout.Milliseconds(), 10)
+
+	// the password can be changed by a refresh of the secrets
+	dbConfig.BeforeConnect = beforeConnect
+	logging.AddSecret(dbConfig.ConnConfig.Password)
+
+	err = applyQueryExecMode(dbConfig.ConnConfig, config.DBQueryExecMode, config.DBStatementCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
 	dbConfig.MaxConns = 30
 	dbConfig.MaxConnIdleTime = 15 * time.Minute
-	dbConfig.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger:   tracelog.LoggerFunc(logFunc),
-		LogLevel: tracelog.LogLevelDebug,
+
+	// the statements are logged with their arguments, so only when asked
+	if config.DBLogLevel != "" && config.DBLogLevel != "none" {
+		level, err := tracelog.LogLevelFromString(config.DBLogLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		dbConfig.ConnConfig.Tracer = &tracelog.TraceLog{
+			Logger:   tracelog.LoggerFunc(logFunc),
+			LogLevel: level,
+		}
 	}
 
 	dbpool, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
@@ -43,6 +82,55 @@ func NewDB(config *config.Config) (*pgxpool.Pool, error) {
 	return dbpool, nil
 }
 
+// queryExecModes are the values of DB_QUERY_EXEC_MODE
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// applyQueryExecMode sets how the queries are sent, the mode of the url is
+// kept when it's empty. Only the cache of the mode is created: the prepared
+// statements of cache_statement or the descriptions of cache_describe.
+func applyQueryExecMode(connConfig *pgx.ConnConfig, mode string, cacheSize int) error {
+	if mode == "" {
+		return nil
+	}
+
+	execMode, ok := queryExecModes[mode]
+	if !ok {
+		return fmt.Errorf("database: unknown query exec mode '%s'", mode)
+	}
+
+	connConfig.DefaultQueryExecMode = execMode
+	connConfig.StatementCacheCapacity = 0
+	connConfig.DescriptionCacheCapacity = 0
+	switch execMode {
+	case pgx.QueryExecModeCacheStatement:
+		connConfig.StatementCacheCapacity = cacheSize
+	case pgx.QueryExecModeCacheDescribe:
+		connConfig.DescriptionCacheCapacity = cacheSize
+	}
+
+	return nil
+}
+
+var zerologLevels = map[tracelog.LogLevel]zerolog.Level{
+	tracelog.LogLevelTrace: zerolog.TraceLevel,
+	tracelog.LogLevelDebug: zerolog.DebugLevel,
+	tracelog.LogLevelInfo:  zerolog.InfoLevel,
+	tracelog.LogLevelWarn:  zerolog.WarnLevel,
+	tracelog.LogLevelError: zerolog.ErrorLevel,
+}
+
 func logFunc(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]interface{}) {
-	log.Debug().Msgf("[%s] %s %v", level, msg, data)
+	// the arguments have the tokens of the sessions and the data of the
+	// users
+	if args, ok := data["args"].([]any); ok {
+		data["args"] = logging.RedactArgs(args)
+	}
+
+	log.WithLevel(zerologLevels[level]).Fields(data).Msgf("pgx: %s", msg)
 }
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFuncRedactsTheArgs(t *testing.T) {
+	logger := log.Logger
+	t.Cleanup(func() { log.Logger = logger })
+
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+
+	logFunc(context.Background(), tracelog.LogLevelInfo, "Query", map[string]any{
+		"sql":  "SELECT username FROM sessions WHERE token = $1 AND expires_at > now() LIMIT $2",
+		"args": []any{"tok-1234567890", 1},
+	})
+
+	assert.NotContains(t, buf.String(), "tok-1234567890")
+	assert.Contains(t, buf.String(), `"args":["[redacted]",1]`)
+	assert.Contains(t, buf.String(), `"level":"info"`)
+}
+
+func TestApplyQueryExecMode(t *testing.T) {
+	for _, tc := range []struct {
+		mode      string
+		execMode  pgx.QueryExecMode
+		statement int
+		describe  int
+	}{
+		{mode: "cache_statement", execMode: pgx.QueryExecModeCacheStatement, statement: 64},
+		{mode: "cache_describe", execMode: pgx.QueryExecModeCacheDescribe, describe: 64},
+		{mode: "describe_exec", execMode: pgx.QueryExecModeDescribeExec},
+		{mode: "exec", execMode: pgx.QueryExecModeExec},
+		{mode: "simple_protocol", execMode: pgx.QueryExecModeSimpleProtocol},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			connConfig, err := pgx.ParseConfig("postgres://shopping@localhost/shopping")
+			assert.NoError(t, err)
+
+			assert.NoError(t, applyQueryExecMode(connConfig, tc.mode, 64))
+			assert.Equal(t, tc.execMode, connConfig.DefaultQueryExecMode)
+			assert.Equal(t, tc.statement, connConfig.StatementCacheCapacity)
+			assert.Equal(t, tc.describe, connConfig.DescriptionCacheCapacity)
+		})
+	}
+
+	t.Run("keeps the mode of the url when empty", func(t *testing.T) {
+		connConfig, err := pgx.ParseConfig("postgres://shopping@localhost/shopping?default_query_exec_mode=exec")
+		assert.NoError(t, err)
+
+		assert.NoError(t, applyQueryExecMode(connConfig, "", 64))
+		assert.Equal(t, pgx.QueryExecModeExec, connConfig.DefaultQueryExecMode)
+	})
+
+	t.Run("rejects the unknown modes", func(t *testing.T) {
+		connConfig, err := pgx.ParseConfig("postgres://shopping@localhost/shopping")
+		assert.NoError(t, err)
+
+		assert.Error(t, applyQueryExecMode(connConfig, "prepared", 64))
+	})
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// ShoppingListChannel receives the id of every list that is inserted, updated
+// or deleted, it's notified by a trigger of the shopping_lists table.
+const ShoppingListChannel = "shopping_list_changed"
+
+// Listener keeps a connection of the pool listening to a channel, it connects
+// again with a backoff when the connection is lost.
+type Listener struct {
+	Pool    *pgxpool.Pool
+	Channel string
+	// OnNotification is called with the payload of each notification
+	OnNotification func(payload string)
+	// OnConnect is called every time the listener starts listening, the
+	// notifications sent while it was disconnected are lost so it's the
+	// place to drop what may be stale
+	OnConnect func()
+}
+
+// Run listens until the context is done
+func (l *Listener) Run(ctx context.Context) {
+	wait := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// only the consecutive failures increase the wait
+		if time.Since(start) > time.Minute {
+			wait = time.Second
+		}
+
+		log.Warn().Err(err).Msgf("database: lost the connection listening to '%s', retrying in %s", l.Channel, wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		wait = min(wait*2, time.Minute)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	pooled, err := l.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection is in LISTEN mode, it can't go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.Channel}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("> listening to the database channel '%s'", l.Channel)
+	if l.OnConnect != nil {
+		l.OnConnect()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		l.OnNotification(notification.Payload)
+	}
+}
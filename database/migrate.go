@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migration is a pair of files named like golang-migrate expects them:
+// 000001_name.up.sql and 000001_name.down.sql
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+var ErrDirty = errors.New("database: the last migration failed, fix the schema and force the version")
+
+// migrationsLock is the advisory lock held while migrating, so two instances
+// starting at the same time don't run the same migration
+const migrationsLock = 7218394711
+
+// LoadMigrations reads the migrations of the directory sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, file := range files {
+		name := path.Base(file)
+		direction := ""
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("database: '%s' must end with .up.sql or .down.sql", name)
+		}
+
+		prefix, rest, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("database: '%s' must start with a version like 000001_", name)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[uint(version)]
+		if m == nil {
+			m = &Migration{Version: uint(version), Name: strings.TrimSuffix(rest, "."+direction+".sql")}
+			byVersion[uint(version)] = m
+		}
+
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return int(a.Version) - int(b.Version) })
+
+	return migrations, nil
+}
+
+// Migrator applies the migrations keeping the version in the
+// schema_migrations table of golang-migrate, so both tools can be used on the
+// same database.
+type Migrator struct {
+	Pool       *pgxpool.Pool
+	Migrations []Migration
+}
+
+// Version returns the current version, 0 when no migration was applied
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	conn, err := m.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Release()
+
+	err = ensureVersionTable(ctx, conn.Conn())
+	if err != nil {
+		return 0, false, err
+	}
+
+	return currentVersion(ctx, conn.Conn())
+}
+
+// Up applies the next steps migrations, all the pending ones when steps is 0
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	return m.migrate(ctx, func(version uint) []Migration {
+		pending := []Migration{}
+		for _, migration := range m.Migrations {
+			if migration.Version > version {
+				pending = append(pending, migration)
+			}
+		}
+
+		if steps > 0 && len(pending) > steps {
+			pending = pending[:steps]
+		}
+
+		return pending
+	}, true)
+}
+
+// Down reverts the last steps migrations, all of them when steps is 0
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return m.migrate(ctx, func(version uint) []Migration {
+		applied := []Migration{}
+		for _, migration := range slices.Backward(m.Migrations) {
+			if migration.Version <= version {
+				applied = append(applied, migration)
+			}
+		}
+
+		if steps > 0 && len(applied) > steps {
+			applied = applied[:steps]
+		}
+
+		return applied
+	}, false)
+}
+
+// Force sets the version without running the migrations, it's used to clear
+// the dirty flag after fixing a failed migration by hand
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	conn, err := m.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	err = ensureVersionTable(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+
+	return setVersion(ctx, conn.Conn(), version, false)
+}
+
+func (m *Migrator) migrate(ctx context.Context, plan func(version uint) []Migration, up bool) ([]Migration, error) {
+	conn, err := m.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLock)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLock)
+
+	err = ensureVersionTable(ctx, conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+
+	done := []Migration{}
+	for _, migration := range plan(version) {
+		sql, target := migration.Up, migration.Version
+		if !up {
+			sql, target = migration.Down, m.previousVersion(migration.Version)
+		}
+
+		// the version is dirty while the migration runs, like golang-migrate
+		// does, so a failure in the middle is noticed
+		err = setVersion(ctx, conn.Conn(), target, true)
+		if err != nil {
+			return done, err
+		}
+
+		// without arguments the files run with the simple protocol, so they
+		// can have several statements
+		_, err = conn.Exec(ctx, sql)
+		if err != nil {
+			return done, fmt.Errorf("database: migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		err = setVersion(ctx, conn.Conn(), target, false)
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) previousVersion(version uint) uint {
+	previous := uint(0)
+	for _, migration := range m.Migrations {
+		if migration.Version < version {
+			previous = migration.Version
+		}
+	}
+
+	return previous
+}
+
+func ensureVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)")
+	return err
+}
+
+func currentVersion(ctx context.Context, conn *pgx.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
+
+// setVersion replaces the row of schema_migrations, there's no row at the
+// version 0
+func setVersion(ctx context.Context, conn *pgx.Conn, version uint, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "TRUNCATE schema_migrations")
+		if err != nil {
+			return err
+		}
+
+		// golang-migrate keeps the dirty row when a migration to the
+		// version 0 fails
+		if version == 0 && !dirty {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", int64(version), dirty)
+		return err
+	})
+}
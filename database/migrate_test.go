@@ -0,0 +1,49 @@
+package database
+
+import (
+	"shopping/database/migrations"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("pairs the files by version", func(t *testing.T) {
+		loaded, err := LoadMigrations(fstest.MapFS{
+			"000002_add_items.down.sql":    {Data: []byte("DROP TABLE items;")},
+			"000001_create_lists.up.sql":   {Data: []byte("CREATE TABLE lists ();")},
+			"000002_add_items.up.sql":      {Data: []byte("CREATE TABLE items ();")},
+			"000001_create_lists.down.sql": {Data: []byte("DROP TABLE lists;")},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "create_lists", Up: "CREATE TABLE lists ();", Down: "DROP TABLE lists;"},
+			{Version: 2, Name: "add_items", Up: "CREATE TABLE items ();", Down: "DROP TABLE items;"},
+		}, loaded)
+	})
+
+	t.Run("rejects the files without version", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{"create_lists.up.sql": {Data: []byte("")}})
+		assert.Error(t, err)
+	})
+
+	t.Run("the embedded migrations", func(t *testing.T) {
+		loaded, err := LoadMigrations(migrations.FS)
+		assert.NoError(t, err)
+
+		for i, migration := range loaded {
+			assert.Equal(t, uint(i+1), migration.Version)
+			assert.NotEmpty(t, migration.Up, migration.Name)
+			assert.NotEmpty(t, migration.Down, migration.Name)
+		}
+	})
+}
+
+func TestPreviousVersion(t *testing.T) {
+	m := &Migrator{Migrations: []Migration{{Version: 1}, {Version: 2}, {Version: 5}}}
+
+	assert.Equal(t, uint(0), m.previousVersion(1))
+	assert.Equal(t, uint(2), m.previousVersion(5))
+}
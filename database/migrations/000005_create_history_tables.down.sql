@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS purchase_history;
+DROP TABLE IF EXISTS list_completions;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS list_completions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  list_id UUID NOT NULL,
+  list_name VARCHAR(255) NOT NULL,
+  username VARCHAR(255) NOT NULL,
+  completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS list_completions_username_completed_at_idx
+  ON list_completions (username, completed_at);
+
+CREATE TABLE IF NOT EXISTS purchase_history (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  completion_id UUID NOT NULL REFERENCES list_completions (id) ON DELETE CASCADE,
+  username VARCHAR(255) NOT NULL,
+  item VARCHAR(255) NOT NULL,
+  price_cents BIGINT, -- null when the price was not provided
+  purchased_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS purchase_history_username_purchased_at_idx
+  ON purchase_history (username, purchased_at);
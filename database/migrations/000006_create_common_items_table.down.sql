@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS purchase_history_item_trgm_idx;
+DROP TABLE IF EXISTS common_items;
@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS common_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name VARCHAR(255) UNIQUE NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS common_items_name_trgm_idx
+  ON common_items USING gin (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS purchase_history_item_trgm_idx
+  ON purchase_history USING gin (LOWER(item) gin_trgm_ops);
+
+INSERT INTO common_items (name) VALUES
+  ('apples'), ('avocados'), ('bacon'), ('bananas'), ('beans'), ('beer'),
+  ('bread'), ('broccoli'), ('butter'), ('carrots'), ('cereal'), ('cheese'),
+  ('chicken'), ('chocolate'), ('coffee'), ('cookies'), ('cream'), ('cucumbers'),
+  ('detergent'), ('dish soap'), ('eggs'), ('fish'), ('flour'), ('garlic'),
+  ('ground beef'), ('ham'), ('honey'), ('ice cream'), ('jam'), ('juice'),
+  ('ketchup'), ('lemons'), ('lettuce'), ('milk'), ('mint'), ('mushrooms'),
+  ('mustard'), ('napkins'), ('oats'), ('olive oil'), ('onions'), ('oranges'),
+  ('pasta'), ('peanut butter'), ('pepper'), ('potatoes'), ('rice'), ('salt'),
+  ('shampoo'), ('soap'), ('spinach'), ('sugar'), ('tea'), ('toilet paper'),
+  ('tomatoes'), ('toothpaste'), ('tortillas'), ('water'), ('wine'), ('yogurt')
+ON CONFLICT (name) DO NOTHING;
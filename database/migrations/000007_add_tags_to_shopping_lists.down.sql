@@ -0,0 +1 @@
+ALTER TABLE shopping_lists DROP COLUMN IF EXISTS tags;
@@ -0,0 +1 @@
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
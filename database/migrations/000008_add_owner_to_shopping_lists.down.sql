@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS shopping_lists_owner_lower_name_idx;
+ALTER TABLE shopping_lists DROP COLUMN IF EXISTS owner;
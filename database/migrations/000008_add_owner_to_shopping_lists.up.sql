@@ -0,0 +1,5 @@
+-- lists created before this migration don't have an owner
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS owner VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS shopping_lists_owner_lower_name_idx
+  ON shopping_lists (owner, LOWER(name));
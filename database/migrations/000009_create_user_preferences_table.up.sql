@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_preferences (
+  username VARCHAR(255) PRIMARY KEY,
+  timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA name like America/Lima
+  locale TEXT NOT NULL DEFAULT 'en-US', -- BCP 47 tag
+  first_day_of_week SMALLINT NOT NULL DEFAULT 1 CHECK (first_day_of_week BETWEEN 0 AND 6), -- 0 is sunday
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+-- the soft deleted lists would be visible again without the columns
+DELETE FROM shopping_lists WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS shopping_lists_deleted_at_idx;
+ALTER TABLE shopping_lists DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE shopping_lists DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- deleted lists are kept for support investigations and restores
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS shopping_lists_deleted_at_idx
+  ON shopping_lists (deleted_at)
+  WHERE deleted_at IS NOT NULL;
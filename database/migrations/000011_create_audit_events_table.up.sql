@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  actor VARCHAR(255) NOT NULL, -- username, empty for the system
+  action VARCHAR(255) NOT NULL, -- e.g. list.updated
+  resource_type VARCHAR(255) NOT NULL,
+  resource_id VARCHAR(255) NOT NULL,
+  data JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_resource_idx
+  ON audit_events (resource_type, resource_id, created_at);
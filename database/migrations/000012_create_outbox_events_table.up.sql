@@ -0,0 +1,14 @@
+-- events are written in the same transaction as the change that produced
+-- them and delivered later, so a crash can't lose them
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id BIGSERIAL PRIMARY KEY,
+  event_type VARCHAR(255) NOT NULL,
+  aggregate_id VARCHAR(255) NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+  ON outbox_events (id)
+  WHERE delivered_at IS NULL;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS outbox_events_pending_idx;
+
+ALTER TABLE outbox_events
+  DROP COLUMN IF EXISTS available_at,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS last_error;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+  ON outbox_events (id)
+  WHERE delivered_at IS NULL;
@@ -0,0 +1,12 @@
+-- a dispatcher claims the pending events by moving available_at forward, so
+-- the events of a crashed dispatcher are claimed again after the lease
+ALTER TABLE outbox_events
+  ADD COLUMN IF NOT EXISTS available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+DROP INDEX IF EXISTS outbox_events_pending_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+  ON outbox_events (available_at, id)
+  WHERE delivered_at IS NULL;
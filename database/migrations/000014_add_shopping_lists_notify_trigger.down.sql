@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS shopping_lists_notify ON shopping_lists;
+DROP FUNCTION IF EXISTS notify_shopping_list_changed();
@@ -0,0 +1,19 @@
+-- every change to a list is notified after the commit, the instances listen
+-- to the channel to drop their cached copy of the list
+CREATE OR REPLACE FUNCTION notify_shopping_list_changed() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM pg_notify('shopping_list_changed', OLD.id::text);
+  ELSE
+    PERFORM pg_notify('shopping_list_changed', NEW.id::text);
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS shopping_lists_notify ON shopping_lists;
+
+CREATE TRIGGER shopping_lists_notify
+  AFTER INSERT OR UPDATE OR DELETE ON shopping_lists
+  FOR EACH ROW EXECUTE FUNCTION notify_shopping_list_changed();
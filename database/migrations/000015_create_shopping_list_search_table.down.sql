@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS shopping_lists_search ON shopping_lists;
+DROP FUNCTION IF EXISTS index_shopping_list();
+DROP TABLE IF EXISTS shopping_list_search;
+DROP FUNCTION IF EXISTS shopping_list_search_document(TEXT, TEXT[], TEXT[]);
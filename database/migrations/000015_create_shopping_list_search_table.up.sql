@@ -0,0 +1,49 @@
+-- the full-text index of the lists, it's kept apart from shopping_lists so
+-- the document doesn't show up in the models. The name weighs more than the
+-- items and the items more than the tags
+CREATE OR REPLACE FUNCTION shopping_list_search_document(name TEXT, items TEXT[], tags TEXT[]) RETURNS TSVECTOR AS $$
+  SELECT setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+         setweight(to_tsvector('simple', array_to_string(coalesce(items, '{}'), ' ')), 'B') ||
+         setweight(to_tsvector('simple', array_to_string(coalesce(tags, '{}'), ' ')), 'C')
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS shopping_list_search (
+  list_id UUID PRIMARY KEY REFERENCES shopping_lists(id) ON DELETE CASCADE,
+  owner VARCHAR(255),
+  document TSVECTOR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS shopping_list_search_document_idx
+  ON shopping_list_search USING GIN (document);
+
+CREATE INDEX IF NOT EXISTS shopping_list_search_owner_idx
+  ON shopping_list_search (owner);
+
+-- the soft deleted lists leave the index, the deleted rows are removed by
+-- the foreign key
+CREATE OR REPLACE FUNCTION index_shopping_list() RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.deleted_at IS NOT NULL THEN
+    DELETE FROM shopping_list_search WHERE list_id = NEW.id;
+  ELSE
+    INSERT INTO shopping_list_search (list_id, owner, document)
+    VALUES (NEW.id, NEW.owner, shopping_list_search_document(NEW.name, NEW.items, NEW.tags))
+    ON CONFLICT (list_id) DO UPDATE
+    SET owner = EXCLUDED.owner, document = EXCLUDED.document;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS shopping_lists_search ON shopping_lists;
+
+CREATE TRIGGER shopping_lists_search
+  AFTER INSERT OR UPDATE ON shopping_lists
+  FOR EACH ROW EXECUTE FUNCTION index_shopping_list();
+
+INSERT INTO shopping_list_search (list_id, owner, document)
+SELECT id, owner, shopping_list_search_document(name, items, tags)
+FROM shopping_lists
+WHERE deleted_at IS NULL
+ON CONFLICT (list_id) DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shopping_lists_tenant_id_idx;
+ALTER TABLE shopping_lists DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,18 @@
+-- a summary of the API requests for the admins, the rows older than
+-- ACCESS_LOG_RETENTION are deleted by the server
+CREATE TABLE IF NOT EXISTS access_log (
+  id BIGSERIAL PRIMARY KEY,
+  request_id VARCHAR(64) NOT NULL,
+  username VARCHAR(255) NOT NULL DEFAULT '', -- empty for the anonymous requests
+  method VARCHAR(16) NOT NULL,
+  route VARCHAR(255) NOT NULL DEFAULT '', -- e.g. /v1/lists/{id}, empty when no route matched
+  status INTEGER NOT NULL,
+  duration_ms INTEGER NOT NULL,
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  user_agent VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS access_log_created_at_idx ON access_log (created_at);
+
+CREATE INDEX IF NOT EXISTS access_log_username_idx ON access_log (username, created_at);
@@ -0,0 +1,24 @@
+-- the channels of the notifications of each user, the users without a row
+-- don't get notifications
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  username VARCHAR(255) PRIMARY KEY,
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  push_token TEXT NOT NULL DEFAULT '', -- FCM registration token of the device
+  email_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  push_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  digest VARCHAR(16) NOT NULL DEFAULT 'off' CHECK (digest IN ('off', 'hourly', 'daily')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- the notifications waiting for the digest of the user, the event id of the
+-- outbox drops the ones queued again by a redelivery
+CREATE TABLE IF NOT EXISTS pending_notifications (
+  id BIGSERIAL PRIMARY KEY,
+  username VARCHAR(255) NOT NULL,
+  event_id BIGINT NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (username, event_id)
+);
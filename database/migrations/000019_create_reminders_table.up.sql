@@ -0,0 +1,18 @@
+-- the reminders of the lists, the scheduler claims the due ones by moving
+-- claimed_until forward so a crashed instance doesn't lose them
+CREATE TABLE IF NOT EXISTS reminders (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  list_id UUID NOT NULL REFERENCES shopping_lists (id) ON DELETE CASCADE,
+  username VARCHAR(255) NOT NULL, -- the user reminded
+  remind_at TIMESTAMPTZ NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  claimed_until TIMESTAMPTZ,
+  delivered_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reminders_due_idx
+  ON reminders (remind_at)
+  WHERE delivered_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS reminders_list_id_idx ON reminders (list_id, remind_at);
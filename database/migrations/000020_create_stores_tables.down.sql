@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shopping_list_stores;
+DROP TABLE IF EXISTS stores;
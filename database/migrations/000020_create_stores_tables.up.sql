@@ -0,0 +1,22 @@
+-- the stores of the users with their aisles in walking order, item_aisles
+-- maps the items to an aisle
+CREATE TABLE IF NOT EXISTS stores (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner VARCHAR(255) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  address TEXT NOT NULL DEFAULT '',
+  aisles TEXT[] NOT NULL DEFAULT '{}',
+  item_aisles JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stores_owner_idx ON stores (owner, name);
+
+-- a list is shopped in at most one store
+CREATE TABLE IF NOT EXISTS shopping_list_stores (
+  list_id UUID PRIMARY KEY REFERENCES shopping_lists (id) ON DELETE CASCADE,
+  store_id UUID NOT NULL REFERENCES stores (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS shopping_list_stores_store_id_idx ON shopping_list_stores (store_id);
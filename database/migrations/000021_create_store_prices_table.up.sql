@@ -0,0 +1,13 @@
+-- the prices of the items in the stores, entered by the users or imported
+-- from a csv. The item is the key of the item name, like the item_aisles of
+-- the stores
+CREATE TABLE IF NOT EXISTS store_prices (
+  store_id UUID NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+  item TEXT NOT NULL,
+  price_cents BIGINT NOT NULL CHECK (price_cents >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (store_id, item)
+);
+
+-- the comparisons look up the prices of the items of a list in every store
+CREATE INDEX IF NOT EXISTS store_prices_item_idx ON store_prices (item, price_cents);
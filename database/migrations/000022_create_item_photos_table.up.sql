@@ -0,0 +1,15 @@
+-- the photos of the items of the lists, the files are in the blob store.
+-- The item is its name in the list, so the photo follows the item when the
+-- list is reordered
+CREATE TABLE IF NOT EXISTS item_photos (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  list_id UUID NOT NULL REFERENCES shopping_lists (id) ON DELETE CASCADE,
+  item TEXT NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  size_bytes BIGINT NOT NULL,
+  blob_key TEXT NOT NULL,
+  thumbnail_key TEXT NOT NULL,
+  uploaded_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (list_id, item)
+);
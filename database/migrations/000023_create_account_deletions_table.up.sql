@@ -0,0 +1,9 @@
+-- the users that asked to delete their account, their data is erased at
+-- erase_at unless they cancel before
+CREATE TABLE IF NOT EXISTS account_deletions (
+  username VARCHAR(255) PRIMARY KEY,
+  requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  erase_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS account_deletions_erase_at_idx ON account_deletions (erase_at);
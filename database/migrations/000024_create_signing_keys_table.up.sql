@@ -0,0 +1,10 @@
+-- the HMAC keys rotated by the admins, every instance reloads them. A key
+-- signs from active_at and verifies until it's retired
+CREATE TABLE IF NOT EXISTS signing_keys (
+  purpose VARCHAR(32) NOT NULL,
+  id VARCHAR(64) NOT NULL,
+  secret TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  active_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (purpose, id)
+);
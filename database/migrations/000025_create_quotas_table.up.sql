@@ -0,0 +1,12 @@
+-- the quotas of a user or a tenant set by the admins, the NULL limits are
+-- taken from the tenant and then from the config
+CREATE TABLE IF NOT EXISTS quotas (
+  scope VARCHAR(16) NOT NULL CHECK (scope IN ('user', 'tenant')),
+  -- the username or the id of the tenant
+  subject TEXT NOT NULL,
+  max_lists INTEGER CHECK (max_lists >= 0),
+  max_items INTEGER CHECK (max_items >= 0),
+  requests_per_minute INTEGER CHECK (requests_per_minute >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (scope, subject)
+);
@@ -8,6 +8,122 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AccessLog struct {
+	ID         int64
+	RequestID  string
+	Username   string
+	Method     string
+	Route      string
+	Status     int32
+	DurationMs int32
+	Ip         string
+	UserAgent  string
+	CreatedAt  pgtype.Timestamptz
+}
+
+type AccountDeletion struct {
+	Username    string
+	RequestedAt pgtype.Timestamptz
+	EraseAt     pgtype.Timestamptz
+}
+
+type AuditEvent struct {
+	ID           pgtype.UUID
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Data         []byte
+	CreatedAt    pgtype.Timestamptz
+}
+
+type CommonItem struct {
+	ID        pgtype.UUID
+	Name      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type ItemPhoto struct {
+	ID           pgtype.UUID
+	ListID       pgtype.UUID
+	Item         string
+	ContentType  string
+	SizeBytes    int64
+	BlobKey      string
+	ThumbnailKey string
+	UploadedBy   string
+	CreatedAt    pgtype.Timestamptz
+}
+
+type ListCompletion struct {
+	ID          pgtype.UUID
+	ListID      pgtype.UUID
+	ListName    string
+	Username    string
+	CompletedAt pgtype.Timestamptz
+}
+
+type NotificationPreference struct {
+	Username     string
+	Email        string
+	PushToken    string
+	EmailEnabled bool
+	PushEnabled  bool
+	Digest       string
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type OutboxEvent struct {
+	ID          int64
+	EventType   string
+	AggregateID string
+	Payload     []byte
+	CreatedAt   pgtype.Timestamptz
+	DeliveredAt pgtype.Timestamptz
+	AvailableAt pgtype.Timestamptz
+	Attempts    int32
+	LastError   pgtype.Text
+}
+
+type PendingNotification struct {
+	ID        int64
+	Username  string
+	EventID   int64
+	Title     string
+	Body      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type PurchaseHistory struct {
+	ID           pgtype.UUID
+	CompletionID pgtype.UUID
+	Username     string
+	Item         string
+	PriceCents   pgtype.Int8
+	PurchasedAt  pgtype.Timestamptz
+}
+
+type Quota struct {
+	Scope             string
+	Subject           string
+	MaxLists          pgtype.Int4
+	MaxItems          pgtype.Int4
+	RequestsPerMinute pgtype.Int4
+	UpdatedAt         pgtype.Timestamptz
+}
+
+type Reminder struct {
+	ID           pgtype.UUID
+	ListID       pgtype.UUID
+	Username     string
+	RemindAt     pgtype.Timestamptz
+	Note         string
+	ClaimedUntil pgtype.Timestamptz
+	DeliveredAt  pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+}
+
 type Session struct {
 	ID        pgtype.UUID
 	Token     string
@@ -15,6 +131,7 @@ type Session struct {
 	Username  string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	TenantID  pgtype.UUID
 }
 
 type ShoppingList struct {
@@ -23,6 +140,55 @@ type ShoppingList struct {
 	Items     []string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	Tags      []string
+	Owner     pgtype.Text
+	DeletedAt pgtype.Timestamptz
+	DeletedBy pgtype.Text
+	TenantID  pgtype.UUID
+}
+
+type ShoppingListSearch struct {
+	ListID   pgtype.UUID
+	Owner    pgtype.Text
+	Document interface{}
+}
+
+type ShoppingListStore struct {
+	ListID  pgtype.UUID
+	StoreID pgtype.UUID
+}
+
+type SigningKey struct {
+	Purpose   string
+	ID        string
+	Secret    string
+	CreatedAt pgtype.Timestamptz
+	ActiveAt  pgtype.Timestamptz
+}
+
+type Store struct {
+	ID         pgtype.UUID
+	Owner      string
+	Name       string
+	Address    string
+	Aisles     []string
+	ItemAisles []byte
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type StorePrice struct {
+	StoreID    pgtype.UUID
+	Item       string
+	PriceCents int64
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type Tenant struct {
+	ID        pgtype.UUID
+	Slug      string
+	Name      string
+	CreatedAt pgtype.Timestamptz
 }
 
 type User struct {
@@ -32,4 +198,14 @@ type User struct {
 	Password  string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	TenantID  pgtype.UUID
+}
+
+type UserPreference struct {
+	Username       string
+	Timezone       string
+	Locale         string
+	FirstDayOfWeek int16
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
 }
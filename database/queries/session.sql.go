@@ -12,9 +12,9 @@ import (
 )
 
 const addSession = `-- name: AddSession :one
-INSERT INTO sessions (token, username, expires_at)
-VALUES ($1, $2, $3)
-RETURNING id, token, username, expires_at, created_at, updated_at
+INSERT INTO sessions (token, username, expires_at, tenant_id)
+VALUES ($1, $2, $3, COALESCE((SELECT tenant_id FROM users WHERE username = $2), '00000000-0000-0000-0000-000000000001'))
+RETURNING id, token, username, expires_at, created_at, updated_at, tenant_id
 `
 
 type AddSessionParams struct {
@@ -30,8 +30,10 @@ type AddSessionRow struct {
 	ExpiresAt pgtype.Timestamptz
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	TenantID  pgtype.UUID
 }
 
+// the session belongs to the tenant of the user
 func (q *Queries) AddSession(ctx context.Context, arg AddSessionParams) (AddSessionRow, error) {
 	row := q.db.QueryRow(ctx, addSession, arg.Token, arg.Username, arg.ExpiresAt)
 	var i AddSessionRow
@@ -42,10 +44,26 @@ func (q *Queries) AddSession(ctx context.Context, arg AddSessionParams) (AddSess
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const deleteOtherSessions = `-- name: DeleteOtherSessions :exec
+DELETE FROM sessions WHERE username = $1 AND token <> $2
+`
+
+type DeleteOtherSessionsParams struct {
+	Username string
+	Token    string
+}
+
+// the sessions of the user on the other devices, after a password change
+func (q *Queries) DeleteOtherSessions(ctx context.Context, arg DeleteOtherSessionsParams) error {
+	_, err := q.db.Exec(ctx, deleteOtherSessions, arg.Username, arg.Token)
+	return err
+}
+
 const deleteSessionByToken = `-- name: DeleteSessionByToken :exec
 DELETE FROM sessions WHERE token = $1
 `
@@ -56,7 +74,7 @@ func (q *Queries) DeleteSessionByToken(ctx context.Context, token string) error
 }
 
 const getSessionByToken = `-- name: GetSessionByToken :one
-SELECT id, token, username, expires_at, created_at, updated_at
+SELECT id, token, username, expires_at, created_at, updated_at, tenant_id
 FROM sessions WHERE token = $1
 `
 
@@ -67,6 +85,7 @@ type GetSessionByTokenRow struct {
 	ExpiresAt pgtype.Timestamptz
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	TenantID  pgtype.UUID
 }
 
 func (q *Queries) GetSessionByToken(ctx context.Context, token string) (GetSessionByTokenRow, error) {
@@ -79,6 +98,47 @@ func (q *Queries) GetSessionByToken(ctx context.Context, token string) (GetSessi
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertSession = `-- name: UpsertSession :one
+INSERT INTO sessions (token, username, expires_at, tenant_id)
+VALUES ($1, $2, $3, COALESCE((SELECT tenant_id FROM users WHERE username = $2), '00000000-0000-0000-0000-000000000001'))
+ON CONFLICT (token) DO UPDATE
+SET username = EXCLUDED.username, expires_at = EXCLUDED.expires_at, tenant_id = EXCLUDED.tenant_id, updated_at = NOW()
+RETURNING id, token, username, expires_at, created_at, updated_at, tenant_id
+`
+
+type UpsertSessionParams struct {
+	Token     string
+	Username  string
+	ExpiresAt pgtype.Timestamptz
+}
+
+type UpsertSessionRow struct {
+	ID        pgtype.UUID
+	Token     string
+	Username  string
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	TenantID  pgtype.UUID
+}
+
+// creates the session with a known token or extends it, used by the seed
+func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) (UpsertSessionRow, error) {
+	row := q.db.QueryRow(ctx, upsertSession, arg.Token, arg.Username, arg.ExpiresAt)
+	var i UpsertSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.Username,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
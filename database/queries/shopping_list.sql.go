@@ -11,19 +11,41 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countShoppingListsByOwner = `-- name: CountShoppingListsByOwner :one
+SELECT COUNT(*) FROM shopping_lists
+WHERE owner = $1 AND deleted_at IS NULL
+`
+
+// the lists that count for the quota of the owner
+func (q *Queries) CountShoppingListsByOwner(ctx context.Context, owner pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countShoppingListsByOwner, owner)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createShoppingList = `-- name: CreateShoppingList :one
-INSERT INTO shopping_lists (name, items)
-VALUES ($1, $2)
-RETURNING id, name, items, created_at, updated_at
+INSERT INTO shopping_lists (name, items, tags, owner, tenant_id)
+VALUES ($1, $2, $3, $4, COALESCE((SELECT tenant_id FROM users WHERE username = $4), '00000000-0000-0000-0000-000000000001'))
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 `
 
 type CreateShoppingListParams struct {
 	Name  string
 	Items []string
+	Tags  []string
+	Owner pgtype.Text
 }
 
+// the list belongs to the tenant of the owner, the built-in users and the
+// lists without owner are in the default tenant
 func (q *Queries) CreateShoppingList(ctx context.Context, arg CreateShoppingListParams) (ShoppingList, error) {
-	row := q.db.QueryRow(ctx, createShoppingList, arg.Name, arg.Items)
+	row := q.db.QueryRow(ctx, createShoppingList,
+		arg.Name,
+		arg.Items,
+		arg.Tags,
+		arg.Owner,
+	)
 	var i ShoppingList
 	err := row.Scan(
 		&i.ID,
@@ -31,27 +53,159 @@ func (q *Queries) CreateShoppingList(ctx context.Context, arg CreateShoppingList
 		&i.Items,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const deleteShoppingListByID = `-- name: DeleteShoppingListByID :exec
-DELETE FROM shopping_lists
-WHERE id = $1
+const deleteShoppingListByID = `-- name: DeleteShoppingListByID :execrows
+UPDATE shopping_lists
+SET deleted_at = NOW(), deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type DeleteShoppingListByIDParams struct {
+	ID        pgtype.UUID
+	DeletedBy pgtype.Text
+}
+
+// soft delete, the row is kept with who deleted it and when
+func (q *Queries) DeleteShoppingListByID(ctx context.Context, arg DeleteShoppingListByIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteShoppingListByID, arg.ID, arg.DeletedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findListNameConflicts = `-- name: FindListNameConflicts :many
+SELECT id, name
+FROM shopping_lists
+WHERE owner = $1 AND LOWER(name) LIKE LOWER($2::text) || '%' AND deleted_at IS NULL
+ORDER BY created_at
 `
 
-func (q *Queries) DeleteShoppingListByID(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteShoppingListByID, id)
-	return err
+type FindListNameConflictsParams struct {
+	Owner      pgtype.Text
+	NamePrefix string
+}
+
+type FindListNameConflictsRow struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+// lists of the owner named like the given name, e.g. "Groceries" or
+// "Groceries (2)", the exact matching is done by the caller
+func (q *Queries) FindListNameConflicts(ctx context.Context, arg FindListNameConflictsParams) ([]FindListNameConflictsRow, error) {
+	rows, err := q.db.Query(ctx, findListNameConflicts, arg.Owner, arg.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindListNameConflictsRow
+	for rows.Next() {
+		var i FindListNameConflictsRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getAllShoppingLists = `-- name: GetAllShoppingLists :many
-SELECT id, name, items, created_at, updated_at
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE deleted_at IS NULL AND ($1::uuid IS NULL OR tenant_id = $1)
+`
+
+// the lists of the tenant, of every tenant when it's null
+func (q *Queries) GetAllShoppingLists(ctx context.Context, tenantID pgtype.UUID) ([]ShoppingList, error) {
+	rows, err := q.db.Query(ctx, getAllShoppingLists, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShoppingList
+	for rows.Next() {
+		var i ShoppingList
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Items,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
+			&i.Owner,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllShoppingListsIncludingDeleted = `-- name: GetAllShoppingListsIncludingDeleted :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE ($1::uuid IS NULL OR tenant_id = $1)
+`
+
+func (q *Queries) GetAllShoppingListsIncludingDeleted(ctx context.Context, tenantID pgtype.UUID) ([]ShoppingList, error) {
+	rows, err := q.db.Query(ctx, getAllShoppingListsIncludingDeleted, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShoppingList
+	for rows.Next() {
+		var i ShoppingList
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Items,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
+			&i.Owner,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentlyUpdatedShoppingLists = `-- name: GetRecentlyUpdatedShoppingLists :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 FROM shopping_lists
+WHERE deleted_at IS NULL
+ORDER BY updated_at DESC
+LIMIT $1
 `
 
-func (q *Queries) GetAllShoppingLists(ctx context.Context) ([]ShoppingList, error) {
-	rows, err := q.db.Query(ctx, getAllShoppingLists)
+func (q *Queries) GetRecentlyUpdatedShoppingLists(ctx context.Context, limit int32) ([]ShoppingList, error) {
+	rows, err := q.db.Query(ctx, getRecentlyUpdatedShoppingLists, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +219,11 @@ func (q *Queries) GetAllShoppingLists(ctx context.Context) ([]ShoppingList, erro
 			&i.Items,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Tags,
+			&i.Owner,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -77,9 +236,9 @@ func (q *Queries) GetAllShoppingLists(ctx context.Context) ([]ShoppingList, erro
 }
 
 const getShoppingListByID = `-- name: GetShoppingListByID :one
-SELECT id, name, items, created_at, updated_at
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 FROM shopping_lists
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetShoppingListByID(ctx context.Context, id pgtype.UUID) (ShoppingList, error) {
@@ -91,6 +250,235 @@ func (q *Queries) GetShoppingListByID(ctx context.Context, id pgtype.UUID) (Shop
 		&i.Items,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getShoppingListByIDForUpdate = `-- name: GetShoppingListByIDForUpdate :one
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+// locks the list until the end of the transaction, the changes that read the
+// list before writing it wait for each other instead of losing the other ones
+func (q *Queries) GetShoppingListByIDForUpdate(ctx context.Context, id pgtype.UUID) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, getShoppingListByIDForUpdate, id)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getShoppingListByIDIncludingDeleted = `-- name: GetShoppingListByIDIncludingDeleted :one
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE id = $1
+`
+
+func (q *Queries) GetShoppingListByIDIncludingDeleted(ctx context.Context, id pgtype.UUID) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, getShoppingListByIDIncludingDeleted, id)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getShoppingListSummaries = `-- name: GetShoppingListSummaries :many
+SELECT id, name, cardinality(items)::int AS item_count, updated_at, deleted_at
+FROM shopping_lists
+WHERE ($1::boolean OR deleted_at IS NULL)
+  AND ($2::uuid IS NULL OR tenant_id = $2)
+`
+
+type GetShoppingListSummariesParams struct {
+	IncludeDeleted bool
+	TenantID       pgtype.UUID
+}
+
+type GetShoppingListSummariesRow struct {
+	ID        pgtype.UUID
+	Name      string
+	ItemCount int32
+	UpdatedAt pgtype.Timestamptz
+	DeletedAt pgtype.Timestamptz
+}
+
+// the lists of the tenant without their items, the collection is read without
+// the arrays
+func (q *Queries) GetShoppingListSummaries(ctx context.Context, arg GetShoppingListSummariesParams) ([]GetShoppingListSummariesRow, error) {
+	rows, err := q.db.Query(ctx, getShoppingListSummaries, arg.IncludeDeleted, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShoppingListSummariesRow
+	for rows.Next() {
+		var i GetShoppingListSummariesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ItemCount,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShoppingListTenant = `-- name: GetShoppingListTenant :one
+SELECT tenant_id
+FROM shopping_lists
+WHERE id = $1
+`
+
+// the deleted lists too, they are still read by the admins
+func (q *Queries) GetShoppingListTenant(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getShoppingListTenant, id)
+	var tenant_id pgtype.UUID
+	err := row.Scan(&tenant_id)
+	return tenant_id, err
+}
+
+const getShoppingListsByOwner = `-- name: GetShoppingListsByOwner :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE owner = $1 AND deleted_at IS NULL
+ORDER BY created_at
+`
+
+func (q *Queries) GetShoppingListsByOwner(ctx context.Context, owner pgtype.Text) ([]ShoppingList, error) {
+	rows, err := q.db.Query(ctx, getShoppingListsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShoppingList
+	for rows.Next() {
+		var i ShoppingList
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Items,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
+			&i.Owner,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShoppingListsVersion = `-- name: GetShoppingListsVersion :one
+SELECT COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE deleted_at IS NULL) AS active,
+    COALESCE(MAX(updated_at), 'epoch')::timestamptz AS last_updated_at,
+    COALESCE(MAX(deleted_at), 'epoch')::timestamptz AS last_deleted_at
+FROM shopping_lists
+WHERE ($1::uuid IS NULL OR tenant_id = $1)
+`
+
+type GetShoppingListsVersionRow struct {
+	Total         int64
+	Active        int64
+	LastUpdatedAt pgtype.Timestamptz
+	LastDeletedAt pgtype.Timestamptz
+}
+
+// a cheap version of the collection for its ETag, every change moves the
+// counts or the last update or deletion
+func (q *Queries) GetShoppingListsVersion(ctx context.Context, tenantID pgtype.UUID) (GetShoppingListsVersionRow, error) {
+	row := q.db.QueryRow(ctx, getShoppingListsVersion, tenantID)
+	var i GetShoppingListsVersionRow
+	err := row.Scan(
+		&i.Total,
+		&i.Active,
+		&i.LastUpdatedAt,
+		&i.LastDeletedAt,
+	)
+	return i, err
+}
+
+const mergeItemIntoShoppingList = `-- name: MergeItemIntoShoppingList :one
+UPDATE shopping_lists
+SET items = coalesce((
+    SELECT items[:i.position::int - 1]
+      || (regexp_replace(i.item, '\s*\(\d{1,6}\)$', '') || ' (' || (coalesce((regexp_match(i.item, '\((\d{1,6})\)$'))[1]::int, 1) + coalesce((regexp_match($1::text, '\((\d{1,6})\)$'))[1]::int, 1)) || ')')
+      || items[i.position::int + 1:]
+    FROM unnest(items) WITH ORDINALITY AS i(item, position)
+    WHERE lower(regexp_replace(i.item, '\s*\(\d{1,6}\)$', '')) = lower(regexp_replace($1::text, '\s*\(\d{1,6}\)$', ''))
+    ORDER BY i.position
+    LIMIT 1
+  ), items || $1::text),
+  updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+`
+
+type MergeItemIntoShoppingListParams struct {
+	Item string
+	ID   pgtype.UUID
+}
+
+// adds the item, or adds its quantity to the first item with the same name
+// without the case: `milk` and `Milk (2)` make `Milk (3)`. The quantity is a
+// number in parentheses at the end, 1 when there is none. The items are the
+// ones of the locked row, so the concurrent pushes are all counted
+func (q *Queries) MergeItemIntoShoppingList(ctx context.Context, arg MergeItemIntoShoppingListParams) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, mergeItemIntoShoppingList, arg.Item, arg.ID)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -98,8 +486,8 @@ func (q *Queries) GetShoppingListByID(ctx context.Context, id pgtype.UUID) (Shop
 const pushItemToShoppingList = `-- name: PushItemToShoppingList :one
 UPDATE shopping_lists
 SET items = items || $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 `
 
 type PushItemToShoppingListParams struct {
@@ -107,6 +495,8 @@ type PushItemToShoppingListParams struct {
 	Items []string
 }
 
+// one update of the row, the concurrent pushes wait for its lock and append
+// to the items it wrote
 func (q *Queries) PushItemToShoppingList(ctx context.Context, arg PushItemToShoppingListParams) (ShoppingList, error) {
 	row := q.db.QueryRow(ctx, pushItemToShoppingList, arg.ID, arg.Items)
 	var i ShoppingList
@@ -116,6 +506,104 @@ func (q *Queries) PushItemToShoppingList(ctx context.Context, arg PushItemToShop
 		&i.Items,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const removeShoppingListItem = `-- name: RemoveShoppingListItem :one
+UPDATE shopping_lists
+SET items = items[:$1::int - 1] || items[$1::int + 1:], updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL AND items[$1::int] = $3::text
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+`
+
+type RemoveShoppingListItemParams struct {
+	Position int32
+	ID       pgtype.UUID
+	Item     string
+}
+
+// removes the item at the position from 1, only when it's still the item the
+// caller read
+func (q *Queries) RemoveShoppingListItem(ctx context.Context, arg RemoveShoppingListItemParams) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, removeShoppingListItem, arg.Position, arg.ID, arg.Item)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const renameShoppingListItem = `-- name: RenameShoppingListItem :one
+UPDATE shopping_lists
+SET items[$1::int] = $2::text, updated_at = NOW()
+WHERE id = $3 AND deleted_at IS NULL AND items[$1::int] = $4::text
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+`
+
+type RenameShoppingListItemParams struct {
+	Position int32
+	Name     string
+	ID       pgtype.UUID
+	Item     string
+}
+
+// renames the item at the position from 1, only when it's still the item the
+// caller read
+func (q *Queries) RenameShoppingListItem(ctx context.Context, arg RenameShoppingListItemParams) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, renameShoppingListItem, arg.Position, arg.Name, arg.ID, arg.Item)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const restoreShoppingList = `-- name: RestoreShoppingList :one
+UPDATE shopping_lists
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+`
+
+// undoes the soft delete
+func (q *Queries) RestoreShoppingList(ctx context.Context, id pgtype.UUID) (ShoppingList, error) {
+	row := q.db.QueryRow(ctx, restoreShoppingList, id)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Items,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -125,8 +613,8 @@ UPDATE shopping_lists
 SET name = COALESCE($2, name),
     items = COALESCE($3, items),
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 `
 
 type ShoppingListPartialUpdateParams struct {
@@ -144,6 +632,11 @@ func (q *Queries) ShoppingListPartialUpdate(ctx context.Context, arg ShoppingLis
 		&i.Items,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -153,8 +646,8 @@ UPDATE shopping_lists
 SET name = $2,
     items = $3,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 `
 
 type UpdateShoppingListByIDParams struct {
@@ -173,6 +666,11 @@ func (q *Queries) UpdateShoppingListByID(ctx context.Context, arg UpdateShopping
 		&i.Items,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Tags,
+		&i.Owner,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TenantID,
 	)
 	return i, err
 }
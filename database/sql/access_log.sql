@@ -0,0 +1,15 @@
+-- name: InsertAccessLogEntries :copyfrom
+INSERT INTO access_log (request_id, username, method, route, status, duration_ms, ip, user_agent, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListAccessLogEntries :many
+-- the last requests first, the null filters are ignored
+SELECT * FROM access_log
+WHERE (sqlc.narg('username')::text IS NULL OR username = sqlc.narg('username'))
+  AND (sqlc.narg('min_status')::int IS NULL OR status >= sqlc.narg('min_status'))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR created_at >= sqlc.narg('since'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteAccessLogEntriesBefore :execrows
+DELETE FROM access_log WHERE created_at < $1;
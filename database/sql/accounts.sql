@@ -0,0 +1,122 @@
+-- name: RequestAccountDeletion :one
+-- asking again keeps the date of the first request
+INSERT INTO account_deletions (username, erase_at)
+VALUES ($1, $2)
+ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username
+RETURNING *;
+
+-- name: GetAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE username = $1;
+
+-- name: DeleteAccountDeletion :execrows
+DELETE FROM account_deletions
+WHERE username = $1;
+
+-- name: ClaimDueAccountDeletion :one
+-- the row stays locked until the erasure commits, SKIP LOCKED lets several
+-- instances erase different accounts at the same time
+SELECT * FROM account_deletions
+WHERE erase_at <= NOW()
+ORDER BY erase_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ListUserPhotoKeys :many
+-- the photos uploaded by the user and the ones of their lists
+SELECT blob_key, thumbnail_key FROM item_photos
+WHERE uploaded_by = @username OR list_id IN (SELECT id FROM shopping_lists WHERE owner = @username);
+
+-- name: DeleteUserPhotos :exec
+DELETE FROM item_photos
+WHERE uploaded_by = $1;
+
+-- name: DeleteUserLists :many
+-- the soft deleted lists too, their photos, reminders and store are deleted
+-- by cascade
+DELETE FROM shopping_lists
+WHERE owner = $1
+RETURNING id;
+
+-- name: AnonymizeListsDeletedBy :exec
+UPDATE shopping_lists
+SET deleted_by = @anonymous
+WHERE deleted_by = @username;
+
+-- name: DeleteListsAuditEvents :exec
+DELETE FROM audit_events
+WHERE resource_type = 'list' AND resource_id = ANY(@list_ids::text[]);
+
+-- name: AnonymizeAuditEvents :exec
+-- the changes of the user to the lists of the others stay in their history
+UPDATE audit_events
+SET actor = @anonymous,
+  data = CASE WHEN data ? 'actor' THEN jsonb_set(data, '{actor}', to_jsonb(@anonymous::text)) ELSE data END
+WHERE actor = @username;
+
+-- name: DeleteListsOutboxEvents :exec
+-- the events waiting for delivery of the lists of the others are kept
+DELETE FROM outbox_events
+WHERE aggregate_id = ANY(@list_ids::text[])
+  OR (delivered_at IS NOT NULL AND payload->>'actor' = @username::text);
+
+-- name: AnonymizeAccessLog :exec
+UPDATE access_log
+SET username = '', ip = '', user_agent = ''
+WHERE username = $1;
+
+-- name: DeleteUserRows :exec
+-- the rows keyed by the username, in one round trip
+WITH sessions AS (
+  DELETE FROM sessions WHERE username = @username
+), preferences AS (
+  DELETE FROM user_preferences WHERE username = @username
+), notification_preferences AS (
+  DELETE FROM notification_preferences WHERE username = @username
+), pending_notifications AS (
+  DELETE FROM pending_notifications WHERE username = @username
+), reminders AS (
+  DELETE FROM reminders WHERE username = @username
+), stores AS (
+  DELETE FROM stores WHERE owner = @username
+), completions AS (
+  DELETE FROM list_completions WHERE username = @username
+), quotas AS (
+  DELETE FROM quotas WHERE scope = 'user' AND subject = @username
+)
+DELETE FROM users
+WHERE username = @username;
+
+-- name: ExportUserData :many
+-- every row about the user with the table it comes from, without the
+-- password hash and the session tokens
+SELECT 'users'::text AS table_name, to_jsonb(t) - 'password' AS data FROM users t WHERE t.username = @username
+UNION ALL
+SELECT 'sessions', to_jsonb(t) - 'token' FROM sessions t WHERE t.username = @username
+UNION ALL
+SELECT 'user_preferences', to_jsonb(t) FROM user_preferences t WHERE t.username = @username
+UNION ALL
+SELECT 'notification_preferences', to_jsonb(t) FROM notification_preferences t WHERE t.username = @username
+UNION ALL
+SELECT 'pending_notifications', to_jsonb(t) FROM pending_notifications t WHERE t.username = @username
+UNION ALL
+SELECT 'shopping_lists', to_jsonb(t) FROM shopping_lists t WHERE t.owner = @username
+UNION ALL
+SELECT 'list_completions', to_jsonb(t) FROM list_completions t WHERE t.username = @username
+UNION ALL
+SELECT 'purchase_history', to_jsonb(t) FROM purchase_history t WHERE t.username = @username
+UNION ALL
+SELECT 'reminders', to_jsonb(t) FROM reminders t WHERE t.username = @username
+UNION ALL
+SELECT 'stores', to_jsonb(t) FROM stores t WHERE t.owner = @username
+UNION ALL
+SELECT 'store_prices', to_jsonb(t) FROM store_prices t WHERE t.store_id IN (SELECT id FROM stores WHERE owner = @username)
+UNION ALL
+SELECT 'item_photos', to_jsonb(t) FROM item_photos t
+WHERE t.uploaded_by = @username OR t.list_id IN (SELECT id FROM shopping_lists WHERE owner = @username)
+UNION ALL
+SELECT 'audit_events', to_jsonb(t) FROM audit_events t WHERE t.actor = @username
+UNION ALL
+SELECT 'access_log', to_jsonb(t) FROM access_log t WHERE t.username = @username
+UNION ALL
+SELECT 'account_deletions', to_jsonb(t) FROM account_deletions t WHERE t.username = @username;
@@ -0,0 +1,14 @@
+-- name: InsertAuditEvent :exec
+INSERT INTO audit_events (actor, action, resource_type, resource_id, data)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: InsertAuditEvents :copyfrom
+INSERT INTO audit_events (actor, action, resource_type, resource_id, data)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListLastAuditEvents :many
+-- the last events of the resource first
+SELECT * FROM audit_events
+WHERE resource_type = $1 AND resource_id = $2
+ORDER BY created_at DESC
+LIMIT $3;
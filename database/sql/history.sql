@@ -0,0 +1,49 @@
+-- name: CompleteShoppingList :one
+-- records the completion and every purchased item in one statement
+WITH completion AS (
+  INSERT INTO list_completions (list_id, list_name, username)
+  VALUES (@list_id, @list_name, @username)
+  RETURNING id, list_id, list_name, username, completed_at
+), purchases AS (
+  INSERT INTO purchase_history (completion_id, username, item, price_cents)
+  SELECT completion.id, completion.username, (@items::text[])[n], NULLIF((@prices::bigint[])[n], 0)
+  FROM completion, generate_subscripts(@items::text[], 1) AS n
+)
+SELECT id, list_id, list_name, username, completed_at
+FROM completion;
+
+-- name: GetFrequentItems :many
+SELECT LOWER(item)::text AS item,
+       COUNT(*) AS times_purchased,
+       MAX(purchased_at)::timestamptz AS last_purchased_at
+FROM purchase_history
+WHERE username = $1
+GROUP BY LOWER(item)
+ORDER BY times_purchased DESC, last_purchased_at DESC
+LIMIT $2;
+
+-- name: GetSpendByMonth :many
+-- the months are computed in the timezone of the user
+SELECT date_trunc('month', purchased_at AT TIME ZONE @timezone::text)::date AS month,
+       COALESCE(SUM(price_cents), 0)::bigint AS total_cents,
+       COUNT(price_cents) AS priced_items
+FROM purchase_history
+WHERE username = @username AND purchased_at >= @since
+GROUP BY month
+ORDER BY month;
+
+-- name: GetListsPerWeek :many
+-- date_trunc always starts the weeks on monday, shifting by the offset
+-- moves the start to the first day of week of the user
+SELECT (date_trunc('week', (completed_at AT TIME ZONE @timezone::text) - make_interval(days => @week_offset::int)) + make_interval(days => @week_offset::int))::date AS week,
+       COUNT(*) AS lists
+FROM list_completions
+WHERE username = @username AND completed_at >= @since
+GROUP BY week
+ORDER BY week;
+
+-- name: GetListHistorySummary :one
+SELECT COUNT(*) AS times_completed,
+       MAX(completed_at)::timestamptz AS last_completed_at
+FROM list_completions
+WHERE list_id = $1;
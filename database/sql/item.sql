@@ -0,0 +1,22 @@
+-- name: SuggestItems :many
+-- the user's own purchases rank first, the common items dictionary fills
+-- the rest. The prefix match keeps short queries like "mi" useful since the
+-- trigram similarity of very short strings is low.
+WITH candidates AS (
+  SELECT LOWER(item)::text AS name, COUNT(*)::bigint AS frequency
+  FROM purchase_history
+  WHERE username = @username
+    AND (LOWER(item) LIKE @query::text || '%' OR LOWER(item) % @query::text)
+  GROUP BY LOWER(item)
+  UNION ALL
+  SELECT common_items.name::text, 0::bigint
+  FROM common_items
+  WHERE common_items.name LIKE @query::text || '%' OR common_items.name % @query::text
+)
+SELECT candidates.name::text AS name,
+       MAX(candidates.frequency)::bigint AS frequency,
+       MAX(similarity(candidates.name, @query::text))::real AS score
+FROM candidates
+GROUP BY candidates.name
+ORDER BY frequency DESC, score DESC, name
+LIMIT @max_results;
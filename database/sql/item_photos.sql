@@ -0,0 +1,23 @@
+-- name: SaveItemPhoto :one
+-- a new photo of the item replaces the previous one, its blobs are deleted
+-- by the caller
+INSERT INTO item_photos (list_id, item, content_type, size_bytes, blob_key, thumbnail_key, uploaded_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (list_id, item) DO UPDATE
+SET id = uuid_generate_v4(), content_type = EXCLUDED.content_type, size_bytes = EXCLUDED.size_bytes,
+  blob_key = EXCLUDED.blob_key, thumbnail_key = EXCLUDED.thumbnail_key,
+  uploaded_by = EXCLUDED.uploaded_by, created_at = NOW()
+RETURNING *;
+
+-- name: GetItemPhoto :one
+SELECT * FROM item_photos
+WHERE list_id = $1 AND item = $2;
+
+-- name: GetItemPhotoByID :one
+SELECT * FROM item_photos
+WHERE id = $1;
+
+-- name: DeleteItemPhoto :one
+DELETE FROM item_photos
+WHERE list_id = $1 AND item = $2
+RETURNING *;
@@ -0,0 +1,40 @@
+-- name: GetNotificationPreferences :one
+SELECT * FROM notification_preferences
+WHERE username = $1;
+
+-- name: SaveNotificationPreferences :one
+INSERT INTO notification_preferences (username, email, push_token, email_enabled, push_enabled, digest)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (username) DO UPDATE
+SET email = EXCLUDED.email,
+    push_token = EXCLUDED.push_token,
+    email_enabled = EXCLUDED.email_enabled,
+    push_enabled = EXCLUDED.push_enabled,
+    digest = EXCLUDED.digest,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: QueueNotification :exec
+INSERT INTO pending_notifications (username, event_id, title, body)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (username, event_id) DO NOTHING;
+
+-- name: ListDueDigests :many
+-- the users with a notification older than the interval of their digest
+SELECT p.username
+FROM pending_notifications p
+JOIN notification_preferences np ON np.username = p.username
+GROUP BY p.username, np.digest
+HAVING MIN(p.created_at) <= CASE np.digest
+  WHEN 'daily' THEN sqlc.arg('daily_before')::timestamptz
+  ELSE sqlc.arg('hourly_before')::timestamptz
+END;
+
+-- name: ListPendingNotifications :many
+SELECT * FROM pending_notifications
+WHERE username = $1
+ORDER BY id;
+
+-- name: DeletePendingNotifications :exec
+DELETE FROM pending_notifications
+WHERE username = $1 AND id <= $2;
@@ -0,0 +1,31 @@
+-- name: EnqueueOutboxEvent :exec
+INSERT INTO outbox_events (event_type, aggregate_id, payload)
+VALUES ($1, $2, $3);
+
+-- name: EnqueueOutboxEvents :copyfrom
+INSERT INTO outbox_events (event_type, aggregate_id, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxEvents :many
+-- SKIP LOCKED lets several dispatchers claim different events at the same time
+UPDATE outbox_events
+SET available_at = @lease_until
+WHERE id IN (
+  SELECT id
+  FROM outbox_events
+  WHERE delivered_at IS NULL AND available_at <= NOW()
+  ORDER BY id
+  LIMIT @batch_size
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox_events
+SET delivered_at = NOW(), last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = @last_error, available_at = @retry_at
+WHERE id = @id;
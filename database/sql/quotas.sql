@@ -0,0 +1,22 @@
+-- name: GetQuotas :many
+-- the quotas of the user and of its tenant
+SELECT * FROM quotas
+WHERE (scope = 'user' AND subject = @username) OR (scope = 'tenant' AND subject = @tenant_id);
+
+-- name: ListQuotas :many
+SELECT * FROM quotas
+ORDER BY scope, subject;
+
+-- name: SetQuota :one
+INSERT INTO quotas (scope, subject, max_lists, max_items, requests_per_minute)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (scope, subject) DO UPDATE
+SET max_lists = EXCLUDED.max_lists,
+    max_items = EXCLUDED.max_items,
+    requests_per_minute = EXCLUDED.requests_per_minute,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteQuota :execrows
+DELETE FROM quotas
+WHERE scope = $1 AND subject = $2;
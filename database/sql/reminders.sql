@@ -0,0 +1,35 @@
+-- name: CreateReminder :one
+INSERT INTO reminders (list_id, username, remind_at, note)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListRemindersByList :many
+SELECT * FROM reminders
+WHERE list_id = $1 AND username = $2
+ORDER BY remind_at, created_at;
+
+-- name: DeletePendingReminder :execrows
+-- the delivered reminders can't be canceled
+DELETE FROM reminders
+WHERE id = $1 AND list_id = $2 AND username = $3 AND delivered_at IS NULL;
+
+-- name: ClaimDueReminders :many
+-- SKIP LOCKED lets several schedulers claim different reminders at the same time
+UPDATE reminders
+SET claimed_until = @lease_until
+WHERE id IN (
+  SELECT id
+  FROM reminders
+  WHERE delivered_at IS NULL
+    AND remind_at <= NOW()
+    AND (claimed_until IS NULL OR claimed_until <= NOW())
+  ORDER BY remind_at
+  LIMIT @batch_size
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkReminderDelivered :exec
+UPDATE reminders
+SET delivered_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,7 @@
+-- name: TruncateSandboxData :exec
+-- every table of the users data but the lists, they are deleted row by row
+-- so the other instances are notified and drop their cached copies
+TRUNCATE list_completions, purchase_history, common_items, user_preferences, sessions, audit_events, outbox_events RESTART IDENTITY;
+
+-- name: DeleteAllShoppingLists :execrows
+DELETE FROM shopping_lists;
@@ -0,0 +1,45 @@
+-- name: SearchShoppingLists :many
+-- the query is a to_tsquery expression built by the search package
+SELECT l.id, l.name, ts_rank(s.document, to_tsquery('simple', sqlc.arg(query)::text))::real AS score
+FROM shopping_list_search s
+JOIN shopping_lists l ON l.id = s.list_id
+WHERE s.owner = sqlc.arg(owner)::text
+  AND s.document @@ to_tsquery('simple', sqlc.arg(query)::text)
+  AND l.deleted_at IS NULL
+ORDER BY score DESC, l.updated_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: RebuildShoppingListSearch :execrows
+INSERT INTO shopping_list_search (list_id, owner, document)
+SELECT id, owner, shopping_list_search_document(name, items, tags)
+FROM shopping_lists
+WHERE deleted_at IS NULL
+ON CONFLICT (list_id) DO UPDATE
+SET owner = EXCLUDED.owner, document = EXCLUDED.document;
+
+-- name: DeleteStaleShoppingListSearch :execrows
+DELETE FROM shopping_list_search s
+USING shopping_lists l
+WHERE s.list_id = l.id AND l.deleted_at IS NOT NULL;
+
+-- name: CountIndexableShoppingLists :one
+SELECT COUNT(*) FROM shopping_lists WHERE deleted_at IS NULL;
+
+-- name: FindShoppingListSearchDrift :many
+-- the lists missing from the index, the deleted lists still in it and the
+-- entries that don't match their list anymore
+SELECT l.id, 'missing'::text AS problem
+FROM shopping_lists l
+LEFT JOIN shopping_list_search s ON s.list_id = l.id
+WHERE l.deleted_at IS NULL AND s.list_id IS NULL
+UNION ALL
+SELECT l.id, 'stale'::text AS problem
+FROM shopping_list_search s
+JOIN shopping_lists l ON l.id = s.list_id
+WHERE l.deleted_at IS NOT NULL
+UNION ALL
+SELECT l.id, 'outdated'::text AS problem
+FROM shopping_list_search s
+JOIN shopping_lists l ON l.id = s.list_id
+WHERE l.deleted_at IS NULL
+  AND (s.owner IS DISTINCT FROM l.owner OR s.document <> shopping_list_search_document(l.name, l.items, l.tags));
@@ -1,11 +1,24 @@
 -- name: AddSession :one
-INSERT INTO sessions (token, username, expires_at)
-VALUES ($1, $2, $3)
-RETURNING id, token, username, expires_at, created_at, updated_at;
+-- the session belongs to the tenant of the user
+INSERT INTO sessions (token, username, expires_at, tenant_id)
+VALUES ($1, $2, $3, COALESCE((SELECT tenant_id FROM users WHERE username = $2), '00000000-0000-0000-0000-000000000001'))
+RETURNING id, token, username, expires_at, created_at, updated_at, tenant_id;
 
 -- name: GetSessionByToken :one
-SELECT id, token, username, expires_at, created_at, updated_at
+SELECT id, token, username, expires_at, created_at, updated_at, tenant_id
 FROM sessions WHERE token = $1;
 
+-- name: DeleteOtherSessions :exec
+-- the sessions of the user on the other devices, after a password change
+DELETE FROM sessions WHERE username = $1 AND token <> $2;
+
 -- name: DeleteSessionByToken :exec
-DELETE FROM sessions WHERE token = $1;
\ No newline at end of file
+DELETE FROM sessions WHERE token = $1;
+
+-- name: UpsertSession :one
+-- creates the session with a known token or extends it, used by the seed
+INSERT INTO sessions (token, username, expires_at, tenant_id)
+VALUES ($1, $2, $3, COALESCE((SELECT tenant_id FROM users WHERE username = $2), '00000000-0000-0000-0000-000000000001'))
+ON CONFLICT (token) DO UPDATE
+SET username = EXCLUDED.username, expires_at = EXCLUDED.expires_at, tenant_id = EXCLUDED.tenant_id, updated_at = NOW()
+RETURNING id, token, username, expires_at, created_at, updated_at, tenant_id;
@@ -3,8 +3,8 @@ UPDATE shopping_lists
 SET name = COALESCE(sqlc.narg('name'), name),
     items = COALESCE(sqlc.narg('items'), items),
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at;
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
 
 -- name: UpdateShoppingListByID :one
 -- its a full update
@@ -12,29 +12,153 @@ UPDATE shopping_lists
 SET name = $2,
     items = $3,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at;
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
 
 -- name: GetShoppingListByID :one
-SELECT id, name, items, created_at, updated_at
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetShoppingListByIDForUpdate :one
+-- locks the list until the end of the transaction, the changes that read the
+-- list before writing it wait for each other instead of losing the other ones
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetShoppingListByIDIncludingDeleted :one
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
 FROM shopping_lists
 WHERE id = $1;
 
 -- name: CreateShoppingList :one
-INSERT INTO shopping_lists (name, items)
-VALUES ($1, $2)
-RETURNING id, name, items, created_at, updated_at;
+-- the list belongs to the tenant of the owner, the built-in users and the
+-- lists without owner are in the default tenant
+INSERT INTO shopping_lists (name, items, tags, owner, tenant_id)
+VALUES ($1, $2, $3, $4, COALESCE((SELECT tenant_id FROM users WHERE username = $4), '00000000-0000-0000-0000-000000000001'))
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
 
--- name: DeleteShoppingListByID :exec
-DELETE FROM shopping_lists
-WHERE id = $1;
+-- name: CreateShoppingLists :batchone
+-- the lists of an import are sent in one round trip
+INSERT INTO shopping_lists (name, items, tags, owner, tenant_id)
+VALUES ($1, $2, $3, $4, COALESCE((SELECT tenant_id FROM users WHERE username = $4), '00000000-0000-0000-0000-000000000001'))
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
+
+-- name: DeleteShoppingListByID :execrows
+-- soft delete, the row is kept with who deleted it and when
+UPDATE shopping_lists
+SET deleted_at = NOW(), deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetAllShoppingLists :many
-SELECT id, name, items, created_at, updated_at
-FROM shopping_lists;
+-- the lists of the tenant, of every tenant when it's null
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE deleted_at IS NULL AND (sqlc.narg('tenant_id')::uuid IS NULL OR tenant_id = sqlc.narg('tenant_id'));
+
+-- name: GetShoppingListsByOwner :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE owner = $1 AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: CountShoppingListsByOwner :one
+-- the lists that count for the quota of the owner
+SELECT COUNT(*) FROM shopping_lists
+WHERE owner = $1 AND deleted_at IS NULL;
+
+-- name: GetAllShoppingListsIncludingDeleted :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE (sqlc.narg('tenant_id')::uuid IS NULL OR tenant_id = sqlc.narg('tenant_id'));
+
+-- name: GetShoppingListSummaries :many
+-- the lists of the tenant without their items, the collection is read without
+-- the arrays
+SELECT id, name, cardinality(items)::int AS item_count, updated_at, deleted_at
+FROM shopping_lists
+WHERE (@include_deleted::boolean OR deleted_at IS NULL)
+  AND (sqlc.narg('tenant_id')::uuid IS NULL OR tenant_id = sqlc.narg('tenant_id'));
+
+-- name: GetRecentlyUpdatedShoppingLists :many
+SELECT id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id
+FROM shopping_lists
+WHERE deleted_at IS NULL
+ORDER BY updated_at DESC
+LIMIT $1;
+
+-- name: GetShoppingListsVersion :one
+-- a cheap version of the collection for its ETag, every change moves the
+-- counts or the last update or deletion
+SELECT COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE deleted_at IS NULL) AS active,
+    COALESCE(MAX(updated_at), 'epoch')::timestamptz AS last_updated_at,
+    COALESCE(MAX(deleted_at), 'epoch')::timestamptz AS last_deleted_at
+FROM shopping_lists
+WHERE (sqlc.narg('tenant_id')::uuid IS NULL OR tenant_id = sqlc.narg('tenant_id'));
+
+-- name: GetShoppingListTenant :one
+-- the deleted lists too, they are still read by the admins
+SELECT tenant_id
+FROM shopping_lists
+WHERE id = $1;
+
+-- name: MergeItemIntoShoppingList :one
+-- adds the item, or adds its quantity to the first item with the same name
+-- without the case: `milk` and `Milk (2)` make `Milk (3)`. The quantity is a
+-- number in parentheses at the end, 1 when there is none. The items are the
+-- ones of the locked row, so the concurrent pushes are all counted
+UPDATE shopping_lists
+SET items = coalesce((
+    SELECT items[:i.position::int - 1]
+      || (regexp_replace(i.item, '\s*\(\d{1,6}\)$', '') || ' (' || (coalesce((regexp_match(i.item, '\((\d{1,6})\)$'))[1]::int, 1) + coalesce((regexp_match(@item::text, '\((\d{1,6})\)$'))[1]::int, 1)) || ')')
+      || items[i.position::int + 1:]
+    FROM unnest(items) WITH ORDINALITY AS i(item, position)
+    WHERE lower(regexp_replace(i.item, '\s*\(\d{1,6}\)$', '')) = lower(regexp_replace(@item::text, '\s*\(\d{1,6}\)$', ''))
+    ORDER BY i.position
+    LIMIT 1
+  ), items || @item::text),
+  updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
 
 -- name: PushItemToShoppingList :one
+-- one update of the row, the concurrent pushes wait for its lock and append
+-- to the items it wrote
 UPDATE shopping_lists
 SET items = items || $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, items, created_at, updated_at;
\ No newline at end of file
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
+
+-- name: RemoveShoppingListItem :one
+-- removes the item at the position from 1, only when it's still the item the
+-- caller read
+UPDATE shopping_lists
+SET items = items[:@position::int - 1] || items[@position::int + 1:], updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL AND items[@position::int] = @item::text
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
+
+-- name: RenameShoppingListItem :one
+-- renames the item at the position from 1, only when it's still the item the
+-- caller read
+UPDATE shopping_lists
+SET items[@position::int] = @name::text, updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL AND items[@position::int] = @item::text
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
+
+-- name: RestoreShoppingList :one
+-- undoes the soft delete
+UPDATE shopping_lists
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, items, created_at, updated_at, tags, owner, deleted_at, deleted_by, tenant_id;
+
+-- name: FindListNameConflicts :many
+-- lists of the owner named like the given name, e.g. "Groceries" or
+-- "Groceries (2)", the exact matching is done by the caller
+SELECT id, name
+FROM shopping_lists
+WHERE owner = @owner AND LOWER(name) LIKE LOWER(@name_prefix::text) || '%' AND deleted_at IS NULL
+ORDER BY created_at;
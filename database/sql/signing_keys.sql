@@ -0,0 +1,18 @@
+-- name: ListSigningKeys :many
+-- the newest keys first, the first active one signs
+SELECT * FROM signing_keys
+ORDER BY purpose, active_at DESC, created_at DESC;
+
+-- name: AddSigningKey :one
+INSERT INTO signing_keys (purpose, id, secret, active_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteRetiredSigningKeys :execrows
+-- the keys replaced by a key active since before @retired_before can't have
+-- signed anything still valid
+DELETE FROM signing_keys
+WHERE purpose = @purpose AND active_at < (
+  SELECT MAX(active_at) FROM signing_keys
+  WHERE purpose = @purpose AND active_at <= @retired_before
+);
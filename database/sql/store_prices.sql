@@ -0,0 +1,33 @@
+-- name: UpsertStorePrices :exec
+INSERT INTO store_prices (store_id, item, price_cents)
+SELECT @store_id::uuid, unnest(@items::text[]), unnest(@prices::bigint[])
+ON CONFLICT (store_id, item) DO UPDATE SET price_cents = EXCLUDED.price_cents, updated_at = NOW();
+
+-- name: ListStorePrices :many
+SELECT * FROM store_prices
+WHERE store_id = $1
+ORDER BY item;
+
+-- name: DeleteStorePrice :execrows
+DELETE FROM store_prices
+WHERE store_id = $1 AND item = $2;
+
+-- name: SumStorePrices :many
+-- the total of the items in each store of the owner, the items without a
+-- price in a store aren't counted
+SELECT stores.id AS store_id, stores.name AS store_name,
+  COUNT(store_prices.item)::int AS priced_items,
+  COALESCE(SUM(store_prices.price_cents), 0)::bigint AS total_cents
+FROM stores
+LEFT JOIN store_prices ON store_prices.store_id = stores.id AND store_prices.item = ANY(@items::text[])
+WHERE stores.owner = @owner
+GROUP BY stores.id, stores.name
+ORDER BY priced_items DESC, total_cents, stores.name;
+
+-- name: CheapestStorePrices :many
+-- the cheapest store of each item among the stores of the owner
+SELECT DISTINCT ON (store_prices.item) store_prices.item, stores.id AS store_id, stores.name AS store_name, store_prices.price_cents
+FROM store_prices
+JOIN stores ON stores.id = store_prices.store_id
+WHERE stores.owner = @owner AND store_prices.item = ANY(@items::text[])
+ORDER BY store_prices.item, store_prices.price_cents, stores.name;
@@ -0,0 +1,38 @@
+-- name: CreateStore :one
+INSERT INTO stores (owner, name, address, aisles, item_aisles)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListStoresByOwner :many
+SELECT * FROM stores
+WHERE owner = $1
+ORDER BY name, created_at;
+
+-- name: GetStore :one
+SELECT * FROM stores
+WHERE id = $1 AND owner = $2;
+
+-- name: UpdateStore :one
+UPDATE stores
+SET name = $3, address = $4, aisles = $5, item_aisles = $6, updated_at = NOW()
+WHERE id = $1 AND owner = $2
+RETURNING *;
+
+-- name: DeleteStore :execrows
+DELETE FROM stores
+WHERE id = $1 AND owner = $2;
+
+-- name: SetListStore :exec
+INSERT INTO shopping_list_stores (list_id, store_id)
+VALUES ($1, $2)
+ON CONFLICT (list_id) DO UPDATE SET store_id = EXCLUDED.store_id;
+
+-- name: UnsetListStore :execrows
+DELETE FROM shopping_list_stores
+WHERE list_id = $1;
+
+-- name: GetListStore :one
+-- the store of the list whoever owns it, the list was already authorized
+SELECT stores.* FROM stores
+JOIN shopping_list_stores ON shopping_list_stores.store_id = stores.id
+WHERE shopping_list_stores.list_id = $1;
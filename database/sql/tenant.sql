@@ -0,0 +1,7 @@
+-- name: CreateTenant :one
+INSERT INTO tenants (slug, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetTenantBySlug :one
+SELECT * FROM tenants WHERE slug = $1;
@@ -0,0 +1,11 @@
+-- name: CreateUser :one
+INSERT INTO users (username, role, password, tenant_id)
+VALUES ($1, $2, $3, COALESCE(sqlc.narg('tenant_id')::uuid, '00000000-0000-0000-0000-000000000001'))
+RETURNING *;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users WHERE username = $1;
+
+-- name: UpdateUserPassword :execrows
+UPDATE users SET password = $2, updated_at = NOW()
+WHERE username = $1;
@@ -0,0 +1,14 @@
+-- name: GetUserPreferences :one
+SELECT username, timezone, locale, first_day_of_week, created_at, updated_at
+FROM user_preferences
+WHERE username = $1;
+
+-- name: SaveUserPreferences :one
+INSERT INTO user_preferences (username, timezone, locale, first_day_of_week)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (username) DO UPDATE
+SET timezone = EXCLUDED.timezone,
+    locale = EXCLUDED.locale,
+    first_day_of_week = EXCLUDED.first_day_of_week,
+    updated_at = NOW()
+RETURNING username, timezone, locale, first_day_of_week, created_at, updated_at;
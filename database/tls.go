@@ -0,0 +1,222 @@
+package database
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// TLSOptions override the TLS settings of the DSN, they follow the libpq
+// sslmode semantics: require only encrypts (it verifies the chain when a root
+// certificate is given), verify-ca also checks the chain and verify-full also
+// checks that the certificate is for the host.
+type TLSOptions struct {
+	Mode     string // empty keeps the settings of the DSN
+	RootCert string // PEM bundle of the CAs, the system pool when empty
+	Cert     string // client certificate for mutual TLS
+	Key      string
+
+	// Pins are the base64 SHA-256 hashes of the public keys (SPKI) that
+	// are accepted, a certificate of the chain must match one of them
+	Pins []string
+}
+
+// applyTLS replaces the TLS settings of the connection and its fallbacks, the
+// fallbacks that only differ in TLS (e.g. sslmode=prefer retries without it)
+// are dropped.
+func applyTLS(connConfig *pgconn.Config, opts TLSOptions) error {
+	if opts.Mode == "" {
+		if opts.RootCert != "" || opts.Cert != "" || opts.Key != "" || len(opts.Pins) > 0 {
+			return errors.New("the database TLS options need a ssl mode")
+		}
+
+		return nil
+	}
+
+	tlsConfig, err := newTLSConfig(connConfig.Host, opts)
+	if err != nil {
+		return err
+	}
+	connConfig.TLSConfig = tlsConfig
+
+	seen := map[string]bool{hostKey(connConfig.Host, connConfig.Port): true}
+	fallbacks := []*pgconn.FallbackConfig{}
+	for _, fallback := range connConfig.Fallbacks {
+		key := hostKey(fallback.Host, fallback.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		tlsConfig, err := newTLSConfig(fallback.Host, opts)
+		if err != nil {
+			return err
+		}
+
+		fallbacks = append(fallbacks, &pgconn.FallbackConfig{
+			Host:      fallback.Host,
+			Port:      fallback.Port,
+			TLSConfig: tlsConfig,
+		})
+	}
+	connConfig.Fallbacks = fallbacks
+
+	return nil
+}
+
+func hostKey(host string, port uint16) string {
+	return host + ":" + strconv.Itoa(int(port))
+}
+
+// newTLSConfig returns nil when the mode is disable
+func newTLSConfig(host string, opts TLSOptions) (*tls.Config, error) {
+	if opts.Mode == SSLModeDisable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if opts.RootCert != "" {
+		pem, err := os.ReadFile(opts.RootCert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the database root certificate: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("the database root certificate has no PEM certificates")
+		}
+	}
+
+	if (opts.Cert == "") != (opts.Key == "") {
+		return nil, errors.New("the database client certificate and key must be set together")
+	}
+
+	if opts.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the database client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	mode := opts.Mode
+	if mode == SSLModeRequire && opts.RootCert != "" {
+		mode = SSLModeVerifyCA
+	}
+
+	switch mode {
+	case SSLModeRequire:
+		tlsConfig.InsecureSkipVerify = true
+	case SSLModeVerifyCA:
+		// the default verification also checks the host name, so it's
+		// skipped and the chain is verified in VerifyConnection
+		tlsConfig.InsecureSkipVerify = true
+	case SSLModeVerifyFull:
+		tlsConfig.ServerName = host
+	default:
+		return nil, fmt.Errorf("unknown database ssl mode '%s'", opts.Mode)
+	}
+
+	pins, err := decodePins(opts.Pins)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := tlsConfig.RootCAs
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("the database server didn't send a certificate")
+		}
+
+		chains := state.VerifiedChains
+		if mode == SSLModeVerifyCA {
+			var err error
+			chains, err = verifyChain(state.PeerCertificates, roots)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the CAs are only in the verified chains when the server
+		// doesn't send them
+		certs := state.PeerCertificates
+		for _, chain := range chains {
+			certs = append(certs, chain...)
+		}
+
+		if len(pins) > 0 && !matchesPin(certs, pins) {
+			return errors.New("the database server certificate doesn't match the pinned keys")
+		}
+
+		return nil
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyChain checks the chain without the host name, the first certificate
+// is the leaf and the others are intermediates.
+func verifyChain(certs []*x509.Certificate, roots *x509.CertPool) ([][]*x509.Certificate, error) {
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	return certs[0].Verify(opts)
+}
+
+func decodePins(encoded []string) ([][]byte, error) {
+	pins := make([][]byte, 0, len(encoded))
+	for _, pin := range encoded {
+		hash, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("the database pin '%s' must be the base64 SHA-256 of a public key", pin)
+		}
+
+		pins = append(pins, hash)
+	}
+
+	return pins, nil
+}
+
+// SPKIPin returns the pin of the public key of the certificate, the same value
+// the openssl command of the README prints.
+func SPKIPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func matchesPin(certs []*x509.Certificate, pins [][]byte) bool {
+	for _, cert := range certs {
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if bytes.Equal(hash[:], pin) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
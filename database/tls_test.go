@@ -0,0 +1,167 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), name+".pem")
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return testCA{cert: cert, key: key, file: file}
+}
+
+func (ca testCA) issue(t *testing.T, host string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake connects a client with the options to a server with the
+// certificate on a loopback port
+func handshake(t *testing.T, host string, opts TLSOptions, serverCert tls.Certificate) error {
+	clientConfig, err := newTLSConfig(host, opts)
+	if err != nil {
+		return err
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func TestTLSModes(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	otherCA := newTestCA(t, "other-ca")
+	serverCert := ca.issue(t, "db.internal")
+
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	assert.NoError(t, err)
+
+	wrongPin := sha256.Sum256([]byte("not a key"))
+
+	tests := []struct {
+		name string
+		host string
+		opts TLSOptions
+		ok   bool
+	}{
+		{"require accepts any certificate", "10.0.0.1", TLSOptions{Mode: SSLModeRequire}, true},
+		{"require with a root verifies the chain", "10.0.0.1", TLSOptions{Mode: SSLModeRequire, RootCert: otherCA.file}, false},
+		{"verify-ca ignores the host", "10.0.0.1", TLSOptions{Mode: SSLModeVerifyCA, RootCert: ca.file}, true},
+		{"verify-ca rejects other CAs", "db.internal", TLSOptions{Mode: SSLModeVerifyCA, RootCert: otherCA.file}, false},
+		{"verify-full checks the host", "db.internal", TLSOptions{Mode: SSLModeVerifyFull, RootCert: ca.file}, true},
+		{"verify-full rejects other hosts", "10.0.0.1", TLSOptions{Mode: SSLModeVerifyFull, RootCert: ca.file}, false},
+		{"the pinned key is accepted", "10.0.0.1", TLSOptions{Mode: SSLModeRequire, Pins: []string{SPKIPin(leaf)}}, true},
+		{"the CA key can be pinned", "db.internal", TLSOptions{Mode: SSLModeVerifyFull, RootCert: ca.file, Pins: []string{SPKIPin(ca.cert)}}, true},
+		{"other keys are rejected", "db.internal", TLSOptions{Mode: SSLModeVerifyFull, RootCert: ca.file, Pins: []string{base64.StdEncoding.EncodeToString(wrongPin[:])}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handshake(t, tt.host, tt.opts, serverCert)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTLSOptionsErrors(t *testing.T) {
+	_, err := newTLSConfig("db.internal", TLSOptions{Mode: "prefer"})
+	assert.Error(t, err)
+
+	_, err = newTLSConfig("db.internal", TLSOptions{Mode: SSLModeRequire, Cert: "client.pem"})
+	assert.Error(t, err)
+
+	_, err = newTLSConfig("db.internal", TLSOptions{Mode: SSLModeRequire, Pins: []string{"short"}})
+	assert.Error(t, err)
+
+	err = applyTLS(&pgconn.Config{}, TLSOptions{RootCert: "ca.pem"})
+	assert.Error(t, err)
+}
+
+func TestApplyTLSDropsPlainFallbacks(t *testing.T) {
+	connConfig, err := pgconn.ParseConfig("postgres://user@db1.internal:5432,db2.internal:5433/shopping?sslmode=prefer")
+	assert.NoError(t, err)
+	assert.Len(t, connConfig.Fallbacks, 3)
+
+	assert.NoError(t, applyTLS(connConfig, TLSOptions{Mode: SSLModeVerifyFull}))
+
+	assert.Equal(t, "db1.internal", connConfig.TLSConfig.ServerName)
+	assert.Len(t, connConfig.Fallbacks, 1)
+	assert.Equal(t, "db2.internal", connConfig.Fallbacks[0].Host)
+	assert.Equal(t, "db2.internal", connConfig.Fallbacks[0].TLSConfig.ServerName)
+}
@@ -33,9 +33,32 @@ const docTemplate = `{
                     "shopping-lists"
                 ],
                 "summary": "Get all shopping lists",
+                "parameters": [
+                    {
+                        "enum": [
+                            "items"
+                        ],
+                        "type": "string",
+                        "description": "items to return the full lists instead of their summaries",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return the soft deleted lists, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "List of shopping lists\" example:[{\"id\":\"123e4567-e89b-12d3-a456-426614174000\",\"name\":\"Grocery List\",\"items\":[\"milk\",\"bread\",\"eggs\"],\"created_at\":\"2023-01-01T00:00:00Z\",\"updated_at\":\"2023-01-01T00:00:00Z\"}]",
+                        "description": "The summaries of the lists, or the lists with include=items",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -43,6 +66,9 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "304": {
+                        "description": "The lists didn't change since the ETag"
+                    },
                     "401": {
                         "description": "Unauthorized - Invalid or missing token",
                         "schema": {
@@ -52,6 +78,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - include_deleted used by a non admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"shopping/repository"
+)
+
+// errPreconditionFailed is returned by the writes made with an If-Match that
+// doesn't match the ETag of the list anymore
+var errPreconditionFailed = errors.New("the list changed since the ETag of If-Match")
+
+// repositoryError writes the response of an error of the repositories, the
+// domain errors get their own status and notFound is the message of the 404.
+func repositoryError(w http.ResponseWriter, err error, notFound string) {
+	if quotaError(w, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, errPreconditionFailed):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repository.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrValidation):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) (*CSVWriter, error) {
+	writer := &CSVWriter{w: csv.NewWriter(w)}
+
+	err := writer.w.Write(Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (c *CSVWriter) WriteRow(row Row) error {
+	values := row.values()
+	for i, v := range values {
+		values[i] = escapeFormula(v)
+	}
+
+	return c.w.Write(values)
+}
+
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSVWriter) Close() error {
+	return c.Flush()
+}
+
+// escapeFormula prevents spreadsheet apps from running user provided values
+// as formulas (CSV injection) when the file is opened.
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+
+	return v
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var Header = []string{"list_id", "list_name", "position", "item"}
+
+// Row is one item of a list, empty lists are exported as a single row
+// without position and item so they don't disappear from the export.
+type Row struct {
+	ListID   string
+	ListName string
+	Position int // 1 based, 0 when the list has no items
+	Item     string
+}
+
+type Writer interface {
+	WriteRow(row Row) error
+	// Flush sends the buffered rows to the underlying writer
+	Flush() error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w)
+	case FormatXLSX:
+		return NewXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("export: unsupported format '%s'", format)
+	}
+}
+
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Filename returns a value safe to use in the Content-Disposition header
+func Filename(name string, format string) string {
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		name = "export"
+	}
+
+	return name + "." + format
+}
+
+func (r Row) values() []string {
+	position := ""
+	if r.Position > 0 {
+		position = fmt.Sprintf("%d", r.Position)
+	}
+
+	return []string{r.ListID, r.ListName, position, r.Item}
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := NewCSVWriter(&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteRow(Row{ListID: "1", ListName: "Groceries", Position: 1, Item: "=HYPERLINK(\"x\")"}))
+	assert.NoError(t, writer.WriteRow(Row{ListID: "2", ListName: "Empty"}))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "list_id,list_name,position,item\n1,Groceries,1,\"'=HYPERLINK(\"\"x\"\")\"\n2,Empty,,\n", buf.String())
+}
+
+func TestXLSXWriterProducesValidSheet(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := NewXLSXWriter(&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteRow(Row{ListID: "1", ListName: "Groceries & more", Position: 1, Item: "milk <1L>"}))
+	assert.NoError(t, writer.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	names := []string{}
+	var sheet []byte
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			sheet, err = io.ReadAll(rc)
+			assert.NoError(t, err)
+			rc.Close()
+		}
+	}
+
+	assert.Contains(t, names, "[Content_Types].xml")
+	assert.Contains(t, names, "xl/workbook.xml")
+
+	var parsed struct {
+		Rows []struct {
+			Cells []struct {
+				Text  string `xml:"is>t"`
+				Value string `xml:"v"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	assert.NoError(t, xml.Unmarshal(sheet, &parsed))
+	assert.Len(t, parsed.Rows, 2)
+	assert.Equal(t, "Groceries & more", parsed.Rows[1].Cells[1].Text)
+	assert.Equal(t, "1", parsed.Rows[1].Cells[2].Value)
+	assert.Equal(t, "milk <1L>", parsed.Rows[1].Cells[3].Text)
+}
+
+func TestFilename(t *testing.T) {
+	assert.Equal(t, "Groceries-week-1.csv", Filename(`Groceries "week" 1`, FormatCSV))
+	assert.Equal(t, "export.xlsx", Filename("😀", FormatXLSX))
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/export"
+	"shopping/portable"
+	"shopping/render"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (app *App) handleExportList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	app.streamExport(w, format, list.Name, []db_queries.ShoppingList{*list})
+}
+
+func (app *App) handleExportAccount(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	lists, err := app.ShoppingListRepository.GetAllShoppingLists(app.tenantScope(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.streamExport(w, format, "shopping-lists", *lists)
+}
+
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		http.Error(w, "'format' must be csv or xlsx", http.StatusBadRequest)
+		return "", false
+	}
+
+	return format, true
+}
+
+// streamExport writes the file as it's generated, flushing after every list
+// so big exports are sent chunked instead of being buffered in memory.
+func (app *App) streamExport(w http.ResponseWriter, format string, name string, lists []db_queries.ShoppingList) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(name, format)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		log.Err(err).Msg("failed to start the export")
+		http.Error(w, "failed to export", http.StatusInternalServerError)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+
+	for _, list := range lists {
+		err = writeListRows(writer, list)
+		if err != nil {
+			// the headers are already sent, the client gets a truncated file
+			log.Err(err).Msgf("failed to export the list with id: %s", list.ID.String())
+			return
+		}
+
+		err = writer.Flush()
+		if err != nil {
+			log.Err(err).Msg("failed to flush the export")
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		log.Err(err).Msg("failed to close the export")
+		return
+	}
+}
+
+func writeListRows(writer export.Writer, list db_queries.ShoppingList) error {
+	return writeItemRows(writer, list.ID.String(), list.Name, list.Items)
+}
+
+// writeItemRows writes a row per item, or a row without item for an empty
+// list
+func writeItemRows(writer export.Writer, listID string, name string, items []string) error {
+	if len(items) == 0 {
+		return writer.WriteRow(export.Row{ListID: listID, ListName: name})
+	}
+
+	for i, item := range items {
+		err := writer.WriteRow(export.Row{
+			ListID:   listID,
+			ListName: name,
+			Position: i + 1,
+			Item:     item,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *App) handleExportPortable(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	doc := portable.Document{
+		Format:     portable.FormatName,
+		Version:    portable.Version,
+		ExportedAt: time.Now().UTC(),
+		List:       portableList(list),
+	}
+
+	summary, err := app.HistoryRepository.GetListHistorySummary(list.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	doc.History = &portable.History{TimesCompleted: summary.TimesCompleted}
+	if summary.LastCompletedAt.Valid {
+		doc.History.LastCompletedAt = &summary.LastCompletedAt.Time
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(list.Name, "json")))
+
+	render.JSON(w, http.StatusOK, doc)
+}
+
+func portableList(list *db_queries.ShoppingList) portable.List {
+	pl := portable.List{
+		Name:  list.Name,
+		Items: make([]portable.Item, 0, len(list.Items)),
+		Tags:  list.Tags,
+		Metadata: portable.Metadata{
+			SourceID:  list.ID.String(),
+			CreatedAt: list.CreatedAt.Time,
+			UpdatedAt: list.UpdatedAt.Time,
+		},
+	}
+
+	for _, item := range list.Items {
+		pl.Items = append(pl.Items, portable.Item{Name: item})
+	}
+
+	if pl.Tags == nil {
+		pl.Tags = []string{}
+	}
+
+	return pl
+}
+
+func (app *App) handleImportPortable(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+
+	var doc portable.Document
+	err := json.NewDecoder(r.Body).Decode(&doc)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	err = doc.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	list, status, ok := app.createList(w, r, currentUser(r).Username, doc.List.Name, doc.List.ItemNames(), doc.List.Tags)
+	if !ok {
+		return
+	}
+
+	render.JSON(w, status, newShoppingListResponse(*list))
+}
+
+func (app *App) handleGetPortableSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+
+	render.Write(w, http.StatusOK, "application/schema+json", portable.Schema)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"shopping/render"
+	"shopping/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CompletedItem struct {
+	Name       string `json:"name"`
+	PriceCents int64  `json:"price_cents"`
+}
+
+type CompleteListRequest struct {
+	// when empty all the items of the list are recorded as purchased
+	Items []CompletedItem `json:"items"`
+}
+
+type CompleteListResponse struct {
+	ID          string    `json:"id"`
+	ListID      string    `json:"list_id"`
+	ListName    string    `json:"list_name"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func (app *App) handleCompleteList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	user := currentUser(r)
+
+	var data CompleteListRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	items := make([]repository.PurchasedItem, 0, len(list.Items))
+	if len(data.Items) == 0 {
+		for _, item := range list.Items {
+			items = append(items, repository.PurchasedItem{Name: item})
+		}
+	}
+
+	for _, item := range data.Items {
+		if strings.TrimSpace(item.Name) == "" || item.PriceCents < 0 {
+			http.Error(w, "invalid item", http.StatusBadRequest)
+			return
+		}
+
+		items = append(items, repository.PurchasedItem{Name: item.Name, PriceCents: item.PriceCents})
+	}
+
+	completion, err := app.HistoryRepository.CompleteShoppingList(list, user.Username, items)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.invalidateStats(user.Username)
+
+	render.JSON(w, http.StatusCreated, CompleteListResponse{
+		ID:          completion.ID.String(),
+		ListID:      completion.ListID.String(),
+		ListName:    completion.ListName,
+		CompletedAt: completion.CompletedAt.Time,
+	})
+}
+
+type FrequentItem struct {
+	Item            string    `json:"item"`
+	TimesPurchased  int64     `json:"times_purchased"`
+	LastPurchasedAt time.Time `json:"last_purchased_at"`
+}
+
+func (app *App) handleFrequentItems(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	limit, err := intQueryParam(r, "limit", 10, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.serveStats(w, user.Username, statsFrequentItems, limit)
+}
+
+func (app *App) frequentItems(username string, limit int) (any, error) {
+	rows, err := app.HistoryRepository.GetFrequentItems(username, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]FrequentItem, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, FrequentItem{
+			Item:            row.Item,
+			TimesPurchased:  row.TimesPurchased,
+			LastPurchasedAt: row.LastPurchasedAt.Time,
+		})
+	}
+
+	return stats, nil
+}
+
+type MonthlySpend struct {
+	Month       string `json:"month"` // YYYY-MM
+	TotalCents  int64  `json:"total_cents"`
+	PricedItems int64  `json:"priced_items"`
+}
+
+func (app *App) handleSpendByMonth(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	months, err := intQueryParam(r, "months", 12, 60)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.serveStats(w, user.Username, statsSpendByMonth, months)
+}
+
+func (app *App) spendByMonth(username string, months int) (any, error) {
+	loc, _, err := app.userCalendar(username)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, -(months - 1), 0)
+
+	rows, err := app.HistoryRepository.GetSpendByMonth(username, loc, since)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]MonthlySpend, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, MonthlySpend{
+			Month:       row.Month.Time.Format("2006-01"),
+			TotalCents:  row.TotalCents,
+			PricedItems: row.PricedItems,
+		})
+	}
+
+	return stats, nil
+}
+
+type WeeklyLists struct {
+	Week  string `json:"week"` // first day of the week, YYYY-MM-DD
+	Lists int64  `json:"lists"`
+}
+
+func (app *App) handleListsPerWeek(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	weeks, err := intQueryParam(r, "weeks", 12, 104)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.serveStats(w, user.Username, statsListsPerWeek, weeks)
+}
+
+func (app *App) listsPerWeek(username string, weeks int) (any, error) {
+	loc, firstDay, err := app.userCalendar(username)
+	if err != nil {
+		return nil, err
+	}
+
+	since := startOfWeek(time.Now().In(loc), firstDay).AddDate(0, 0, -7*(weeks-1))
+
+	rows, err := app.HistoryRepository.GetListsPerWeek(username, loc, firstDay, since)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]WeeklyLists, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, WeeklyLists{
+			Week:  row.Week.Time.Format(time.DateOnly),
+			Lists: row.Lists,
+		})
+	}
+
+	return stats, nil
+}
+
+const (
+	statsFrequentItems = "frequent-items"
+	statsSpendByMonth  = "spend-by-month"
+	statsListsPerWeek  = "lists-per-week"
+)
+
+// statsFunc computes a stat of a user, n is the size of the stat like the
+// number of items or months
+type statsFunc func(app *App, username string, n int) (any, error)
+
+var statsFuncs = map[string]statsFunc{
+	statsFrequentItems: (*App).frequentItems,
+	statsSpendByMonth:  (*App).spendByMonth,
+	statsListsPerWeek:  (*App).listsPerWeek,
+}
+
+// statsKey is the key of a stat in the cache, "<username>:<kind>:<n>"
+func statsKey(username string, kind string, n int) string {
+	return fmt.Sprintf("%s:%s:%d", username, kind, n)
+}
+
+// parseStatsKey splits a key of the cache, the username may have colons
+func parseStatsKey(key string) (username string, kind string, n int, ok bool) {
+	rest, rawN, found := cutLast(key, ":")
+	if !found {
+		return "", "", 0, false
+	}
+	username, kind, found = cutLast(rest, ":")
+	if !found {
+		return "", "", 0, false
+	}
+
+	n, err := strconv.Atoi(rawN)
+	if err != nil {
+		return "", "", 0, false
+	}
+
+	return username, kind, n, true
+}
+
+func cutLast(s string, sep string) (before string, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+// serveStats writes the cached stat or computes it and caches it
+func (app *App) serveStats(w http.ResponseWriter, username string, kind string, n int) {
+	key := statsKey(username, kind, n)
+	if cached, ok := app.StatsCache.Get(key); ok {
+		app.writeStats(w, cached)
+		return
+	}
+
+	stats, err := statsFuncs[kind](app, username, n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.StatsCache.Add(key, stats)
+	app.writeStats(w, stats)
+}
+
+func (app *App) writeStats(w http.ResponseWriter, stats any) {
+	w.Header().Set("Cache-Control", "private, max-age=60")
+
+	render.JSON(w, http.StatusOK, stats)
+}
+
+// invalidateStats removes every cached stat of the user, it's called after
+// recording a new completion so the next read is fresh.
+func (app *App) invalidateStats(username string) {
+	prefix := username + ":"
+	for _, key := range app.StatsCache.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			app.StatsCache.Remove(key)
+		}
+	}
+}
+
+func intQueryParam(r *http.Request, name string, defaultValue int, max int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 1 || v > max {
+		return 0, fmt.Errorf("'%s' must be a number between 1 and %d", name, max)
+	}
+
+	return v, nil
+}
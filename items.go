@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/render"
+	"shopping/repository"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errItemNotFound = errors.New("item not found, the item id is its position in the list from 1 or its name")
+
+type PopItemResponse struct {
+	Item string               `json:"item"`
+	List ShoppingListResponse `json:"list"`
+}
+
+type RenameItemRequest struct {
+	Name string `json:"name"`
+}
+
+type ItemSuggestion struct {
+	Name      string `json:"name"`
+	Frequency int64  `json:"frequency"` // times the user bought it, 0 for dictionary items
+}
+
+func (app *App) handleSuggestItems(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > 100 {
+		http.Error(w, "'q' is required and must have at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := intQueryParam(r, "limit", 10, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.ItemRepository.SuggestItems(user.Username, query, int32(limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	suggestions := make([]ItemSuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestions = append(suggestions, ItemSuggestion{
+			Name:      row.Name,
+			Frequency: row.Frequency,
+		})
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=60")
+
+	render.JSON(w, http.StatusOK, suggestions)
+}
+
+// handlePopItem removes the last item of the list and returns it
+func (app *App) handlePopItem(w http.ResponseWriter, r *http.Request) {
+	updated, item, err := app.writeItem(r, eventListItemRemoved, func(items []string) (int, error) {
+		if len(items) == 0 {
+			return 0, fmt.Errorf("the list has no items: %w", repository.ErrConflict)
+		}
+		return len(items), nil
+	}, removeItem)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, PopItemResponse{Item: item, List: newShoppingListResponse(*updated)})
+}
+
+func (app *App) handleRemoveItem(w http.ResponseWriter, r *http.Request) {
+	updated, _, err := app.writeItem(r, eventListItemRemoved, pathItem(r), removeItem)
+	if err != nil {
+		itemError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*updated))
+}
+
+func (app *App) handleRenameItem(w http.ResponseWriter, r *http.Request) {
+	var data RenameItemRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil || strings.TrimSpace(data.Name) == "" {
+		http.Error(w, "'name' is required", http.StatusBadRequest)
+		return
+	}
+
+	updated, _, err := app.writeItem(r, eventListItemRenamed, pathItem(r), func(repos repository.Repositories, id string, position int, item string) (*db_queries.ShoppingList, error) {
+		return repos.ShoppingLists.RenameShoppingListItem(id, position, item, data.Name)
+	})
+	if err != nil {
+		itemError(w, err)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*updated))
+}
+
+// writeItem changes the item of the list at the position returned by find.
+// The item is only changed when it's still the one read, a concurrent change
+// of the list is a conflict instead of changing another item
+func (app *App) writeItem(
+	r *http.Request,
+	eventType string,
+	find func(items []string) (int, error),
+	write func(repos repository.Repositories, id string, position int, item string) (*db_queries.ShoppingList, error),
+) (*db_queries.ShoppingList, string, error) {
+	id := r.PathValue("id")
+
+	var item string
+	updated, err := app.writeList(currentUser(r).Username, eventType, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		current, err := repos.ShoppingLists.GetShoppingListByIDForUpdate(id)
+		if err != nil {
+			return nil, err
+		}
+
+		position, err := find(current.Items)
+		if err != nil {
+			return nil, err
+		}
+		item = current.Items[position-1]
+
+		updated, err := write(repos, id, position, item)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("the item changed, read the list again: %w", repository.ErrConflict)
+		}
+		return updated, err
+	})
+
+	return updated, item, err
+}
+
+// pathItem finds the itemId of the path in the items, it's the position of
+// the item from 1, like in the csv export and the photos, or its name
+func pathItem(r *http.Request) func(items []string) (int, error) {
+	itemID := r.PathValue("itemId")
+
+	return func(items []string) (int, error) {
+		position, err := strconv.Atoi(itemID)
+		if err != nil {
+			position = slices.Index(items, itemID) + 1
+		}
+
+		if position < 1 || position > len(items) {
+			return 0, errItemNotFound
+		}
+		return position, nil
+	}
+}
+
+func removeItem(repos repository.Repositories, id string, position int, item string) (*db_queries.ShoppingList, error) {
+	return repos.ShoppingLists.RemoveShoppingListItem(id, position, item)
+}
+
+func itemError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errItemNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	repositoryError(w, err, "list not found")
+}
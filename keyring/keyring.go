@@ -0,0 +1,161 @@
+// Package keyring keeps the HMAC keys of a purpose, like the share links,
+// identified by the kid sent with what they sign. The newest active key
+// signs and every key verifies, so a rotation doesn't invalidate what was
+// signed before it.
+package keyring
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Key is a secret with the id sent with the signatures, the key only signs
+// from NotBefore so every instance knows it before its signatures arrive
+type Key struct {
+	ID        string
+	Secret    []byte
+	NotBefore time.Time
+}
+
+// the ids are sent in the URLs and in the tokens
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Parse reads the keys like <id>:<secret> of the config, in the order they
+// are given
+func Parse(values []string) ([]Key, error) {
+	keys := []Key{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		id, secret, ok := strings.Cut(strings.TrimSpace(value), ":")
+		// the value can be a secret without its id, it isn't in the error
+		if !ok || id == "" || secret == "" {
+			return nil, errors.New("the keys must be like <id>:<secret>")
+		}
+		if !validID.MatchString(id) {
+			return nil, fmt.Errorf("the key id '%s' can only have letters, digits, - and _", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("the key id '%s' is repeated", id)
+		}
+		seen[id] = true
+
+		keys = append(keys, Key{ID: id, Secret: []byte(secret)})
+	}
+
+	return keys, nil
+}
+
+// Generate returns a random key of 256 bits with an id made of the time, it
+// signs from notBefore
+func Generate(now time.Time, notBefore time.Time) (Key, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return Key{}, err
+	}
+
+	suffix := make([]byte, 3)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{
+		ID:        now.UTC().Format("20060102T150405") + "-" + base64.RawURLEncoding.EncodeToString(suffix),
+		Secret:    []byte(base64.RawURLEncoding.EncodeToString(secret)),
+		NotBefore: notBefore,
+	}, nil
+}
+
+// Ring has the keys of the config and the ones rotated while the server
+// runs, the rotated keys come first
+type Ring struct {
+	mu         sync.Mutex
+	configured []Key
+	rotated    []Key
+	keys       atomic.Pointer[[]Key]
+}
+
+func NewRing(configured ...Key) *Ring {
+	r := &Ring{}
+	r.SetConfigured(configured)
+
+	return r
+}
+
+// SetConfigured replaces the keys of the config, the first one signs until
+// a rotated key is active
+func (r *Ring) SetConfigured(keys []Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configured = keys
+	r.publish()
+}
+
+// SetRotated replaces the rotated keys, newest first
+func (r *Ring) SetRotated(keys []Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rotated = keys
+	r.publish()
+}
+
+func (r *Ring) publish() {
+	keys := append(append([]Key{}, r.rotated...), r.configured...)
+	r.keys.Store(&keys)
+}
+
+// Keys returns the rotated keys newest first and then the configured ones
+func (r *Ring) Keys() []Key {
+	return *r.keys.Load()
+}
+
+// Configured returns the keys of the config
+func (r *Ring) Configured() []Key {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Key{}, r.configured...)
+}
+
+// Rotated returns the rotated keys, newest first
+func (r *Ring) Rotated() []Key {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Key{}, r.rotated...)
+}
+
+// Signing returns the key that signs at now, the first one that is active
+func (r *Ring) Signing(now time.Time) Key {
+	keys := r.Keys()
+	for _, key := range keys {
+		if !now.Before(key.NotBefore) {
+			return key
+		}
+	}
+
+	// only keys that are not active yet, the oldest is the closest
+	return keys[len(keys)-1]
+}
+
+// Lookup finds the key of an id, the keys that are not active yet already
+// verify
+func (r *Ring) Lookup(id string) (Key, bool) {
+	for _, key := range r.Keys() {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
@@ -0,0 +1,62 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	now := time.Now()
+	ring := NewRing(Key{ID: "config", Secret: []byte("configured")})
+	assert.Equal(t, "config", ring.Signing(now).ID)
+
+	pending, err := Generate(now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	ring.SetRotated([]Key{pending})
+
+	// the new key verifies right away and signs once it's active
+	_, ok := ring.Lookup(pending.ID)
+	assert.True(t, ok)
+	assert.Equal(t, "config", ring.Signing(now).ID)
+	assert.Equal(t, pending.ID, ring.Signing(now.Add(time.Minute)).ID)
+
+	// the configured keys keep verifying
+	_, ok = ring.Lookup("config")
+	assert.True(t, ok)
+
+	ring.SetConfigured(nil)
+	_, ok = ring.Lookup("config")
+	assert.False(t, ok)
+	assert.Equal(t, pending.ID, ring.Signing(now).ID)
+}
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	first, err := Generate(now, now)
+	assert.NoError(t, err)
+	second, err := Generate(now, now)
+	assert.NoError(t, err)
+
+	assert.Regexp(t, `^20261016T120000-[A-Za-z0-9_-]{4}$`, first.ID)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.NotEqual(t, first.Secret, second.Secret)
+	assert.Len(t, first.Secret, 43)
+}
+
+func TestParse(t *testing.T) {
+	keys, err := Parse([]string{"2026-10:new-secret", " 2026-04:old:secret "})
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{{ID: "2026-10", Secret: []byte("new-secret")}, {ID: "2026-04", Secret: []byte("old:secret")}}, keys)
+
+	_, err = Parse([]string{"no-secret"})
+	assert.Error(t, err)
+
+	_, err = Parse([]string{"a.b:1"})
+	assert.ErrorContains(t, err, "can only have")
+
+	_, err = Parse([]string{"a:1", "a:2"})
+	assert.ErrorContains(t, err, "repeated")
+	assert.NotContains(t, err.Error(), ":2")
+}
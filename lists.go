@@ -0,0 +1,601 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"shopping/authz"
+	db_queries "shopping/database/queries"
+	"shopping/export"
+	"shopping/outbox"
+	"shopping/patch"
+	"shopping/render"
+	"shopping/repository"
+	"shopping/sharelink"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// the types of the events written to the audit log and the outbox
+const (
+	eventListCreated     = "list.created"
+	eventListUpdated     = "list.updated"
+	eventListDeleted     = "list.deleted"
+	eventListItemAdded   = "list.item_added"
+	eventListItemRemoved = "list.item_removed"
+	eventListItemRenamed = "list.item_renamed"
+)
+
+const (
+	onConflictError  = "error"
+	onConflictRename = "rename"
+	onConflictMerge  = "merge"
+)
+
+type ListNameConflictResponse struct {
+	Error       string   `json:"error"`
+	ExistingID  string   `json:"existing_id"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// createList creates a new list for the owner applying the `on_conflict`
+// query param when the owner already has a list with the same name. It
+// writes the error response itself and returns false in that case, the
+// status is 201 for new lists and 200 when the items were merged.
+func (app *App) createList(w http.ResponseWriter, r *http.Request, owner string, name string, items []string, tags []string) (*db_queries.ShoppingList, int, bool) {
+	mode := r.URL.Query().Get("on_conflict")
+	if mode != "" && mode != onConflictError && mode != onConflictRename && mode != onConflictMerge {
+		http.Error(w, "'on_conflict' must be error, rename or merge", http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	// without an explicit mode we only check the names when the unique
+	// constraint is enabled, and lists without owner can't conflict
+	settings := app.settings()
+	uniqueNames := settings != nil && settings.UniqueListNames
+	if (mode == "" && !uniqueNames) || owner == "" {
+		return app.createListWithoutConflicts(w, owner, name, items, tags)
+	}
+
+	if mode == "" {
+		mode = onConflictError
+	}
+
+	similar, err := app.ShoppingListRepository.FindListNameConflicts(owner, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, 0, false
+	}
+
+	var existing *db_queries.FindListNameConflictsRow
+	taken := make([]string, 0, len(similar))
+	for i, row := range similar {
+		taken = append(taken, row.Name)
+		if existing == nil && strings.EqualFold(row.Name, name) {
+			existing = &similar[i]
+		}
+	}
+
+	if existing == nil {
+		return app.createListWithoutConflicts(w, owner, name, items, tags)
+	}
+
+	suggestions := suggestListNames(name, taken, 3)
+
+	switch mode {
+	case onConflictRename:
+		return app.createListWithoutConflicts(w, owner, suggestions[0], items, tags)
+	case onConflictMerge:
+		return app.mergeIntoList(w, owner, existing.ID.String(), items)
+	default:
+		render.JSON(w, http.StatusConflict, ListNameConflictResponse{
+			Error:       fmt.Sprintf("a list named '%s' already exists", existing.Name),
+			ExistingID:  existing.ID.String(),
+			Suggestions: suggestions,
+		})
+
+		return nil, 0, false
+	}
+}
+
+func (app *App) createListWithoutConflicts(w http.ResponseWriter, owner string, name string, items []string, tags []string) (*db_queries.ShoppingList, int, bool) {
+	list, err := app.writeList(owner, eventListCreated, "", func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		return repos.ShoppingLists.CreateShoppingList(owner, name, items, tags)
+	})
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return nil, 0, false
+	}
+
+	return list, http.StatusCreated, true
+}
+
+// mergeIntoList appends the items the existing list doesn't have yet, the
+// comparison ignores the case.
+func (app *App) mergeIntoList(w http.ResponseWriter, actor string, id string, items []string) (*db_queries.ShoppingList, int, bool) {
+	list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return nil, 0, false
+	}
+
+	missing := missingItems(list.Items, items)
+	if len(missing) == 0 {
+		return list, http.StatusOK, true
+	}
+
+	merged, err := app.writeList(actor, eventListItemAdded, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		return repos.ShoppingLists.AppendItemsToShoppingList(id, missing)
+	})
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return nil, 0, false
+	}
+
+	return merged, http.StatusOK, true
+}
+
+// ListEvent is the payload of the audit and outbox events of a list, List is
+// the state after the change and it's nil for the deleted lists.
+type ListEvent struct {
+	Type       string                `json:"type"`
+	ListID     string                `json:"list_id"`
+	Actor      string                `json:"actor"`
+	List       *ShoppingListResponse `json:"list,omitempty"`
+	OccurredAt time.Time             `json:"occurred_at"`
+}
+
+// writeList runs write in a unit of work that also records the audit event
+// and enqueues the outbox event of the change, so the three of them are
+// committed or none is. The id is empty for the new lists, it's taken from
+// the list returned by write. The errors of write are returned as they are.
+func (app *App) writeList(actor string, eventType string, id string, write func(repos repository.Repositories) (*db_queries.ShoppingList, error)) (*db_queries.ShoppingList, error) {
+	var list *db_queries.ShoppingList
+	listID := id
+	err := app.UnitOfWork.Do(func(repos repository.Repositories) error {
+		var err error
+		list, err = write(repos)
+		if err != nil {
+			return err
+		}
+
+		err = app.checkListQuotas(repos, list, eventType == eventListCreated || eventType == eventListUndone)
+		if err != nil {
+			return err
+		}
+
+		// a retry creates the list again with another id
+		listID = id
+		if listID == "" {
+			listID = list.ID.String()
+		}
+
+		return recordListEvent(repos, actor, eventType, listID, list)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app.listChanged(listID)
+
+	return list, nil
+}
+
+// recordListEvent writes the audit and outbox events of a change, it must be
+// called in the unit of work of the change.
+func recordListEvent(repos repository.Repositories, actor string, eventType string, id string, list *db_queries.ShoppingList) error {
+	payload, err := listEventPayload(actor, eventType, id, list)
+	if err != nil {
+		return err
+	}
+
+	err = repos.Audit.Record(db_queries.InsertAuditEventParams{
+		Actor:        actor,
+		Action:       eventType,
+		ResourceType: "list",
+		ResourceID:   id,
+		Data:         payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return repos.Outbox.Enqueue(db_queries.EnqueueOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: id,
+		Payload:     payload,
+	})
+}
+
+// recordListEvents is recordListEvent for many lists, the events of each
+// table are copied in one round trip
+func recordListEvents(repos repository.Repositories, actor string, eventType string, lists []db_queries.ShoppingList) error {
+	audit := make([]db_queries.InsertAuditEventsParams, 0, len(lists))
+	outbox := make([]db_queries.EnqueueOutboxEventsParams, 0, len(lists))
+	for i := range lists {
+		id := lists[i].ID.String()
+		payload, err := listEventPayload(actor, eventType, id, &lists[i])
+		if err != nil {
+			return err
+		}
+
+		audit = append(audit, db_queries.InsertAuditEventsParams{
+			Actor:        actor,
+			Action:       eventType,
+			ResourceType: "list",
+			ResourceID:   id,
+			Data:         payload,
+		})
+		outbox = append(outbox, db_queries.EnqueueOutboxEventsParams{
+			EventType:   eventType,
+			AggregateID: id,
+			Payload:     payload,
+		})
+	}
+
+	err := repos.Audit.RecordAll(audit)
+	if err != nil {
+		return err
+	}
+
+	return repos.Outbox.EnqueueAll(outbox)
+}
+
+func listEventPayload(actor string, eventType string, id string, list *db_queries.ShoppingList) ([]byte, error) {
+	var state *ShoppingListResponse
+	if list != nil {
+		response := newShoppingListResponse(*list)
+		state = &response
+	}
+
+	return json.Marshal(ListEvent{
+		Type:       eventType,
+		ListID:     id,
+		Actor:      actor,
+		List:       state,
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+// outboxPublishers are the destinations of the outbox events. The live views
+// are notified again by the dispatcher because the instance that handled the
+// change may have crashed before doing it, the notifications are idempotent.
+func (app *App) outboxPublishers() []outbox.Publisher {
+	publishers := []outbox.Publisher{
+		outbox.PublisherFunc(func(ctx context.Context, event outbox.Event) error {
+			if strings.HasPrefix(event.Type, "list.") {
+				app.ListEvents.Publish(event.AggregateID)
+			}
+
+			return nil
+		}),
+		app.searchPublisher(),
+	}
+
+	if app.Notifications != nil {
+		publishers = append(publishers, app.notificationsPublisher())
+	}
+
+	for _, url := range app.Config.OutboxWebhookURLs {
+		publishers = append(publishers, &outbox.Webhook{
+			URL: url,
+			SecretFunc: func() []byte {
+				return []byte(app.secret("OUTBOX_WEBHOOK_SECRET", app.Config.OutboxWebhookSecret))
+			},
+			Client: &http.Client{Timeout: 5 * time.Second},
+		})
+	}
+
+	return publishers
+}
+
+// missingItems returns the items that are not in the list yet, without
+// repeating them. The comparison ignores the case.
+func missingItems(list []string, items []string) []string {
+	present := make(map[string]bool, len(list))
+	for _, item := range list {
+		present[strings.ToLower(item)] = true
+	}
+
+	missing := []string{}
+	for _, item := range items {
+		key := strings.ToLower(item)
+		if !present[key] {
+			present[key] = true
+			missing = append(missing, item)
+		}
+	}
+
+	return missing
+}
+
+// listChanged must be called after every write to a list, it drops the
+// cached copy and notifies the live views of the list. It's also called for
+// the changes made by the other instances, notified by the database.
+func (app *App) listChanged(id string) {
+	app.listLoads.Forget(id)
+	app.ListsCache.Remove(id)
+	if app.MissingLists != nil {
+		app.MissingLists.Remove(id)
+	}
+	app.ListEvents.Publish(id)
+}
+
+// includeDeleted reads the `include_deleted` query param, only the users that
+// can read the deleted lists (the admins with the default policy) can use it.
+func (app *App) includeDeleted(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := r.URL.Query().Get("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		http.Error(w, "'include_deleted' must be true or false", http.StatusBadRequest)
+		return false, false
+	}
+
+	if !include {
+		return false, true
+	}
+
+	user := currentUser(r)
+	allowed, err := app.Authorizer.Authorize(r.Context(), authz.Request{
+		Subject:  authz.Subject{Username: user.Username, Role: user.Role},
+		Action:   authz.ActionListReadDeleted,
+		Resource: authz.Resource{Type: "shopping_list", ID: r.PathValue("id")},
+	})
+	if err != nil {
+		log.Err(err).Msgf("authorization error for action '%s'", authz.ActionListReadDeleted)
+		http.Error(w, "authorization error", http.StatusInternalServerError)
+		return false, false
+	}
+
+	if !allowed {
+		http.Error(w, "only admins can see the deleted lists", http.StatusForbidden)
+		return false, false
+	}
+
+	return true, true
+}
+
+// includeItems reads the `include` query param, `items` returns the full lists
+// instead of their summaries
+func includeItems(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("include") {
+	case "":
+		return false, true
+	case "items":
+		return true, true
+	default:
+		http.Error(w, "'include' must be items", http.StatusBadRequest)
+		return false, false
+	}
+}
+
+// listDocument is the list the patches are applied to, a name or items
+// removed by the patch are cleared
+type listDocument struct {
+	Name  *string  `json:"name"`
+	Items []string `json:"items"`
+}
+
+// applyListPatch returns the name and the items of the list changed by the
+// patch. A patch that can't be applied or leaves another document than a list
+// is invalid, a failed test is a conflict
+func applyListPatch(listPatch patch.Patch, list *db_queries.ShoppingList) (string, []string, error) {
+	doc, err := json.Marshal(listDocument{Name: &list.Name, Items: list.Items})
+	if err != nil {
+		return "", nil, err
+	}
+
+	doc, err = listPatch.Apply(doc)
+	if errors.Is(err, patch.ErrTestFailed) {
+		return "", nil, fmt.Errorf("%s: %w", err, repository.ErrConflict)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", err, repository.ErrValidation)
+	}
+
+	var patched listDocument
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&patched)
+	if err != nil {
+		return "", nil, fmt.Errorf("the patched list must have a string name and an array of string items: %w", repository.ErrValidation)
+	}
+
+	name := ""
+	if patched.Name != nil {
+		name = *patched.Name
+	}
+	if patched.Items == nil {
+		patched.Items = []string{}
+	}
+
+	return name, patched.Items, nil
+}
+
+// ShoppingListResponse is a list in the responses of the API, the keys are
+// the ones the rows of the database had when they were sent as is
+type ShoppingListResponse struct {
+	ID        *string    `json:"ID"`
+	Name      string     `json:"Name"`
+	Items     []string   `json:"Items"`
+	CreatedAt *time.Time `json:"CreatedAt"`
+	UpdatedAt *time.Time `json:"UpdatedAt"`
+	Tags      []string   `json:"Tags"`
+	Owner     *string    `json:"Owner"`
+	DeletedAt *time.Time `json:"DeletedAt"`
+	DeletedBy *string    `json:"DeletedBy"`
+	TenantID  *string    `json:"TenantID"`
+}
+
+func newShoppingListResponse(row db_queries.ShoppingList) ShoppingListResponse {
+	return ShoppingListResponse{
+		ID:        uuidValue(row.ID),
+		Name:      row.Name,
+		Items:     row.Items,
+		CreatedAt: timeValue(row.CreatedAt),
+		UpdatedAt: timeValue(row.UpdatedAt),
+		Tags:      row.Tags,
+		Owner:     textValue(row.Owner),
+		DeletedAt: timeValue(row.DeletedAt),
+		DeletedBy: textValue(row.DeletedBy),
+		TenantID:  uuidValue(row.TenantID),
+	}
+}
+
+func newShoppingListResponses(rows []db_queries.ShoppingList) []ShoppingListResponse {
+	lists := make([]ShoppingListResponse, 0, len(rows))
+	for _, row := range rows {
+		lists = append(lists, newShoppingListResponse(row))
+	}
+
+	return lists
+}
+
+// ShoppingListSummaryResponse is a list in the collection, with the number of
+// its items instead of the items
+type ShoppingListSummaryResponse struct {
+	ID        *string    `json:"ID"`
+	Name      string     `json:"Name"`
+	ItemCount int        `json:"ItemCount"`
+	UpdatedAt *time.Time `json:"UpdatedAt"`
+	DeletedAt *time.Time `json:"DeletedAt"`
+}
+
+func newShoppingListSummaryResponses(rows []db_queries.GetShoppingListSummariesRow) []ShoppingListSummaryResponse {
+	lists := make([]ShoppingListSummaryResponse, 0, len(rows))
+	for _, row := range rows {
+		lists = append(lists, ShoppingListSummaryResponse{
+			ID:        uuidValue(row.ID),
+			Name:      row.Name,
+			ItemCount: int(row.ItemCount),
+			UpdatedAt: timeValue(row.UpdatedAt),
+			DeletedAt: timeValue(row.DeletedAt),
+		})
+	}
+
+	return lists
+}
+
+// the NULL columns are null in the responses
+
+func uuidValue(v pgtype.UUID) *string {
+	if !v.Valid {
+		return nil
+	}
+
+	s := v.String()
+	return &s
+}
+
+func timeValue(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Time
+}
+
+func textValue(v pgtype.Text) *string {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.String
+}
+
+// listRepresentation adds the csv and plain text representations to a list
+type listRepresentation struct {
+	ShoppingListResponse
+}
+
+func (l listRepresentation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.ShoppingListResponse)
+}
+
+func (l listRepresentation) MarshalCSV() ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := export.NewCSVWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	listID := ""
+	if l.ID != nil {
+		listID = *l.ID
+	}
+
+	err = writeItemRows(writer, listID, l.Name, l.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l listRepresentation) MarshalPlainText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := sharelink.RenderText(&buf, sharelink.List{Name: l.Name, Items: l.Items})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var numberedListName = regexp.MustCompile(`^(.*) \((\d+)\)$`)
+
+// suggestListNames returns the next n free names like "Groceries (2)"
+func suggestListNames(name string, taken []string, n int) []string {
+	used := map[int]bool{}
+	for _, t := range taken {
+		if strings.EqualFold(t, name) {
+			used[1] = true
+			continue
+		}
+
+		match := numberedListName.FindStringSubmatch(t)
+		if match != nil && strings.EqualFold(match[1], name) {
+			k, err := strconv.Atoi(match[2])
+			if err == nil {
+				used[k] = true
+			}
+		}
+	}
+
+	suggestions := make([]string, 0, n)
+	for k := 2; len(suggestions) < n; k++ {
+		if used[k] {
+			continue
+		}
+
+		suffix := fmt.Sprintf(" (%d)", k)
+		base := name
+		// the name column is a VARCHAR(255)
+		if len(base)+len(suffix) > 255 {
+			base = strings.ToValidUTF8(base[:255-len(suffix)], "")
+		}
+
+		suggestions = append(suggestions, base+suffix)
+	}
+
+	return suggestions
+}
@@ -0,0 +1,173 @@
+package loadshed
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Stats are published in /debug/vars as http_concurrency: in_flight and
+// queued are the requests running and waiting right now, shed the requests
+// rejected since the start and saturation the share of the slots in use. The
+// keys of the named limiters start with their name.
+var Stats = expvar.NewMap("http_concurrency")
+
+type Options struct {
+	// Name prefixes the stats of the limiter, like a route. It's empty for
+	// the limiter of the whole server
+	Name string
+	// MaxConcurrent is the number of requests served at the same time
+	MaxConcurrent int
+	// MaxQueue is the number of requests that wait for a slot, the others
+	// are rejected right away
+	MaxQueue int
+	// QueueTimeout is how long a request waits for a slot
+	QueueTimeout time.Duration
+	// RetryAfter is sent to the rejected clients
+	RetryAfter time.Duration
+	// Skip excludes requests from the limit, like the event streams that
+	// stay open
+	Skip func(r *http.Request) bool
+}
+
+// Limiter serves a bounded number of requests at the same time and sheds the
+// rest with a 503, so a traffic spike doesn't pile up connections in front of
+// the database.
+type Limiter struct {
+	opts   Options
+	limits atomic.Pointer[limits]
+
+	inFlight atomic.Int64
+	queued   atomic.Int64
+	shed     atomic.Int64
+}
+
+// limits are replaced as a whole by SetLimits, the requests release the slot
+// of the limits they acquired it from
+type limits struct {
+	maxConcurrent int
+	queueTimeout  time.Duration
+	slots         chan struct{}
+	queue         chan struct{}
+}
+
+func newLimits(maxConcurrent int, maxQueue int, queueTimeout time.Duration) *limits {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 100
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	if queueTimeout <= 0 {
+		queueTimeout = time.Second
+	}
+
+	return &limits{
+		maxConcurrent: maxConcurrent,
+		queueTimeout:  queueTimeout,
+		slots:         make(chan struct{}, maxConcurrent),
+		queue:         make(chan struct{}, maxQueue),
+	}
+}
+
+func NewLimiter(opts Options) *Limiter {
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+
+	l := &Limiter{opts: opts}
+	l.limits.Store(newLimits(opts.MaxConcurrent, opts.MaxQueue, opts.QueueTimeout))
+
+	prefix := ""
+	if opts.Name != "" {
+		prefix = opts.Name + "."
+	}
+	Stats.Set(prefix+"in_flight", expvar.Func(func() any { return l.inFlight.Load() }))
+	Stats.Set(prefix+"queued", expvar.Func(func() any { return l.queued.Load() }))
+	Stats.Set(prefix+"shed", expvar.Func(func() any { return l.shed.Load() }))
+	Stats.Set(prefix+"saturation", expvar.Func(func() any { return l.Saturation() }))
+
+	return l
+}
+
+// SetLimits changes the limits while the server runs. The requests in flight
+// keep their slots, so for a moment the old and the new limits add up.
+func (l *Limiter) SetLimits(maxConcurrent int, maxQueue int, queueTimeout time.Duration) {
+	l.limits.Store(newLimits(maxConcurrent, maxQueue, queueTimeout))
+}
+
+// Saturation returns the share of the slots in use, it can go over 1 for a
+// moment after the limits are lowered
+func (l *Limiter) Saturation() float64 {
+	return float64(l.inFlight.Load()) / float64(l.limits.Load().maxConcurrent)
+}
+
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.opts.Skip != nil && l.opts.Skip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		lim := l.acquire(r)
+		if lim == nil {
+			l.shed.Add(1)
+			log.Warn().Msgf("loadshed: rejected %s %s, %d requests in flight", r.Method, r.URL.Path, l.inFlight.Load())
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(l.opts.RetryAfter.Round(time.Second).Seconds())))
+			http.Error(w, "the server is busy, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		defer l.release(lim)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire returns the limits the slot was taken from, nil when the request
+// must be rejected
+func (l *Limiter) acquire(r *http.Request) *limits {
+	lim := l.limits.Load()
+
+	select {
+	case lim.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return lim
+	default:
+	}
+
+	// wait in the queue when there's room in it
+	select {
+	case lim.queue <- struct{}{}:
+	default:
+		return nil
+	}
+
+	l.queued.Add(1)
+	defer func() {
+		<-lim.queue
+		l.queued.Add(-1)
+	}()
+
+	timer := time.NewTimer(lim.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case lim.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return lim
+	case <-timer.C:
+		return nil
+	case <-r.Context().Done():
+		return nil
+	}
+}
+
+func (l *Limiter) release(lim *limits) {
+	l.inFlight.Add(-1)
+	<-lim.slots
+}
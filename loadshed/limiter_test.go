@@ -0,0 +1,141 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler holds the requests until it's released
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("sheds the requests when the slots and the queue are full", func(t *testing.T) {
+		limiter := NewLimiter(Options{MaxConcurrent: 1, MaxQueue: 0, RetryAfter: 2 * time.Second})
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handler := limiter.Middleware(blockingHandler(started, release))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+		<-started
+
+		assert.Equal(t, 1.0, limiter.Saturation())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+		close(release)
+		wg.Wait()
+		assert.Equal(t, 0.0, limiter.Saturation())
+	})
+
+	t.Run("queued requests get the released slot", func(t *testing.T) {
+		limiter := NewLimiter(Options{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second})
+
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		handler := limiter.Middleware(blockingHandler(started, release))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+		<-started
+
+		rec := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			close(done)
+		}()
+
+		close(release)
+		<-done
+		wg.Wait()
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("queued requests time out", func(t *testing.T) {
+		limiter := NewLimiter(Options{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handler := limiter.Middleware(blockingHandler(started, release))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+		<-started
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("skipped requests don't take a slot", func(t *testing.T) {
+		limiter := NewLimiter(Options{MaxConcurrent: 1, Skip: func(r *http.Request) bool { return true }})
+
+		handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, 0.0, limiter.Saturation())
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+	t.Run("new limits apply to the next requests", func(t *testing.T) {
+		limiter := NewLimiter(Options{MaxConcurrent: 1})
+
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		handler := limiter.Middleware(blockingHandler(started, release))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+		<-started
+
+		limiter.SetLimits(2, 0, time.Second)
+		assert.Equal(t, 0.5, limiter.Saturation())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}()
+		<-started
+
+		close(release)
+		wg.Wait()
+		assert.Equal(t, 0.0, limiter.Saturation())
+	})
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"io"
+	stdlog "log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatPretty = "pretty"
+)
+
+type Options struct {
+	// trace, debug, info, warn or error, debug when empty
+	Level string
+	// json or pretty, json when empty
+	Format string
+	// stderr, stdout or the path of a file the logs are appended to,
+	// stderr when empty
+	Output string
+	// keeps 1 of every SampleRate debug and info messages, the warnings
+	// and the errors are always written
+	SampleRate int
+}
+
+// Setup replaces the global logger of zerolog, the messages of slog go to
+// the same output. Every line is redacted, see Redact. The returned closer
+// closes the file of the output.
+func Setup(opts Options) (io.Closer, error) {
+	w, closer, err := openOutput(opts.Output)
+	if err != nil {
+		return nil, err
+	}
+	w = redactWriter{w: w}
+
+	if opts.Format == FormatPretty {
+		w = zerolog.ConsoleWriter{
+			Out:        w,
+			TimeFormat: time.RFC3339,
+			// the colors are escape codes in a file
+			NoColor: opts.Output != "" && opts.Output != "stderr" && opts.Output != "stdout",
+		}
+	}
+
+	logger := zerolog.New(w).With().Timestamp().Logger()
+	if opts.SampleRate > 1 {
+		sampler := &zerolog.BasicSampler{N: uint32(opts.SampleRate)}
+		logger = logger.Sample(zerolog.LevelSampler{
+			TraceSampler: sampler,
+			DebugSampler: sampler,
+			InfoSampler:  sampler,
+		})
+	}
+
+	log.Logger = logger
+	stdlog.SetOutput(w)
+	SetLevel(opts.Level)
+
+	return closer, nil
+}
+
+func openOutput(output string) (io.Writer, io.Closer, error) {
+	switch output {
+	case "", "stderr":
+		return os.Stderr, io.NopCloser(nil), nil
+	case "stdout":
+		return os.Stdout, io.NopCloser(nil), nil
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file, nil
+}
+
+// SetLevel sets the level of zerolog and of slog, it can be called while the
+// server runs
+func SetLevel(level string) {
+	zerologLevel, err := zerolog.ParseLevel(level)
+	if err != nil || level == "" {
+		zerologLevel = zerolog.DebugLevel
+	}
+	zerolog.SetGlobalLevel(zerologLevel)
+
+	slogLevels := map[zerolog.Level]slog.Level{
+		zerolog.TraceLevel: slog.LevelDebug - 4,
+		zerolog.DebugLevel: slog.LevelDebug,
+		zerolog.InfoLevel:  slog.LevelInfo,
+		zerolog.WarnLevel:  slog.LevelWarn,
+		zerolog.ErrorLevel: slog.LevelError,
+	}
+	slog.SetLogLoggerLevel(slogLevels[zerologLevel])
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// setup writes the logs to a file and restores the global logger after the
+// test
+func setup(t *testing.T, opts Options) string {
+	logger, level, std := log.Logger, zerolog.GlobalLevel(), stdlog.Writer()
+	t.Cleanup(func() {
+		log.Logger = logger
+		zerolog.SetGlobalLevel(level)
+		stdlog.SetOutput(std)
+	})
+
+	opts.Output = filepath.Join(t.TempDir(), "shopping.log")
+	closer, err := Setup(opts)
+	assert.NoError(t, err)
+	t.Cleanup(func() { closer.Close() })
+
+	return opts.Output
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("json with the level", func(t *testing.T) {
+		path := setup(t, Options{Level: "info", Format: FormatJSON})
+
+		log.Debug().Msg("hidden")
+		log.Info().Str("list", "groceries").Msg("created")
+
+		lines := readLines(t, path)
+		assert.Len(t, lines, 1)
+		assert.Contains(t, lines[0], `"level":"info"`)
+		assert.Contains(t, lines[0], `"list":"groceries"`)
+	})
+
+	t.Run("pretty without colors in a file", func(t *testing.T) {
+		path := setup(t, Options{Format: FormatPretty})
+
+		log.Info().Msg("created")
+
+		lines := readLines(t, path)
+		assert.Contains(t, lines[0], "INF created")
+		assert.NotContains(t, lines[0], "\x1b[")
+	})
+
+	t.Run("sampling keeps the warnings", func(t *testing.T) {
+		path := setup(t, Options{Level: "debug", Format: FormatJSON, SampleRate: 5})
+
+		for range 10 {
+			log.Info().Msg("sampled")
+		}
+		log.Warn().Msg("kept")
+
+		lines := readLines(t, path)
+		assert.Len(t, lines, 3)
+		assert.Contains(t, lines[2], "kept")
+	})
+}
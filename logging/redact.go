@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Redacted = "[redacted]"
+
+// secrets shorter than this aren't redacted, they would hide common words
+const minSecretLength = 6
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret redacts the values wherever they appear in the logs, like the
+// configured secrets and the password of the database
+func AddSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minSecretLength && !slices.Contains(secrets, value) {
+			secrets = append(secrets, value)
+		}
+	}
+
+	// the longest first, so a secret that contains another one is
+	// redacted whole
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// sensitive ends the names of the fields, headers and parameters with a
+// secret value, SECRETS_REFRESH_INTERVAL isn't one
+const sensitive = `(?:authorization|password|passwd|secret|token|access_token|refresh_token|api_key|apikey|session|cookie)`
+
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// "password":"s3cret" in the JSON logs, escaped quotes included
+	{regexp.MustCompile(`(?i)("[a-z_]*` + sensitive + `"\s*:\s*)"(?:\\.|[^"\\])*"`), `${1}"` + Redacted + `"`},
+	// Authorization: Bearer abc with the scheme
+	{regexp.MustCompile(`(?i)\b(authorization\s*[=:]\s*)(?:(?:bearer|basic|token)\s+)?[^\s&"',;]+`), "${1}" + Redacted},
+	// the credentials of the schemes out of the header
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9\-._~+/]{8,}=*`), "${1} " + Redacted},
+	// password=s3cret in the query strings, the DSNs and the pretty logs
+	{regexp.MustCompile(`(?i)\b([a-z_]*` + sensitive + `\s*[=:]\s*)([^\s&"',;]+)`), "${1}" + Redacted},
+	// the password of the connection URLs
+	{regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.\-]*://[^:/@\s"]+:)[^@\s"]+@`), "${1}" + Redacted + "@"},
+	// the values of the constraint errors, like Key (token)=(abc)
+	{regexp.MustCompile(`(?i)(\([a-z_, ]*` + sensitive + `[a-z_, ]*\)=\()[^)]*\)`), "${1}" + Redacted + ")"},
+}
+
+// Redact hides the secrets of a log line: the values of the fields named
+// like a password or a token, the Authorization credentials, the passwords
+// of the connection strings and the values of AddSecret.
+func Redact(line string) string {
+	for _, r := range redactions {
+		line = r.pattern.ReplaceAllString(line, r.replacement)
+	}
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		line = strings.ReplaceAll(line, secret, Redacted)
+	}
+
+	return line
+}
+
+// RedactArgs hides the arguments of a statement that can be a secret, only
+// the numbers, the booleans and the times are kept
+func RedactArgs(args []any) []any {
+	redacted := make([]any, len(args))
+	for i, arg := range args {
+		switch arg.(type) {
+		case nil, bool, int, int16, int32, int64, uint, uint16, uint32, uint64, float32, float64, time.Time:
+			redacted[i] = arg
+		default:
+			redacted[i] = Redacted
+		}
+	}
+
+	return redacted
+}
+
+// redactWriter redacts every line written to the output of the logs
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(r.w, Redact(string(p)))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -1,26 +1,62 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"os"
+	"shopping/accesslog"
+	"shopping/authz"
+	"shopping/backup"
+	"shopping/blob"
+	"shopping/clientip"
 	"shopping/config"
+	"shopping/consistency"
 	"shopping/database"
+	"shopping/database/migrations"
 	db_queries "shopping/database/queries"
+	"shopping/keyring"
+	"shopping/loadshed"
+	"shopping/logging"
+	"shopping/notify"
+	"shopping/outbox"
+	"shopping/passwords"
+	"shopping/patch"
+	"shopping/products"
+	"shopping/pubsub"
+	"shopping/quota"
+	"shopping/recipe"
+	"shopping/recovery"
+	"shopping/reminder"
+	"shopping/render"
 	"shopping/repository"
+	"shopping/requestid"
+	"shopping/search"
+	"shopping/secrets"
+	"shopping/sharelink"
+	"shopping/signedurl"
+	"shopping/static"
+	"shopping/tenancy"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"shopping/docs"
+	"shopping/openapi"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 
-	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
 type ShoppingList struct {
@@ -35,6 +71,8 @@ type User struct {
 	Role     string
 	Username string
 	Password string
+	// the built-in users belong to the default tenant
+	TenantID string
 }
 
 type Session struct {
@@ -45,21 +83,97 @@ type Session struct {
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Session is "cookie" for a cookie session, a bearer token otherwise
+	Session string `json:"session,omitempty"`
 }
 
 var sessions = map[string]*Session{}
 
 var allUsers = map[string]*User{
-	"admin": {Role: "admin", Username: "admin", Password: "password"},
-	"user":  {Role: "user", Username: "user", Password: "password"},
+	"admin": {Role: "admin", Username: "admin", Password: "password", TenantID: tenancy.DefaultID},
+	"user":  {Role: "user", Username: "user", Password: "password", TenantID: tenancy.DefaultID},
 }
 
 type App struct {
-	DBQueries              *db_queries.Queries
-	Config                 *config.Config
-	SessionRepository      repository.SessionRepository
-	ShoppingListRepository repository.ShoppingListRepository
-	ListsCache             *lru.Cache[string, *db_queries.ShoppingList]
+	DBQueries                 *db_queries.Queries
+	Config                    *config.Config
+	SessionRepository         repository.SessionRepository
+	ShoppingListRepository    repository.ShoppingListRepository
+	HistoryRepository         repository.HistoryRepository
+	ItemRepository            repository.ItemRepository
+	UserPreferencesRepository repository.UserPreferencesRepository
+	UserRepository            repository.UserRepository
+	TenantRepository          repository.TenantRepository
+	StoreRepository           repository.StoreRepository
+	AccountRepository         repository.AccountRepository
+	UnitOfWork                repository.UnitOfWork
+	ListsCache                *expirable.LRU[string, *CachedList]
+	StatsCache                *expirable.LRU[string, any]
+	Authorizer                authz.Authorizer
+	PasswordPolicy            *passwords.Policy
+	// the HMAC keys of the share links and of the signed URLs by purpose,
+	// the keys rotated by the admins are in the SigningKeyRepository
+	SigningKeys          map[string]*keyring.Ring
+	SigningKeyRepository repository.SigningKeyRepository
+	ShareLinks           *sharelink.Signer
+	// signs the URLs of the Signable routes, they are served by signedMux
+	// without a session
+	SignedURLs *signedurl.Signer
+	signedMux  *http.ServeMux
+	// the routes of the server, listed by GET /v1/admin/routes
+	routeMux      *RouteMux
+	ListEvents    *pubsub.Broker
+	SearchIndex   search.Index
+	searchRebuild searchRebuild
+	Consistency   *consistency.Checker
+	// the current values of the config keys read from a secret provider
+	Secrets *secrets.Store
+	// the current config, with the keys reloaded while the server runs
+	Settings *config.Holder
+	// finds the tenant of the requests, nil when MULTI_TENANCY is disabled
+	TenantResolver *tenancy.Resolver
+	// finds the products of the barcodes, nil when PRODUCTS_PROVIDER is none
+	Products products.Provider
+	// reads the ingredients of the recipe pages, nil in the tests
+	Recipes recipe.Source
+	// the files of the photos of the items, nil when PHOTO_MAX_BYTES is 0
+	Blobs           blob.Store
+	PhotoRepository repository.PhotoRepository
+	// the backups of the data, nil when BACKUP_S3_BUCKET is empty
+	BackupStore blob.Store
+	BackupDB    backup.DB
+	backups     backupJob
+	restores    backupJob
+	// the panics of the handlers are reported to it, they are only logged
+	// when it's nil
+	ErrorReporter recovery.Reporter
+	// the summaries of the requests for the admins, nil when ACCESS_LOG is
+	// disabled
+	AccessLog           *accesslog.Recorder
+	AccessLogRepository repository.AccessLogRepository
+	// notifies the owners of the lists, nil when NOTIFICATIONS is disabled
+	Notifications          *notify.Service
+	NotificationRepository repository.NotificationRepository
+	ReminderRepository     repository.ReminderRepository
+	// the ids of the lists that were not found, nil when it's disabled
+	MissingLists *expirable.LRU[string, struct{}]
+	// the price comparisons of the lists, nil in the tests
+	PriceComparisons *expirable.LRU[string, *PriceComparison]
+	// reads the migration version for the runtime info, nil in the tests
+	Migrator  *database.Migrator
+	startedAt time.Time
+	listeners []string
+	// the concurrent cache misses of a list share one query
+	listLoads singleflight.Group
+	// only set in the sandbox deployments
+	SandboxRepository repository.SandboxRepository
+	sandboxMu         sync.Mutex
+	// the quotas of the users and the tenants set by the admins, the
+	// defaults are in the config. No quota is read when it's nil
+	QuotaRepository repository.QuotaRepository
+	quotaOverrides  *expirable.LRU[string, []db_queries.Quota]
+	// counts the requests of the API keys for the rate quota
+	RateLimiter *quota.Limiter
 }
 
 // @title Shopping List API
@@ -74,57 +188,335 @@ type App struct {
 // @name Authorization
 // @description Send the jwt auth token in the Authorization token like `Authorization: Bearer <token>`
 func main() {
-	config := config.SetupConfig()
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// runServe runs `shopping serve`, the API server, it's the default command.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	settings := config.NewHolder(config.SetupConfig(configFlags), configFlags)
+	config := settings.Get()
+	logOutput, err := logging.Setup(logging.Options{
+		Level:      config.LogLevel,
+		Format:     config.LogFormat,
+		Output:     config.LogOutput,
+		SampleRate: config.LogSampleRate,
+	})
+	if err != nil {
+		log.Err(err).Msgf("Unable to open the log output '%s'", config.LogOutput)
+		return 1
+	}
+	defer logOutput.Close()
+	logging.AddSecret(config.SecretValues()...)
+
+	logEffectiveConfig(config)
 	dbpool, err := database.NewDB(config)
 	if err != nil {
 		log.Fatal().Msgf("Cannot connect to the database")
 	}
 	defer dbpool.Close()
 
-	dbQueries := db_queries.New(dbpool)
+	repository.SetDeadlines(repository.Deadlines{
+		Read:        config.DBReadTimeout,
+		Write:       config.DBWriteTimeout,
+		Transaction: config.DBTransactionTimeout,
+	})
+
+	retrier := repository.NewRetrier(repository.RetryOptions{
+		MaxAttempts: config.DBRetryMaxAttempts,
+		BaseDelay:   config.DBRetryBaseDelay,
+	})
+	dbQueries := db_queries.New(repository.WithRetry(dbpool, retrier))
 
 	// repositories
 	sessionRepo := repository.NewSessionRepository(dbQueries)
 	shoppingListRepo := repository.NewShoppingListRepository(dbQueries)
+	historyRepo := repository.NewHistoryRepository(dbQueries)
+	itemRepo := repository.NewItemRepository(dbQueries)
+	userPreferencesRepo := repository.NewUserPreferencesRepository(dbQueries)
+
+	// the lists are dropped when they change, the TTL bounds how long a copy
+	// can be stale when a notification is lost
+	listsCache := expirable.NewLRU[string, *CachedList](listsCacheSize, nil, config.ListsCacheTTL)
+
+	// the lookups of the ids that don't exist are answered from memory for
+	// a few seconds
+	var missingLists *expirable.LRU[string, struct{}]
+	if config.ListsCacheMissingTTL > 0 {
+		missingLists = expirable.NewLRU[string, struct{}](missingListsCacheSize, nil, config.ListsCacheMissingTTL)
+	}
+
+	// stats are expensive aggregations, we keep them for a few minutes and
+	// drop them when the user completes a new list
+	statsCache := expirable.NewLRU[string, any](statsCacheSize, nil, statsCacheTTL)
+
+	authorizer, err := authz.New(authz.Options{
+		Engine:     config.AuthzEngine,
+		PolicyFile: config.AuthzPolicyFile,
+		OPAUrl:     config.OPAUrl,
+	})
+	if err != nil {
+		log.Err(err).Msg("Unable to initialize the authorization engine")
+		os.Exit(1)
+	}
 
-	listsCache, err := lru.New[string, *db_queries.ShoppingList](128)
+	searchIndex, err := search.New(search.Options{
+		Backend:           config.SearchBackend,
+		Repository:        repository.NewSearchRepository(dbQueries),
+		MeilisearchURL:    config.MeilisearchURL,
+		MeilisearchAPIKey: config.MeilisearchAPIKey,
+		MeilisearchIndex:  config.MeilisearchIndex,
+	})
 	if err != nil {
-		log.Err(err).Msg("Unable to initialize the lists cache")
+		log.Err(err).Msg("Unable to initialize the search backend")
+		os.Exit(1)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(config)
+	if err != nil {
+		log.Err(err).Msg("Unable to read PASSWORD_BLOCKLIST_FILE")
+		os.Exit(1)
+	}
+
+	productsProvider, err := products.New(products.Options{
+		Provider:         config.ProductsProvider,
+		OpenFoodFactsURL: config.OpenFoodFactsURL,
+		UserAgent:        productsUserAgent(config.PublicURL),
+		CacheTTL:         config.ProductsCacheTTL,
+	})
+	if err != nil {
+		log.Err(err).Msg("Unable to initialize the products provider")
+		os.Exit(1)
+	}
+
+	signingKeys, err := newKeyRings(config)
+	if err != nil {
+		log.Err(err).Msg("Unable to generate the signing keys")
 		os.Exit(1)
 	}
 
 	app := App{
-		DBQueries:              dbQueries,
-		Config:                 config,
-		SessionRepository:      sessionRepo,
-		ShoppingListRepository: shoppingListRepo,
-		ListsCache:             listsCache,
-	}
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /v1/lists", app.addCacheHeaders(app.authRequired(app.handleCreateList)))
-	mux.HandleFunc("GET /v1/lists", app.authRequired(app.handleGetLists))
-	mux.HandleFunc("PUT /v1/lists/{id}", app.adminRequired(app.handleUpdateList))
-	mux.HandleFunc("DELETE /v1/lists/{id}", app.adminRequired(app.handleDeleteList))
-	mux.HandleFunc("PATCH /v1/lists/{id}", app.adminRequired(app.handlePatchList))
-	mux.HandleFunc("GET /v1/lists/{id}", app.authRequired(app.handleGetList))
-	mux.HandleFunc("POST /v1/lists/{id}/push", app.adminRequired(app.handleListPush))
-
-	mux.HandleFunc("POST /v1/login", app.handleLogin)
-
-	mux.HandleFunc("GET /v1/swagger/", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/v1/swagger/doc.json"),
-	))
-	mux.HandleFunc("GET /v1/swagger/doc.json", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_, err := w.Write([]byte(docs.SwaggerInfo.ReadDoc()))
+		DBQueries:                 dbQueries,
+		Config:                    config,
+		Settings:                  settings,
+		SessionRepository:         sessionRepo,
+		ShoppingListRepository:    shoppingListRepo,
+		HistoryRepository:         historyRepo,
+		ItemRepository:            itemRepo,
+		UserPreferencesRepository: userPreferencesRepo,
+		UserRepository:            repository.NewUserRepository(dbQueries),
+		TenantRepository:          repository.NewTenantRepository(dbQueries),
+		StoreRepository:           repository.NewStoreRepository(dbQueries),
+		AccountRepository:         repository.NewAccountRepository(dbQueries),
+		UnitOfWork:                repository.NewUnitOfWork(dbpool, retrier),
+		ListsCache:                listsCache,
+		PasswordPolicy:            passwordPolicy,
+		MissingLists:              missingLists,
+		StatsCache:                statsCache,
+		PriceComparisons:          expirable.NewLRU[string, *PriceComparison](priceComparisonsCacheSize, nil, priceComparisonsCacheTTL),
+		Authorizer:                authorizer,
+		SigningKeys:               signingKeys,
+		SigningKeyRepository:      repository.NewSigningKeyRepository(dbQueries),
+		QuotaRepository:           repository.NewQuotaRepository(dbQueries),
+		quotaOverrides:            expirable.NewLRU[string, []db_queries.Quota](quotaOverridesCacheSize, nil, quotaOverridesCacheTTL),
+		RateLimiter:               quota.NewLimiter(),
+		ShareLinks:                sharelink.NewSigner(signingKeys[keyPurposeShareLinks]),
+		SignedURLs:                signedurl.NewSigner(signingKeys[keyPurposeSignedURLs]),
+		ListEvents:                pubsub.NewBroker(),
+		SearchIndex:               searchIndex,
+		Products:                  productsProvider,
+		Recipes:                   recipe.NewFetcher(productsUserAgent(config.PublicURL)),
+		startedAt:                 time.Now().UTC(),
+	}
+
+	if config.MultiTenancy {
+		app.TenantResolver = tenancy.NewResolver(tenancy.Options{
+			Header:     config.TenantHeader,
+			BaseDomain: config.TenantBaseDomain,
+			Lookup:     app.lookupTenant,
+		})
+		log.Info().Msgf("> multi-tenancy: the tenant is the subdomain of '%s' or the %s header", config.TenantBaseDomain, app.TenantResolver.Header())
+	}
+
+	loadedMigrations, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		log.Err(err).Msg("Unable to load the migrations")
+		os.Exit(1)
+	}
+	app.Migrator = &database.Migrator{Pool: dbpool, Migrations: loadedMigrations}
+	app.Consistency = consistency.NewChecker(app.consistencyChecks()...)
+
+	app.Secrets = config.SecretStore()
+	app.Secrets.OnChange(app.applySecret)
+	if config.SecretsRefreshInterval > 0 {
+		go app.Secrets.Run(context.Background(), config.SecretsRefreshInterval)
+	}
+	// the links signed with the rotated keys are valid right after a restart
+	app.reloadSigningKeys()
+	go app.runSigningKeyRefresh(context.Background(), config.SigningKeysRefreshInterval)
+	if config.ConsistencyCheckInterval > 0 {
+		go app.Consistency.Run(context.Background(), config.ConsistencyCheckInterval, func() bool {
+			return app.settings().ConsistencyRepair
+		})
+	}
+
+	if config.Sandbox {
+		log.Warn().Msg("> sandbox mode: the data of every user is deleted on each reset")
+		app.SandboxRepository = repository.NewSandboxRepository(dbQueries)
+		if config.SandboxResetInterval > 0 {
+			go app.runSandboxResets(context.Background(), config.SandboxResetInterval)
+		}
+	}
+
+	if config.AccessLog {
+		app.AccessLogRepository = repository.NewAccessLogRepository(dbQueries)
+		app.AccessLog = accesslog.NewRecorder(app.AccessLogRepository, accesslog.Options{
+			SampleRate: config.AccessLogSampleRate,
+			Retention:  config.AccessLogRetention,
+		})
+		go app.AccessLog.Run(context.Background())
+	}
+
+	if config.PhotoMaxBytes > 0 {
+		app.Blobs, err = blob.New(blob.Options{
+			Store:       config.BlobStore,
+			Dir:         config.BlobDir,
+			S3Endpoint:  config.S3Endpoint,
+			S3Region:    config.S3Region,
+			S3Bucket:    config.S3Bucket,
+			S3AccessKey: config.S3AccessKeyID,
+			S3SecretKey: func() string {
+				return app.secret("S3_SECRET_ACCESS_KEY", app.Config.S3SecretAccessKey)
+			},
+			S3PathStyle: config.S3PathStyle,
+			Client:      &http.Client{Timeout: 30 * time.Second},
+		})
 		if err != nil {
-			http.Error(w, "Failed to write response", http.StatusInternalServerError)
-			return
+			log.Err(err).Msg("Unable to initialize the blob store")
+			os.Exit(1)
 		}
+		app.PhotoRepository = repository.NewPhotoRepository(dbQueries)
+	}
+
+	if config.BackupS3Bucket != "" {
+		app.BackupStore, err = blob.New(blob.Options{
+			Store:       blob.StoreS3,
+			S3Endpoint:  config.S3Endpoint,
+			S3Region:    config.S3Region,
+			S3Bucket:    config.BackupS3Bucket,
+			S3AccessKey: config.S3AccessKeyID,
+			S3SecretKey: func() string {
+				return app.secret("S3_SECRET_ACCESS_KEY", app.Config.S3SecretAccessKey)
+			},
+			S3PathStyle: config.S3PathStyle,
+			Client:      &http.Client{Timeout: 10 * time.Minute},
+		})
+		if err != nil {
+			log.Err(err).Msg("Unable to initialize the backups bucket")
+			os.Exit(1)
+		}
+		app.BackupDB = dbpool
+		// like the reminders, the scheduled backups are made by the
+		// instances that dispatch the events
+		if config.BackupInterval > 0 && config.OutboxDispatcher {
+			go app.runBackups(context.Background(), config.BackupInterval)
+		}
+	}
+
+	if config.NotificationsEnabled {
+		app.setupNotifications(repository.NewNotificationRepository(dbQueries))
+		// the digests and the reminders are sent by the instances that
+		// dispatch the events, like the notifications sent right away
+		app.ReminderRepository = repository.NewReminderRepository(dbQueries)
+		if config.OutboxDispatcher {
+			go app.Notifications.Run(context.Background(), digestCheckInterval)
+			go reminder.NewScheduler(app.ReminderRepository, app.fireReminder, reminder.Options{}).Run(context.Background())
+		}
+	}
+
+	if config.OutboxDispatcher {
+		dispatcher := outbox.NewDispatcher(
+			repository.NewOutboxRepository(dbQueries),
+			outbox.Options{Interval: config.OutboxPollInterval},
+			app.outboxPublishers()...,
+		)
+		go dispatcher.Run(context.Background())
+		// the erased lists are removed from the search index by the
+		// dispatcher
+		go app.runAccountErasures(context.Background(), accountErasureInterval)
+	}
+
+	// the other instances change the lists too, their changes are
+	// notified by the database
+	listListener := &database.Listener{
+		Pool:           dbpool,
+		Channel:        database.ShoppingListChannel,
+		OnNotification: app.listChanged,
+		OnConnect:      app.resetListsCache,
+	}
+	go listListener.Run(context.Background())
+
+	mux := NewRouteMux()
+	app.registerRoutes(mux, app.routes())
+	mux.Register(RegisteredRoute{Method: "GET", Pattern: "/v1/embed/", Summary: "Script and styles of the embeddable widget", Auth: authPublic, Middlewares: []string{}}, widgetAssets())
+
+	// the UI files are compiled in the binary and the document is loaded
+	// relative to the page, so the docs work behind any host or offline
+	docs := []string{"docs_access"}
+	mux.Register(RegisteredRoute{Method: "GET", Pattern: "/v1/swagger/", Summary: "Swagger UI", Auth: authPublic, Middlewares: docs}, app.docsAccess(httpSwagger.Handler(
+		httpSwagger.URL("doc.json"),
+	)))
+	swaggerDoc := swaggerDocWithExamples()
+	mux.Register(RegisteredRoute{Method: "GET", Pattern: "/v1/swagger/doc.json", Summary: "OpenAPI document of the API", Auth: authPublic, Middlewares: docs}, app.docsAccess(func(w http.ResponseWriter, r *http.Request) {
+		render.Write(w, http.StatusOK, render.MediaTypeJSON, swaggerDoc)
+	}))
+	log.Info().Msgf("> Swagger docs access: %s (APP_ENV=%s)", config.SwaggerAccess, config.AppEnv)
+
+	staticFS, err := static.FS(config.StaticDir)
+	if err != nil {
+		log.Err(err).Msgf("Unable to open the static files directory '%s'", config.StaticDir)
+		os.Exit(1)
+	}
+
+	mux.Register(RegisteredRoute{Method: "GET", Pattern: "/static/", Summary: "Files of STATIC_DIR", Auth: authPublic, Middlewares: []string{}}, static.Handler("/static/", staticFS, 24*time.Hour))
+	mux.Register(RegisteredRoute{Method: "GET", Pattern: "/robots.txt", Summary: "robots.txt of STATIC_DIR", Auth: authPublic, Middlewares: []string{}}, static.Handler("/", staticFS, 24*time.Hour))
+
+	// the event streams stay open, they would take the slots forever
+	limiter := loadshed.NewLimiter(loadshed.Options{
+		MaxConcurrent: config.MaxConcurrentRequests,
+		MaxQueue:      config.MaxQueuedRequests,
+		QueueTimeout:  config.RequestQueueTimeout,
+		Skip: func(r *http.Request) bool {
+			return strings.HasSuffix(r.URL.Path, "/events")
+		},
 	})
 
-	handler := app.enableCors(mux)
+	var tenantHandler http.Handler = mux
+	if app.TenantResolver != nil {
+		tenantHandler = app.TenantResolver.Middleware(mux)
+	}
+
+	handler := recovery.Middleware(app.ErrorReporter, app.enableCors(limiter.Middleware(tenantHandler)))
+	if app.AccessLog != nil {
+		handler = app.AccessLog.Middleware(handler)
+	}
+	// the access log and the admin routes need the address of the client
+	clientIPs, err := clientip.NewResolver(config.TrustedProxies)
+	if err != nil {
+		log.Err(err).Msg("invalid TRUSTED_PROXIES")
+		os.Exit(1)
+	}
+	handler = clientIPs.Middleware(handler)
+	handler = requestid.Middleware(handler)
+
+	settings.OnReload(applyReloadedConfig(limiter))
+	go settings.Watch(context.Background())
 
 	// certManager := autocert.Manager{
 	// 	Prompt:     autocert.AcceptTOS,
@@ -141,24 +533,51 @@ func main() {
 	// go http.ListenAndServe(fmt.Sprintf(":%d", PORT), certManager.HTTPHandler(nil))
 	// server.ListenAndServeTLS("", "")
 
-	log.Info().Msgf("> Server running on http://localhost:%d\n", config.Port)
-	err = http.ListenAndServe(fmt.Sprintf(":%d", config.Port), handler)
+	addr := fmt.Sprintf(":%d", config.Port)
+	app.listeners = []string{"http://" + addr}
+	app.logStartup(app.runtimeInfo(context.Background()))
+
+	err = http.ListenAndServe(addr, handler)
+	if err != nil {
+		log.Err(err).Msg("the server stopped")
+		return 1
+	}
+
+	return 0
+}
+
+// swaggerDocWithExamples fills the response examples of the swagger document
+// with the fixtures recorded by the tests, so they always match the real
+// responses. When something goes wrong we serve the document as it is.
+func swaggerDocWithExamples() []byte {
+	doc := []byte(docs.SwaggerInfo.ReadDoc())
+
+	fixtures, err := openapi.LoadFixtures(openapi.Fixtures)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to load the openapi fixtures")
+		return doc
+	}
+
+	withExamples, err := openapi.WithExamples(doc, fixtures)
 	if err != nil {
-		panic(err)
+		log.Warn().Err(err).Msg("unable to add the examples to the swagger document")
+		return doc
 	}
 
+	return withExamples
 }
 
 type CreateShoppingListRequest struct {
 	Name  string   `json:"name"`
 	Items []string `json:"items"`
+	Tags  []string `json:"tags"`
 }
 
 func (app *App) handleCreateList(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("Creating new shopping list",
-		slog.String("ip", r.RemoteAddr),
+		slog.String("ip", clientip.FromRequest(r)),
 		slog.String("user", r.Header.Get("X-User")),
-		slog.String("request_id", r.Header.Get("X-Request-ID")),
+		slog.String("request_id", requestid.FromContext(r.Context())),
 	)
 
 	var newList CreateShoppingListRequest
@@ -169,24 +588,17 @@ func (app *App) handleCreateList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newShoppingList, err := app.ShoppingListRepository.CreateShoppingList(newList.Name, newList.Items)
-	if err != nil {
-		slog.Error("failed to create new shopping list", slog.Any("error", err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	owner := ""
+	if user := currentUser(r); user != nil {
+		owner = user.Username
 	}
 
-	w.WriteHeader(http.StatusCreated)
-
-	// encode automatically sets the content type to application/json
-	// more memory efficient for large objects instead of using json.Marshal + w.Header().Set + w.Write()
-	// its recommended over the manually marshal, write etc
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(newShoppingList)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	newShoppingList, status, ok := app.createList(w, r, owner, newList.Name, newList.Items, newList.Tags)
+	if !ok {
 		return
 	}
+
+	render.JSON(w, status, newShoppingListResponse(*newShoppingList))
 }
 
 // GetShoppingLists godoc
@@ -196,44 +608,115 @@ func (app *App) handleCreateList(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Security AuthToken
-// @Success 200 {array} object "List of shopping lists" example:[{"id":"123e4567-e89b-12d3-a456-426614174000","name":"Grocery List","items":["milk","bread","eggs"],"created_at":"2023-01-01T00:00:00Z","updated_at":"2023-01-01T00:00:00Z"}]
+// @Param include query string false "items to return the full lists instead of their summaries" Enums(items)
+// @Param include_deleted query bool false "Also return the soft deleted lists, admins only"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} object "The summaries of the lists, or the lists with include=items"
+// @Success 304 "The lists didn't change since the ETag"
 // @Failure 401 {object} map[string]string "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} map[string]string "Forbidden - include_deleted used by a non admin"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /lists [get]
 func (app *App) handleGetLists(w http.ResponseWriter, r *http.Request) {
-	lists, err := app.ShoppingListRepository.GetAllShoppingLists()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	withItems, ok := includeItems(w, r)
+	if !ok {
+		return
+	}
+
+	includeDeleted, ok := app.includeDeleted(w, r)
+	if !ok {
 		return
 	}
 
-	data, err := json.Marshal(lists)
+	// the polling clients get a 304 without reading the lists
+	tenant := app.tenantScope(r)
+	version, err := app.ShoppingListRepository.GetShoppingListsVersion(tenant)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	etag := collectionETag(version, includeDeleted, withItems)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Etag", etag)
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// the summaries don't read the items of every list
+	if !withItems {
+		lists, err := app.ShoppingListRepository.GetShoppingListSummaries(tenant, includeDeleted)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, newShoppingListSummaryResponses(lists))
+		return
+	}
+
+	if includeDeleted {
+		lists, err := app.ShoppingListRepository.GetAllShoppingListsIncludingDeleted(tenant)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	_, err = w.Write(data)
+		render.JSON(w, http.StatusOK, newShoppingListResponses(lists))
+		return
+	}
+
+	lists, err := app.ShoppingListRepository.GetAllShoppingLists(tenant)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponses(*lists))
+}
+
+// collectionETag is the ETag of the lists, the deleted lists and the full
+// lists are other representations
+func collectionETag(version *db_queries.GetShoppingListsVersionRow, includeDeleted bool, withItems bool) string {
+	key := fmt.Sprintf("%d:%d:%d:%d:%t:%t",
+		version.Total,
+		version.Active,
+		version.LastUpdatedAt.Time.UnixNano(),
+		version.LastDeletedAt.Time.UnixNano(),
+		includeDeleted,
+		withItems,
+	)
+
+	return fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(key)))
+}
+
+// matchesETag tells if the If-None-Match header has the etag, it can be a
+// list of etags or * and the weak ones are compared as strong
+func matchesETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (app *App) handleDeleteList(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	err := app.ShoppingListRepository.DeleteShoppingListByID(id)
+	user := currentUser(r)
+
+	_, err := app.writeList(user.Username, eventListDeleted, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		return nil, repos.ShoppingLists.DeleteShoppingListByID(id, user.Username)
+	})
 	if err != nil {
-		http.Error(w, "list not found", http.StatusInternalServerError)
+		repositoryError(w, err, "list not found")
 		return
 	}
 
-	app.ListsCache.Remove(id)
-
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -252,27 +735,19 @@ func (app *App) handleUpdateList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedList, err := app.ShoppingListRepository.UpdateShoppingListByID(
-		id,
-		bodyData.Name,
-		bodyData.Items,
-	)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	app.ListsCache.Remove(id)
-
-	// w.Header().Set("Content-Type", "application/json")
-
-	err = json.NewEncoder(w).Encode(updatedList)
+	updatedList, err := app.writeList(currentUser(r).Username, eventListUpdated, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		return repos.ShoppingLists.UpdateShoppingListByID(
+			id,
+			bodyData.Name,
+			bodyData.Items,
+		)
+	})
 	if err != nil {
-		log.Err(err).Msgf("failed to encode updated list data with id: %s", id)
-		http.Error(w, "failed to parse data", http.StatusInternalServerError)
+		repositoryError(w, err, "list not found")
 		return
 	}
 
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*updatedList))
 }
 
 type ShoppingListPatch struct {
@@ -280,83 +755,153 @@ type ShoppingListPatch struct {
 	Items *[]string `json:"items"`
 }
 
+// handlePatchList changes the fields of the list given in a json body, or
+// applies a JSON Merge Patch or a JSON Patch to {"name": ..., "items": [...]}.
+// With If-Match the list is only changed when it's still the one of the ETag
 func (app *App) handlePatchList(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	ifMatch := r.Header.Get("If-Match")
 
 	var data ShoppingListPatch
-	err := json.NewDecoder(r.Body).Decode(&data)
-	if err != nil {
-		http.Error(w, "invalid data", http.StatusBadRequest)
-		return
-	}
+	var listPatch patch.Patch
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == patch.MediaTypeMergePatch || mediaType == patch.MediaTypeJSONPatch {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid data", http.StatusBadRequest)
+			return
+		}
 
-	updated, err := app.ShoppingListRepository.PartialUpdate(
-		id,
-		data.Name,
-		data.Items,
-	)
-	if err != nil {
-		log.Err(err).Msgf("error to patch update the list with id: %s", id)
-		http.Error(w, "list not found", http.StatusNotFound)
-		return
+		listPatch, err = patch.Parse(mediaType, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		err := json.NewDecoder(r.Body).Decode(&data)
+		if err != nil {
+			http.Error(w, "invalid data", http.StatusBadRequest)
+			return
+		}
 	}
 
-	app.ListsCache.Remove(id)
+	updated, err := app.writeList(currentUser(r).Username, eventListUpdated, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		if listPatch == nil && ifMatch == "" {
+			return repos.ShoppingLists.PartialUpdate(id, data.Name, data.Items)
+		}
+
+		current, err := repos.ShoppingLists.GetShoppingListByIDForUpdate(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if ifMatch != "" && !matchesETag(ifMatch, newCachedList(*current).ETag) {
+			return nil, errPreconditionFailed
+		}
+
+		if listPatch == nil {
+			return repos.ShoppingLists.PartialUpdate(id, data.Name, data.Items)
+		}
 
-	err = json.NewEncoder(w).Encode(updated)
+		name, items, err := applyListPatch(listPatch, current)
+		if err != nil {
+			return nil, err
+		}
+
+		return repos.ShoppingLists.UpdateShoppingListByID(id, name, items)
+	})
 	if err != nil {
-		log.Err(err).Msgf("failed to parse the updated data: %+v", updated)
-		http.Error(w, "failed to parse data", http.StatusInternalServerError)
+		repositoryError(w, err, "list not found")
 		return
 	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*updated))
 }
 
 func (app *App) handleGetList(w http.ResponseWriter, r *http.Request) {
 	var err error
 	id := r.PathValue("id")
 
-	// check cache first
-	list, ok := app.ListsCache.Get(id)
+	includeDeleted, ok := app.includeDeleted(w, r)
 	if !ok {
-		list, err = app.ShoppingListRepository.GetShoppingListByID(id)
+		return
+	}
+
+	var cached *CachedList
+	if includeDeleted {
+		// the cache only has the lists that are not deleted
+		row, err := app.ShoppingListRepository.GetShoppingListByIDIncludingDeleted(id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			repositoryError(w, err, "list not found")
 			return
 		}
+		cached = newCachedList(*row)
+	} else {
+		cached, err = app.cachedList(id)
+		if err != nil {
+			repositoryError(w, err, "list not found")
+			return
+		}
+	}
 
-		app.ListsCache.Add(id, list)
+	list, ok := app.orderList(w, r, cached.List)
+	if !ok {
+		return
 	}
 
-	data, err := json.Marshal(list)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	mediaType := render.Negotiate(r, render.MediaTypeJSON, render.MediaTypeCSV, render.MediaTypeText)
+	if mediaType == "" {
+		http.Error(w, "the list can be returned as application/json, text/csv or text/plain", http.StatusNotAcceptable)
 		return
 	}
 
+	// the JSON of the list as it's cached is the most requested, it's
+	// marshaled once
+	data, etag := cached.JSON, cached.ETag
+	if mediaType != render.MediaTypeJSON || r.URL.Query().Get("order") != "" {
+		data, err = render.Marshal(mediaType, listRepresentation{list})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		etag = fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+	}
+
 	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("Accept-Patch", patch.MediaTypeMergePatch+", "+patch.MediaTypeJSONPatch)
 
-	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(data))
-	if match := r.Header.Get("If-None-Match"); match == etag {
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("Etag", etag)
 
-	_, err = w.Write(data)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	render.Write(w, http.StatusOK, mediaType, data)
 }
 
 type ListPushAction struct {
 	Item string `json:"item"`
 }
 
+// the ways an item is pushed to a list that already has it
+const (
+	pushModeAppend = "append"
+	pushModeMerge  = "merge"
+)
+
+// handleListPush adds an item at the end of the list, with ?mode=merge an
+// item already in the list gets the quantity of the new one instead
 func (app *App) handleListPush(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != pushModeAppend && mode != pushModeMerge {
+		http.Error(w, "'mode' must be append or merge", http.StatusBadRequest)
+		return
+	}
+
 	var data ListPushAction
 	err := json.NewDecoder(r.Body).Decode(&data)
 	if err != nil {
@@ -364,20 +909,22 @@ func (app *App) handleListPush(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updated, err := app.ShoppingListRepository.PushItemToShoppingList(
-		id,
-		data.Item,
-	)
-	if err != nil {
-		http.Error(w, "list not found", http.StatusNotFound)
-		return
-	}
+	updated, err := app.writeList(currentUser(r).Username, eventListItemAdded, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		if mode == pushModeMerge {
+			return repos.ShoppingLists.MergeItemIntoShoppingList(id, data.Item)
+		}
 
-	err = json.NewEncoder(w).Encode(updated)
+		return repos.ShoppingLists.PushItemToShoppingList(
+			id,
+			data.Item,
+		)
+	})
 	if err != nil {
-		http.Error(w, "error to process data", http.StatusInternalServerError)
+		repositoryError(w, err, "list not found")
 		return
 	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*updated))
 }
 
 func (app *App) handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -388,22 +935,32 @@ func (app *App) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := allUsers[data.Username]
-	if user != nil && user.Password == data.Password {
+	cookie := data.Session == sessionModeCookie
+	if cookie && !app.cookieSessions() {
+		http.Error(w, "the cookie sessions are disabled, set SESSION_COOKIES", http.StatusBadRequest)
+		return
+	}
+
+	user, err := app.checkCredentials(data.Username, data.Password)
+	if err != nil {
+		http.Error(w, "error to check the credentials", http.StatusInternalServerError)
+		return
+	}
+
+	// the users of another tenant can't log in from this one
+	if user != nil && app.inTenant(r, user.TenantID) {
 		session, err := app.SessionRepository.AddSession(user.Username)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-
-		err = json.NewEncoder(w).Encode(map[string]string{"token": session.Token})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if cookie {
+			app.loginWithCookie(w, session.Token, session.ExpiresAt.Time)
 			return
 		}
 
+		render.JSON(w, http.StatusOK, map[string]string{"token": session.Token})
 		return
 	}
 
@@ -412,53 +969,114 @@ func (app *App) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 func (app *App) authRequired(next http.HandlerFunc) http.HandlerFunc {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if !strings.HasPrefix(token, "Bearer") {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		// the signed URLs stand for their user instead of a session
+		if user := signedUser(r); user != nil {
+			if !app.inTenant(r, user.TenantID) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next(w, r)
 			return
 		}
 
-		token = token[7:]
+		token, fromCookie := sessionToken(r)
+		if token == "" || fromCookie && !app.cookieSessions() {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
 
-		_, err := app.SessionRepository.GetSessionByToken(token)
+		session, err := app.SessionRepository.GetSessionByToken(token)
 		if err != nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		// the browsers send the cookies with the requests of any site
+		if fromCookie && !validCSRF(r, token) {
+			http.Error(w, "invalid CSRF token, send the csrf_token cookie in the "+csrfHeader+" header", http.StatusForbidden)
+			return
+		}
+
+		// the sessions are only valid in the tenant of their user
+		if !app.inTenant(r, session.TenantID.String()) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
 		next(w, r)
 	}
 
 	return fn
 }
 
-func (app *App) adminRequired(next http.HandlerFunc) http.HandlerFunc {
+// authorized delegates the access decision to the configured policy engine
+// instead of hardcoding the roles in each route.
+func (app *App) authorized(action authz.Action, next http.HandlerFunc) http.HandlerFunc {
 	return app.authRequired(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		token = token[7:]
-		session, err := app.SessionRepository.GetSessionByToken(token)
+		user, err := app.requestUser(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			http.Error(w, "authorization error", http.StatusInternalServerError)
 			return
 		}
 
-		user := allUsers[session.Username]
+		if user == nil {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		accesslog.SetUser(r.Context(), user.Username)
 
-		if user.Role != "admin" {
+		allowed, err := app.Authorizer.Authorize(r.Context(), authz.Request{
+			Subject:  authz.Subject{Username: user.Username, Role: user.Role},
+			Action:   action,
+			Resource: authz.Resource{Type: "shopping_list", ID: r.PathValue("id")},
+		})
+		if err != nil {
+			log.Err(err).Msgf("authorization error for action '%s'", action)
+			http.Error(w, "authorization error", http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
 			http.Error(w, "forbidden", http.StatusForbidden)
 			return
 		}
 
-		next(w, r)
+		if !app.tenantGuard(w, r) {
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
 	})
 }
 
-func (app *App) enableCors(next http.Handler) http.Handler {
-	trustedOrigins := []string{
-		"http://localhost:9000",
-		"http://localhost:9002",
-		"http://localhost:3000",
+// requestUser finds the user of the signed URL or of the session
+func (app *App) requestUser(r *http.Request) (*User, error) {
+	if user := signedUser(r); user != nil {
+		return user, nil
+	}
+
+	token, _ := sessionToken(r)
+	session, err := app.SessionRepository.GetSessionByToken(token)
+	if err != nil {
+		return nil, nil
 	}
+
+	return app.findUser(session.Username)
+}
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// currentUser returns the user resolved by the authorized middleware
+func currentUser(r *http.Request) *User {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	return user
+}
+
+// enableCors reads the trusted origins on every request, they can be reloaded
+func (app *App) enableCors(next http.Handler) http.Handler {
 	allowedMethods := []string{
 		http.MethodGet,
 		http.MethodPost,
@@ -471,6 +1089,7 @@ func (app *App) enableCors(next http.Handler) http.Handler {
 	allowedHeaders := []string{
 		"Authorization",
 		"Content-Type",
+		csrfHeader,
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -484,8 +1103,12 @@ func (app *App) enableCors(next http.Handler) http.Handler {
 			return
 		}
 
-		if slices.Contains(trustedOrigins, origin) {
+		if slices.Contains(app.settings().CORSOrigins, origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
+			// the trusted origins send the session cookie with credentials: "include"
+			if app.cookieSessions() {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
 
 			// check if the request has the HTTP method OPTIONS and contains
 			// the "Access-Control-Request-Method" header. If it does, then we treat
@@ -531,6 +1154,40 @@ func (app *App) enableCors(next http.Handler) http.Handler {
 	})
 }
 
+// docsAccess applies SWAGGER_ACCESS to the docs routes, they are always
+// registered so the decision is only made here.
+func (app *App) docsAccess(next http.HandlerFunc) http.HandlerFunc {
+	switch app.Config.SwaggerAccess {
+	case config.SwaggerAccessOpen:
+		return next
+	case config.SwaggerAccessBasicAuth:
+		// compare the hashes so the time doesn't depend on the lengths
+		expectedUser := sha256.Sum256([]byte(app.Config.SwaggerUser))
+		expectedPassword := sha256.Sum256([]byte(app.Config.SwaggerPassword))
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if ok {
+				userHash := sha256.Sum256([]byte(user))
+				passwordHash := sha256.Sum256([]byte(password))
+				userMatch := subtle.ConstantTimeCompare(userHash[:], expectedUser[:]) == 1
+				passwordMatch := subtle.ConstantTimeCompare(passwordHash[:], expectedPassword[:]) == 1
+
+				if userMatch && passwordMatch {
+					next(w, r)
+					return
+				}
+			}
+
+			w.Header().Set("WWW-Authenticate", `Basic realm="API docs", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	default:
+		// same response as a route that doesn't exist
+		return http.NotFound
+	}
+}
+
 func (app *App) addCacheHeaders(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Cache-Control", "public, max-age=300")
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"shopping/config"
+	"shopping/database"
+	"shopping/database/migrations"
+	"strconv"
+)
+
+// runMigrate runs `shopping migrate up|down|version|force`, the migrations
+// are built in the binary.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopping migrate up [N] | down N | --all down | version | force VERSION")
+		flags.PrintDefaults()
+	}
+	all := flags.Bool("all", false, "revert every migration with down")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	action := flags.Arg(0)
+	n := 0
+	if flags.NArg() > 1 {
+		n, err = strconv.Atoi(flags.Arg(1))
+		if err != nil || n < 0 {
+			fmt.Fprintf(os.Stderr, "migrate: '%s' must be a positive number\n", flags.Arg(1))
+			return 2
+		}
+	}
+
+	// reverting everything must be explicit
+	if action == "down" && n == 0 && !*all {
+		fmt.Fprintln(os.Stderr, "migrate: down needs the number of migrations or --all")
+		return 2
+	}
+
+	loaded, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %s\n", err)
+		return 1
+	}
+
+	config := config.SetupConfig(configFlags)
+	// the migrations can take longer than the queries of the API
+	config.DBStatementTimeout = 0
+
+	dbpool, err := database.NewDB(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate: cannot connect to the database")
+		return 1
+	}
+	defer dbpool.Close()
+
+	migrator := &database.Migrator{Pool: dbpool, Migrations: loaded}
+	ctx := context.Background()
+
+	var done []database.Migration
+	switch action {
+	case "up":
+		done, err = migrator.Up(ctx, n)
+	case "down":
+		done, err = migrator.Down(ctx, n)
+	case "force":
+		if flags.NArg() < 2 {
+			fmt.Fprintln(os.Stderr, "migrate: force needs the version")
+			return 2
+		}
+		err = migrator.Force(ctx, uint(n))
+	case "version":
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	for _, migration := range done {
+		fmt.Printf("%s %06d_%s\n", action, migration.Version, migration.Name)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %s\n", err)
+		return 1
+	}
+
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %s\n", err)
+		return 1
+	}
+
+	if dirty {
+		fmt.Printf("version %d (dirty)\n", version)
+		return 1
+	}
+
+	fmt.Printf("version %d\n", version)
+	return 0
+}
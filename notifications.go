@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	db_queries "shopping/database/queries"
+	"shopping/notify"
+	"shopping/outbox"
+	"shopping/render"
+	"shopping/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// time between the checks of the digests to send
+const digestCheckInterval = time.Minute
+
+type NotificationPreferences struct {
+	Email        string `json:"email"`
+	EmailEnabled bool   `json:"email_enabled"`
+	PushToken    string `json:"push_token"`
+	PushEnabled  bool   `json:"push_enabled"`
+	Digest       string `json:"digest"` // off, hourly, daily
+}
+
+type PatchNotificationPreferencesRequest struct {
+	Email        *string `json:"email"`
+	EmailEnabled *bool   `json:"email_enabled"`
+	PushToken    *string `json:"push_token"`
+	PushEnabled  *bool   `json:"push_enabled"`
+	Digest       *string `json:"digest"`
+}
+
+// setupNotifications creates the notifications service with the senders
+// configured, the channels without config are disabled
+func (app *App) setupNotifications(notifications repository.NotificationRepository) {
+	config := app.Config
+
+	opts := notify.Options{}
+	if config.SMTPAddr != "" {
+		opts.Email = &notify.SMTP{
+			Addr:     config.SMTPAddr,
+			From:     config.SMTPFrom,
+			Username: config.SMTPUsername,
+			PasswordFunc: func() string {
+				return app.secret("SMTP_PASSWORD", app.Config.SMTPPassword)
+			},
+		}
+	}
+	if config.FCMProjectID != "" {
+		opts.Push = &notify.FCM{
+			ProjectID: config.FCMProjectID,
+			AccessToken: func() string {
+				return app.secret("FCM_ACCESS_TOKEN", app.Config.FCMAccessToken)
+			},
+			Client: &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+	if opts.Email == nil && opts.Push == nil {
+		log.Warn().Msg("> notifications: neither SMTP_ADDR nor FCM_PROJECT_ID are set, nothing will be sent")
+	}
+
+	app.NotificationRepository = notifications
+	app.Notifications = notify.NewService(notifications, opts)
+}
+
+// notificationsPublisher notifies the owner of a list of the items added by
+// the other users, the owners aren't notified of their own changes
+func (app *App) notificationsPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, event outbox.Event) error {
+		if event.Type != eventListItemAdded {
+			return nil
+		}
+
+		var data ListEvent
+		err := json.Unmarshal(event.Payload, &data)
+		if err != nil {
+			// the payload can't change in a retry
+			log.Err(err).Msgf("notifications: invalid payload of the event %d", event.ID)
+			return nil
+		}
+
+		if data.List == nil || data.List.Owner == nil || *data.List.Owner == data.Actor {
+			return nil
+		}
+
+		return app.Notifications.Notify(ctx, *data.List.Owner, event.ID, notify.Message{
+			Title: fmt.Sprintf("New items in %s", data.List.Name),
+			Body:  fmt.Sprintf("%s added items to your list %s", data.Actor, data.List.Name),
+		})
+	})
+}
+
+func (app *App) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	if app.NotificationRepository == nil {
+		http.Error(w, "the notifications are disabled, set NOTIFICATIONS=true", http.StatusNotFound)
+		return
+	}
+
+	prefs, err := app.NotificationRepository.GetPreferences(currentUser(r).Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationPreferences(w, prefs)
+}
+
+func (app *App) handlePatchNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	if app.NotificationRepository == nil {
+		http.Error(w, "the notifications are disabled, set NOTIFICATIONS=true", http.StatusNotFound)
+		return
+	}
+
+	user := currentUser(r)
+
+	var data PatchNotificationPreferencesRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := app.NotificationRepository.GetPreferences(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params := db_queries.SaveNotificationPreferencesParams{
+		Username:     user.Username,
+		Email:        prefs.Email,
+		PushToken:    prefs.PushToken,
+		EmailEnabled: prefs.EmailEnabled,
+		PushEnabled:  prefs.PushEnabled,
+		Digest:       prefs.Digest,
+	}
+
+	if data.Email != nil {
+		params.Email = *data.Email
+		if params.Email != "" {
+			address, err := mail.ParseAddress(params.Email)
+			if err != nil || address.Name != "" {
+				http.Error(w, fmt.Sprintf("'email' must be an address like user@example.com, got '%s'", params.Email), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+	}
+	if data.PushToken != nil {
+		params.PushToken = *data.PushToken
+	}
+	if data.EmailEnabled != nil {
+		params.EmailEnabled = *data.EmailEnabled
+	}
+	if data.PushEnabled != nil {
+		params.PushEnabled = *data.PushEnabled
+	}
+	if data.Digest != nil {
+		switch *data.Digest {
+		case notify.DigestOff, notify.DigestHourly, notify.DigestDaily:
+			params.Digest = *data.Digest
+		default:
+			http.Error(w, fmt.Sprintf("'digest' must be off, hourly or daily, got '%s'", *data.Digest), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	if params.EmailEnabled && params.Email == "" {
+		http.Error(w, "'email' is required to enable the emails", http.StatusUnprocessableEntity)
+		return
+	}
+	if params.PushEnabled && params.PushToken == "" {
+		http.Error(w, "'push_token' is required to enable the push notifications", http.StatusUnprocessableEntity)
+		return
+	}
+
+	saved, err := app.NotificationRepository.SavePreferences(params)
+	if err != nil {
+		repositoryError(w, err, "user not found")
+		return
+	}
+
+	writeNotificationPreferences(w, saved)
+}
+
+func writeNotificationPreferences(w http.ResponseWriter, prefs *db_queries.NotificationPreference) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	render.JSON(w, http.StatusOK, NotificationPreferences{
+		Email:        prefs.Email,
+		EmailEnabled: prefs.EmailEnabled,
+		PushToken:    prefs.PushToken,
+		PushEnabled:  prefs.PushEnabled,
+		Digest:       prefs.Digest,
+	})
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const fcmURL = "https://fcm.googleapis.com"
+
+// FCM sends push notifications with the HTTP v1 API of Firebase Cloud
+// Messaging
+type FCM struct {
+	ProjectID string
+	// AccessToken returns an OAuth 2 token of a service account with the
+	// firebase.messaging scope, it's called on every delivery because the
+	// tokens expire after an hour
+	AccessToken func() string
+	// URL of the API, the one of Google when it's empty
+	URL    string
+	Client *http.Client
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string          `json:"token"`
+	Notification fcmNotification `json:"notification"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (f *FCM) Send(ctx context.Context, to Recipient, msg Message) error {
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        to.PushToken,
+		Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+	}})
+	if err != nil {
+		return err
+	}
+
+	base := f.URL
+	if base == "" {
+		base = fcmURL
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", base, url.PathEscape(f.ProjectID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.AccessToken())
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return nil
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusNotFound:
+		// the token is malformed or the app was uninstalled
+		return fmt.Errorf("%w: fcm answered %d", ErrInvalidRecipient, res.StatusCode)
+	default:
+		return fmt.Errorf("fcm answered %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,232 @@
+// Package notify sends the notifications of the users by email and push, right
+// away or batched in a digest depending on their preferences.
+package notify
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Stats are published in /debug/vars as notifications: the messages sent by
+// each channel, the notifications queued for a digest, the digests sent and
+// the messages that failed
+var Stats = expvar.NewMap("notifications")
+
+// the digest preferences of the users
+const (
+	DigestOff    = "off"
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// ErrInvalidRecipient is returned by the senders when the address or the
+// device can't receive messages, sending again doesn't help
+var ErrInvalidRecipient = errors.New("notify: invalid recipient")
+
+type Message struct {
+	Title string
+	Body  string
+}
+
+type Recipient struct {
+	Username  string
+	Email     string
+	PushToken string
+}
+
+// Sender delivers the messages of a channel
+type Sender interface {
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
+
+type Options struct {
+	// Email and Push are the senders of each channel, the channel is
+	// disabled when it's nil
+	Email Sender
+	Push  Sender
+}
+
+type Service struct {
+	repo  repository.NotificationRepository
+	email Sender
+	push  Sender
+	now   func() time.Time
+}
+
+func NewService(repo repository.NotificationRepository, opts Options) *Service {
+	return &Service{
+		repo:  repo,
+		email: opts.Email,
+		push:  opts.Push,
+		now:   time.Now,
+	}
+}
+
+// Notify sends the message to the user or queues it for the digest. The
+// event id drops the notifications queued again when an event is redelivered,
+// the ones sent right away can be sent twice.
+func (s *Service) Notify(ctx context.Context, username string, eventID int64, msg Message) error {
+	prefs, err := s.repo.GetPreferences(username)
+	if err != nil {
+		return err
+	}
+
+	if !s.enabled(prefs) {
+		return nil
+	}
+
+	if prefs.Digest != DigestOff {
+		err = s.repo.Queue(db_queries.QueueNotificationParams{
+			Username: username,
+			EventID:  eventID,
+			Title:    msg.Title,
+			Body:     msg.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		Stats.Add("queued", 1)
+		return nil
+	}
+
+	return s.send(ctx, prefs, msg)
+}
+
+// Send sends the message to the user right away, even when the user has a
+// digest, for the messages that are expected at a given time
+func (s *Service) Send(ctx context.Context, username string, msg Message) error {
+	prefs, err := s.repo.GetPreferences(username)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, prefs, msg)
+}
+
+// SendDigests sends the digests of the users that have notifications queued
+// for longer than their interval
+func (s *Service) SendDigests(ctx context.Context) error {
+	now := s.now()
+	usernames, err := s.repo.DueDigests(now.Add(-time.Hour), now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	for _, username := range usernames {
+		err := s.sendDigest(ctx, username)
+		if err != nil {
+			// the notifications stay queued for the next round
+			log.Warn().Err(err).Msgf("notify: error to send the digest of the user: %s", username)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) sendDigest(ctx context.Context, username string) error {
+	pending, err := s.repo.Pending(username)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	prefs, err := s.repo.GetPreferences(username)
+	if err != nil {
+		return err
+	}
+
+	err = s.send(ctx, prefs, Digest(pending))
+	if err != nil {
+		return err
+	}
+
+	Stats.Add("digests", 1)
+	return s.repo.DeletePending(username, pending[len(pending)-1].ID)
+}
+
+// Digest is the message that summarizes the notifications
+func Digest(pending []db_queries.PendingNotification) Message {
+	if len(pending) == 1 {
+		return Message{Title: pending[0].Title, Body: pending[0].Body}
+	}
+
+	lines := make([]string, 0, len(pending))
+	for _, notification := range pending {
+		lines = append(lines, fmt.Sprintf("- %s: %s", notification.Title, notification.Body))
+	}
+
+	return Message{
+		Title: fmt.Sprintf("%d updates of your shopping lists", len(pending)),
+		Body:  strings.Join(lines, "\n"),
+	}
+}
+
+// Run sends the due digests on every interval until the context is done
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.SendDigests(ctx)
+			if err != nil {
+				log.Warn().Err(err).Msg("notify: error to send the digests")
+			}
+		}
+	}
+}
+
+func (s *Service) enabled(prefs *db_queries.NotificationPreference) bool {
+	return s.emailEnabled(prefs) || s.pushEnabled(prefs)
+}
+
+func (s *Service) emailEnabled(prefs *db_queries.NotificationPreference) bool {
+	return s.email != nil && prefs.EmailEnabled && prefs.Email != ""
+}
+
+func (s *Service) pushEnabled(prefs *db_queries.NotificationPreference) bool {
+	return s.push != nil && prefs.PushEnabled && prefs.PushToken != ""
+}
+
+// send delivers the message by every channel the user enabled, the invalid
+// recipients are logged and skipped so they aren't retried forever
+func (s *Service) send(ctx context.Context, prefs *db_queries.NotificationPreference, msg Message) error {
+	to := Recipient{Username: prefs.Username, Email: prefs.Email, PushToken: prefs.PushToken}
+
+	var errs []error
+	if s.emailEnabled(prefs) {
+		errs = append(errs, s.sendBy(ctx, "email", s.email, to, msg))
+	}
+	if s.pushEnabled(prefs) {
+		errs = append(errs, s.sendBy(ctx, "push", s.push, to, msg))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) sendBy(ctx context.Context, channel string, sender Sender, to Recipient, msg Message) error {
+	err := sender.Send(ctx, to, msg)
+	if errors.Is(err, ErrInvalidRecipient) {
+		Stats.Add("failed", 1)
+		log.Warn().Err(err).Msgf("notify: the %s of the user %s can't receive notifications", channel, to.Username)
+		return nil
+	}
+	if err != nil {
+		Stats.Add("failed", 1)
+		return fmt.Errorf("notify: error to send the %s: %w", channel, err)
+	}
+
+	Stats.Add(channel, 1)
+	return nil
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type senderFunc func(ctx context.Context, to Recipient, msg Message) error
+
+func (f senderFunc) Send(ctx context.Context, to Recipient, msg Message) error {
+	return f(ctx, to, msg)
+}
+
+func TestNotify(t *testing.T) {
+	repo := repository.NewMockNotificationRepository(gomock.NewController(t))
+
+	var emails, pushes []Recipient
+	service := NewService(repo, Options{
+		Email: senderFunc(func(ctx context.Context, to Recipient, msg Message) error {
+			emails = append(emails, to)
+			return nil
+		}),
+		Push: senderFunc(func(ctx context.Context, to Recipient, msg Message) error {
+			pushes = append(pushes, to)
+			return ErrInvalidRecipient
+		}),
+	})
+	msg := Message{Title: "New items in Groceries", Body: "admin added items"}
+
+	// the channels are sent right away without digest, the invalid
+	// recipients aren't retried
+	repo.EXPECT().GetPreferences("user").Return(&db_queries.NotificationPreference{
+		Username: "user", Email: "user@example.com", EmailEnabled: true, PushToken: "device", PushEnabled: true, Digest: DigestOff,
+	}, nil)
+	assert.NoError(t, service.Notify(context.Background(), "user", 1, msg))
+	assert.Len(t, emails, 1)
+	assert.Len(t, pushes, 1)
+
+	// the digests queue the message
+	repo.EXPECT().GetPreferences("user").Return(&db_queries.NotificationPreference{
+		Username: "user", Email: "user@example.com", EmailEnabled: true, Digest: DigestHourly,
+	}, nil)
+	repo.EXPECT().Queue(db_queries.QueueNotificationParams{Username: "user", EventID: 2, Title: msg.Title, Body: msg.Body}).Return(nil)
+	assert.NoError(t, service.Notify(context.Background(), "user", 2, msg))
+
+	// nothing is sent or queued without an enabled channel
+	repo.EXPECT().GetPreferences("other").Return(repository.DefaultNotificationPreferences("other"), nil)
+	assert.NoError(t, service.Notify(context.Background(), "other", 3, msg))
+	assert.Len(t, emails, 1)
+}
+
+func TestSendDigests(t *testing.T) {
+	repo := repository.NewMockNotificationRepository(gomock.NewController(t))
+
+	var sent []Message
+	service := NewService(repo, Options{
+		Email: senderFunc(func(ctx context.Context, to Recipient, msg Message) error {
+			sent = append(sent, msg)
+			return nil
+		}),
+	})
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	repo.EXPECT().DueDigests(now.Add(-time.Hour), now.Add(-24*time.Hour)).Return([]string{"user"}, nil)
+	repo.EXPECT().Pending("user").Return([]db_queries.PendingNotification{
+		{ID: 4, Title: "New items in Groceries", Body: "admin added milk"},
+		{ID: 7, Title: "New items in Party", Body: "admin added cake"},
+	}, nil)
+	repo.EXPECT().GetPreferences("user").Return(&db_queries.NotificationPreference{
+		Username: "user", Email: "user@example.com", EmailEnabled: true, Digest: DigestDaily,
+	}, nil)
+	repo.EXPECT().DeletePending("user", int64(7)).Return(nil)
+
+	assert.NoError(t, service.SendDigests(context.Background()))
+	assert.Equal(t, []Message{{
+		Title: "2 updates of your shopping lists",
+		Body:  "- New items in Groceries: admin added milk\n- New items in Party: admin added cake",
+	}}, sent)
+}
+
+func TestFCM(t *testing.T) {
+	var got fcmRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/shopping-app/messages:send", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		_ = json.NewDecoder(r.Body).Decode(&got)
+
+		if got.Message.Token == "uninstalled" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fcm := &FCM{ProjectID: "shopping-app", AccessToken: func() string { return "token" }, URL: server.URL}
+
+	err := fcm.Send(context.Background(), Recipient{PushToken: "device"}, Message{Title: "title", Body: "body"})
+	assert.NoError(t, err)
+	assert.Equal(t, fcmMessage{Token: "device", Notification: fcmNotification{Title: "title", Body: "body"}}, got.Message)
+
+	err = fcm.Send(context.Background(), Recipient{PushToken: "uninstalled"}, Message{})
+	assert.ErrorIs(t, err, ErrInvalidRecipient)
+}
+
+func TestEmailMessage(t *testing.T) {
+	data := string(emailMessage("shopping@example.com", "user@example.com", Message{
+		Title: "New items in Bcc: x@example.com\r\nGroceries",
+		Body:  "admin added milk",
+	}))
+
+	assert.Contains(t, data, "Subject: New items in Bcc: x@example.com  Groceries\r\n")
+	assert.NotContains(t, data, "\r\nBcc:")
+	assert.Contains(t, data, "\r\n\r\nadmin added milk\r\n")
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTP sends the messages by email. The connection is upgraded with STARTTLS
+// when the server supports it, the credentials are only sent over TLS.
+type SMTP struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+	// PasswordFunc is called on every delivery instead of using Password
+	// when it's set, for the secrets that are refreshed
+	PasswordFunc func() string
+}
+
+func (s *SMTP) Send(ctx context.Context, to Recipient, msg Message) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid address '%s': %w", s.Addr, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Username != "" {
+		password := s.Password
+		if s.PasswordFunc != nil {
+			password = s.PasswordFunc()
+		}
+
+		err = client.Auth(smtp.PlainAuth("", s.Username, password, host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(s.From)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to.Email)
+	if err != nil {
+		// 5xx answers are permanent, like an unknown mailbox
+		if tpErr, ok := err.(*textproto.Error); ok && tpErr.Code >= 500 {
+			return fmt.Errorf("%w: %s", ErrInvalidRecipient, err)
+		}
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(emailMessage(s.From, to.Email, msg))
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// emailMessage builds a plain text email, the title is a single line so the
+// names of the lists can't add headers
+func emailMessage(from string, to string, msg Message) []byte {
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Title)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Fixtures are the responses recorded by the handler tests, run
+// `go test . -run TestRecordedFixtures -update-fixtures` to refresh them.
+//
+//go:embed fixtures/*.json
+var Fixtures embed.FS
+
+type Fixture struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"` // swagger path without the base path, e.g. /lists/{id}
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+func LoadFixtures(fsys fs.FS) ([]Fixture, error) {
+	files, err := fs.Glob(fsys, "fixtures/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]Fixture, 0, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fixture Fixture
+		err = json.Unmarshal(data, &fixture)
+		if err != nil {
+			return nil, fmt.Errorf("openapi: invalid fixture '%s': %w", path.Base(file), err)
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// WithExamples sets the recorded fixtures as the response examples of the
+// swagger document, fixtures for operations that are not documented yet are
+// ignored.
+func WithExamples(doc []byte, fixtures []Fixture) ([]byte, error) {
+	var spec map[string]any
+	err := json.Unmarshal(doc, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: invalid document: %w", err)
+	}
+
+	paths, _ := spec["paths"].(map[string]any)
+
+	for _, fixture := range fixtures {
+		operations, ok := paths[fixture.Path].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		operation, ok := operations[strings.ToLower(fixture.Method)].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		responses, ok := operation["responses"].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		response, ok := responses[strconv.Itoa(fixture.Status)].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		response["examples"] = map[string]any{
+			"application/json": fixture.Response,
+		}
+	}
+
+	return json.Marshal(spec)
+}
@@ -0,0 +1,31 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithExamples(t *testing.T) {
+	doc := []byte(`{"paths": {"/lists": {"get": {"responses": {"200": {"description": "ok"}}}}}}`)
+
+	fixtures, err := LoadFixtures(Fixtures)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, fixtures)
+
+	withExamples, err := WithExamples(doc, fixtures)
+	assert.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Examples map[string]json.RawMessage `json:"examples"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(withExamples, &spec))
+
+	example := spec.Paths["/lists"]["get"].Responses["200"].Examples["application/json"]
+	assert.Contains(t, string(example), "Grocery List")
+}
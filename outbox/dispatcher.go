@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"shopping/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event is an outbox event ready to be published, ID is the same in every
+// attempt so the consumers can drop the duplicates.
+type Event struct {
+	ID          int64
+	Type        string
+	AggregateID string
+	Payload     []byte
+	CreatedAt   time.Time
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type PublisherFunc func(ctx context.Context, event Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type Options struct {
+	Interval  time.Duration // time between polls when there is nothing to send
+	BatchSize int32
+	// Lease is how long the claimed events are reserved, it must be longer
+	// than the time needed to publish a batch
+	Lease time.Duration
+	// Timeout of each Publish call
+	Timeout time.Duration
+}
+
+// Dispatcher polls the outbox and sends the pending events to every
+// publisher, an event is marked as delivered only when all of them succeed
+// and it's retried with a backoff otherwise. The delivery is at least once:
+// a crash after publishing and before marking the event publishes it again.
+type Dispatcher struct {
+	repo       repository.OutboxRepository
+	publishers []Publisher
+	opts       Options
+}
+
+func NewDispatcher(repo repository.OutboxRepository, opts Options, publishers ...Publisher) *Dispatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = time.Duration(opts.BatchSize) * opts.Timeout
+	}
+
+	return &Dispatcher{
+		repo:       repo,
+		publishers: publishers,
+		opts:       opts,
+	}
+}
+
+// Run polls the outbox until the context is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means there may be more events waiting
+		for {
+			sent, err := d.DispatchOnce(ctx)
+			if err != nil || sent < int(d.opts.BatchSize) || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce publishes one batch and returns how many events were claimed
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.repo.ClaimPending(d.opts.BatchSize, d.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range events {
+		event := Event{
+			ID:          row.ID,
+			Type:        row.EventType,
+			AggregateID: row.AggregateID,
+			Payload:     row.Payload,
+			CreatedAt:   row.CreatedAt.Time,
+		}
+
+		err := d.publish(ctx, event)
+		if err != nil {
+			retryAt := time.Now().Add(Backoff(row.Attempts + 1))
+			log.Warn().Err(err).Msgf("outbox: error to publish the event %d (%s), retrying at %s", event.ID, event.Type, retryAt.Format(time.RFC3339))
+
+			// when this fails the event is claimed again after the lease
+			_ = d.repo.MarkFailed(event.ID, err.Error(), retryAt)
+			continue
+		}
+
+		_ = d.repo.MarkDelivered(event.ID)
+	}
+
+	return len(events), nil
+}
+
+func (d *Dispatcher) publish(ctx context.Context, event Event) error {
+	errs := []error{}
+	for _, publisher := range d.publishers {
+		publishCtx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+		err := publisher.Publish(publishCtx, event)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Backoff returns the wait before the given attempt, it doubles from 5
+// seconds up to an hour.
+func Backoff(attempt int32) time.Duration {
+	wait := 5 * time.Second
+	for i := int32(1); i < attempt && wait < time.Hour; i++ {
+		wait *= 2
+	}
+
+	return min(wait, time.Hour)
+}
@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDispatchOnce(t *testing.T) {
+	repo := repository.NewMockOutboxRepository(gomock.NewController(t))
+	repo.EXPECT().ClaimPending(int32(10), time.Minute).Return([]db_queries.OutboxEvent{
+		{ID: 1, EventType: "list.updated", AggregateID: "list-1", Payload: []byte(`{}`)},
+		{ID: 2, EventType: "list.deleted", AggregateID: "list-2", Payload: []byte(`{}`), Attempts: 2},
+	}, nil)
+	repo.EXPECT().MarkDelivered(int64(1)).Return(nil)
+	repo.EXPECT().MarkFailed(int64(2), "webhook down", gomock.Any()).DoAndReturn(func(id int64, cause string, retryAt time.Time) error {
+		assert.WithinDuration(t, time.Now().Add(20*time.Second), retryAt, time.Second)
+		return nil
+	})
+
+	published := []string{}
+	ok := PublisherFunc(func(ctx context.Context, event Event) error {
+		published = append(published, event.AggregateID)
+		return nil
+	})
+	failing := PublisherFunc(func(ctx context.Context, event Event) error {
+		if event.Type == "list.deleted" {
+			return errors.New("webhook down")
+		}
+		return nil
+	})
+
+	dispatcher := NewDispatcher(repo, Options{BatchSize: 10, Lease: time.Minute}, ok, failing)
+	sent, err := dispatcher.DispatchOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	// the publishers that succeeded get the event again in the retry
+	assert.Equal(t, []string{"list-1", "list-2"}, published)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, Backoff(1))
+	assert.Equal(t, 10*time.Second, Backoff(2))
+	assert.Equal(t, 40*time.Second, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(50))
+}
+
+func TestWebhook(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	event := Event{ID: 7, Type: "list.created", AggregateID: "list-1", Payload: []byte(`{"type":"list.created"}`)}
+
+	webhook := &Webhook{URL: server.URL, Secret: []byte("secret")}
+	assert.NoError(t, webhook.Publish(context.Background(), event))
+	assert.Equal(t, `{"type":"list.created"}`, string(body))
+	assert.Equal(t, "list.created", headers.Get("X-Shopping-Event"))
+	assert.Equal(t, "7", headers.Get("X-Shopping-Delivery"))
+	assert.Equal(t, "sha256="+Sign([]byte("secret"), body), headers.Get("X-Shopping-Signature"))
+
+	failing := &Webhook{URL: server.URL + "/fail"}
+	assert.Error(t, failing.Publish(context.Background(), event))
+}
@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Webhook posts the payload of the events to a URL. When there is a secret
+// the body is signed with HMAC-SHA256 in the X-Shopping-Signature header as
+// "sha256=<hex>", the receivers must compare it with their own signature.
+type Webhook struct {
+	URL    string
+	Secret []byte
+	// SecretFunc is called on every delivery instead of using Secret when
+	// it's set, for the secrets that are refreshed
+	SecretFunc func() []byte
+	Client     *http.Client
+}
+
+func (wh *Webhook) Publish(ctx context.Context, event Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Shopping-Event", event.Type)
+	req.Header.Set("X-Shopping-Delivery", strconv.FormatInt(event.ID, 10))
+	secret := wh.Secret
+	if wh.SecretFunc != nil {
+		secret = wh.SecretFunc()
+	}
+	if len(secret) > 0 {
+		req.Header.Set("X-Shopping-Signature", "sha256="+Sign(secret, event.Payload))
+	}
+
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %d", wh.URL, res.StatusCode)
+	}
+
+	return nil
+}
+
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
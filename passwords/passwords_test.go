@@ -0,0 +1,31 @@
+package passwords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	assert.NoError(t, err)
+
+	ok, err := Verify(hash, "correct horse battery staple")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify(hash, "Tr0ub4dor&3")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash has its own salt")
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, hash := range []string{"", "password", "bcrypt$10$salt$key", "pbkdf2-sha256$x$c2FsdA$a2V5", "pbkdf2-sha256$1000$c2FsdA$"} {
+		_, err := Verify(hash, "password")
+		assert.ErrorIs(t, err, ErrInvalidHash, hash)
+	}
+}
@@ -0,0 +1,124 @@
+package passwords
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength caps the passwords, the longer ones are typos or attacks on the
+// hashing time
+const MaxLength = 256
+
+//go:embed common.txt
+var commonPasswords string
+
+// Violation is a rule of the policy the password breaks, the Rule is stable
+// for the clients and the Message is for the users
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+const (
+	RuleTooShort = "too_short"
+	RuleTooLong  = "too_long"
+	RuleCommon   = "common"
+	RulePersonal = "personal"
+	RuleWeak     = "weak"
+)
+
+// PolicyError lists every rule the password breaks
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return "the password " + strings.Join(messages, ", ")
+}
+
+// Policy has the rules of the new passwords, the existing hashes aren't
+// checked again
+type Policy struct {
+	MinLength int
+	// MinScore is the strength estimated by Score the passwords need, from 0
+	// to 4, 0 disables it
+	MinScore  int
+	blocklist map[string]bool
+}
+
+// NewPolicy refuses the common passwords of the built-in list and the ones
+// of blocklist
+func NewPolicy(minLength int, minScore int, blocklist []string) *Policy {
+	words, _ := ReadBlocklist(strings.NewReader(commonPasswords))
+
+	policy := &Policy{MinLength: minLength, MinScore: minScore, blocklist: map[string]bool{}}
+	for _, word := range append(words, blocklist...) {
+		policy.blocklist[strings.ToLower(word)] = true
+	}
+
+	return policy
+}
+
+// ReadBlocklist reads a password per line, the empty lines and the ones
+// starting with # are skipped
+func ReadBlocklist(r io.Reader) ([]string, error) {
+	words := []string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	return words, scanner.Err()
+}
+
+// Check returns a *PolicyError when the password breaks a rule, the
+// userInputs are the username and the other data of the user the password
+// must not be built from
+func (p *Policy) Check(password string, userInputs ...string) error {
+	violations := []Violation{}
+	length := utf8.RuneCountInString(password)
+	lower := strings.ToLower(password)
+
+	if length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleTooShort, Message: fmt.Sprintf("must have at least %d characters", p.MinLength)})
+	}
+	if length > MaxLength {
+		violations = append(violations, Violation{Rule: RuleTooLong, Message: fmt.Sprintf("must have at most %d characters", MaxLength)})
+	}
+	if p.blocklist[lower] || p.blocklist[unleet(lower)] {
+		violations = append(violations, Violation{Rule: RuleCommon, Message: "is one of the most common passwords"})
+	}
+	for _, input := range userInputs {
+		if len(input) >= 3 && strings.Contains(lower, strings.ToLower(input)) {
+			violations = append(violations, Violation{Rule: RulePersonal, Message: "can't contain the username"})
+			break
+		}
+	}
+
+	if p.MinScore > 0 && len(violations) == 0 {
+		score := p.Score(password, userInputs...)
+		if score < p.MinScore {
+			violations = append(violations, Violation{Rule: RuleWeak, Message: fmt.Sprintf("is too easy to guess, its strength is %d of 4 and %d is required: add more words or characters", score, p.MinScore)})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := NewPolicy(12, 0, []string{"acme-shopping-2026"})
+
+	for _, tc := range []struct {
+		password string
+		rules    []string
+	}{
+		{password: "correct horse battery staple"},
+		{password: "short", rules: []string{RuleTooShort}},
+		{password: strings.Repeat("a", MaxLength+1), rules: []string{RuleTooLong}},
+		{password: "password", rules: []string{RuleTooShort, RuleCommon}},
+		{password: "Qwertyuiop", rules: []string{RuleTooShort, RuleCommon}},
+		{password: "P@ssw0rd1", rules: []string{RuleTooShort, RuleCommon}},
+		{password: "ACME-shopping-2026", rules: []string{RuleCommon}},
+		{password: "alice-loves-carrots", rules: []string{RulePersonal}},
+	} {
+		t.Run(tc.password, func(t *testing.T) {
+			err := policy.Check(tc.password, "alice")
+			if tc.rules == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PolicyError
+			assert.True(t, errors.As(err, &policyErr))
+			rules := []string{}
+			for _, violation := range policyErr.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			assert.Equal(t, tc.rules, rules)
+		})
+	}
+}
+
+func TestPolicyMinScore(t *testing.T) {
+	policy := NewPolicy(8, 3, nil)
+
+	assert.NoError(t, policy.Check("correct horse battery staple"))
+	assert.NoError(t, policy.Check("kV9#m2Lq!x"))
+
+	err := policy.Check("password2024")
+	assert.ErrorContains(t, err, "too easy to guess")
+	assert.Equal(t, RuleWeak, err.(*PolicyError).Violations[0].Rule)
+}
+
+func TestScore(t *testing.T) {
+	policy := NewPolicy(0, 0, nil)
+
+	for _, tc := range []struct {
+		password string
+		max      int
+		min      int
+	}{
+		{password: "aaaaaaaaaaaa", max: 0},
+		{password: "abcdefghijkl", max: 1},
+		{password: "qwertyuiop123", max: 1},
+		{password: "password1234", max: 1},
+		{password: "summer2024", max: 1},
+		{password: "zxcvbnm0987654321", max: 2},
+		{password: "correct horse battery staple", min: 4},
+		{password: "kV9#m2Lq!x", min: 4},
+	} {
+		t.Run(tc.password, func(t *testing.T) {
+			score := policy.Score(tc.password)
+			assert.GreaterOrEqual(t, score, tc.min)
+			if tc.min == 0 {
+				assert.LessOrEqual(t, score, tc.max)
+			}
+		})
+	}
+
+	// the user inputs are a single guess
+	assert.Less(t, policy.Score("alicealice", "alice"), policy.Score("alicealice"))
+}
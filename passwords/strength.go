@@ -0,0 +1,173 @@
+package passwords
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// the rows of the keyboard, the walks along them are as easy as sequences
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "azertyuiop", "qsdfghjklm", "wxcvbn"}
+
+var leet = strings.NewReplacer("@", "a", "4", "a", "0", "o", "3", "e", "$", "s", "5", "s", "7", "t", "!", "i")
+
+func unleet(s string) string {
+	return leet.Replace(s)
+}
+
+// Score estimates how hard the password is to guess from 0 (trivial) to 4
+// (strong), in the spirit of zxcvbn: the common passwords, the user inputs,
+// the repeats, the sequences, the keyboard walks and the years count as a
+// few guesses instead of one per character
+func (p *Policy) Score(password string, userInputs ...string) int {
+	bits := p.entropy(password, userInputs)
+
+	// the thresholds of zxcvbn, 10^3, 10^6, 10^8 and 10^10 guesses
+	switch {
+	case bits < 10:
+		return 0
+	case bits < 20:
+		return 1
+	case bits < 26.6:
+		return 2
+	case bits < 33.2:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// entropy is the log2 of the guesses needed to find the password, walking
+// it from the left with the cheapest pattern at each position
+func (p *Policy) entropy(password string, userInputs []string) float64 {
+	chars := []rune(strings.ToLower(password))
+	plain := []rune(unleet(strings.ToLower(password)))
+	perChar := math.Log2(float64(charsetSize(password)))
+	wordBits := math.Log2(float64(len(p.blocklist) + 1))
+
+	inputs := map[string]bool{}
+	for _, input := range userInputs {
+		if len(input) >= 3 {
+			inputs[strings.ToLower(input)] = true
+		}
+	}
+
+	bits := 0.0
+	for i := 0; i < len(chars); {
+		if n := p.wordAt(plain, i, inputs); n > 0 {
+			bits += wordBits
+			i += n
+			continue
+		}
+
+		if isYear(chars[i:]) {
+			bits += math.Log2(200)
+			i += 4
+			continue
+		}
+
+		if n := runAt(chars, i); n >= 3 {
+			bits += perChar + math.Log2(float64(n))
+			i += n
+			continue
+		}
+
+		bits += perChar
+		i++
+	}
+
+	return bits
+}
+
+// wordAt returns the length of the longest common password or user input of
+// 4 characters or more starting at i, 0 when there's none
+func (p *Policy) wordAt(chars []rune, i int, inputs map[string]bool) int {
+	for n := min(len(chars)-i, 32); n >= 4; n-- {
+		word := string(chars[i : i+n])
+		if p.blocklist[word] || inputs[word] {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// runAt returns the length of the repeat, sequence or keyboard walk starting
+// at i, like aaa, abc, 987 or qwer
+func runAt(chars []rune, i int) int {
+	if i+1 >= len(chars) {
+		return 1
+	}
+
+	n := 1
+	delta := chars[i+1] - chars[i]
+	for delta >= -1 && delta <= 1 && i+n < len(chars) && chars[i+n]-chars[i+n-1] == delta {
+		n++
+	}
+
+	for _, row := range keyboardRows {
+		step := strings.IndexRune(row, chars[i+1]) - strings.IndexRune(row, chars[i])
+		if !strings.ContainsRune(row, chars[i]) || !strings.ContainsRune(row, chars[i+1]) || step != 1 && step != -1 {
+			continue
+		}
+
+		walk := 2
+		for ; i+walk < len(chars); walk++ {
+			next := strings.IndexRune(row, chars[i+walk])
+			if next < 0 || next-strings.IndexRune(row, chars[i+walk-1]) != step {
+				break
+			}
+		}
+		n = max(n, walk)
+	}
+
+	return n
+}
+
+// isYear tells if the characters start with a year of 1900 to 2099
+func isYear(chars []rune) bool {
+	if len(chars) < 4 {
+		return false
+	}
+
+	for _, c := range chars[:4] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	prefix := string(chars[:2])
+	return prefix == "19" || prefix == "20"
+}
+
+// charsetSize is the size of the alphabet a brute force of the password
+// would try
+func charsetSize(password string) int {
+	var lower, upper, digit, symbol, other bool
+	for _, c := range password {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		case c < unicode.MaxASCII && unicode.IsPrint(c):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			size += class.size
+		}
+	}
+
+	return max(size, 2)
+}
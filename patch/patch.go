@@ -0,0 +1,370 @@
+// Package patch applies the JSON Merge Patch (RFC 7386) and the JSON Patch
+// (RFC 6902) documents to a JSON document.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupported is returned for the media types that aren't patches
+	ErrUnsupported = errors.New("patch: unsupported media type")
+	// ErrInvalid is returned for the malformed patches
+	ErrInvalid = errors.New("patch: invalid patch")
+	// ErrPath is returned when a path of the patch isn't in the document
+	ErrPath = errors.New("patch: the path doesn't exist")
+	// ErrTestFailed is returned when a test operation doesn't match, the
+	// document isn't the one the patch was made for
+	ErrTestFailed = errors.New("patch: test failed")
+)
+
+// Patch changes a JSON document, the document given isn't modified
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Parse reads a patch of the media type, application/merge-patch+json or
+// application/json-patch+json
+func Parse(mediaType string, data []byte) (Patch, error) {
+	switch mediaType {
+	case MediaTypeMergePatch:
+		var value any
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+		}
+
+		return MergePatch{value: value}, nil
+	case MediaTypeJSONPatch:
+		var operations JSONPatch
+		err := json.Unmarshal(data, &operations)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+		}
+
+		for i, operation := range operations {
+			err = operation.validate()
+			if err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalid, i, err)
+			}
+		}
+
+		return operations, nil
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// MergePatch replaces the members of the document with the ones of the
+// patch, the null members are removed and the other values than objects
+// replace the whole value
+type MergePatch struct {
+	value any
+}
+
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	var target any
+	err := json.Unmarshal(doc, &target)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(target, p.value))
+}
+
+func merge(target any, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+
+	return object
+}
+
+// JSONPatch is a list of operations applied in order, the document is only
+// changed when they all succeed
+type JSONPatch []Operation
+
+type Operation struct {
+	// add, remove, replace, move, copy or test
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// the path moved or copied
+	From string `json:"from"`
+	// the value of add, replace and test, null is a value
+	Value json.RawMessage `json:"value"`
+}
+
+func (o Operation) validate() error {
+	_, err := parsePointer(o.Path)
+	if err != nil {
+		return err
+	}
+
+	switch o.Op {
+	case "remove":
+		if o.Path == "" {
+			return errors.New("the document can't be removed")
+		}
+	case "add", "replace", "test":
+		if len(o.Value) == 0 {
+			return fmt.Errorf("%s needs a value", o.Op)
+		}
+	case "move", "copy":
+		_, err = parsePointer(o.From)
+		if err != nil {
+			return err
+		}
+		if o.Op == "move" && strings.HasPrefix(o.Path, o.From+"/") {
+			return errors.New("a value can't be moved into itself")
+		}
+	default:
+		return fmt.Errorf("unknown op '%s'", o.Op)
+	}
+
+	return nil
+}
+
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+	var value any
+	err := json.Unmarshal(doc, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range p {
+		value, err = operation.apply(value)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(value)
+}
+
+func (o Operation) apply(doc any) (any, error) {
+	path, _ := parsePointer(o.Path)
+
+	var value any
+	if len(o.Value) > 0 {
+		err := json.Unmarshal(o.Value, &value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch o.Op {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		return update(doc, path, remove)
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		doc, err := update(doc, path, remove)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move", "copy":
+		from, _ := parsePointer(o.From)
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "copy" {
+			value, err = deepCopy(value)
+			if err != nil {
+				return nil, err
+			}
+		} else if o.From != o.Path {
+			doc, err = update(doc, from, remove)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return add(doc, path, value)
+	default:
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrTestFailed, o.Path)
+		}
+
+		return doc, nil
+	}
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			if token == "-" {
+				return append(c, value), nil
+			}
+
+			i, err := arrayIndex(token, len(c)+1)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(c, i, value), nil
+		default:
+			return nil, ErrPath
+		}
+	})
+}
+
+func remove(container any, token string) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		if _, ok := c[token]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPath, token)
+		}
+		delete(c, token)
+		return c, nil
+	case []any:
+		i, err := arrayIndex(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return slices.Delete(c, i, i+1), nil
+	default:
+		return nil, ErrPath
+	}
+}
+
+// update replaces the container of the last token of the path with the one
+// returned by fn, the arrays can grow or shrink
+func update(doc any, path []string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPath, path[0])
+		}
+
+		value, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = value
+		return c, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(c))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := update(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = value
+		return c, nil
+	default:
+		return nil, ErrPath
+	}
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPath, token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, ErrPath
+		}
+	}
+
+	return doc, nil
+}
+
+// arrayIndex reads an index of an array of size elements, without the
+// leading zeros
+func arrayIndex(token string, size int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= size || strconv.Itoa(i) != token {
+		return 0, fmt.Errorf("%w: index %s", ErrPath, token)
+	}
+
+	return i, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) in its unescaped tokens, ""
+// is the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("the path '%s' must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func deepCopy(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied any
+	err = json.Unmarshal(data, &copied)
+	return copied, err
+}
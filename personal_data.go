@@ -0,0 +1,277 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/export"
+	"shopping/render"
+	"shopping/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	personalDataFormatName = "shopping-personal-data"
+	personalDataVersion    = 1
+
+	// how often the dispatcher instances look for the accounts to erase
+	accountErasureInterval = time.Minute
+)
+
+// personalDataTables are the files of the export, in the order of the
+// ExportUserData query. Every file is in the archive, empty or not
+var personalDataTables = []string{
+	"users",
+	"sessions",
+	"user_preferences",
+	"notification_preferences",
+	"pending_notifications",
+	"shopping_lists",
+	"list_completions",
+	"purchase_history",
+	"reminders",
+	"stores",
+	"store_prices",
+	"item_photos",
+	"audit_events",
+	"access_log",
+	"account_deletions",
+}
+
+type AccountDeletionResponse struct {
+	Username    string    `json:"username"`
+	RequestedAt time.Time `json:"requested_at"`
+	EraseAt     time.Time `json:"erase_at"`
+}
+
+func accountDeletionResponse(row db_queries.AccountDeletion) AccountDeletionResponse {
+	return AccountDeletionResponse{
+		Username:    row.Username,
+		RequestedAt: row.RequestedAt.Time.UTC(),
+		EraseAt:     row.EraseAt.Time.UTC(),
+	}
+}
+
+// PersonalDataManifest is the account.json of the export, the built-in
+// users have no row in users.json
+type PersonalDataManifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	TenantID   string    `json:"tenant_id"`
+	Files      []string  `json:"files"`
+}
+
+// handleDeleteMe schedules the erasure of the account, the user keeps using
+// it during the grace period and can cancel it
+func (app *App) handleDeleteMe(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	row, err := app.AccountRepository.RequestDeletion(user.Username, time.Now().Add(app.Config.AccountDeletionGrace))
+	if err != nil {
+		repositoryError(w, err, "account not found")
+		return
+	}
+
+	log.Info().Msgf("> the account %s will be erased at %s", user.Username, row.EraseAt.Time.UTC().Format(time.RFC3339))
+	render.JSON(w, http.StatusAccepted, accountDeletionResponse(*row))
+}
+
+func (app *App) handleGetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	row, err := app.AccountRepository.GetDeletion(currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "the account has no deletion request")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, accountDeletionResponse(*row))
+}
+
+func (app *App) handleCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	err := app.AccountRepository.CancelDeletion(currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "the account has no deletion request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// eraseNextAccount erases the account that is due the longest, it returns
+// repository.ErrNotFound when there's none
+func (app *App) eraseNextAccount() (string, error) {
+	var username string
+	var erased *repository.ErasedAccount
+	err := app.UnitOfWork.Do(func(repos repository.Repositories) error {
+		deletion, err := repos.Accounts.ClaimDueDeletion()
+		if err != nil {
+			return err
+		}
+		username = deletion.Username
+
+		erased, err = repos.Accounts.Erase(username)
+		if err != nil {
+			return err
+		}
+
+		// the external search index and the webhooks learn that the lists
+		// are gone, without the name of the user
+		events := make([]db_queries.EnqueueOutboxEventsParams, 0, len(erased.ListIDs))
+		for _, id := range erased.ListIDs {
+			payload, err := listEventPayload(repository.AnonymousUser, eventListDeleted, id, nil)
+			if err != nil {
+				return err
+			}
+			events = append(events, db_queries.EnqueueOutboxEventsParams{EventType: eventListDeleted, AggregateID: id, Payload: payload})
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		return repos.Outbox.EnqueueAll(events)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range erased.ListIDs {
+		app.listChanged(id)
+	}
+	if app.Blobs != nil {
+		app.deleteBlobs(erased.BlobKeys...)
+	}
+	log.Info().Msgf("> the account %s was erased, %d lists and %d files deleted", username, len(erased.ListIDs), len(erased.BlobKeys))
+
+	return username, nil
+}
+
+// runAccountErasures erases the due accounts on every interval until the
+// context is done
+func (app *App) runAccountErasures(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				_, err := app.eraseNextAccount()
+				if errors.Is(err, repository.ErrNotFound) {
+					break
+				}
+				if err != nil {
+					log.Err(err).Msg("error to erase an account")
+					break
+				}
+			}
+		}
+	}
+}
+
+// handleExportMe returns a zip with everything stored about the user: a
+// JSON file per table, the files of the photos and account.json
+func (app *App) handleExportMe(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	rows, err := app.AccountRepository.Export(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tables := map[string][]json.RawMessage{}
+	for _, row := range rows {
+		tables[row.TableName] = append(tables[row.TableName], json.RawMessage(row.Data))
+	}
+
+	manifest := PersonalDataManifest{
+		Format:     personalDataFormatName,
+		Version:    personalDataVersion,
+		ExportedAt: time.Now().UTC(),
+		Username:   user.Username,
+		Role:       user.Role,
+		TenantID:   user.TenantID,
+	}
+	for _, table := range personalDataTables {
+		manifest.Files = append(manifest.Files, table+".json")
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(user.Username+"-data", "zip")))
+	w.Header().Set("Cache-Control", "no-store")
+
+	// the status is sent with the first file, the errors after it can only
+	// cut the archive
+	archive := zip.NewWriter(w)
+	err = writeZipJSON(archive, "account.json", manifest)
+	for _, table := range personalDataTables {
+		if err == nil {
+			err = writeZipJSON(archive, table+".json", append([]json.RawMessage{}, tables[table]...))
+		}
+	}
+	if err == nil {
+		err = app.writePhotoFiles(r.Context(), archive, tables["item_photos"])
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		log.Err(err).Msgf("error to write the data export of %s", user.Username)
+	}
+}
+
+func writeZipJSON(archive *zip.Writer, name string, value any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writePhotoFiles adds the photos to the archive under photos/, the blobs
+// that can't be read are skipped
+func (app *App) writePhotoFiles(ctx context.Context, archive *zip.Writer, photos []json.RawMessage) error {
+	if app.Blobs == nil {
+		return nil
+	}
+
+	for _, data := range photos {
+		var photo struct {
+			BlobKey string `json:"blob_key"`
+		}
+		if json.Unmarshal(data, &photo) != nil || photo.BlobKey == "" {
+			continue
+		}
+
+		reader, err := app.Blobs.Get(ctx, photo.BlobKey)
+		if err != nil {
+			log.Warn().Err(err).Msgf("the photo %s isn't in the data export", photo.BlobKey)
+			continue
+		}
+
+		file, err := archive.Create(photo.BlobKey)
+		if err == nil {
+			_, err = io.Copy(file, reader)
+		}
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
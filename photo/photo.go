@@ -0,0 +1,106 @@
+// Package photo checks the images uploaded by the users and makes their
+// thumbnails.
+package photo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"slices"
+
+	// the decoders of the accepted types
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// ThumbnailSize is the longest side of the thumbnails
+	ThumbnailSize = 256
+	// maxPixels stops the small files that decode to huge images
+	maxPixels = 50_000_000
+)
+
+var ErrUnsupported = errors.New("photo: the image must be a jpeg, png or gif")
+
+// ContentTypes are the accepted types, they are detected from the content
+// and not from the name or the headers of the upload
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Check returns the type of the image, it fails when it isn't one of the
+// ContentTypes or it's too big to decode. Only the header is read, a broken
+// image fails in Thumbnail
+func Check(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(ContentTypes, contentType) {
+		return "", ErrUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return "", fmt.Errorf("%w: the image is %dx%d", ErrUnsupported, config.Width, config.Height)
+	}
+
+	return contentType, nil
+}
+
+// Thumbnail returns a jpeg of the image that fits in a square of size, the
+// smaller images keep their size
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	var out bytes.Buffer
+	err = jpeg.Encode(&out, resize(src, width, height), &jpeg.Options{Quality: 80})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// resize averages the source pixels under each destination pixel, a box
+// filter that is good enough to shrink photos
+func resize(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := range width {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,59 @@
+package photo
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pngImage(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, png.Encode(&out, img))
+
+	return out.Bytes()
+}
+
+func TestCheck(t *testing.T) {
+	contentType, err := Check(pngImage(t, 10, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	_, err = Check([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	// only the header is checked, the thumbnail decodes the whole image
+	truncated := pngImage(t, 10, 10)[:40]
+	_, err = Check(truncated)
+	assert.NoError(t, err)
+	_, err = Thumbnail(truncated, ThumbnailSize)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestThumbnail(t *testing.T) {
+	thumbnail, err := Thumbnail(pngImage(t, 600, 300), ThumbnailSize)
+	assert.NoError(t, err)
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 256, config.Width)
+	assert.Equal(t, 128, config.Height)
+
+	// the small images aren't enlarged
+	thumbnail, err = Thumbnail(pngImage(t, 40, 60), ThumbnailSize)
+	assert.NoError(t, err)
+	config, _, _ = image.DecodeConfig(bytes.NewReader(thumbnail))
+	assert.Equal(t, 40, config.Width)
+	assert.Equal(t, 60, config.Height)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"shopping/blob"
+	db_queries "shopping/database/queries"
+	"shopping/photo"
+	"shopping/render"
+	"shopping/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	photoVariantOriginal  = "original"
+	photoVariantThumbnail = "thumbnail"
+	// the photos share the signer of the share links, the prefix keeps the
+	// tokens of the two apart
+	photoTokenPrefix = "photo:"
+)
+
+type ItemPhotoResponse struct {
+	Item         string    `json:"item"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	UploadedBy   string    `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// photoResponse signs the download URLs of the photo and its thumbnail
+func (app *App) photoResponse(r *http.Request, row db_queries.ItemPhoto) ItemPhotoResponse {
+	expiresAt := time.Now().Add(app.Config.PhotoURLTTL).Truncate(time.Second)
+	url := func(variant string) string {
+		return app.publicURL(r) + "/v1/photos/" + app.ShareLinks.Sign(photoTokenPrefix+row.ID.String()+":"+variant, expiresAt)
+	}
+
+	return ItemPhotoResponse{
+		Item:         row.Item,
+		ContentType:  row.ContentType,
+		SizeBytes:    row.SizeBytes,
+		URL:          url(photoVariantOriginal),
+		ThumbnailURL: url(photoVariantThumbnail),
+		ExpiresAt:    expiresAt.UTC(),
+		UploadedBy:   row.UploadedBy,
+		CreatedAt:    row.CreatedAt.Time.UTC(),
+	}
+}
+
+// photoItem returns the list and the name of the item of the request, the
+// itemId is the position of the item in the list from 1, like in the csv
+// export. It writes the error response itself and returns false when the
+// photos are disabled or the item doesn't exist
+func (app *App) photoItem(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	if app.Blobs == nil {
+		http.Error(w, "the photos are disabled, set PHOTO_MAX_BYTES", http.StatusNotFound)
+		return "", "", false
+	}
+
+	id := r.PathValue("id")
+	cached, err := app.cachedList(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return "", "", false
+	}
+
+	position, err := strconv.Atoi(r.PathValue("itemId"))
+	if err != nil || position < 1 || position > len(cached.List.Items) {
+		http.Error(w, "item not found, the item id is its position in the list from 1", http.StatusNotFound)
+		return "", "", false
+	}
+
+	return id, cached.List.Items[position-1], true
+}
+
+// readPhoto reads the `photo` part of the multipart upload, it writes the
+// error response itself and returns false when the upload isn't valid
+func readPhoto(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, bool) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "the photo must be uploaded as multipart/form-data in the 'photo' field", http.StatusBadRequest)
+		return nil, false
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "the 'photo' field is missing", http.StatusBadRequest)
+			return nil, false
+		}
+		if err != nil {
+			http.Error(w, "invalid multipart data", http.StatusBadRequest)
+			return nil, false
+		}
+		if part.FormName() != "photo" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			http.Error(w, "invalid multipart data", http.StatusBadRequest)
+			return nil, false
+		}
+		if int64(len(data)) > maxBytes {
+			http.Error(w, fmt.Sprintf("the photo can't be larger than %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+
+		return data, true
+	}
+}
+
+// handleUploadItemPhoto stores the photo of the item and its thumbnail, it
+// replaces the previous photo of the item
+func (app *App) handleUploadItemPhoto(w http.ResponseWriter, r *http.Request) {
+	listID, item, ok := app.photoItem(w, r)
+	if !ok {
+		return
+	}
+
+	data, ok := readPhoto(w, r, app.Config.PhotoMaxBytes)
+	if !ok {
+		return
+	}
+
+	contentType, err := photo.Check(data)
+	if err != nil {
+		http.Error(w, "the photo must be a jpeg, png or gif image", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	thumbnail, err := photo.Thumbnail(data, photo.ThumbnailSize)
+	if err != nil {
+		http.Error(w, "the photo must be a jpeg, png or gif image", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// new keys on every upload, the URLs of the previous photo stop working
+	prefix := "photos/" + listID + "/" + uuid.NewString()
+	blobKey := prefix + "." + strings.TrimPrefix(contentType, "image/")
+	thumbnailKey := prefix + "-thumbnail.jpeg"
+
+	err = app.Blobs.Put(r.Context(), blobKey, contentType, data)
+	if err == nil {
+		err = app.Blobs.Put(r.Context(), thumbnailKey, "image/jpeg", thumbnail)
+	}
+	if err != nil {
+		log.Err(err).Msgf("photos: error to store the photo of an item of the list %s", listID)
+		app.deleteBlobs(blobKey, thumbnailKey)
+		http.Error(w, "the photo can't be stored", http.StatusInternalServerError)
+		return
+	}
+
+	previous, err := app.PhotoRepository.GetPhoto(listID, item)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		app.deleteBlobs(blobKey, thumbnailKey)
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	saved, err := app.PhotoRepository.SavePhoto(repository.NewItemPhoto{
+		ListID:       listID,
+		Item:         item,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		BlobKey:      blobKey,
+		ThumbnailKey: thumbnailKey,
+		UploadedBy:   currentUser(r).Username,
+	})
+	if err != nil {
+		app.deleteBlobs(blobKey, thumbnailKey)
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	if previous != nil {
+		app.deleteBlobs(previous.BlobKey, previous.ThumbnailKey)
+	}
+
+	render.JSON(w, http.StatusCreated, app.photoResponse(r, *saved))
+}
+
+func (app *App) handleGetItemPhoto(w http.ResponseWriter, r *http.Request) {
+	listID, item, ok := app.photoItem(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := app.PhotoRepository.GetPhoto(listID, item)
+	if err != nil {
+		repositoryError(w, err, "the item has no photo")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, app.photoResponse(r, *row))
+}
+
+func (app *App) handleDeleteItemPhoto(w http.ResponseWriter, r *http.Request) {
+	listID, item, ok := app.photoItem(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := app.PhotoRepository.DeletePhoto(listID, item)
+	if err != nil {
+		repositoryError(w, err, "the item has no photo")
+		return
+	}
+
+	app.deleteBlobs(row.BlobKey, row.ThumbnailKey)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDownloadPhoto serves a photo without authentication, the signed
+// token is the only credential like the share links
+func (app *App) handleDownloadPhoto(w http.ResponseWriter, r *http.Request) {
+	if app.Blobs == nil {
+		http.Error(w, "the photos are disabled", http.StatusNotFound)
+		return
+	}
+
+	subject, expiresAt, ok := app.verifySharedToken(w, r)
+	if !ok {
+		return
+	}
+
+	id, variant, _ := strings.Cut(strings.TrimPrefix(subject, photoTokenPrefix), ":")
+	if !strings.HasPrefix(subject, photoTokenPrefix) || (variant != photoVariantOriginal && variant != photoVariantThumbnail) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	row, err := app.PhotoRepository.GetPhotoByID(id)
+	if err != nil {
+		// the photo was replaced or deleted
+		repositoryError(w, err, "link not found")
+		return
+	}
+
+	app.sendPhoto(w, r, *row, variant, fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds())))
+}
+
+// handleDownloadItemPhoto serves the photo of an item to its users, the
+// route can be signed to hand it out like the /v1/photos URLs
+func (app *App) handleDownloadItemPhoto(w http.ResponseWriter, r *http.Request) {
+	listID, item, ok := app.photoItem(w, r)
+	if !ok {
+		return
+	}
+
+	variant := r.PathValue("variant")
+	if variant != photoVariantOriginal && variant != photoVariantThumbnail {
+		http.Error(w, "the variant must be original or thumbnail", http.StatusNotFound)
+		return
+	}
+
+	row, err := app.PhotoRepository.GetPhoto(listID, item)
+	if err != nil {
+		repositoryError(w, err, "the item has no photo")
+		return
+	}
+
+	app.sendPhoto(w, r, *row, variant, "private, no-cache")
+}
+
+// sendPhoto streams the file of a variant of the photo
+func (app *App) sendPhoto(w http.ResponseWriter, r *http.Request, row db_queries.ItemPhoto, variant string, cacheControl string) {
+	key, contentType := row.BlobKey, row.ContentType
+	if variant == photoVariantThumbnail {
+		key, contentType = row.ThumbnailKey, "image/jpeg"
+	}
+
+	reader, err := app.Blobs.Get(r.Context(), key)
+	if err != nil {
+		log.Err(err).Msgf("photos: error to read the blob %s", key)
+		http.Error(w, "the photo can't be read", http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		log.Warn().Err(err).Msgf("photos: error to send the blob %s", key)
+	}
+}
+
+// deleteBlobs deletes the files of a photo, the errors are only logged and
+// leave orphan files in the store
+func (app *App) deleteBlobs(keys ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, key := range keys {
+		if err := app.Blobs.Delete(ctx, key); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			log.Err(err).Msgf("photos: error to delete the blob %s", key)
+		}
+	}
+}
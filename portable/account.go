@@ -0,0 +1,118 @@
+package portable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	AccountFormatName = "shopping-account"
+	AccountVersion    = 1
+
+	MaxAccountLists = 10000
+)
+
+var ErrInvalidSignature = errors.New("portable: the account bundle signature is invalid")
+
+// Account is the bundle used to move a user to another deployment, it has
+// the lists and the preferences of the user. The share links are signed by
+// the source deployment and the history belongs to it, so they are not moved.
+type Account struct {
+	Format      string       `json:"format"`
+	Version     int          `json:"version"`
+	ExportedAt  time.Time    `json:"exported_at"`
+	Source      string       `json:"source"` // public URL of the source deployment
+	Username    string       `json:"username"`
+	Preferences *Preferences `json:"preferences,omitempty"`
+	Lists       []List       `json:"lists"`
+}
+
+type Preferences struct {
+	Timezone       string `json:"timezone"`
+	Locale         string `json:"locale"`
+	FirstDayOfWeek string `json:"first_day_of_week"`
+}
+
+// SignedAccount keeps the account as it was signed, the signature is checked
+// against these bytes and not against a new encoding of the account.
+type SignedAccount struct {
+	Account   json.RawMessage `json:"account"`
+	Signature string          `json:"signature"`
+}
+
+func (a *Account) Validate() error {
+	if a.Format != AccountFormatName {
+		return fmt.Errorf("portable: 'format' must be '%s'", AccountFormatName)
+	}
+
+	if a.Version < 1 || a.Version > AccountVersion {
+		return fmt.Errorf("%w %d, the supported versions are 1 to %d", ErrUnsupportedVersion, a.Version, AccountVersion)
+	}
+
+	if strings.TrimSpace(a.Username) == "" {
+		return errors.New("portable: 'username' is required")
+	}
+
+	if len(a.Lists) > MaxAccountLists {
+		return fmt.Errorf("portable: an account can have at most %d lists", MaxAccountLists)
+	}
+
+	for i := range a.Lists {
+		// the lists are validated with the rules of the list documents
+		doc := Document{Format: FormatName, Version: Version, List: a.Lists[i]}
+		err := doc.Validate()
+		if err != nil {
+			return fmt.Errorf("'lists[%d]': %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// SignAccount signs the account with HMAC-SHA256, the source and the
+// destination deployments must share the secret.
+func SignAccount(secret []byte, account *Account) (*SignedAccount, error) {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedAccount{
+		Account:   data,
+		Signature: base64.RawURLEncoding.EncodeToString(accountMAC(secret, data)),
+	}, nil
+}
+
+// OpenAccount checks the signature and returns the validated account
+func OpenAccount(secret []byte, signed *SignedAccount) (*Account, error) {
+	sig, err := base64.RawURLEncoding.DecodeString(signed.Signature)
+	if err != nil || !hmac.Equal(sig, accountMAC(secret, signed.Account)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var account Account
+	err = json.Unmarshal(signed.Account, &account)
+	if err != nil {
+		return nil, fmt.Errorf("portable: invalid account: %w", err)
+	}
+
+	err = account.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func accountMAC(secret []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(data)
+
+	return h.Sum(nil)
+}
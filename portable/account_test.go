@@ -0,0 +1,45 @@
+package portable
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAccount(t *testing.T) {
+	account := &Account{
+		Format:     AccountFormatName,
+		Version:    AccountVersion,
+		ExportedAt: time.Date(2025, time.January, 1, 10, 0, 0, 0, time.UTC),
+		Source:     "https://old.example.com",
+		Username:   "user",
+		Lists: []List{
+			{Name: "Groceries", Items: []Item{{Name: "milk"}}, Tags: []string{}},
+		},
+	}
+
+	signed, err := SignAccount([]byte("secret"), account)
+	assert.NoError(t, err)
+
+	opened, err := OpenAccount([]byte("secret"), signed)
+	assert.NoError(t, err)
+	assert.Equal(t, account, opened)
+
+	_, err = OpenAccount([]byte("other secret"), signed)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	tampered := *signed
+	tampered.Account = []byte(`{"format":"shopping-account","version":1,"username":"admin","lists":[]}`)
+	_, err = OpenAccount([]byte("secret"), &tampered)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+}
+
+func TestAccountValidate(t *testing.T) {
+	account := &Account{Format: AccountFormatName, Version: AccountVersion, Username: "user", Lists: []List{{Name: ""}}}
+	assert.ErrorContains(t, account.Validate(), "'lists[0]'")
+
+	account = &Account{Format: AccountFormatName, Version: 2, Username: "user"}
+	assert.True(t, errors.Is(account.Validate(), ErrUnsupportedVersion))
+}
@@ -0,0 +1,97 @@
+package portable
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	FormatName = "shopping-list"
+	// Version must be increased for changes that older deployments can't
+	// read, new optional fields don't need a new version.
+	Version = 1
+
+	MaxItems = 1000
+	MaxTags  = 50
+)
+
+// Schema is the JSON schema of the current version of the format
+//
+//go:embed schema.v1.json
+var Schema []byte
+
+type Document struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	List       List      `json:"list"`
+	History    *History  `json:"history,omitempty"`
+}
+
+type List struct {
+	Name     string   `json:"name"`
+	Items    []Item   `json:"items"`
+	Tags     []string `json:"tags"`
+	Metadata Metadata `json:"metadata"`
+}
+
+type Item struct {
+	Name string `json:"name"`
+}
+
+type Metadata struct {
+	SourceID  string    `json:"source_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// History is informative only, completions belong to the users of the
+// source deployment so they are not imported.
+type History struct {
+	TimesCompleted  int64      `json:"times_completed"`
+	LastCompletedAt *time.Time `json:"last_completed_at,omitempty"`
+}
+
+var ErrUnsupportedVersion = errors.New("portable: unsupported version")
+
+func (d *Document) Validate() error {
+	if d.Format != FormatName {
+		return fmt.Errorf("portable: 'format' must be '%s'", FormatName)
+	}
+
+	if d.Version < 1 || d.Version > Version {
+		return fmt.Errorf("%w %d, the supported versions are 1 to %d", ErrUnsupportedVersion, d.Version, Version)
+	}
+
+	if strings.TrimSpace(d.List.Name) == "" || len(d.List.Name) > 255 {
+		return errors.New("portable: 'list.name' is required and must have at most 255 characters")
+	}
+
+	if len(d.List.Items) > MaxItems {
+		return fmt.Errorf("portable: a list can have at most %d items", MaxItems)
+	}
+
+	for i, item := range d.List.Items {
+		if strings.TrimSpace(item.Name) == "" {
+			return fmt.Errorf("portable: 'list.items[%d].name' is required", i)
+		}
+	}
+
+	if len(d.List.Tags) > MaxTags {
+		return fmt.Errorf("portable: a list can have at most %d tags", MaxTags)
+	}
+
+	return nil
+}
+
+func (l *List) ItemNames() []string {
+	names := make([]string, 0, len(l.Items))
+	for _, item := range l.Items {
+		names = append(names, item.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/render"
+	"strings"
+	"time"
+	// the docker image doesn't have the zoneinfo files
+	_ "time/tzdata"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/language"
+)
+
+type UserPreferences struct {
+	Timezone       string `json:"timezone"`
+	Locale         string `json:"locale"`
+	FirstDayOfWeek string `json:"first_day_of_week"` // sunday, monday, ...
+}
+
+type PatchUserPreferencesRequest struct {
+	Timezone       *string `json:"timezone"`
+	Locale         *string `json:"locale"`
+	FirstDayOfWeek *string `json:"first_day_of_week"`
+}
+
+func (app *App) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	prefs, err := app.UserPreferencesRepository.GetUserPreferences(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writePreferences(w, prefs)
+}
+
+func (app *App) handlePatchPreferences(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	var data PatchUserPreferencesRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := app.UserPreferencesRepository.GetUserPreferences(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params := db_queries.SaveUserPreferencesParams{
+		Username:       user.Username,
+		Timezone:       prefs.Timezone,
+		Locale:         prefs.Locale,
+		FirstDayOfWeek: prefs.FirstDayOfWeek,
+	}
+
+	if data.Timezone != nil {
+		loc, err := parseTimezone(*data.Timezone)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		params.Timezone = loc.String()
+	}
+
+	if data.Locale != nil {
+		tag, err := language.Parse(*data.Locale)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("'locale' must be a BCP 47 language tag like en-US, got '%s'", *data.Locale), http.StatusUnprocessableEntity)
+			return
+		}
+
+		params.Locale = tag.String()
+	}
+
+	if data.FirstDayOfWeek != nil {
+		day, ok := parseWeekday(*data.FirstDayOfWeek)
+		if !ok {
+			http.Error(w, "'first_day_of_week' must be a day name like monday", http.StatusUnprocessableEntity)
+			return
+		}
+
+		params.FirstDayOfWeek = int16(day)
+	}
+
+	saved, err := app.UserPreferencesRepository.SaveUserPreferences(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the stats are grouped with the previous timezone and week start
+	app.invalidateStats(user.Username)
+
+	writePreferences(w, saved)
+}
+
+func writePreferences(w http.ResponseWriter, prefs *db_queries.UserPreference) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	render.JSON(w, http.StatusOK, UserPreferences{
+		Timezone:       prefs.Timezone,
+		Locale:         prefs.Locale,
+		FirstDayOfWeek: strings.ToLower(time.Weekday(prefs.FirstDayOfWeek).String()),
+	})
+}
+
+// userCalendar returns the timezone and the first day of week of the user,
+// every date boundary (months, weeks, days) must be computed with them
+// instead of the server timezone.
+func (app *App) userCalendar(username string) (*time.Location, time.Weekday, error) {
+	prefs, err := app.UserPreferencesRepository.GetUserPreferences(username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		// the timezone was valid when saved, it can only fail if it was
+		// removed from the tz database
+		log.Warn().Msgf("unknown timezone '%s' for the user %s, using UTC", prefs.Timezone, username)
+		loc = time.UTC
+	}
+
+	return loc, time.Weekday(prefs.FirstDayOfWeek), nil
+}
+
+func parseTimezone(name string) (*time.Location, error) {
+	// LoadLocation returns UTC for "" and the server timezone for "Local"
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("'timezone' must be an IANA timezone like America/Lima, got '%s'", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("'timezone' must be an IANA timezone like America/Lima, got '%s'", name)
+	}
+
+	return loc, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// startOfWeek returns the midnight of the first day of the week of t in its
+// own location.
+func startOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	days := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+}
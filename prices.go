@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"shopping/recipe"
+	"shopping/render"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the comparisons are aggregations over every store of the user, they are
+// kept for a few minutes and dropped when the user changes a store or a price
+const (
+	priceComparisonsCacheSize = 1024
+	priceComparisonsCacheTTL  = 5 * time.Minute
+	maxPricesPerRequest       = 1000
+)
+
+type StorePriceResponse struct {
+	Item       string    `json:"item"`
+	PriceCents int64     `json:"price_cents"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type SetPricesRequest struct {
+	// Prices maps the items to their price in cents
+	Prices map[string]int64 `json:"prices"`
+}
+
+type StoreTotal struct {
+	StoreID     string `json:"store_id"`
+	Name        string `json:"name"`
+	TotalCents  int64  `json:"total_cents"`
+	PricedItems int    `json:"priced_items"`
+	// Complete tells if the store has a price for every item of the list
+	Complete bool `json:"complete"`
+}
+
+type ItemPrice struct {
+	Item       string `json:"item"`
+	PriceCents int64  `json:"price_cents"`
+}
+
+type StoreSplit struct {
+	StoreID    string      `json:"store_id"`
+	Name       string      `json:"name"`
+	TotalCents int64       `json:"total_cents"`
+	Items      []ItemPrice `json:"items"`
+}
+
+// PriceSplit buys each item in the store where it's the cheapest
+type PriceSplit struct {
+	TotalCents int64        `json:"total_cents"`
+	Stores     []StoreSplit `json:"stores"`
+	// Unpriced are the items without a price in any store
+	Unpriced []string `json:"unpriced"`
+}
+
+type PriceComparison struct {
+	Items  int          `json:"items"`
+	Stores []StoreTotal `json:"stores"`
+	Split  PriceSplit   `json:"split"`
+}
+
+// priceComparisonKey identifies a comparison by the items of the list, so a
+// change of the list is a new key
+func priceComparisonKey(username string, listID string, keys []string) string {
+	return fmt.Sprintf("%s:%s:%x", username, listID, sha256.Sum256([]byte(strings.Join(keys, "\n"))))
+}
+
+// invalidatePriceComparisons drops the comparisons of the user in this
+// instance, the other ones keep theirs until the TTL
+func (app *App) invalidatePriceComparisons(username string) {
+	if app.PriceComparisons == nil {
+		return
+	}
+
+	prefix := username + ":"
+	for _, key := range app.PriceComparisons.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			app.PriceComparisons.Remove(key)
+		}
+	}
+}
+
+// comparePrices totals the items in each store of the user and splits them
+// between the stores where they are the cheapest
+func (app *App) comparePrices(username string, items []string) (*PriceComparison, error) {
+	// the first name of each item in the list, by key
+	names := map[string]string{}
+	keys := []string{}
+	for _, item := range items {
+		key := recipe.Key(item)
+		if _, ok := names[key]; key == "" || ok {
+			continue
+		}
+		names[key] = item
+		keys = append(keys, key)
+	}
+
+	totals, err := app.StoreRepository.SumPrices(username, keys)
+	if err != nil {
+		return nil, err
+	}
+	cheapest, err := app.StoreRepository.CheapestPrices(username, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &PriceComparison{
+		Items:  len(keys),
+		Stores: make([]StoreTotal, 0, len(totals)),
+		Split:  PriceSplit{Stores: []StoreSplit{}, Unpriced: []string{}},
+	}
+	for _, row := range totals {
+		comparison.Stores = append(comparison.Stores, StoreTotal{
+			StoreID:     row.StoreID.String(),
+			Name:        row.StoreName,
+			TotalCents:  row.TotalCents,
+			PricedItems: int(row.PricedItems),
+			Complete:    int(row.PricedItems) == len(keys),
+		})
+	}
+
+	priced := map[string]bool{}
+	for _, row := range cheapest {
+		priced[row.Item] = true
+		comparison.Split.TotalCents += row.PriceCents
+
+		i := slices.IndexFunc(comparison.Split.Stores, func(split StoreSplit) bool {
+			return split.StoreID == row.StoreID.String()
+		})
+		if i < 0 {
+			i = len(comparison.Split.Stores)
+			comparison.Split.Stores = append(comparison.Split.Stores, StoreSplit{StoreID: row.StoreID.String(), Name: row.StoreName})
+		}
+		comparison.Split.Stores[i].TotalCents += row.PriceCents
+		comparison.Split.Stores[i].Items = append(comparison.Split.Stores[i].Items, ItemPrice{Item: names[row.Item], PriceCents: row.PriceCents})
+	}
+
+	for _, key := range keys {
+		if !priced[key] {
+			comparison.Split.Unpriced = append(comparison.Split.Unpriced, names[key])
+		}
+	}
+
+	return comparison, nil
+}
+
+func (app *App) handlePriceComparison(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	username := currentUser(r).Username
+
+	entry, err := app.cachedList(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	keys := []string{}
+	for _, item := range entry.List.Items {
+		keys = append(keys, recipe.Key(item))
+	}
+	key := priceComparisonKey(username, id, keys)
+
+	if app.PriceComparisons != nil {
+		if cached, ok := app.PriceComparisons.Get(key); ok {
+			app.writeStats(w, cached)
+			return
+		}
+	}
+
+	comparison, err := app.comparePrices(username, entry.List.Items)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if app.PriceComparisons != nil {
+		app.PriceComparisons.Add(key, comparison)
+	}
+	app.writeStats(w, comparison)
+}
+
+// readPrices reads the prices of a json body, or of a csv with the item and
+// its price like "milk,1.29" when the body is text/csv
+func readPrices(r *http.Request) (map[string]int64, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/csv" {
+		var data SetPricesRequest
+		err := json.NewDecoder(r.Body).Decode(&data)
+		if err != nil {
+			return nil, errors.New("invalid data")
+		}
+
+		return data.Prices, nil
+	}
+
+	prices := map[string]int64{}
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		// an optional header
+		if line == 1 && strings.EqualFold(record[0], "item") {
+			continue
+		}
+
+		cents, err := parsePrice(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		prices[record[0]] = cents
+	}
+
+	return prices, nil
+}
+
+// parsePrice reads a price like 1.29 in cents
+func parsePrice(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	units, decimals, _ := strings.Cut(value, ".")
+	if len(decimals) > 2 {
+		return 0, fmt.Errorf("the price '%s' has more than 2 decimals", value)
+	}
+
+	n, err := strconv.ParseInt(units+(decimals + "00")[:2], 10, 64)
+	if err != nil || n < 0 || units == "" {
+		return 0, fmt.Errorf("the price '%s' must be a positive number like 1.29", value)
+	}
+
+	return n, nil
+}
+
+func (app *App) handleSetPrices(w http.ResponseWriter, r *http.Request) {
+	storeID := r.PathValue("storeID")
+	username := currentUser(r).Username
+
+	prices, err := readPrices(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(prices) == 0 || len(prices) > maxPricesPerRequest {
+		http.Error(w, fmt.Sprintf("between 1 and %d prices can be set at once", maxPricesPerRequest), http.StatusUnprocessableEntity)
+		return
+	}
+
+	keyed := map[string]int64{}
+	for item, price := range prices {
+		key := recipe.Key(item)
+		if key == "" || price < 0 {
+			http.Error(w, fmt.Sprintf("the item '%s' must have a name and a price that isn't negative", item), http.StatusUnprocessableEntity)
+			return
+		}
+		keyed[key] = price
+	}
+
+	_, err = app.StoreRepository.GetStore(storeID, username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	err = app.StoreRepository.SetPrices(storeID, keyed)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	app.invalidatePriceComparisons(username)
+	app.writeStorePrices(w, storeID)
+}
+
+func (app *App) handleListPrices(w http.ResponseWriter, r *http.Request) {
+	storeID := r.PathValue("storeID")
+
+	_, err := app.StoreRepository.GetStore(storeID, currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	app.writeStorePrices(w, storeID)
+}
+
+func (app *App) handleDeletePrice(w http.ResponseWriter, r *http.Request) {
+	storeID := r.PathValue("storeID")
+	username := currentUser(r).Username
+
+	_, err := app.StoreRepository.GetStore(storeID, username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	err = app.StoreRepository.DeletePrice(storeID, recipe.Key(r.PathValue("item")))
+	if err != nil {
+		repositoryError(w, err, "price not found")
+		return
+	}
+
+	app.invalidatePriceComparisons(username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) writeStorePrices(w http.ResponseWriter, storeID string) {
+	rows, err := app.StoreRepository.ListPrices(storeID)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	prices := make([]StorePriceResponse, 0, len(rows))
+	for _, row := range rows {
+		prices = append(prices, StorePriceResponse{Item: row.Item, PriceCents: row.PriceCents, UpdatedAt: row.UpdatedAt.Time.UTC()})
+	}
+
+	render.JSON(w, http.StatusOK, prices)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/products"
+	"shopping/render"
+	"shopping/repository"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type AddItemByBarcodeRequest struct {
+	Barcode string `json:"barcode"`
+}
+
+type AddItemByBarcodeResponse struct {
+	Product products.Product     `json:"product"`
+	List    ShoppingListResponse `json:"list"`
+}
+
+// productsUserAgent identifies the server in the requests to the providers
+func productsUserAgent(publicURL string) string {
+	agent := "shopping/" + buildInfo().Version
+	if publicURL != "" {
+		agent += " (+" + publicURL + ")"
+	}
+
+	return agent
+}
+
+// lookupProduct writes the error response itself and returns false when the
+// product can't be found
+func (app *App) lookupProduct(w http.ResponseWriter, r *http.Request, barcode string) (*products.Product, bool) {
+	if app.Products == nil {
+		http.Error(w, "the product lookups are disabled, set PRODUCTS_PROVIDER", http.StatusNotFound)
+		return nil, false
+	}
+
+	barcode = strings.TrimSpace(barcode)
+	if !products.ValidBarcode(barcode) {
+		http.Error(w, "'barcode' must be an EAN-8, UPC-A, EAN-13 or GTIN-14 with its check digit", http.StatusBadRequest)
+		return nil, false
+	}
+
+	product, err := app.Products.Lookup(r.Context(), barcode)
+	if errors.Is(err, products.ErrNotFound) {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("products: error to look up the barcode %s", barcode)
+		http.Error(w, "the product provider is unavailable", http.StatusBadGateway)
+		return nil, false
+	}
+
+	return product, true
+}
+
+func (app *App) handleLookupProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := app.lookupProduct(w, r, r.URL.Query().Get("barcode"))
+	if !ok {
+		return
+	}
+
+	// the products rarely change, the apps can keep them
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	render.JSON(w, http.StatusOK, product)
+}
+
+// handleAddItemByBarcode adds the product of the barcode to the list, the
+// name of the item has the brand and the size of the product
+func (app *App) handleAddItemByBarcode(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var data AddItemByBarcodeRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	product, ok := app.lookupProduct(w, r, data.Barcode)
+	if !ok {
+		return
+	}
+
+	updated, err := app.writeList(currentUser(r).Username, eventListItemAdded, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		return repos.ShoppingLists.PushItemToShoppingList(id, product.ItemName())
+	})
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, AddItemByBarcodeResponse{Product: *product, List: newShoppingListResponse(*updated)})
+}
@@ -0,0 +1,85 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const openFoodFactsURL = "https://world.openfoodfacts.org"
+
+// OpenFoodFacts finds the products in the open database of
+// https://openfoodfacts.org, it mostly knows food and groceries
+type OpenFoodFacts struct {
+	// URL of the API, the world database when it's empty
+	URL       string
+	UserAgent string
+	Client    *http.Client
+}
+
+type openFoodFactsResponse struct {
+	Status  int `json:"status"` // 1 when the product was found
+	Product struct {
+		ProductName string `json:"product_name"`
+		Brands      string `json:"brands"` // comma separated
+		Quantity    string `json:"quantity"`
+	} `json:"product"`
+}
+
+func (o *OpenFoodFacts) Lookup(ctx context.Context, barcode string) (*Product, error) {
+	base := o.URL
+	if base == "" {
+		base = openFoodFactsURL
+	}
+	endpoint := fmt.Sprintf("%s/api/v2/product/%s?fields=product_name,brands,quantity", strings.TrimRight(base, "/"), url.PathEscape(barcode))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserAgent != "" {
+		req.Header.Set("User-Agent", o.UserAgent)
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// the unknown barcodes are answered with a 404 and a status 0
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openfoodfacts answered %d", res.StatusCode)
+	}
+
+	var data openFoodFactsResponse
+	err = json.NewDecoder(res.Body).Decode(&data)
+	if err != nil {
+		return nil, fmt.Errorf("openfoodfacts: invalid response: %w", err)
+	}
+
+	name := strings.TrimSpace(data.Product.ProductName)
+	if data.Status != 1 || name == "" {
+		return nil, ErrNotFound
+	}
+
+	brand, _, _ := strings.Cut(data.Product.Brands, ",")
+
+	return &Product{
+		Barcode: barcode,
+		Name:    name,
+		Brand:   strings.TrimSpace(brand),
+		Size:    strings.TrimSpace(data.Product.Quantity),
+	}, nil
+}
@@ -0,0 +1,176 @@
+// Package products finds the products of the barcodes scanned by the apps.
+package products
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	ProviderOpenFoodFacts = "openfoodfacts"
+	ProviderNone          = "none"
+)
+
+// Stats are published in /debug/vars as products: the lookups served by the
+// cache, the ones sent to the provider and the barcodes not found
+var Stats = expvar.NewMap("products")
+
+var (
+	ErrNotFound        = errors.New("products: product not found")
+	ErrInvalidBarcode  = errors.New("products: invalid barcode")
+	ErrUnknownProvider = errors.New("products: unknown provider")
+)
+
+type Product struct {
+	Barcode string `json:"barcode"`
+	Name    string `json:"name"`
+	Brand   string `json:"brand"`
+	Size    string `json:"size"` // e.g. 400 g, as written in the package
+}
+
+// ItemName is the name of the product in a list, like "Nutella (Ferrero,
+// 400 g)"
+func (p Product) ItemName() string {
+	details := []string{}
+	for _, detail := range []string{p.Brand, p.Size} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+
+	if len(details) == 0 {
+		return p.Name
+	}
+
+	return fmt.Sprintf("%s (%s)", p.Name, strings.Join(details, ", "))
+}
+
+// Provider finds the product of a barcode, ErrNotFound when it doesn't know
+// it
+type Provider interface {
+	Lookup(ctx context.Context, barcode string) (*Product, error)
+}
+
+type Options struct {
+	Provider         string
+	OpenFoodFactsURL string
+	// UserAgent identifies the app in the requests, OpenFoodFacts asks for
+	// it
+	UserAgent string
+	// CacheTTL is how long the products and the unknown barcodes are kept,
+	// no cache when it's 0
+	CacheTTL  time.Duration
+	CacheSize int
+}
+
+// New returns the provider with a cache in front, nil when the provider is
+// none
+func New(opts Options) (Provider, error) {
+	var provider Provider
+	switch opts.Provider {
+	case ProviderNone:
+		return nil, nil
+	case "", ProviderOpenFoodFacts:
+		provider = &OpenFoodFacts{
+			URL:       opts.OpenFoodFactsURL,
+			UserAgent: opts.UserAgent,
+			Client:    &http.Client{Timeout: 5 * time.Second},
+		}
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownProvider, opts.Provider)
+	}
+
+	if opts.CacheTTL <= 0 {
+		return provider, nil
+	}
+
+	return NewCached(provider, opts.CacheSize, opts.CacheTTL), nil
+}
+
+// ValidBarcode tells if the barcode is an EAN-8, UPC-A, EAN-13 or GTIN-14
+// with a valid check digit
+func ValidBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(barcode) - 2; i >= 0; i-- {
+		c := barcode[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		// the digits are weighted 3 and 1 from the right, the check digit
+		// excluded
+		weight := 1
+		if (len(barcode)-2-i)%2 == 0 {
+			weight = 3
+		}
+		sum += int(c-'0') * weight
+	}
+
+	check := barcode[len(barcode)-1]
+	return check >= '0' && check <= '9' && int(check-'0') == (10-sum%10)%10
+}
+
+// lookup is the result of a lookup kept in the cache, the unknown barcodes
+// are cached too so the scans of the same product don't hit the provider
+type lookup struct {
+	product *Product
+	found   bool
+}
+
+// Cached keeps the results of the provider in memory, the errors other than
+// ErrNotFound aren't cached
+type Cached struct {
+	provider Provider
+	cache    *expirable.LRU[string, lookup]
+}
+
+func NewCached(provider Provider, size int, ttl time.Duration) *Cached {
+	if size <= 0 {
+		size = 10000
+	}
+
+	return &Cached{
+		provider: provider,
+		cache:    expirable.NewLRU[string, lookup](size, nil, ttl),
+	}
+}
+
+func (c *Cached) Lookup(ctx context.Context, barcode string) (*Product, error) {
+	if cached, ok := c.cache.Get(barcode); ok {
+		Stats.Add("cache_hits", 1)
+		if !cached.found {
+			return nil, ErrNotFound
+		}
+
+		product := *cached.product
+		return &product, nil
+	}
+
+	Stats.Add("provider_lookups", 1)
+	product, err := c.provider.Lookup(ctx, barcode)
+	if errors.Is(err, ErrNotFound) {
+		Stats.Add("not_found", 1)
+		c.cache.Add(barcode, lookup{})
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.Add(barcode, lookup{product: product, found: true})
+	copied := *product
+	return &copied, nil
+}
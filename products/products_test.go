@@ -0,0 +1,68 @@
+package products
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidBarcode(t *testing.T) {
+	for _, barcode := range []string{"3017620422003", "96385074", "036000291452", "10012345678902"} {
+		assert.True(t, ValidBarcode(barcode), barcode)
+	}
+
+	for _, barcode := range []string{"", "3017620422004", "30176204220a3", "12345", "301762042200300"} {
+		assert.False(t, ValidBarcode(barcode), barcode)
+	}
+}
+
+func TestItemName(t *testing.T) {
+	assert.Equal(t, "Nutella (Ferrero, 400 g)", Product{Name: "Nutella", Brand: "Ferrero", Size: "400 g"}.ItemName())
+	assert.Equal(t, "Bananas (1 kg)", Product{Name: "Bananas", Size: "1 kg"}.ItemName())
+	assert.Equal(t, "Bread", Product{Name: "Bread"}.ItemName())
+}
+
+func TestOpenFoodFactsWithCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "shopping-test", r.UserAgent())
+
+		switch r.URL.Path {
+		case "/api/v2/product/3017620422003":
+			_, _ = w.Write([]byte(`{"status":1,"product":{"product_name":"Nutella","brands":"Ferrero, Nutella","quantity":"400 g"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":0,"status_verbose":"product not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New(Options{OpenFoodFactsURL: server.URL, UserAgent: "shopping-test", CacheTTL: time.Minute})
+	assert.NoError(t, err)
+
+	for range 2 {
+		product, err := provider.Lookup(context.Background(), "3017620422003")
+		assert.NoError(t, err)
+		assert.Equal(t, &Product{Barcode: "3017620422003", Name: "Nutella", Brand: "Ferrero", Size: "400 g"}, product)
+
+		_, err = provider.Lookup(context.Background(), "96385074")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+
+	// the second round is served by the cache, the unknown barcode too
+	assert.Equal(t, 2, requests)
+}
+
+func TestNew(t *testing.T) {
+	provider, err := New(Options{Provider: ProviderNone})
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	_, err = New(Options{Provider: "upcitemdb"})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
@@ -0,0 +1,69 @@
+package pubsub
+
+import "sync"
+
+// Broker notifies the subscribers of a key that something changed, it's
+// in memory so only the subscribers of the same instance are notified.
+//
+// The notifications don't carry data and they are coalesced: a subscriber
+// that is busy gets a single notification for all the changes it missed,
+// so it must read the current state again when notified.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: map[string]map[chan struct{}]struct{}{},
+	}
+}
+
+// Subscribe returns the channel that receives the notifications of the key
+// and the function that must be called to stop receiving them.
+func (b *Broker) Subscribe(key string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = map[chan struct{}]struct{}{}
+	}
+	b.subscribers[key][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[key], ch)
+			if len(b.subscribers[key]) == 0 {
+				delete(b.subscribers, key)
+			}
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish never blocks, slow subscribers already have a pending notification
+func (b *Broker) Publish(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribers returns how many subscribers the key has
+func (b *Broker) Subscribers(key string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers[key])
+}
@@ -0,0 +1,30 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerCoalescesNotifications(t *testing.T) {
+	broker := NewBroker()
+
+	ch, unsubscribe := broker.Subscribe("list-1")
+	other, unsubscribeOther := broker.Subscribe("list-2")
+	defer unsubscribeOther()
+
+	broker.Publish("list-1")
+	broker.Publish("list-1")
+
+	assert.Len(t, ch, 1)
+	assert.Len(t, other, 0)
+
+	<-ch
+	unsubscribe()
+	unsubscribe()
+
+	broker.Publish("list-1")
+	assert.Len(t, ch, 0)
+	assert.Equal(t, 0, broker.Subscribers("list-1"))
+	assert.Equal(t, 1, broker.Subscribers("list-2"))
+}
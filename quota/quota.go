@@ -0,0 +1,118 @@
+// Package quota has the limits of the users, the lists they keep, the items
+// of a list and the requests they send, and counts the requests of each
+// API key in windows of a minute.
+package quota
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Stats are published in /debug/vars as quotas: the requests rejected by the
+// rate limits and the writes rejected by the lists and items limits
+var Stats = expvar.NewMap("quotas")
+
+// Limits of a user, 0 is no limit
+type Limits struct {
+	MaxLists          int `json:"max_lists"`
+	MaxItems          int `json:"max_items"`
+	RequestsPerMinute int `json:"requests_per_minute"`
+}
+
+// Override replaces some limits, the nil ones are kept
+type Override struct {
+	MaxLists          *int `json:"max_lists"`
+	MaxItems          *int `json:"max_items"`
+	RequestsPerMinute *int `json:"requests_per_minute"`
+}
+
+// Apply returns the limits with the ones of the override
+func (l Limits) Apply(o Override) Limits {
+	if o.MaxLists != nil {
+		l.MaxLists = *o.MaxLists
+	}
+	if o.MaxItems != nil {
+		l.MaxItems = *o.MaxItems
+	}
+	if o.RequestsPerMinute != nil {
+		l.RequestsPerMinute = *o.RequestsPerMinute
+	}
+
+	return l
+}
+
+// Window is the time the requests are counted in
+const Window = time.Minute
+
+// Result is the state of the window of a key after a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the window ends and the count starts again
+	Reset time.Time
+}
+
+// Limiter counts the requests of each key in fixed windows, the counts are
+// kept in memory so each instance has its own
+type Limiter struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{windows: map[string]*window{}, now: time.Now}
+}
+
+// Allow counts a request of the key unless its window already has limit
+// requests, a limit of 0 allows every request
+func (l *Limiter) Allow(key string, limit int) Result {
+	if limit <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	w := l.windows[key]
+	if w == nil || now.Sub(w.start) >= Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	result := Result{Limit: limit, Reset: w.start.Add(Window)}
+	if w.count >= limit {
+		return result
+	}
+
+	w.count++
+	result.Allowed = true
+	result.Remaining = limit - w.count
+	return result
+}
+
+// prune drops the ended windows once per window, so the keys that stopped
+// sending requests don't stay in memory
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < Window {
+		return
+	}
+	l.lastPrune = now
+
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= Window {
+			delete(l.windows, key)
+		}
+	}
+}
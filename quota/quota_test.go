@@ -0,0 +1,45 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+
+	first := limiter.Allow("key", 2)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: now.Add(Window)}, first)
+	assert.True(t, limiter.Allow("key", 2).Allowed)
+
+	rejected := limiter.Allow("key", 2)
+	assert.False(t, rejected.Allowed)
+	assert.Equal(t, 0, rejected.Remaining)
+
+	// the keys have their own windows
+	assert.True(t, limiter.Allow("other", 2).Allowed)
+
+	// the count starts again with the next window
+	now = now.Add(Window)
+	assert.Equal(t, 1, limiter.Allow("key", 2).Remaining)
+
+	// the windows that ended are dropped
+	now = now.Add(2 * Window)
+	limiter.Allow("key", 2)
+	assert.Len(t, limiter.windows, 1)
+
+	assert.True(t, limiter.Allow("key", 0).Allowed)
+}
+
+func TestApply(t *testing.T) {
+	zero := 0
+	ten := 10
+	limits := Limits{MaxLists: 100, MaxItems: 1000, RequestsPerMinute: 600}
+
+	assert.Equal(t, Limits{MaxLists: 10, MaxItems: 1000, RequestsPerMinute: 0}, limits.Apply(Override{MaxLists: &ten, RequestsPerMinute: &zero}))
+	assert.Equal(t, limits, limits.Apply(Override{}))
+}
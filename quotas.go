@@ -0,0 +1,365 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/quota"
+	"shopping/render"
+	"shopping/repository"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// the quotas of the users and their tenants are read again after the TTL, so
+// a change of the admins reaches the other instances within a minute
+const (
+	quotaOverridesCacheSize = 1024
+	quotaOverridesCacheTTL  = time.Minute
+)
+
+// the names of the quotas in the errors and the X-Quota header
+const (
+	quotaMaxLists          = "max_lists"
+	quotaMaxItems          = "max_items"
+	quotaRequestsPerMinute = "requests_per_minute"
+)
+
+type QuotaRequest struct {
+	// null takes the limit of the tenant and then of the config, 0 is no
+	// limit
+	MaxLists          *int `json:"max_lists"`
+	MaxItems          *int `json:"max_items"`
+	RequestsPerMinute *int `json:"requests_per_minute"`
+}
+
+type QuotaResponse struct {
+	// user or tenant
+	Scope string `json:"scope"`
+	// the username or the id of the tenant
+	Subject           string    `json:"subject"`
+	MaxLists          *int      `json:"max_lists"`
+	MaxItems          *int      `json:"max_items"`
+	RequestsPerMinute *int      `json:"requests_per_minute"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type QuotasResponse struct {
+	Defaults quota.Limits    `json:"defaults"`
+	Quotas   []QuotaResponse `json:"quotas"`
+}
+
+// quotaExceededError is returned by the writes that put a list or its owner
+// over a quota, the write is rolled back
+type quotaExceededError struct {
+	Quota string
+	Limit int
+}
+
+func (e *quotaExceededError) Error() string {
+	if e.Quota == quotaMaxLists {
+		return fmt.Sprintf("the quota of %d lists of the owner is exceeded, delete a list first", e.Limit)
+	}
+
+	return fmt.Sprintf("the quota of %d items of a list is exceeded", e.Limit)
+}
+
+// quotaError writes the 403 of the quota errors, it tells if err was one
+func quotaError(w http.ResponseWriter, err error) bool {
+	var exceeded *quotaExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+
+	w.Header().Set("X-Quota", exceeded.Quota)
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(exceeded.Limit))
+	http.Error(w, exceeded.Error(), http.StatusForbidden)
+	return true
+}
+
+func (app *App) defaultQuotas() quota.Limits {
+	settings := app.settings()
+	if settings == nil {
+		return quota.Limits{}
+	}
+
+	return quota.Limits{
+		MaxLists:          settings.QuotaMaxLists,
+		MaxItems:          settings.QuotaMaxItems,
+		RequestsPerMinute: settings.QuotaRequestsPerMinute,
+	}
+}
+
+// userQuotas returns the limits of the config overridden by the quota of the
+// tenant and then by the one of the user. The defaults are used when the
+// quotas can't be read, so the database doesn't stop the requests
+func (app *App) userQuotas(username string, tenantID string) quota.Limits {
+	limits := app.defaultQuotas()
+	if app.QuotaRepository == nil {
+		return limits
+	}
+
+	key := username + "|" + tenantID
+	rows, ok := []db_queries.Quota(nil), false
+	if app.quotaOverrides != nil {
+		rows, ok = app.quotaOverrides.Get(key)
+	}
+	if !ok {
+		var err error
+		rows, err = app.QuotaRepository.GetQuotas(username, tenantID)
+		if err != nil {
+			log.Err(err).Msgf("error to get the quotas of %s, the defaults are applied", username)
+			return limits
+		}
+		if app.quotaOverrides != nil {
+			app.quotaOverrides.Add(key, rows)
+		}
+	}
+
+	for _, scope := range []string{repository.QuotaScopeTenant, repository.QuotaScopeUser} {
+		for _, row := range rows {
+			if row.Scope == scope {
+				limits = limits.Apply(quotaOverride(row))
+			}
+		}
+	}
+
+	return limits
+}
+
+func quotaOverride(row db_queries.Quota) quota.Override {
+	return quota.Override{
+		MaxLists:          int4Value(row.MaxLists),
+		MaxItems:          int4Value(row.MaxItems),
+		RequestsPerMinute: int4Value(row.RequestsPerMinute),
+	}
+}
+
+// checkListQuotas rejects a list over the items quota of its owner and, for
+// the new lists, an owner over the lists quota. It runs in the unit of work
+// of the write, so the write is rolled back
+func (app *App) checkListQuotas(repos repository.Repositories, list *db_queries.ShoppingList, created bool) error {
+	if list == nil || !list.Owner.Valid {
+		return nil
+	}
+
+	return checkListQuotas(repos, app.userQuotas(list.Owner.String, list.TenantID.String()), list, created)
+}
+
+func checkListQuotas(repos repository.Repositories, limits quota.Limits, list *db_queries.ShoppingList, created bool) error {
+	if limits.MaxItems > 0 && len(list.Items) > limits.MaxItems {
+		quota.Stats.Add(quotaMaxItems, 1)
+		return &quotaExceededError{Quota: quotaMaxItems, Limit: limits.MaxItems}
+	}
+
+	if !created || limits.MaxLists <= 0 {
+		return nil
+	}
+
+	count, err := repos.ShoppingLists.CountShoppingListsByOwner(list.Owner.String)
+	if err != nil {
+		return err
+	}
+	if count > int64(limits.MaxLists) {
+		quota.Stats.Add(quotaMaxLists, 1)
+		return &quotaExceededError{Quota: quotaMaxLists, Limit: limits.MaxLists}
+	}
+
+	return nil
+}
+
+// rateLimited counts the requests of the API key, the session token or the
+// user of a signed URL, against the rate quota of the user and the rate of
+// the route. The tightest window is sent in the X-RateLimit headers
+func (app *App) rateLimited(route Route, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		if app.RateLimiter == nil || user == nil {
+			next(w, r)
+			return
+		}
+
+		key := apiKey(r, user)
+		limits := app.userQuotas(user.Username, user.TenantID)
+		results := []quota.Result{app.RateLimiter.Allow(key, limits.RequestsPerMinute)}
+		if route.RateLimit > 0 {
+			results = append(results, app.RateLimiter.Allow(route.Method+" "+route.Path+"|"+key, route.RateLimit))
+		}
+
+		tightest := quota.Result{Allowed: true}
+		for _, result := range results {
+			switch {
+			case result.Limit == 0:
+			case tightest.Limit == 0, !result.Allowed, tightest.Allowed && result.Remaining < tightest.Remaining:
+				tightest = result
+			}
+		}
+		if tightest.Limit == 0 {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(tightest.Reset.Unix(), 10))
+
+		if !tightest.Allowed {
+			quota.Stats.Add(quotaRequestsPerMinute, 1)
+			retryAfter := max(int(time.Until(tightest.Reset).Seconds()+0.5), 1)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, fmt.Sprintf("the rate limit of %d requests per minute is exceeded, retry in %d seconds", tightest.Limit, retryAfter), http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// apiKey identifies the credential of the request without keeping the token
+func apiKey(r *http.Request, user *User) string {
+	token, _ := sessionToken(r)
+	if token == "" || signedUser(r) != nil {
+		return "user:" + user.Username
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "session:" + hex.EncodeToString(sum[:16])
+}
+
+// handleListQuotas returns the quotas of the config and the ones set to the
+// users and the tenants
+func (app *App) handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.QuotaRepository.ListQuotas()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	quotas := make([]QuotaResponse, 0, len(rows))
+	for _, row := range rows {
+		quotas = append(quotas, newQuotaResponse(row))
+	}
+
+	render.JSON(w, http.StatusOK, QuotasResponse{Defaults: app.defaultQuotas(), Quotas: quotas})
+}
+
+// handleSetQuota replaces the quota of a user or a tenant, the tenants are
+// given by their slug
+func (app *App) handleSetQuota(w http.ResponseWriter, r *http.Request) {
+	scope, subject, ok := app.quotaSubject(w, r)
+	if !ok {
+		return
+	}
+
+	var data QuotaRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	for _, limit := range []*int{data.MaxLists, data.MaxItems, data.RequestsPerMinute} {
+		if limit != nil && (*limit < 0 || *limit > 1<<30) {
+			http.Error(w, "the limits must be null, 0 for no limit or a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	row, err := app.QuotaRepository.SetQuota(scope, subject, repository.QuotaLimits{
+		MaxLists:          data.MaxLists,
+		MaxItems:          data.MaxItems,
+		RequestsPerMinute: data.RequestsPerMinute,
+	})
+	if err != nil {
+		repositoryError(w, err, "quota not found")
+		return
+	}
+	app.purgeQuotas()
+
+	render.JSON(w, http.StatusOK, newQuotaResponse(*row))
+}
+
+// handleDeleteQuota removes the quota of a user or a tenant, the wider scope
+// applies again
+func (app *App) handleDeleteQuota(w http.ResponseWriter, r *http.Request) {
+	scope, subject, ok := app.quotaSubject(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.QuotaRepository.DeleteQuota(scope, subject)
+	if err != nil {
+		repositoryError(w, err, "quota not found")
+		return
+	}
+	app.purgeQuotas()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// quotaSubject reads the scope and the subject of the path, users/{username}
+// or tenants/{slug}
+func (app *App) quotaSubject(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	subject := r.PathValue("subject")
+
+	switch r.PathValue("scope") {
+	case "users":
+		user, err := app.findUser(subject)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return "", "", false
+		}
+		if user == nil {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return "", "", false
+		}
+
+		return repository.QuotaScopeUser, user.Username, true
+	case "tenants":
+		tenant, err := app.TenantRepository.GetTenantBySlug(subject)
+		if err != nil {
+			repositoryError(w, err, "tenant not found")
+			return "", "", false
+		}
+
+		return repository.QuotaScopeTenant, tenant.ID.String(), true
+	default:
+		http.Error(w, "the quotas are set to users or tenants", http.StatusNotFound)
+		return "", "", false
+	}
+}
+
+// purgeQuotas drops the quotas read by the instance, the other instances
+// read them again within quotaOverridesCacheTTL
+func (app *App) purgeQuotas() {
+	if app.quotaOverrides != nil {
+		app.quotaOverrides.Purge()
+	}
+}
+
+func newQuotaResponse(row db_queries.Quota) QuotaResponse {
+	return QuotaResponse{
+		Scope:             row.Scope,
+		Subject:           row.Subject,
+		MaxLists:          int4Value(row.MaxLists),
+		MaxItems:          int4Value(row.MaxItems),
+		RequestsPerMinute: int4Value(row.RequestsPerMinute),
+		UpdatedAt:         row.UpdatedAt.Time.UTC(),
+	}
+}
+
+func int4Value(v pgtype.Int4) *int {
+	if !v.Valid {
+		return nil
+	}
+
+	value := int(v.Int32)
+	return &value
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/recipe"
+	"shopping/render"
+	"shopping/repository"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxRecipeIngredients stops the pages and the pastes that aren't recipes
+// from filling a list
+const maxRecipeIngredients = 100
+
+type ImportRecipeRequest struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+type ImportRecipeResponse struct {
+	Added   []string             `json:"added"`
+	Merged  []string             `json:"merged"`
+	Skipped []string             `json:"skipped"`
+	List    ShoppingListResponse `json:"list"`
+}
+
+// handleImportRecipe adds the ingredients of a recipe page or of a pasted
+// recipe to the list, the ingredients already in the list add up to their
+// quantity
+func (app *App) handleImportRecipe(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var data ImportRecipeRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data.URL = strings.TrimSpace(data.URL)
+	if (data.URL == "") == (strings.TrimSpace(data.Text) == "") {
+		http.Error(w, "one of 'url' or 'text' is required", http.StatusBadRequest)
+		return
+	}
+
+	lines := strings.Split(data.Text, "\n")
+	if data.URL != "" {
+		if app.Recipes == nil {
+			http.Error(w, "the recipe pages can't be read, paste the ingredients in 'text'", http.StatusNotFound)
+			return
+		}
+
+		lines, err = app.Recipes.Fetch(r.Context(), data.URL)
+		if errors.Is(err, recipe.ErrNoRecipe) {
+			http.Error(w, "the page has no recipe, paste the ingredients in 'text'", http.StatusUnprocessableEntity)
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msgf("recipe: error to read the page %s", data.URL)
+			http.Error(w, "the recipe page can't be read", http.StatusBadGateway)
+			return
+		}
+	}
+
+	ingredients := []recipe.Ingredient{}
+	for _, line := range lines {
+		if ingredient, ok := recipe.Parse(line); ok {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	ingredients = recipe.Merge(ingredients)
+
+	if len(ingredients) == 0 {
+		http.Error(w, "the recipe has no ingredients", http.StatusUnprocessableEntity)
+		return
+	}
+	if len(ingredients) > maxRecipeIngredients {
+		http.Error(w, "the recipe has too many ingredients", http.StatusUnprocessableEntity)
+		return
+	}
+
+	var response ImportRecipeResponse
+	updated, err := app.writeList(currentUser(r).Username, eventListUpdated, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		list, err := repos.ShoppingLists.GetShoppingListByIDForUpdate(id)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []string
+		items, response.Added, response.Merged, response.Skipped = recipe.AddTo(list.Items, ingredients)
+
+		return repos.ShoppingLists.UpdateShoppingListByID(id, list.Name, items)
+	})
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+	response.List = newShoppingListResponse(*updated)
+
+	render.JSON(w, http.StatusOK, response)
+}
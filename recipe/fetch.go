@@ -0,0 +1,179 @@
+package recipe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+const maxPageBytes = 2 << 20
+
+var (
+	ErrNoRecipe        = errors.New("recipe: the page has no recipe")
+	ErrForbiddenTarget = errors.New("recipe: the address isn't public")
+)
+
+var ldJSONPattern = regexp.MustCompile(`(?is)<script[^>]+type=["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// Source gives the ingredient lines of the recipe of a page
+type Source interface {
+	Fetch(ctx context.Context, pageURL string) ([]string, error)
+}
+
+// Fetcher reads the ingredients of the recipes published with the
+// schema.org Recipe markup, most recipe sites have it for the search engines
+type Fetcher struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// NewFetcher returns a fetcher that only connects to public addresses, the
+// URLs are given by the users and must not reach the internal network
+func NewFetcher(userAgent string) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// the address is checked after the name is resolved, so a public
+		// name can't point to a private address
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !PublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+			}
+
+			return nil
+		},
+	}
+
+	return &Fetcher{
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return errors.New("recipe: too many redirects")
+				}
+				return nil
+			},
+		},
+		UserAgent: userAgent,
+	}
+}
+
+// PublicIP tells if the address is reachable on the internet
+func PublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// Fetch returns the ingredient lines of the recipe of the page
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) ([]string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("recipe: '%s' isn't an http or https URL", pageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("recipe: the page answered %d", res.StatusCode)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(res.Body, maxPageBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return Ingredients(page)
+}
+
+// Ingredients returns the recipeIngredient of the first Recipe of the
+// JSON-LD blocks of the page
+func Ingredients(page []byte) ([]string, error) {
+	for _, match := range ldJSONPattern.FindAllSubmatch(page, -1) {
+		var data any
+		if json.Unmarshal(match[1], &data) != nil {
+			// the invalid blocks of other markups are ignored
+			continue
+		}
+
+		if lines, ok := findRecipe(data); ok {
+			for i := range lines {
+				lines[i] = html.UnescapeString(lines[i])
+			}
+			return lines, nil
+		}
+	}
+
+	return nil, ErrNoRecipe
+}
+
+// findRecipe walks the JSON-LD document, the recipe can be the document, be
+// in a list or in the @graph of the page
+func findRecipe(data any) ([]string, bool) {
+	switch value := data.(type) {
+	case []any:
+		for _, item := range value {
+			if lines, ok := findRecipe(item); ok {
+				return lines, true
+			}
+		}
+	case map[string]any:
+		if isRecipe(value["@type"]) {
+			lines := []string{}
+			if ingredients, ok := value["recipeIngredient"].([]any); ok {
+				for _, ingredient := range ingredients {
+					if line, ok := ingredient.(string); ok {
+						lines = append(lines, line)
+					}
+				}
+			}
+			return lines, len(lines) > 0
+		}
+
+		return findRecipe(value["@graph"])
+	}
+
+	return nil, false
+}
+
+func isRecipe(kind any) bool {
+	switch value := kind.(type) {
+	case string:
+		return value == "Recipe"
+	case []any:
+		for _, item := range value {
+			if item == "Recipe" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
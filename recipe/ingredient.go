@@ -0,0 +1,241 @@
+// Package recipe reads the ingredients of the recipes, pasted as text or
+// published in a web page, to add them to the lists.
+package recipe
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ingredient is a line of a recipe like "2 cups of flour, sifted"
+type Ingredient struct {
+	// Quantity is 0 when the line has none, like "salt to taste"
+	Quantity float64
+	// Unit is the canonical name of the unit like g, cup or tbsp, empty for
+	// the things that are counted like "3 eggs"
+	Unit string
+	Name string
+}
+
+// units maps the spellings of the units to their canonical name
+var units = map[string]string{
+	"g": "g", "gr": "g", "gram": "g", "grams": "g", "gramme": "g", "grammes": "g",
+	"kg": "kg", "kgs": "kg", "kilogram": "kg", "kilograms": "kg",
+	"mg": "mg",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"cl": "cl",
+	"dl": "dl",
+	"l":  "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"tsp": "tsp", "tsps": "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	"tbsp": "tbsp", "tbsps": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp", "tbs": "tbsp", "tbl": "tbsp",
+	"cup": "cup", "cups": "cup", "c": "cup",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"pint": "pint", "pints": "pint", "pt": "pint",
+	"quart": "quart", "quarts": "quart", "qt": "quart",
+	"pinch": "pinch", "pinches": "pinch",
+	"clove": "clove", "cloves": "clove",
+	"can": "can", "cans": "can",
+	"slice": "slice", "slices": "slice",
+	"bunch": "bunch", "bunches": "bunch",
+	"package": "package", "packages": "package", "pkg": "package",
+}
+
+// plurals of the canonical units that are words, the abbreviations don't
+// change
+var plurals = map[string]string{
+	"cup": "cups", "pint": "pints", "quart": "quarts", "pinch": "pinches", "clove": "cloves",
+	"can": "cans", "slice": "slices", "bunch": "bunches", "package": "packages",
+}
+
+var fractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4", "⅛", " 1/8",
+)
+
+var (
+	// a number like 2, 1.5, 1,5, 1/2 or 1 1/2 and an optional range end
+	quantityPattern = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?)(?:\s*(?:-|–|to)\s*(?:\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?))?`)
+	unitPattern     = regexp.MustCompile(`^([a-zA-Z]+)\.?(?:\s+|$)`)
+	notesPattern    = regexp.MustCompile(`\([^)]*\)`)
+)
+
+// Parse reads an ingredient line, it returns false for the lines without a
+// name like the empty ones
+func Parse(line string) (Ingredient, bool) {
+	line = strings.TrimSpace(fractions.Replace(line))
+	line = strings.TrimSpace(strings.TrimLeft(line, "-*•·"))
+
+	var ingredient Ingredient
+	ingredient.Quantity, ingredient.Unit, line = parseAmount(line)
+	line = strings.TrimPrefix(line, "of ")
+
+	// the notes in parentheses and the preparation after the comma aren't
+	// bought
+	name := notesPattern.ReplaceAllString(line, "")
+	name, _, _ = strings.Cut(name, ",")
+	ingredient.Name = strings.Join(strings.Fields(name), " ")
+
+	return ingredient, ingredient.Name != ""
+}
+
+// parseAmount reads the quantity and the unit at the start of the line, it
+// returns the rest of the line
+func parseAmount(line string) (float64, string, string) {
+	match := quantityPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, "", line
+	}
+
+	quantity := parseQuantity(match[1])
+	line = strings.TrimSpace(line[len(match[0]):])
+	if unit := unitPattern.FindStringSubmatch(line); unit != nil {
+		if canonical, ok := units[strings.ToLower(unit[1])]; ok {
+			return quantity, canonical, strings.TrimSpace(line[len(unit[0]):])
+		}
+	}
+
+	return quantity, "", line
+}
+
+func parseQuantity(value string) float64 {
+	total := 0.0
+	for _, part := range strings.Fields(value) {
+		if numerator, denominator, ok := strings.Cut(part, "/"); ok {
+			n, _ := strconv.ParseFloat(numerator, 64)
+			d, _ := strconv.ParseFloat(denominator, 64)
+			if d != 0 {
+				total += n / d
+			}
+			continue
+		}
+
+		n, _ := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		total += n
+	}
+
+	return total
+}
+
+// Item is the name of the ingredient in a list, like "flour (2 cups)"
+func (i Ingredient) Item() string {
+	if i.Quantity == 0 {
+		return i.Name
+	}
+
+	amount := formatQuantity(i.Quantity)
+	switch {
+	case i.Unit == "":
+	case i.Quantity > 1 && plurals[i.Unit] != "":
+		amount += " " + plurals[i.Unit]
+	default:
+		amount += " " + i.Unit
+	}
+
+	return fmt.Sprintf("%s (%s)", i.Name, amount)
+}
+
+// ParseItem reads an item written by Item back, the other items are
+// ingredients without a quantity
+func ParseItem(item string) Ingredient {
+	item = strings.TrimSpace(item)
+	if name, amount, ok := strings.Cut(item, " ("); ok && strings.HasSuffix(amount, ")") {
+		quantity, unit, rest := parseAmount(strings.TrimSuffix(amount, ")"))
+		if quantity > 0 && rest == "" {
+			return Ingredient{Quantity: quantity, Unit: unit, Name: name}
+		}
+	}
+
+	return Ingredient{Name: item}
+}
+
+// formatQuantity writes the common fractions of the recipes as fractions
+func formatQuantity(quantity float64) string {
+	whole, fraction := math.Modf(quantity)
+	names := map[float64]string{0.125: "1/8", 0.25: "1/4", 0.333: "1/3", 0.5: "1/2", 0.667: "2/3", 0.75: "3/4"}
+	if name, ok := names[math.Round(fraction*1000)/1000]; ok {
+		if whole == 0 {
+			return name
+		}
+		return fmt.Sprintf("%d %s", int(whole), name)
+	}
+
+	return strconv.FormatFloat(math.Round(quantity*100)/100, 'f', -1, 64)
+}
+
+// Key identifies the ingredient of an item to find the duplicates: the case,
+// the quantities and a plural s are ignored, so "Eggs (3)" and "2 eggs" are
+// the same ingredient
+func Key(item string) string {
+	ingredient, ok := Parse(item)
+	if !ok {
+		return ""
+	}
+
+	key := strings.ToLower(ingredient.Name)
+	if len(key) > 3 {
+		key = strings.TrimSuffix(key, "s")
+	}
+
+	return key
+}
+
+// Merge adds up the quantities of the same ingredient in the same unit, the
+// ingredients keep the order of their first line
+func Merge(ingredients []Ingredient) []Ingredient {
+	merged := []Ingredient{}
+	index := map[string]int{}
+	for _, ingredient := range ingredients {
+		key := Key(ingredient.Name) + "|" + ingredient.Unit
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += ingredient.Quantity
+			continue
+		}
+
+		index[key] = len(merged)
+		merged = append(merged, ingredient)
+	}
+
+	return merged
+}
+
+// AddTo adds the ingredients to the items of a list. An ingredient already in
+// the list in the same unit adds up to its quantity, in another unit it is
+// skipped so the list doesn't get the same thing twice. It returns the items
+// of the list and the items added, merged and skipped
+func AddTo(items []string, ingredients []Ingredient) (result, added, merged, skipped []string) {
+	result = append([]string{}, items...)
+	index := map[string]int{}
+	for i, item := range result {
+		if key := Key(item); key != "" {
+			if _, ok := index[key]; !ok {
+				index[key] = i
+			}
+		}
+	}
+
+	for _, ingredient := range ingredients {
+		key := Key(ingredient.Name)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(result)
+			result = append(result, ingredient.Item())
+			added = append(added, ingredient.Item())
+			continue
+		}
+
+		existing := ParseItem(result[i])
+		if existing.Unit != ingredient.Unit || (existing.Quantity == 0) != (ingredient.Quantity == 0) {
+			skipped = append(skipped, ingredient.Item())
+			continue
+		}
+
+		existing.Quantity += ingredient.Quantity
+		result[i] = existing.Item()
+		merged = append(merged, result[i])
+	}
+
+	return result, added, merged, skipped
+}
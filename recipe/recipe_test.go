@@ -0,0 +1,112 @@
+package recipe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]Ingredient{
+		"2 cups of flour, sifted":        {Quantity: 2, Unit: "cup", Name: "flour"},
+		"1 ½ tsp salt":                   {Quantity: 1.5, Unit: "tsp", Name: "salt"},
+		"200g butter (softened)":         {Quantity: 200, Unit: "g", Name: "butter"},
+		"- 3 large eggs":                 {Quantity: 3, Name: "large eggs"},
+		"2-3 Tbsp. olive oil":            {Quantity: 2, Unit: "tbsp", Name: "olive oil"},
+		"1,5 l milk":                     {Quantity: 1.5, Unit: "l", Name: "milk"},
+		"Salt and pepper, to taste":      {Name: "Salt and pepper"},
+		"1/2 onion":                      {Quantity: 0.5, Name: "onion"},
+		"4 cloves garlic, minced":        {Quantity: 4, Unit: "clove", Name: "garlic"},
+		"1 can (400 g) chopped tomatoes": {Quantity: 1, Unit: "can", Name: "chopped tomatoes"},
+	}
+
+	for line, expected := range tests {
+		ingredient, ok := Parse(line)
+		assert.True(t, ok, line)
+		assert.Equal(t, expected, ingredient, line)
+	}
+
+	for _, line := range []string{"", "  ", "- ", "(optional)"} {
+		_, ok := Parse(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestItem(t *testing.T) {
+	assert.Equal(t, "flour (2 cups)", Ingredient{Quantity: 2, Unit: "cup", Name: "flour"}.Item())
+	assert.Equal(t, "salt (1 1/2 tsp)", Ingredient{Quantity: 1.5, Unit: "tsp", Name: "salt"}.Item())
+	assert.Equal(t, "eggs (3)", Ingredient{Quantity: 3, Name: "eggs"}.Item())
+	assert.Equal(t, "onion (1/2)", Ingredient{Quantity: 0.5, Name: "onion"}.Item())
+	assert.Equal(t, "pepper", Ingredient{Name: "pepper"}.Item())
+}
+
+func TestMergeAndKey(t *testing.T) {
+	merged := Merge([]Ingredient{
+		{Quantity: 1, Unit: "cup", Name: "sugar"},
+		{Quantity: 2, Name: "eggs"},
+		{Quantity: 1, Unit: "cup", Name: "Sugar"},
+		{Quantity: 2, Unit: "tbsp", Name: "sugar"},
+		{Quantity: 1, Name: "egg"},
+	})
+
+	assert.Equal(t, []Ingredient{
+		{Quantity: 2, Unit: "cup", Name: "sugar"},
+		{Quantity: 3, Name: "eggs"},
+		{Quantity: 2, Unit: "tbsp", Name: "sugar"},
+	}, merged)
+
+	assert.Equal(t, Key("Eggs (3)"), Key("2 eggs"))
+	assert.Equal(t, Key("flour"), Key("flour (2 cups)"))
+}
+
+func TestIngredients(t *testing.T) {
+	page := []byte(`<html><head>
+		<script type="application/ld+json">{"@type":"Organization","name":"Recipes"}</script>
+		<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+			{"@type":"WebPage"},
+			{"@type":["Recipe","NewsArticle"],"name":"Pancakes","recipeIngredient":["2 cups flour","1 tbsp sugar","Milk &amp; eggs"]}
+		]}</script>
+	</head></html>`)
+
+	lines, err := Ingredients(page)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2 cups flour", "1 tbsp sugar", "Milk & eggs"}, lines)
+
+	_, err = Ingredients([]byte(`<html><body>no recipe</body></html>`))
+	assert.ErrorIs(t, err, ErrNoRecipe)
+}
+
+func TestFetcherOnlyReachesPublicAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the fetcher reached the local server")
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher("shopping-test").Fetch(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrForbiddenTarget)
+
+	assert.False(t, PublicIP(net.ParseIP("10.0.0.1")))
+	assert.False(t, PublicIP(net.ParseIP("169.254.169.254")))
+	assert.False(t, PublicIP(net.ParseIP("::1")))
+	assert.True(t, PublicIP(net.ParseIP("93.184.216.34")))
+}
+
+func TestAddTo(t *testing.T) {
+	items := []string{"Flour (1 cup)", "eggs", "milk (1 l)"}
+	result, added, merged, skipped := AddTo(items, []Ingredient{
+		{Quantity: 2, Unit: "cup", Name: "flour"},
+		{Quantity: 3, Name: "eggs"},
+		{Quantity: 200, Unit: "ml", Name: "milk"},
+		{Quantity: 1, Unit: "tbsp", Name: "sugar"},
+	})
+
+	assert.Equal(t, []string{"Flour (3 cups)", "eggs", "milk (1 l)", "sugar (1 tbsp)"}, result)
+	assert.Equal(t, []string{"sugar (1 tbsp)"}, added)
+	assert.Equal(t, []string{"Flour (3 cups)"}, merged)
+	assert.Equal(t, []string{"eggs (3)", "milk (200 ml)"}, skipped)
+	assert.Equal(t, []string{"Flour (1 cup)", "eggs", "milk (1 l)"}, items)
+}
@@ -0,0 +1,111 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"shopping/requestid"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Stats are published in /debug/vars as panics: the number of handler
+// panics since the start
+var Stats = expvar.NewInt("panics")
+
+// Event is a panic of a handler
+type Event struct {
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Message   string    `json:"message"`
+	Stack     string    `json:"stack"`
+	Time      time.Time `json:"time"`
+}
+
+// Reporter sends the panics to an error tracker, the adapters of Sentry or
+// Rollbar implement it. Report must not block the response for long.
+type Reporter interface {
+	Report(ctx context.Context, event Event)
+}
+
+// NopReporter doesn't report the panics, they are only logged
+type NopReporter struct{}
+
+func (NopReporter) Report(ctx context.Context, event Event) {}
+
+// Middleware recovers the panics of the handlers: the stack is logged, the
+// panic is reported and the client gets a 500 with the id of the request.
+// The server keeps serving the other requests.
+func Middleware(reporter Reporter, next http.Handler) http.Handler {
+	if reporter == nil {
+		reporter = NopReporter{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+
+			// the handler asked to abort the response, the server handles
+			// it quietly
+			if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(value)
+			}
+
+			Stats.Add(1)
+			event := Event{
+				RequestID: requestid.FromContext(r.Context()),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Message:   fmt.Sprint(value),
+				Stack:     string(debug.Stack()),
+				Time:      time.Now().UTC(),
+			}
+
+			log.Error().
+				Str("request_id", event.RequestID).
+				Str("stack", event.Stack).
+				Msgf("recovery: panic in %s %s: %s", event.Method, event.Path, event.Message)
+			reporter.Report(context.WithoutCancel(r.Context()), event)
+
+			// a part of the response was sent, the client sees it cut
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			http.Error(w, "internal server error, request id: "+event.RequestID, http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// responseWriter tells if the response was started
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap lets http.ResponseController flush the event streams
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,68 @@
+package recovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shopping/requestid"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingReporter struct {
+	events []Event
+}
+
+func (r *recordingReporter) Report(ctx context.Context, event Event) {
+	r.events = append(r.events, event)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("answers 500 with the request id and reports the panic", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		handler := requestid.Middleware(Middleware(reporter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var lists map[string][]string
+			lists["groceries"] = nil
+		})))
+
+		req := httptest.NewRequest("GET", "/v1/lists", nil)
+		req.Header.Set(requestid.Header, "req-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "internal server error, request id: req-123\n", rec.Body.String())
+		assert.Equal(t, "req-123", rec.Header().Get(requestid.Header))
+
+		assert.Len(t, reporter.events, 1)
+		event := reporter.events[0]
+		assert.Equal(t, "req-123", event.RequestID)
+		assert.Equal(t, "GET", event.Method)
+		assert.Equal(t, "/v1/lists", event.Path)
+		assert.Equal(t, "assignment to entry in nil map", event.Message)
+		assert.Contains(t, event.Stack, "recovery_test.go")
+	})
+
+	t.Run("the aborted responses panic again", func(t *testing.T) {
+		handler := Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			panic("the stream broke")
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+
+	t.Run("doesn't change the other responses", func(t *testing.T) {
+		handler := Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+	})
+}
@@ -0,0 +1,110 @@
+// Package reminder fires the reminders of the lists when they are due.
+package reminder
+
+import (
+	"context"
+	"expvar"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Stats are published in /debug/vars as reminders: the reminders delivered
+// and the attempts that failed
+var Stats = expvar.NewMap("reminders")
+
+// Fire delivers a reminder, the reminder is tried again after the lease when
+// it returns an error
+type Fire func(ctx context.Context, reminder db_queries.Reminder) error
+
+type Options struct {
+	Interval  time.Duration // time between the polls
+	BatchSize int32
+	// Lease is how long the claimed reminders are reserved, they are fired
+	// again after it when the instance crashed or Fire failed
+	Lease time.Duration
+	// Timeout of each Fire call
+	Timeout time.Duration
+}
+
+// Scheduler polls the due reminders and fires them. The delivery is at
+// least once: a crash after firing and before marking the reminder fires it
+// again.
+type Scheduler struct {
+	repo repository.ReminderRepository
+	fire Fire
+	opts Options
+}
+
+func NewScheduler(repo repository.ReminderRepository, fire Fire, opts Options) *Scheduler {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = time.Minute
+	}
+
+	return &Scheduler{
+		repo: repo,
+		fire: fire,
+		opts: opts,
+	}
+}
+
+// Run fires the due reminders until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means there may be more reminders due
+		for {
+			claimed, err := s.FireDue(ctx)
+			if err != nil || claimed < int(s.opts.BatchSize) || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FireDue fires one batch of due reminders and returns how many were claimed
+func (s *Scheduler) FireDue(ctx context.Context) (int, error) {
+	reminders, err := s.repo.ClaimDue(s.opts.BatchSize, s.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, reminder := range reminders {
+		fireCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+		err := s.fire(fireCtx, reminder)
+		cancel()
+		if err != nil {
+			Stats.Add("failed", 1)
+			log.Warn().Err(err).Msgf("reminder: error to fire the reminder %s, retrying after the lease", reminder.ID.String())
+			continue
+		}
+
+		err = s.repo.MarkDelivered(reminder.ID)
+		if err != nil {
+			// it's fired again after the lease
+			continue
+		}
+		Stats.Add("delivered", 1)
+	}
+
+	return len(reminders), nil
+}
@@ -0,0 +1,40 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFireDue(t *testing.T) {
+	repo := repository.NewMockReminderRepository(gomock.NewController(t))
+
+	delivered := db_queries.Reminder{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "user"}
+	failed := db_queries.Reminder{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "other"}
+	repo.EXPECT().ClaimDue(int32(100), time.Minute).Return([]db_queries.Reminder{delivered, failed}, nil)
+	// only the reminders fired are marked, the others are claimed again
+	// after the lease
+	repo.EXPECT().MarkDelivered(delivered.ID).Return(nil)
+
+	var fired []string
+	scheduler := NewScheduler(repo, func(ctx context.Context, reminder db_queries.Reminder) error {
+		fired = append(fired, reminder.Username)
+		if reminder.Username == "other" {
+			return errors.New("smtp is down")
+		}
+		return nil
+	}, Options{})
+
+	claimed, err := scheduler.FireDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	assert.Equal(t, []string{"user", "other"}, fired)
+}
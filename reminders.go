@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/notify"
+	"shopping/render"
+	"shopping/repository"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxReminderNoteLength = 280
+	maxReminderHorizon    = 365 * 24 * time.Hour
+)
+
+type CreateReminderRequest struct {
+	RemindAt time.Time `json:"remind_at"`
+	Note     string    `json:"note"`
+}
+
+type ReminderResponse struct {
+	ID          string     `json:"id"`
+	ListID      string     `json:"list_id"`
+	RemindAt    time.Time  `json:"remind_at"`
+	Note        string     `json:"note"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// fireReminder sends the reminder to the user right away, the digests are
+// skipped because the user asked for that time
+func (app *App) fireReminder(ctx context.Context, reminder db_queries.Reminder) error {
+	list, err := app.ShoppingListRepository.GetShoppingListByID(reminder.ListID.String())
+	if errors.Is(err, repository.ErrNotFound) {
+		// the list was deleted, there is nothing to remind
+		log.Info().Msgf("reminders: the list of the reminder %s was deleted", reminder.ID.String())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	body := reminder.Note
+	if body == "" {
+		body = fmt.Sprintf("%d items in your list %s", len(list.Items), list.Name)
+	}
+
+	return app.Notifications.Send(ctx, reminder.Username, notify.Message{
+		Title: fmt.Sprintf("Reminder: %s", list.Name),
+		Body:  body,
+	})
+}
+
+func (app *App) handleCreateReminder(w http.ResponseWriter, r *http.Request) {
+	if app.ReminderRepository == nil {
+		http.Error(w, "the reminders are disabled, set NOTIFICATIONS=true", http.StatusNotFound)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	var data CreateReminderRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data, 'remind_at' must be a RFC 3339 time like 2025-01-01T10:00:00Z", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if !data.RemindAt.After(now) || data.RemindAt.After(now.Add(maxReminderHorizon)) {
+		http.Error(w, "'remind_at' must be in the future and within a year", http.StatusUnprocessableEntity)
+		return
+	}
+	if utf8.RuneCountInString(data.Note) > maxReminderNoteLength {
+		http.Error(w, fmt.Sprintf("'note' can't be longer than %d characters", maxReminderNoteLength), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// the deleted lists can't have reminders
+	_, err = app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	reminder, err := app.ReminderRepository.Create(id, currentUser(r).Username, data.RemindAt.UTC(), data.Note)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	render.JSON(w, http.StatusCreated, reminderResponse(*reminder))
+}
+
+func (app *App) handleListReminders(w http.ResponseWriter, r *http.Request) {
+	if app.ReminderRepository == nil {
+		http.Error(w, "the reminders are disabled, set NOTIFICATIONS=true", http.StatusNotFound)
+		return
+	}
+
+	rows, err := app.ReminderRepository.List(r.PathValue("id"), currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	reminders := make([]ReminderResponse, 0, len(rows))
+	for _, row := range rows {
+		reminders = append(reminders, reminderResponse(row))
+	}
+
+	render.JSON(w, http.StatusOK, reminders)
+}
+
+func (app *App) handleCancelReminder(w http.ResponseWriter, r *http.Request) {
+	if app.ReminderRepository == nil {
+		http.Error(w, "the reminders are disabled, set NOTIFICATIONS=true", http.StatusNotFound)
+		return
+	}
+
+	err := app.ReminderRepository.Cancel(r.PathValue("id"), currentUser(r).Username, r.PathValue("reminderID"))
+	if err != nil {
+		repositoryError(w, err, "reminder not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func reminderResponse(row db_queries.Reminder) ReminderResponse {
+	reminder := ReminderResponse{
+		ID:        row.ID.String(),
+		ListID:    row.ListID.String(),
+		RemindAt:  row.RemindAt.Time.UTC(),
+		Note:      row.Note,
+		CreatedAt: row.CreatedAt.Time.UTC(),
+	}
+	if row.DeliveredAt.Valid {
+		deliveredAt := row.DeliveredAt.Time.UTC()
+		reminder.DeliveredAt = &deliveredAt
+	}
+
+	return reminder
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the offer that best matches the Accept header of the
+// request. The first offer is the default when the header is empty, an
+// empty string means that none of the offers is acceptable (406).
+func Negotiate(r *http.Request, offers ...string) string {
+	ranges := parseAccept(strings.Join(r.Header.Values("Accept"), ","))
+	if len(ranges) == 0 {
+		return offers[0]
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, offer := range offers {
+		q := quality(ranges, offer)
+		// the order of the offers breaks the ties
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+type mediaRange struct {
+	value string // type/subtype, may have wildcards
+	q     float64
+}
+
+func parseAccept(header string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if k == "q" {
+				parsed, err := strconv.ParseFloat(v, 64)
+				if err == nil && parsed >= 0 && parsed <= 1 {
+					q = parsed
+				}
+			}
+		}
+
+		ranges = append(ranges, mediaRange{value: value, q: q})
+	}
+
+	return ranges
+}
+
+// quality returns the q of the most specific range that matches the offer,
+// so "text/*;q=0.1, text/csv" accepts csv with 1.
+func quality(ranges []mediaRange, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	q := 0.0
+	specificity := -1
+	for _, mr := range ranges {
+		s := -1
+		switch {
+		case mr.value == offer:
+			s = 2
+		case mr.value == offerType+"/*":
+			s = 1
+		case mr.value == "*/*":
+			s = 0
+		}
+
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeCSV  = "text/csv"
+	MediaTypeText = "text/plain"
+)
+
+// CSVMarshaler is implemented by the values that have a text/csv
+// representation
+type CSVMarshaler interface {
+	MarshalCSV() ([]byte, error)
+}
+
+// PlainTextMarshaler is implemented by the values that have a text/plain
+// representation. It's not encoding.TextMarshaler because encoding/json
+// uses that one to encode the value as a string.
+type PlainTextMarshaler interface {
+	MarshalPlainText() ([]byte, error)
+}
+
+// Marshal encodes v with the representation of the media type
+func Marshal(mediaType string, v any) ([]byte, error) {
+	switch mediaType {
+	case MediaTypeJSON:
+		return json.Marshal(v)
+	case MediaTypeCSV:
+		if m, ok := v.(CSVMarshaler); ok {
+			return m.MarshalCSV()
+		}
+	case MediaTypeText:
+		if m, ok := v.(PlainTextMarshaler); ok {
+			return m.MarshalPlainText()
+		}
+	}
+
+	return nil, fmt.Errorf("render: %T can't be rendered as %s", v, mediaType)
+}
+
+// ContentType adds the charset to the text media types
+func ContentType(mediaType string) string {
+	if mediaType == MediaTypeCSV || mediaType == MediaTypeText {
+		return mediaType + "; charset=utf-8"
+	}
+
+	return mediaType
+}
+
+// JSON writes v as json. The encoder streams the value so it's fine for big
+// responses, but an encoding error can't change the status anymore.
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", MediaTypeJSON)
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Err(err).Msgf("render: failed to encode the %T response", v)
+	}
+}
+
+// Respond writes v with the representation of the media type, it's encoded
+// before writing the status so a failure is still sent as a 500.
+func Respond(w http.ResponseWriter, status int, mediaType string, v any) {
+	data, err := Marshal(mediaType, v)
+	if err != nil {
+		log.Err(err).Msg("render: failed to encode the response")
+		http.Error(w, "failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+
+	Write(w, status, mediaType, data)
+}
+
+// Write sends an already encoded body, it's used when the body is needed
+// before writing it, e.g. to compute the etag.
+func Write(w http.ResponseWriter, status int, mediaType string, data []byte) {
+	w.Header().Set("Content-Type", ContentType(mediaType))
+	w.WriteHeader(status)
+
+	_, err := w.Write(data)
+	if err != nil {
+		log.Err(err).Msg("render: failed to write the response")
+	}
+}
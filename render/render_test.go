@@ -0,0 +1,55 @@
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{MediaTypeJSON, MediaTypeCSV, MediaTypeText}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: MediaTypeJSON},
+		{accept: "*/*", want: MediaTypeJSON},
+		{accept: "text/csv", want: MediaTypeCSV},
+		{accept: "text/*", want: MediaTypeCSV},
+		{accept: "text/plain, application/json;q=0.5", want: MediaTypeText},
+		{accept: "text/*;q=0.1, text/plain", want: MediaTypeText},
+		{accept: "application/json;q=0, */*;q=0.2", want: MediaTypeCSV},
+		{accept: "image/png", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			assert.Equal(t, tt.want, Negotiate(r, offers...))
+		})
+	}
+}
+
+type plainOnly struct{}
+
+func (plainOnly) MarshalPlainText() ([]byte, error) { return []byte("plain"), nil }
+
+func TestRespond(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Respond(rec, 200, MediaTypeText, plainOnly{})
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "plain", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	Respond(rec, 200, MediaTypeCSV, plainOnly{})
+
+	assert.Equal(t, 500, rec.Code)
+}
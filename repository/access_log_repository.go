@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// AccessLogFilter selects the entries of the access log, the empty fields
+// are ignored
+type AccessLogFilter struct {
+	Username  string
+	MinStatus int
+	Since     time.Time
+	Limit     int
+}
+
+// AccessLogRepository keeps a summary of the API requests for the admins
+type AccessLogRepository interface {
+	// RecordAll copies the entries in one round trip
+	RecordAll(entries []db_queries.InsertAccessLogEntriesParams) error
+	// List returns the last entries first
+	List(filter AccessLogFilter) ([]db_queries.AccessLog, error)
+	// DeleteBefore deletes the entries older than the time and returns how
+	// many were deleted
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type AccessLogPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewAccessLogRepository(dbQueries *db_queries.Queries) AccessLogRepository {
+	return &AccessLogPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *AccessLogPostgresRepository) RecordAll(entries []db_queries.InsertAccessLogEntriesParams) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	_, err := r.dbQueries.InsertAccessLogEntries(ctx, entries)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to record %d access log entries", len(entries))
+		return errors.New("repository: error to record the access log entries")
+	}
+
+	return nil
+}
+
+func (r *AccessLogPostgresRepository) List(filter AccessLogFilter) ([]db_queries.AccessLog, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ListAccessLogEntries(ctx, db_queries.ListAccessLogEntriesParams{
+		Username:  pgtype.Text{String: filter.Username, Valid: filter.Username != ""},
+		MinStatus: pgtype.Int4{Int32: int32(filter.MinStatus), Valid: filter.MinStatus != 0},
+		Since:     pgtype.Timestamptz{Time: filter.Since, Valid: !filter.Since.IsZero()},
+		Limit:     int32(filter.Limit),
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to list the access log")
+		return nil, errors.New("repository: error to list the access log")
+	}
+
+	return rows, nil
+}
+
+func (r *AccessLogPostgresRepository) DeleteBefore(before time.Time) (int64, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	deleted, err := r.dbQueries.DeleteAccessLogEntriesBefore(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		log.Err(err).Msg("repository: error to delete the old access log entries")
+		return 0, errors.New("repository: error to delete the old access log entries")
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/access_log_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/access_log_repository.go -package repository -destination repository/access_log_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccessLogRepository is a mock of AccessLogRepository interface.
+type MockAccessLogRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessLogRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAccessLogRepositoryMockRecorder is the mock recorder for MockAccessLogRepository.
+type MockAccessLogRepositoryMockRecorder struct {
+	mock *MockAccessLogRepository
+}
+
+// NewMockAccessLogRepository creates a new mock instance.
+func NewMockAccessLogRepository(ctrl *gomock.Controller) *MockAccessLogRepository {
+	mock := &MockAccessLogRepository{ctrl: ctrl}
+	mock.recorder = &MockAccessLogRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessLogRepository) EXPECT() *MockAccessLogRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteBefore mocks base method.
+func (m *MockAccessLogRepository) DeleteBefore(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBefore", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBefore indicates an expected call of DeleteBefore.
+func (mr *MockAccessLogRepositoryMockRecorder) DeleteBefore(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBefore", reflect.TypeOf((*MockAccessLogRepository)(nil).DeleteBefore), before)
+}
+
+// List mocks base method.
+func (m *MockAccessLogRepository) List(filter AccessLogFilter) ([]db_queries.AccessLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]db_queries.AccessLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAccessLogRepositoryMockRecorder) List(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAccessLogRepository)(nil).List), filter)
+}
+
+// RecordAll mocks base method.
+func (m *MockAccessLogRepository) RecordAll(entries []db_queries.InsertAccessLogEntriesParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAll", entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAll indicates an expected call of RecordAll.
+func (mr *MockAccessLogRepositoryMockRecorder) RecordAll(entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAll", reflect.TypeOf((*MockAccessLogRepository)(nil).RecordAll), entries)
+}
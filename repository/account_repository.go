@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AnonymousUser replaces the username in the data that is kept after an
+// account is erased, like the changes to the lists of the others
+const AnonymousUser = "[deleted]"
+
+// ErasedAccount is what the erasure left outside of the database
+type ErasedAccount struct {
+	// ListIDs are the lists deleted, the caches and the search index still
+	// have them
+	ListIDs []string
+	// BlobKeys are the files of the photos deleted
+	BlobKeys []string
+}
+
+// AccountRepository has the deletion requests of the accounts and the data
+// of the users as a whole
+type AccountRepository interface {
+	// RequestDeletion schedules the erasure of the account, a second request
+	// keeps the first date
+	RequestDeletion(username string, eraseAt time.Time) (*db_queries.AccountDeletion, error)
+	GetDeletion(username string) (*db_queries.AccountDeletion, error)
+	// CancelDeletion returns ErrNotFound when there's no request
+	CancelDeletion(username string) error
+	// ClaimDueDeletion locks the request that is due the longest until the
+	// end of the unit of work, ErrNotFound when there's none
+	ClaimDueDeletion() (*db_queries.AccountDeletion, error)
+	// Erase deletes the data of the user and anonymizes what is kept for the
+	// others, it must run in a unit of work
+	Erase(username string) (*ErasedAccount, error)
+	// Export returns every row about the user with the table it comes from
+	Export(username string) ([]db_queries.ExportUserDataRow, error)
+}
+
+type AccountPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewAccountRepository(dbQueries *db_queries.Queries) AccountRepository {
+	return &AccountPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *AccountPostgresRepository) RequestDeletion(username string, eraseAt time.Time) (*db_queries.AccountDeletion, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.RequestAccountDeletion(ctx, db_queries.RequestAccountDeletionParams{
+		Username: username,
+		EraseAt:  pgtype.Timestamptz{Time: eraseAt, Valid: true},
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to request the deletion of the account: %s", username))
+	}
+
+	return &row, nil
+}
+
+func (r *AccountPostgresRepository) GetDeletion(username string) (*db_queries.AccountDeletion, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetAccountDeletion(ctx, username)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the deletion of the account: %s", username))
+	}
+
+	return &row, nil
+}
+
+func (r *AccountPostgresRepository) CancelDeletion(username string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	deleted, err := r.dbQueries.DeleteAccountDeletion(ctx, username)
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to cancel the deletion of the account: %s", username))
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("repository: the account %s has no deletion request: %w", username, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *AccountPostgresRepository) ClaimDueDeletion() (*db_queries.AccountDeletion, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.ClaimDueAccountDeletion(ctx)
+	if err != nil {
+		return nil, dbError(err, "repository: error to claim the due account deletions")
+	}
+
+	return &row, nil
+}
+
+func (r *AccountPostgresRepository) Erase(username string) (*ErasedAccount, error) {
+	ctx, cancel := transactionContext()
+	defer cancel()
+
+	msg := fmt.Sprintf("repository: error to erase the account: %s", username)
+	owner := pgtype.Text{String: username, Valid: true}
+
+	// the photos are deleted with their lists, their keys are read first
+	photos, err := r.dbQueries.ListUserPhotoKeys(ctx, username)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	erased := &ErasedAccount{ListIDs: []string{}, BlobKeys: []string{}}
+	for _, photo := range photos {
+		erased.BlobKeys = append(erased.BlobKeys, photo.BlobKey, photo.ThumbnailKey)
+	}
+
+	err = r.dbQueries.DeleteUserPhotos(ctx, username)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	ids, err := r.dbQueries.DeleteUserLists(ctx, owner)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+	for _, id := range ids {
+		erased.ListIDs = append(erased.ListIDs, id.String())
+	}
+
+	err = r.dbQueries.AnonymizeListsDeletedBy(ctx, db_queries.AnonymizeListsDeletedByParams{
+		Anonymous: pgtype.Text{String: AnonymousUser, Valid: true},
+		Username:  owner,
+	})
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	// the history of the lists of the user goes with them, and their events
+	// that carry the lists
+	err = r.dbQueries.DeleteListsAuditEvents(ctx, erased.ListIDs)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	err = r.dbQueries.AnonymizeAuditEvents(ctx, db_queries.AnonymizeAuditEventsParams{
+		Anonymous: AnonymousUser,
+		Username:  username,
+	})
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	err = r.dbQueries.DeleteListsOutboxEvents(ctx, db_queries.DeleteListsOutboxEventsParams{
+		ListIds:  erased.ListIDs,
+		Username: username,
+	})
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	err = r.dbQueries.AnonymizeAccessLog(ctx, username)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	err = r.dbQueries.DeleteUserRows(ctx, username)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	_, err = r.dbQueries.DeleteAccountDeletion(ctx, username)
+	if err != nil {
+		return nil, dbError(err, msg)
+	}
+
+	return erased, nil
+}
+
+func (r *AccountPostgresRepository) Export(username string) ([]db_queries.ExportUserDataRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ExportUserData(ctx, username)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to export the data of the account: %s", username))
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/account_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/account_repository.go -package repository -destination repository/account_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccountRepository is a mock of AccountRepository interface.
+type MockAccountRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAccountRepositoryMockRecorder is the mock recorder for MockAccountRepository.
+type MockAccountRepositoryMockRecorder struct {
+	mock *MockAccountRepository
+}
+
+// NewMockAccountRepository creates a new mock instance.
+func NewMockAccountRepository(ctrl *gomock.Controller) *MockAccountRepository {
+	mock := &MockAccountRepository{ctrl: ctrl}
+	mock.recorder = &MockAccountRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CancelDeletion mocks base method.
+func (m *MockAccountRepository) CancelDeletion(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockAccountRepositoryMockRecorder) CancelDeletion(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockAccountRepository)(nil).CancelDeletion), username)
+}
+
+// ClaimDueDeletion mocks base method.
+func (m *MockAccountRepository) ClaimDueDeletion() (*db_queries.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueDeletion")
+	ret0, _ := ret[0].(*db_queries.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueDeletion indicates an expected call of ClaimDueDeletion.
+func (mr *MockAccountRepositoryMockRecorder) ClaimDueDeletion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueDeletion", reflect.TypeOf((*MockAccountRepository)(nil).ClaimDueDeletion))
+}
+
+// Erase mocks base method.
+func (m *MockAccountRepository) Erase(username string) (*ErasedAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Erase", username)
+	ret0, _ := ret[0].(*ErasedAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Erase indicates an expected call of Erase.
+func (mr *MockAccountRepositoryMockRecorder) Erase(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Erase", reflect.TypeOf((*MockAccountRepository)(nil).Erase), username)
+}
+
+// Export mocks base method.
+func (m *MockAccountRepository) Export(username string) ([]db_queries.ExportUserDataRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", username)
+	ret0, _ := ret[0].([]db_queries.ExportUserDataRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockAccountRepositoryMockRecorder) Export(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockAccountRepository)(nil).Export), username)
+}
+
+// GetDeletion mocks base method.
+func (m *MockAccountRepository) GetDeletion(username string) (*db_queries.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletion", username)
+	ret0, _ := ret[0].(*db_queries.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletion indicates an expected call of GetDeletion.
+func (mr *MockAccountRepositoryMockRecorder) GetDeletion(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletion", reflect.TypeOf((*MockAccountRepository)(nil).GetDeletion), username)
+}
+
+// RequestDeletion mocks base method.
+func (m *MockAccountRepository) RequestDeletion(username string, eraseAt time.Time) (*db_queries.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestDeletion", username, eraseAt)
+	ret0, _ := ret[0].(*db_queries.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestDeletion indicates an expected call of RequestDeletion.
+func (mr *MockAccountRepositoryMockRecorder) RequestDeletion(username, eraseAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestDeletion", reflect.TypeOf((*MockAccountRepository)(nil).RequestDeletion), username, eraseAt)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+
+	"github.com/rs/zerolog/log"
+)
+
+type AuditRepository interface {
+	Record(event db_queries.InsertAuditEventParams) error
+	// RecordAll copies the events in one round trip
+	RecordAll(events []db_queries.InsertAuditEventsParams) error
+	// Last returns up to limit events of the resource, the last one first
+	Last(resourceType string, resourceID string, limit int32) ([]db_queries.AuditEvent, error)
+}
+
+type AuditPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewAuditRepository(dbQueries *db_queries.Queries) AuditRepository {
+	return &AuditPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *AuditPostgresRepository) Record(event db_queries.InsertAuditEventParams) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.InsertAuditEvent(ctx, event)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to record the audit event %s of %s %s", event.Action, event.ResourceType, event.ResourceID)
+		return errors.New("repository: error to record the audit event")
+	}
+
+	return nil
+}
+
+func (r *AuditPostgresRepository) RecordAll(events []db_queries.InsertAuditEventsParams) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	_, err := r.dbQueries.InsertAuditEvents(ctx, events)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to record %d audit events", len(events))
+		return errors.New("repository: error to record the audit events")
+	}
+
+	return nil
+}
+
+func (r *AuditPostgresRepository) Last(resourceType string, resourceID string, limit int32) ([]db_queries.AuditEvent, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	events, err := r.dbQueries.ListLastAuditEvents(ctx, db_queries.ListLastAuditEventsParams{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Limit:        limit,
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to list the audit events of %s %s", resourceType, resourceID)
+		return nil, errors.New("repository: error to list the audit events")
+	}
+
+	return events, nil
+}
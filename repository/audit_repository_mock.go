@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/audit_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/audit_repository.go -package repository -destination repository/audit_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Last mocks base method.
+func (m *MockAuditRepository) Last(resourceType, resourceID string, limit int32) ([]db_queries.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Last", resourceType, resourceID, limit)
+	ret0, _ := ret[0].([]db_queries.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Last indicates an expected call of Last.
+func (mr *MockAuditRepositoryMockRecorder) Last(resourceType, resourceID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Last", reflect.TypeOf((*MockAuditRepository)(nil).Last), resourceType, resourceID, limit)
+}
+
+// Record mocks base method.
+func (m *MockAuditRepository) Record(event db_queries.InsertAuditEventParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditRepositoryMockRecorder) Record(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditRepository)(nil).Record), event)
+}
+
+// RecordAll mocks base method.
+func (m *MockAuditRepository) RecordAll(events []db_queries.InsertAuditEventsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAll", events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAll indicates an expected call of RecordAll.
+func (mr *MockAuditRepositoryMockRecorder) RecordAll(events any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAll", reflect.TypeOf((*MockAuditRepository)(nil).RecordAll), events)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Deadlines of the repository operations, the statement_timeout of the pool
+// stops the queries in the server and the deadlines stop waiting for them
+// in the client, including the wait for a free connection.
+type Deadlines struct {
+	Read        time.Duration
+	Write       time.Duration
+	Transaction time.Duration // a whole unit of work
+}
+
+var DefaultDeadlines = Deadlines{
+	Read:        3 * time.Second,
+	Write:       5 * time.Second,
+	Transaction: 10 * time.Second,
+}
+
+var deadlines atomic.Pointer[Deadlines]
+
+func init() {
+	SetDeadlines(DefaultDeadlines)
+}
+
+// SetDeadlines replaces the deadlines, the zero values keep the default
+func SetDeadlines(d Deadlines) {
+	if d.Read <= 0 {
+		d.Read = DefaultDeadlines.Read
+	}
+	if d.Write <= 0 {
+		d.Write = DefaultDeadlines.Write
+	}
+	if d.Transaction <= 0 {
+		d.Transaction = DefaultDeadlines.Transaction
+	}
+
+	deadlines.Store(&d)
+}
+
+func CurrentDeadlines() Deadlines {
+	return *deadlines.Load()
+}
+
+func readContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), deadlines.Load().Read)
+}
+
+func writeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), deadlines.Load().Write)
+}
+
+func transactionContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), deadlines.Load().Transaction)
+}
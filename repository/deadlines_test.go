@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDeadlines(t *testing.T) {
+	defer SetDeadlines(DefaultDeadlines)
+
+	SetDeadlines(Deadlines{Read: time.Second})
+	assert.Equal(t, Deadlines{Read: time.Second, Write: DefaultDeadlines.Write, Transaction: DefaultDeadlines.Transaction}, CurrentDeadlines())
+
+	ctx, cancel := readContext()
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// The domain errors of the repositories, the handlers check them with
+// errors.Is to choose the status of the response.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("invalid data")
+)
+
+// dbError returns msg wrapping the domain error that matches the database
+// error, the unexpected errors are logged and returned without the details of
+// the database.
+func dbError(err error, msg string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s: %w", msg, ErrNotFound)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505": // unique_violation
+			return fmt.Errorf("%s: %w", msg, ErrConflict)
+		case pgErr.Code == "23502", // not_null_violation
+			pgErr.Code == "23503",               // foreign_key_violation
+			pgErr.Code == "23514",               // check_violation
+			strings.HasPrefix(pgErr.Code, "22"): // data exceptions
+			log.Warn().Err(err).Msg(msg)
+			return fmt.Errorf("%s: %w", msg, ErrValidation)
+		}
+	}
+
+	log.Err(err).Msg(msg)
+	return errors.New(msg)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBError(t *testing.T) {
+	assert.ErrorIs(t, dbError(pgx.ErrNoRows, "repository: missing"), ErrNotFound)
+	assert.ErrorIs(t, dbError(fmt.Errorf("scan: %w", pgx.ErrNoRows), "repository: missing"), ErrNotFound)
+	assert.ErrorIs(t, dbError(&pgconn.PgError{Code: "23505"}, "repository: taken"), ErrConflict)
+	assert.ErrorIs(t, dbError(&pgconn.PgError{Code: "22001"}, "repository: too long"), ErrValidation)
+
+	err := dbError(&pgconn.PgError{Code: "XX000", Message: "internal error"}, "repository: broken")
+	assert.EqualError(t, err, "repository: broken")
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, dbError(context.DeadlineExceeded, "repository: slow"), ErrNotFound)
+}
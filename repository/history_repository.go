@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+type PurchasedItem struct {
+	Name       string
+	PriceCents int64 // 0 when the price is unknown
+}
+
+type HistoryRepository interface {
+	CompleteShoppingList(list *db_queries.ShoppingList, username string, items []PurchasedItem) (*db_queries.CompleteShoppingListRow, error)
+	GetFrequentItems(username string, limit int32) ([]db_queries.GetFrequentItemsRow, error)
+	GetSpendByMonth(username string, loc *time.Location, since time.Time) ([]db_queries.GetSpendByMonthRow, error)
+	GetListsPerWeek(username string, loc *time.Location, firstDay time.Weekday, since time.Time) ([]db_queries.GetListsPerWeekRow, error)
+	GetListHistorySummary(listID pgtype.UUID) (*db_queries.GetListHistorySummaryRow, error)
+}
+
+type HistoryPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewHistoryRepository(dbQueries *db_queries.Queries) HistoryRepository {
+	return &HistoryPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *HistoryPostgresRepository) CompleteShoppingList(list *db_queries.ShoppingList, username string, items []PurchasedItem) (*db_queries.CompleteShoppingListRow, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	names := make([]string, 0, len(items))
+	prices := make([]int64, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+		prices = append(prices, item.PriceCents)
+	}
+
+	row, err := r.dbQueries.CompleteShoppingList(ctx, db_queries.CompleteShoppingListParams{
+		ListID:   list.ID,
+		ListName: list.Name,
+		Username: username,
+		Items:    names,
+		Prices:   prices,
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to complete the shopping list with id: %s", list.ID.String())
+		return nil, fmt.Errorf("repository: error to complete the shopping list with id: %s", list.ID.String())
+	}
+
+	return &row, nil
+}
+
+func (r *HistoryPostgresRepository) GetFrequentItems(username string, limit int32) ([]db_queries.GetFrequentItemsRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetFrequentItems(ctx, db_queries.GetFrequentItemsParams{
+		Username: username,
+		Limit:    limit,
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the frequent items")
+		return nil, errors.New("repository: error to get the frequent items")
+	}
+
+	return rows, nil
+}
+
+func (r *HistoryPostgresRepository) GetSpendByMonth(username string, loc *time.Location, since time.Time) ([]db_queries.GetSpendByMonthRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetSpendByMonth(ctx, db_queries.GetSpendByMonthParams{
+		Timezone: loc.String(),
+		Username: username,
+		Since:    pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the spend by month")
+		return nil, errors.New("repository: error to get the spend by month")
+	}
+
+	return rows, nil
+}
+
+func (r *HistoryPostgresRepository) GetListsPerWeek(username string, loc *time.Location, firstDay time.Weekday, since time.Time) ([]db_queries.GetListsPerWeekRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetListsPerWeek(ctx, db_queries.GetListsPerWeekParams{
+		Timezone: loc.String(),
+		// postgres weeks start on monday
+		WeekOffset: int32((firstDay + 6) % 7),
+		Username:   username,
+		Since:      pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the lists per week")
+		return nil, errors.New("repository: error to get the lists per week")
+	}
+
+	return rows, nil
+}
+
+func (r *HistoryPostgresRepository) GetListHistorySummary(listID pgtype.UUID) (*db_queries.GetListHistorySummaryRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetListHistorySummary(ctx, listID)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to get the history summary of the list with id: %s", listID.String())
+		return nil, errors.New("repository: error to get the list history summary")
+	}
+
+	return &row, nil
+}
@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/history_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/history_repository.go -package repository -destination repository/history_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+	time "time"
+
+	pgtype "github.com/jackc/pgx/v5/pgtype"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHistoryRepository is a mock of HistoryRepository interface.
+type MockHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryRepositoryMockRecorder is the mock recorder for MockHistoryRepository.
+type MockHistoryRepositoryMockRecorder struct {
+	mock *MockHistoryRepository
+}
+
+// NewMockHistoryRepository creates a new mock instance.
+func NewMockHistoryRepository(ctrl *gomock.Controller) *MockHistoryRepository {
+	mock := &MockHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryRepository) EXPECT() *MockHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CompleteShoppingList mocks base method.
+func (m *MockHistoryRepository) CompleteShoppingList(list *db_queries.ShoppingList, username string, items []PurchasedItem) (*db_queries.CompleteShoppingListRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteShoppingList", list, username, items)
+	ret0, _ := ret[0].(*db_queries.CompleteShoppingListRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteShoppingList indicates an expected call of CompleteShoppingList.
+func (mr *MockHistoryRepositoryMockRecorder) CompleteShoppingList(list, username, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteShoppingList", reflect.TypeOf((*MockHistoryRepository)(nil).CompleteShoppingList), list, username, items)
+}
+
+// GetFrequentItems mocks base method.
+func (m *MockHistoryRepository) GetFrequentItems(username string, limit int32) ([]db_queries.GetFrequentItemsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFrequentItems", username, limit)
+	ret0, _ := ret[0].([]db_queries.GetFrequentItemsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFrequentItems indicates an expected call of GetFrequentItems.
+func (mr *MockHistoryRepositoryMockRecorder) GetFrequentItems(username, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFrequentItems", reflect.TypeOf((*MockHistoryRepository)(nil).GetFrequentItems), username, limit)
+}
+
+// GetListHistorySummary mocks base method.
+func (m *MockHistoryRepository) GetListHistorySummary(listID pgtype.UUID) (*db_queries.GetListHistorySummaryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListHistorySummary", listID)
+	ret0, _ := ret[0].(*db_queries.GetListHistorySummaryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListHistorySummary indicates an expected call of GetListHistorySummary.
+func (mr *MockHistoryRepositoryMockRecorder) GetListHistorySummary(listID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListHistorySummary", reflect.TypeOf((*MockHistoryRepository)(nil).GetListHistorySummary), listID)
+}
+
+// GetListsPerWeek mocks base method.
+func (m *MockHistoryRepository) GetListsPerWeek(username string, loc *time.Location, firstDay time.Weekday, since time.Time) ([]db_queries.GetListsPerWeekRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListsPerWeek", username, loc, firstDay, since)
+	ret0, _ := ret[0].([]db_queries.GetListsPerWeekRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListsPerWeek indicates an expected call of GetListsPerWeek.
+func (mr *MockHistoryRepositoryMockRecorder) GetListsPerWeek(username, loc, firstDay, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListsPerWeek", reflect.TypeOf((*MockHistoryRepository)(nil).GetListsPerWeek), username, loc, firstDay, since)
+}
+
+// GetSpendByMonth mocks base method.
+func (m *MockHistoryRepository) GetSpendByMonth(username string, loc *time.Location, since time.Time) ([]db_queries.GetSpendByMonthRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpendByMonth", username, loc, since)
+	ret0, _ := ret[0].([]db_queries.GetSpendByMonthRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpendByMonth indicates an expected call of GetSpendByMonth.
+func (mr *MockHistoryRepositoryMockRecorder) GetSpendByMonth(username, loc, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpendByMonth", reflect.TypeOf((*MockHistoryRepository)(nil).GetSpendByMonth), username, loc, since)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type ItemRepository interface {
+	SuggestItems(username string, query string, limit int32) ([]db_queries.SuggestItemsRow, error)
+}
+
+type ItemPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewItemRepository(dbQueries *db_queries.Queries) ItemRepository {
+	return &ItemPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+// the query is used in a LIKE prefix match so the wildcards must be escaped
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *ItemPostgresRepository) SuggestItems(username string, query string, limit int32) ([]db_queries.SuggestItemsRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.SuggestItems(ctx, db_queries.SuggestItemsParams{
+		Query:      likeEscaper.Replace(strings.ToLower(query)),
+		MaxResults: limit,
+		Username:   username,
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to suggest items for '%s'", query)
+		return nil, errors.New("repository: error to suggest items")
+	}
+
+	return rows, nil
+}
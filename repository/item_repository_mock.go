@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/item_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/item_repository.go -package repository -destination repository/item_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockItemRepository is a mock of ItemRepository interface.
+type MockItemRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockItemRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockItemRepositoryMockRecorder is the mock recorder for MockItemRepository.
+type MockItemRepositoryMockRecorder struct {
+	mock *MockItemRepository
+}
+
+// NewMockItemRepository creates a new mock instance.
+func NewMockItemRepository(ctrl *gomock.Controller) *MockItemRepository {
+	mock := &MockItemRepository{ctrl: ctrl}
+	mock.recorder = &MockItemRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockItemRepository) EXPECT() *MockItemRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SuggestItems mocks base method.
+func (m *MockItemRepository) SuggestItems(username, query string, limit int32) ([]db_queries.SuggestItemsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestItems", username, query, limit)
+	ret0, _ := ret[0].([]db_queries.SuggestItemsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestItems indicates an expected call of SuggestItems.
+func (mr *MockItemRepositoryMockRecorder) SuggestItems(username, query, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestItems", reflect.TypeOf((*MockItemRepository)(nil).SuggestItems), username, query, limit)
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// NotificationRepository keeps the notification channels of the users and
+// the notifications waiting for their digest
+type NotificationRepository interface {
+	GetPreferences(username string) (*db_queries.NotificationPreference, error)
+	SavePreferences(prefs db_queries.SaveNotificationPreferencesParams) (*db_queries.NotificationPreference, error)
+	// Queue adds the notification to the next digest of the user, a second
+	// call with the same event does nothing
+	Queue(notification db_queries.QueueNotificationParams) error
+	// DueDigests returns the users with a notification queued before
+	// hourlyBefore or dailyBefore, depending on their digest
+	DueDigests(hourlyBefore time.Time, dailyBefore time.Time) ([]string, error)
+	// Pending returns the queued notifications of the user, the oldest first
+	Pending(username string) ([]db_queries.PendingNotification, error)
+	// DeletePending deletes the queued notifications of the user up to the id
+	DeletePending(username string, upTo int64) error
+}
+
+// DefaultNotificationPreferences are used for the users that never saved
+// their preferences, every channel is disabled.
+func DefaultNotificationPreferences(username string) *db_queries.NotificationPreference {
+	return &db_queries.NotificationPreference{
+		Username: username,
+		Digest:   "off",
+	}
+}
+
+type NotificationPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewNotificationRepository(dbQueries *db_queries.Queries) NotificationRepository {
+	return &NotificationPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *NotificationPostgresRepository) GetPreferences(username string) (*db_queries.NotificationPreference, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetNotificationPreferences(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultNotificationPreferences(username), nil
+	}
+
+	if err != nil {
+		log.Err(err).Msgf("repository: error to get the notification preferences of the user: %s", username)
+		return nil, errors.New("repository: error to get the notification preferences")
+	}
+
+	return &row, nil
+}
+
+func (r *NotificationPostgresRepository) SavePreferences(prefs db_queries.SaveNotificationPreferencesParams) (*db_queries.NotificationPreference, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.SaveNotificationPreferences(ctx, prefs)
+	if err != nil {
+		return nil, dbError(err, "repository: error to save the notification preferences")
+	}
+
+	return &row, nil
+}
+
+func (r *NotificationPostgresRepository) Queue(notification db_queries.QueueNotificationParams) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.QueueNotification(ctx, notification)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to queue a notification of the user: %s", notification.Username)
+		return errors.New("repository: error to queue the notification")
+	}
+
+	return nil
+}
+
+func (r *NotificationPostgresRepository) DueDigests(hourlyBefore time.Time, dailyBefore time.Time) ([]string, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	usernames, err := r.dbQueries.ListDueDigests(ctx, db_queries.ListDueDigestsParams{
+		DailyBefore:  pgtype.Timestamptz{Time: dailyBefore, Valid: true},
+		HourlyBefore: pgtype.Timestamptz{Time: hourlyBefore, Valid: true},
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to list the due digests")
+		return nil, errors.New("repository: error to list the due digests")
+	}
+
+	return usernames, nil
+}
+
+func (r *NotificationPostgresRepository) Pending(username string) ([]db_queries.PendingNotification, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ListPendingNotifications(ctx, username)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to list the pending notifications of the user: %s", username)
+		return nil, errors.New("repository: error to list the pending notifications")
+	}
+
+	return rows, nil
+}
+
+func (r *NotificationPostgresRepository) DeletePending(username string, upTo int64) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.DeletePendingNotifications(ctx, db_queries.DeletePendingNotificationsParams{
+		Username: username,
+		ID:       upTo,
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to delete the pending notifications of the user: %s", username)
+		return errors.New("repository: error to delete the pending notifications")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/notification_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/notification_repository.go -package repository -destination repository/notification_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationRepository is a mock of NotificationRepository interface.
+type MockNotificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationRepositoryMockRecorder is the mock recorder for MockNotificationRepository.
+type MockNotificationRepositoryMockRecorder struct {
+	mock *MockNotificationRepository
+}
+
+// NewMockNotificationRepository creates a new mock instance.
+func NewMockNotificationRepository(ctrl *gomock.Controller) *MockNotificationRepository {
+	mock := &MockNotificationRepository{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepository) EXPECT() *MockNotificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeletePending mocks base method.
+func (m *MockNotificationRepository) DeletePending(username string, upTo int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePending", username, upTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePending indicates an expected call of DeletePending.
+func (mr *MockNotificationRepositoryMockRecorder) DeletePending(username, upTo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePending", reflect.TypeOf((*MockNotificationRepository)(nil).DeletePending), username, upTo)
+}
+
+// DueDigests mocks base method.
+func (m *MockNotificationRepository) DueDigests(hourlyBefore, dailyBefore time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DueDigests", hourlyBefore, dailyBefore)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DueDigests indicates an expected call of DueDigests.
+func (mr *MockNotificationRepositoryMockRecorder) DueDigests(hourlyBefore, dailyBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DueDigests", reflect.TypeOf((*MockNotificationRepository)(nil).DueDigests), hourlyBefore, dailyBefore)
+}
+
+// GetPreferences mocks base method.
+func (m *MockNotificationRepository) GetPreferences(username string) (*db_queries.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferences", username)
+	ret0, _ := ret[0].(*db_queries.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferences indicates an expected call of GetPreferences.
+func (mr *MockNotificationRepositoryMockRecorder) GetPreferences(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferences", reflect.TypeOf((*MockNotificationRepository)(nil).GetPreferences), username)
+}
+
+// Pending mocks base method.
+func (m *MockNotificationRepository) Pending(username string) ([]db_queries.PendingNotification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pending", username)
+	ret0, _ := ret[0].([]db_queries.PendingNotification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pending indicates an expected call of Pending.
+func (mr *MockNotificationRepositoryMockRecorder) Pending(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockNotificationRepository)(nil).Pending), username)
+}
+
+// Queue mocks base method.
+func (m *MockNotificationRepository) Queue(notification db_queries.QueueNotificationParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Queue", notification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Queue indicates an expected call of Queue.
+func (mr *MockNotificationRepositoryMockRecorder) Queue(notification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Queue", reflect.TypeOf((*MockNotificationRepository)(nil).Queue), notification)
+}
+
+// SavePreferences mocks base method.
+func (m *MockNotificationRepository) SavePreferences(prefs db_queries.SaveNotificationPreferencesParams) (*db_queries.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePreferences", prefs)
+	ret0, _ := ret[0].(*db_queries.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePreferences indicates an expected call of SavePreferences.
+func (mr *MockNotificationRepositoryMockRecorder) SavePreferences(prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePreferences", reflect.TypeOf((*MockNotificationRepository)(nil).SavePreferences), prefs)
+}
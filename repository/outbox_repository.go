@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// OutboxRepository stores the events that must be delivered to other systems
+// (webhooks, search, ...). Enqueue them with the repositories of a unit of
+// work so they are only stored if the change that produced them is committed.
+type OutboxRepository interface {
+	Enqueue(event db_queries.EnqueueOutboxEventParams) error
+	// EnqueueAll copies the events in one round trip
+	EnqueueAll(events []db_queries.EnqueueOutboxEventsParams) error
+	// ClaimPending returns up to limit events that are ready to be
+	// delivered, nobody else can claim them until the lease expires
+	ClaimPending(limit int32, lease time.Duration) ([]db_queries.OutboxEvent, error)
+	MarkDelivered(id int64) error
+	MarkFailed(id int64, cause string, retryAt time.Time) error
+}
+
+type OutboxPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewOutboxRepository(dbQueries *db_queries.Queries) OutboxRepository {
+	return &OutboxPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *OutboxPostgresRepository) Enqueue(event db_queries.EnqueueOutboxEventParams) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.EnqueueOutboxEvent(ctx, event)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to enqueue the outbox event %s of %s", event.EventType, event.AggregateID)
+		return errors.New("repository: error to enqueue the outbox event")
+	}
+
+	return nil
+}
+
+func (r *OutboxPostgresRepository) EnqueueAll(events []db_queries.EnqueueOutboxEventsParams) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	_, err := r.dbQueries.EnqueueOutboxEvents(ctx, events)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to enqueue %d outbox events", len(events))
+		return errors.New("repository: error to enqueue the outbox events")
+	}
+
+	return nil
+}
+
+func (r *OutboxPostgresRepository) ClaimPending(limit int32, lease time.Duration) ([]db_queries.OutboxEvent, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	events, err := r.dbQueries.ClaimOutboxEvents(ctx, db_queries.ClaimOutboxEventsParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(lease), Valid: true},
+		BatchSize:  limit,
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to claim the pending outbox events")
+		return nil, errors.New("repository: error to claim the outbox events")
+	}
+
+	return events, nil
+}
+
+func (r *OutboxPostgresRepository) MarkDelivered(id int64) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.MarkOutboxEventDelivered(ctx, id)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to mark the outbox event %d as delivered", id)
+		return errors.New("repository: error to mark the outbox event as delivered")
+	}
+
+	return nil
+}
+
+func (r *OutboxPostgresRepository) MarkFailed(id int64, cause string, retryAt time.Time) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.MarkOutboxEventFailed(ctx, db_queries.MarkOutboxEventFailedParams{
+		ID:        id,
+		LastError: pgtype.Text{String: cause, Valid: true},
+		RetryAt:   pgtype.Timestamptz{Time: retryAt, Valid: true},
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to mark the outbox event %d as failed", id)
+		return errors.New("repository: error to mark the outbox event as failed")
+	}
+
+	return nil
+}
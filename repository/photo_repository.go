@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+)
+
+// NewItemPhoto is a photo of an item whose files were written to the blob
+// store
+type NewItemPhoto struct {
+	ListID       string
+	Item         string
+	ContentType  string
+	SizeBytes    int64
+	BlobKey      string
+	ThumbnailKey string
+	UploadedBy   string
+}
+
+// PhotoRepository has the photos of the items, one per item of a list
+type PhotoRepository interface {
+	// SavePhoto replaces the photo of the item
+	SavePhoto(photo NewItemPhoto) (*db_queries.ItemPhoto, error)
+	GetPhoto(listID string, item string) (*db_queries.ItemPhoto, error)
+	GetPhotoByID(id string) (*db_queries.ItemPhoto, error)
+	// DeletePhoto returns the deleted photo so its files can be deleted
+	DeletePhoto(listID string, item string) (*db_queries.ItemPhoto, error)
+}
+
+type PhotoPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewPhotoRepository(dbQueries *db_queries.Queries) PhotoRepository {
+	return &PhotoPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *PhotoPostgresRepository) SavePhoto(photo NewItemPhoto) (*db_queries.ItemPhoto, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(photo.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.SaveItemPhoto(ctx, db_queries.SaveItemPhotoParams{
+		ListID:       uid,
+		Item:         photo.Item,
+		ContentType:  photo.ContentType,
+		SizeBytes:    photo.SizeBytes,
+		BlobKey:      photo.BlobKey,
+		ThumbnailKey: photo.ThumbnailKey,
+		UploadedBy:   photo.UploadedBy,
+	})
+	if err != nil {
+		// a foreign key violation when the list doesn't exist
+		return nil, dbError(err, fmt.Sprintf("repository: error to save the photo of an item of the list: %s", photo.ListID))
+	}
+
+	return &row, nil
+}
+
+func (r *PhotoPostgresRepository) GetPhoto(listID string, item string) (*db_queries.ItemPhoto, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.GetItemPhoto(ctx, db_queries.GetItemPhotoParams{ListID: uid, Item: item})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the photo of an item of the list: %s", listID))
+	}
+
+	return &row, nil
+}
+
+func (r *PhotoPostgresRepository) GetPhotoByID(id string) (*db_queries.ItemPhoto, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.GetItemPhotoByID(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the photo with id: %s", id))
+	}
+
+	return &row, nil
+}
+
+func (r *PhotoPostgresRepository) DeletePhoto(listID string, item string) (*db_queries.ItemPhoto, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.DeleteItemPhoto(ctx, db_queries.DeleteItemPhotoParams{ListID: uid, Item: item})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to delete the photo of an item of the list: %s", listID))
+	}
+
+	return &row, nil
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/photo_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/photo_repository.go -package repository -destination repository/photo_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPhotoRepository is a mock of PhotoRepository interface.
+type MockPhotoRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPhotoRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPhotoRepositoryMockRecorder is the mock recorder for MockPhotoRepository.
+type MockPhotoRepositoryMockRecorder struct {
+	mock *MockPhotoRepository
+}
+
+// NewMockPhotoRepository creates a new mock instance.
+func NewMockPhotoRepository(ctrl *gomock.Controller) *MockPhotoRepository {
+	mock := &MockPhotoRepository{ctrl: ctrl}
+	mock.recorder = &MockPhotoRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPhotoRepository) EXPECT() *MockPhotoRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeletePhoto mocks base method.
+func (m *MockPhotoRepository) DeletePhoto(listID, item string) (*db_queries.ItemPhoto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePhoto", listID, item)
+	ret0, _ := ret[0].(*db_queries.ItemPhoto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePhoto indicates an expected call of DeletePhoto.
+func (mr *MockPhotoRepositoryMockRecorder) DeletePhoto(listID, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePhoto", reflect.TypeOf((*MockPhotoRepository)(nil).DeletePhoto), listID, item)
+}
+
+// GetPhoto mocks base method.
+func (m *MockPhotoRepository) GetPhoto(listID, item string) (*db_queries.ItemPhoto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPhoto", listID, item)
+	ret0, _ := ret[0].(*db_queries.ItemPhoto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPhoto indicates an expected call of GetPhoto.
+func (mr *MockPhotoRepositoryMockRecorder) GetPhoto(listID, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhoto", reflect.TypeOf((*MockPhotoRepository)(nil).GetPhoto), listID, item)
+}
+
+// GetPhotoByID mocks base method.
+func (m *MockPhotoRepository) GetPhotoByID(id string) (*db_queries.ItemPhoto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPhotoByID", id)
+	ret0, _ := ret[0].(*db_queries.ItemPhoto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPhotoByID indicates an expected call of GetPhotoByID.
+func (mr *MockPhotoRepositoryMockRecorder) GetPhotoByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhotoByID", reflect.TypeOf((*MockPhotoRepository)(nil).GetPhotoByID), id)
+}
+
+// SavePhoto mocks base method.
+func (m *MockPhotoRepository) SavePhoto(photo NewItemPhoto) (*db_queries.ItemPhoto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePhoto", photo)
+	ret0, _ := ret[0].(*db_queries.ItemPhoto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePhoto indicates an expected call of SavePhoto.
+func (mr *MockPhotoRepositoryMockRecorder) SavePhoto(photo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePhoto", reflect.TypeOf((*MockPhotoRepository)(nil).SavePhoto), photo)
+}
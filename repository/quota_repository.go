@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// the scopes of the quotas, the quota of a user overrides the one of its
+// tenant
+const (
+	QuotaScopeUser   = "user"
+	QuotaScopeTenant = "tenant"
+)
+
+// QuotaLimits are the limits of a quota, nil takes the limit of the wider
+// scope
+type QuotaLimits struct {
+	MaxLists          *int
+	MaxItems          *int
+	RequestsPerMinute *int
+}
+
+// QuotaRepository has the quotas the admins set to the users and the
+// tenants, the defaults are in the config
+type QuotaRepository interface {
+	// GetQuotas returns the quotas of the user and of its tenant
+	GetQuotas(username string, tenantID string) ([]db_queries.Quota, error)
+	ListQuotas() ([]db_queries.Quota, error)
+	SetQuota(scope string, subject string, limits QuotaLimits) (*db_queries.Quota, error)
+	// DeleteQuota returns ErrNotFound when the subject has no quota
+	DeleteQuota(scope string, subject string) error
+}
+
+type QuotaPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewQuotaRepository(dbQueries *db_queries.Queries) QuotaRepository {
+	return &QuotaPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *QuotaPostgresRepository) GetQuotas(username string, tenantID string) ([]db_queries.Quota, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetQuotas(ctx, db_queries.GetQuotasParams{
+		Username: username,
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the quotas of the user: %s", username))
+	}
+
+	return rows, nil
+}
+
+func (r *QuotaPostgresRepository) ListQuotas() ([]db_queries.Quota, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ListQuotas(ctx)
+	if err != nil {
+		return nil, dbError(err, "repository: error to list the quotas")
+	}
+
+	return rows, nil
+}
+
+func (r *QuotaPostgresRepository) SetQuota(scope string, subject string, limits QuotaLimits) (*db_queries.Quota, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.SetQuota(ctx, db_queries.SetQuotaParams{
+		Scope:             scope,
+		Subject:           subject,
+		MaxLists:          quotaLimit(limits.MaxLists),
+		MaxItems:          quotaLimit(limits.MaxItems),
+		RequestsPerMinute: quotaLimit(limits.RequestsPerMinute),
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to set the quota of the %s %s", scope, subject))
+	}
+
+	return &row, nil
+}
+
+func (r *QuotaPostgresRepository) DeleteQuota(scope string, subject string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	deleted, err := r.dbQueries.DeleteQuota(ctx, db_queries.DeleteQuotaParams{
+		Scope:   scope,
+		Subject: subject,
+	})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to delete the quota of the %s %s", scope, subject))
+	}
+	if deleted == 0 {
+		return fmt.Errorf("the %s %s has no quota: %w", scope, subject, ErrNotFound)
+	}
+
+	return nil
+}
+
+func quotaLimit(limit *int) pgtype.Int4 {
+	if limit == nil {
+		return pgtype.Int4{}
+	}
+
+	return pgtype.Int4{Int32: int32(*limit), Valid: true}
+}
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/quota_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/quota_repository.go -package repository -destination repository/quota_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQuotaRepository is a mock of QuotaRepository interface.
+type MockQuotaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockQuotaRepositoryMockRecorder is the mock recorder for MockQuotaRepository.
+type MockQuotaRepositoryMockRecorder struct {
+	mock *MockQuotaRepository
+}
+
+// NewMockQuotaRepository creates a new mock instance.
+func NewMockQuotaRepository(ctrl *gomock.Controller) *MockQuotaRepository {
+	mock := &MockQuotaRepository{ctrl: ctrl}
+	mock.recorder = &MockQuotaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaRepository) EXPECT() *MockQuotaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteQuota mocks base method.
+func (m *MockQuotaRepository) DeleteQuota(scope, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQuota", scope, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQuota indicates an expected call of DeleteQuota.
+func (mr *MockQuotaRepositoryMockRecorder) DeleteQuota(scope, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuota", reflect.TypeOf((*MockQuotaRepository)(nil).DeleteQuota), scope, subject)
+}
+
+// GetQuotas mocks base method.
+func (m *MockQuotaRepository) GetQuotas(username, tenantID string) ([]db_queries.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotas", username, tenantID)
+	ret0, _ := ret[0].([]db_queries.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotas indicates an expected call of GetQuotas.
+func (mr *MockQuotaRepositoryMockRecorder) GetQuotas(username, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotas", reflect.TypeOf((*MockQuotaRepository)(nil).GetQuotas), username, tenantID)
+}
+
+// ListQuotas mocks base method.
+func (m *MockQuotaRepository) ListQuotas() ([]db_queries.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQuotas")
+	ret0, _ := ret[0].([]db_queries.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuotas indicates an expected call of ListQuotas.
+func (mr *MockQuotaRepositoryMockRecorder) ListQuotas() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQuotas", reflect.TypeOf((*MockQuotaRepository)(nil).ListQuotas))
+}
+
+// SetQuota mocks base method.
+func (m *MockQuotaRepository) SetQuota(scope, subject string, limits QuotaLimits) (*db_queries.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQuota", scope, subject, limits)
+	ret0, _ := ret[0].(*db_queries.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetQuota indicates an expected call of SetQuota.
+func (mr *MockQuotaRepositoryMockRecorder) SetQuota(scope, subject, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQuota", reflect.TypeOf((*MockQuotaRepository)(nil).SetQuota), scope, subject, limits)
+}
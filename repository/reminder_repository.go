@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// ReminderRepository keeps the reminders of the lists until the scheduler
+// delivers them
+type ReminderRepository interface {
+	Create(listID string, username string, remindAt time.Time, note string) (*db_queries.Reminder, error)
+	// List returns the reminders of the user in the list, the delivered
+	// ones too
+	List(listID string, username string) ([]db_queries.Reminder, error)
+	// Cancel deletes a reminder of the user not delivered yet, ErrNotFound
+	// otherwise
+	Cancel(listID string, username string, id string) error
+	// ClaimDue returns up to limit reminders that are due, nobody else can
+	// claim them until the lease expires
+	ClaimDue(limit int32, lease time.Duration) ([]db_queries.Reminder, error)
+	MarkDelivered(id pgtype.UUID) error
+}
+
+type ReminderPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewReminderRepository(dbQueries *db_queries.Queries) ReminderRepository {
+	return &ReminderPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *ReminderPostgresRepository) Create(listID string, username string, remindAt time.Time, note string) (*db_queries.Reminder, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.CreateReminder(ctx, db_queries.CreateReminderParams{
+		ListID:   uid,
+		Username: username,
+		RemindAt: pgtype.Timestamptz{Time: remindAt, Valid: true},
+		Note:     note,
+	})
+	if err != nil {
+		// a foreign key violation when the list doesn't exist
+		return nil, dbError(err, fmt.Sprintf("repository: error to create a reminder of the list: %s", listID))
+	}
+
+	return &row, nil
+}
+
+func (r *ReminderPostgresRepository) List(listID string, username string) ([]db_queries.Reminder, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbQueries.ListRemindersByList(ctx, db_queries.ListRemindersByListParams{ListID: uid, Username: username})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to list the reminders of the list: %s", listID))
+	}
+
+	return rows, nil
+}
+
+func (r *ReminderPostgresRepository) Cancel(listID string, username string, id string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	listUID, err := convertStringToUUID(listID)
+	if err != nil {
+		return err
+	}
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := r.dbQueries.DeletePendingReminder(ctx, db_queries.DeletePendingReminderParams{
+		ID:       uid,
+		ListID:   listUID,
+		Username: username,
+	})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to cancel the reminder with id: %s", id))
+	}
+
+	// the reminder doesn't exist, it's of another list or user or it was
+	// delivered
+	if deleted == 0 {
+		return fmt.Errorf("repository: the reminder with id %s can't be canceled: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *ReminderPostgresRepository) ClaimDue(limit int32, lease time.Duration) ([]db_queries.Reminder, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ClaimDueReminders(ctx, db_queries.ClaimDueRemindersParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(lease), Valid: true},
+		BatchSize:  limit,
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to claim the due reminders")
+		return nil, errors.New("repository: error to claim the due reminders")
+	}
+
+	return rows, nil
+}
+
+func (r *ReminderPostgresRepository) MarkDelivered(id pgtype.UUID) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.dbQueries.MarkReminderDelivered(ctx, id)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to mark the reminder %s as delivered", id.String())
+		return errors.New("repository: error to mark the reminder as delivered")
+	}
+
+	return nil
+}
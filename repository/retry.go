@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	db_queries "shopping/database/queries"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// RetryStats are published in /debug/vars as db_retries:
+// retries is every new attempt, recovered the calls that succeeded after
+// retrying, exhausted the calls that failed after the last attempt and
+// budget_exhausted the retries that were skipped by the budget.
+var RetryStats = expvar.NewMap("db_retries")
+
+type RetryOptions struct {
+	MaxAttempts int // including the first one
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// BudgetRatio is the share of the calls that can be retried, so an
+	// outage doesn't multiply the load of the database
+	BudgetRatio float64
+}
+
+// Retrier retries the calls that failed with a transient error (serialization
+// failures, deadlocks, failovers and connection errors before the query was
+// sent) with an exponential backoff with full jitter.
+type Retrier struct {
+	opts RetryOptions
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+const maxRetryTokens = 10
+
+func NewRetrier(opts RetryOptions) *Retrier {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 50 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = time.Second
+	}
+	if opts.BudgetRatio <= 0 {
+		opts.BudgetRatio = 0.2
+	}
+
+	return &Retrier{
+		opts:   opts,
+		tokens: maxRetryTokens,
+	}
+}
+
+// Do calls fn until it succeeds, fails with a permanent error, runs out of
+// attempts or the retry budget is exhausted.
+func (r *Retrier) Do(ctx context.Context, fn func() error) error {
+	return r.do(ctx, fn, IsTransient)
+}
+
+func (r *Retrier) do(ctx context.Context, fn func() error, retryable func(err error) bool) error {
+	r.deposit()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				RetryStats.Add("recovered", 1)
+			}
+			return nil
+		}
+
+		if !retryable(err) {
+			return err
+		}
+
+		if attempt >= r.opts.MaxAttempts {
+			RetryStats.Add("exhausted", 1)
+			return err
+		}
+
+		if !r.withdraw() {
+			RetryStats.Add("budget_exhausted", 1)
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		log.Warn().Err(err).Msgf("repository: transient database error, retrying in %s (attempt %d of %d)", delay, attempt+1, r.opts.MaxAttempts)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		RetryStats.Add("retries", 1)
+	}
+}
+
+// backoff returns a random wait between 0 and BaseDelay * 2^(attempt-1),
+// capped by MaxDelay
+func (r *Retrier) backoff(attempt int) time.Duration {
+	ceiling := r.opts.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.opts.MaxDelay {
+		ceiling = r.opts.MaxDelay
+	}
+
+	return rand.N(ceiling) + 1
+}
+
+// every call earns a fraction of a retry and every retry spends a whole one
+func (r *Retrier) deposit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = min(r.tokens+r.opts.BudgetRatio, maxRetryTokens)
+}
+
+func (r *Retrier) withdraw() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tokens < 1 {
+		return false
+	}
+
+	r.tokens--
+	return true
+}
+
+// IsTransient reports if the statement can be run again: the server rejected
+// it without applying it, or it was never sent.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"53300", // too_many_connections
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+
+		// connection exceptions
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	return pgconn.SafeToRetry(err)
+}
+
+// WithRetry wraps the connection pool so every statement run through the
+// queries is retried, it must not wrap a transaction: a failed statement
+// aborts the transaction and the whole unit of work must be retried instead.
+func WithRetry(db db_queries.DBTX, retrier *Retrier) db_queries.DBTX {
+	return &retryDB{db: db, retrier: retrier}
+}
+
+type retryDB struct {
+	db      db_queries.DBTX
+	retrier *Retrier
+}
+
+func (r *retryDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := r.retrier.Do(ctx, func() error {
+		var err error
+		tag, err = r.db.Exec(ctx, sql, args...)
+		return err
+	})
+
+	return tag, err
+}
+
+func (r *retryDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	var rows pgx.Rows
+	err := r.retrier.Do(ctx, func() error {
+		var err error
+		rows, err = r.db.Query(ctx, sql, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// QueryRow runs the query when the row is scanned, so the retries happen in
+// Scan
+func (r *retryDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return &retryRow{ctx: ctx, sql: sql, args: args, db: r.db, retrier: r.retrier}
+}
+
+// CopyFrom isn't retried, the rows of the source are consumed by the first
+// attempt
+func (r *retryDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return r.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch isn't retried, its errors are only known when the results are
+// read
+func (r *retryDB) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return r.db.SendBatch(ctx, batch)
+}
+
+type retryRow struct {
+	ctx     context.Context
+	sql     string
+	args    []interface{}
+	db      db_queries.DBTX
+	retrier *Retrier
+}
+
+func (r *retryRow) Scan(dest ...any) error {
+	return r.retrier.Do(r.ctx, func() error {
+		return r.db.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
+
+// transientObserver remembers if a statement of a transaction failed with a
+// transient error, the repositories hide the database errors from the unit
+// of work.
+type transientObserver struct {
+	db        db_queries.DBTX
+	mu        sync.Mutex
+	transient bool
+}
+
+func (o *transientObserver) observe(err error) error {
+	if IsTransient(err) {
+		o.mu.Lock()
+		o.transient = true
+		o.mu.Unlock()
+	}
+
+	return err
+}
+
+func (o *transientObserver) sawTransient() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.transient
+}
+
+func (o *transientObserver) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := o.db.Exec(ctx, sql, args...)
+	return tag, o.observe(err)
+}
+
+func (o *transientObserver) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := o.db.Query(ctx, sql, args...)
+	return rows, o.observe(err)
+}
+
+func (o *transientObserver) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return &observedRow{row: o.db.QueryRow(ctx, sql, args...), observer: o}
+}
+
+func (o *transientObserver) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	n, err := o.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return n, o.observe(err)
+}
+
+func (o *transientObserver) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return &observedBatch{results: o.db.SendBatch(ctx, batch), observer: o}
+}
+
+type observedBatch struct {
+	results  pgx.BatchResults
+	observer *transientObserver
+}
+
+func (b *observedBatch) Exec() (pgconn.CommandTag, error) {
+	tag, err := b.results.Exec()
+	return tag, b.observer.observe(err)
+}
+
+func (b *observedBatch) Query() (pgx.Rows, error) {
+	rows, err := b.results.Query()
+	return rows, b.observer.observe(err)
+}
+
+func (b *observedBatch) QueryRow() pgx.Row {
+	return &observedRow{row: b.results.QueryRow(), observer: b.observer}
+}
+
+func (b *observedBatch) Close() error {
+	return b.observer.observe(b.results.Close())
+}
+
+type observedRow struct {
+	row      pgx.Row
+	observer *transientObserver
+}
+
+func (r *observedRow) Scan(dest ...any) error {
+	return r.observer.observe(r.row.Scan(dest...))
+}
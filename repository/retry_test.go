@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db_queries "shopping/database/queries"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var serializationFailure = &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(serializationFailure))
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "08006"})))
+	assert.False(t, IsTransient(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsTransient(context.DeadlineExceeded))
+	assert.False(t, IsTransient(errors.New("connection reset by peer")))
+}
+
+func TestRetrierDo(t *testing.T) {
+	retrier := NewRetrier(RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	t.Run("retries the transient errors", func(t *testing.T) {
+		calls := 0
+		err := retrier.Do(context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return serializationFailure
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		err := retrier.Do(context.Background(), func() error {
+			calls++
+			return serializationFailure
+		})
+
+		assert.ErrorIs(t, err, serializationFailure)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("doesn't retry the permanent errors", func(t *testing.T) {
+		calls := 0
+		err := retrier.Do(context.Background(), func() error {
+			calls++
+			return &pgconn.PgError{Code: "23505"}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	retrier := NewRetrier(RetryOptions{MaxAttempts: 2, BaseDelay: time.Millisecond, BudgetRatio: 0.1})
+
+	calls := 0
+	for range 20 {
+		_ = retrier.Do(context.Background(), func() error {
+			calls++
+			return serializationFailure
+		})
+	}
+
+	// the initial budget and what the 20 calls earned, not a retry per call
+	assert.Less(t, calls, 40)
+	assert.Greater(t, calls, 20)
+}
+
+func TestUnitOfWorkRetriesTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := NewMockTxBeginner(ctrl)
+
+	failed := &fakeTx{execErr: serializationFailure}
+	ok := &fakeTx{}
+	gomock.InOrder(
+		db.EXPECT().Begin(gomock.Any()).Return(failed, nil),
+		db.EXPECT().Begin(gomock.Any()).Return(ok, nil),
+	)
+
+	uow := NewUnitOfWork(db, NewRetrier(RetryOptions{BaseDelay: time.Millisecond}))
+
+	calls := 0
+	err := uow.Do(func(repos Repositories) error {
+		calls++
+		return repos.Audit.Record(db_queries.InsertAuditEventParams{Actor: "user", Action: "list.updated"})
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.False(t, failed.committed)
+	assert.True(t, ok.committed)
+}
+
+// fakeTx is a transaction whose statements fail with execErr
+type fakeTx struct {
+	pgx.Tx
+	execErr   error
+	committed bool
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, tx.execErr
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	return nil
+}
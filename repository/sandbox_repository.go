@@ -0,0 +1,40 @@
+package repository
+
+import (
+	db_queries "shopping/database/queries"
+)
+
+// SandboxRepository wipes the data of the users, it's only used by the
+// sandbox deployments.
+type SandboxRepository interface {
+	// Reset deletes every list, session, preference and history entry, it
+	// returns the number of lists deleted
+	Reset() (int64, error)
+}
+
+type SandboxPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewSandboxRepository(dbQueries *db_queries.Queries) SandboxRepository {
+	return &SandboxPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *SandboxPostgresRepository) Reset() (int64, error) {
+	ctx, cancel := transactionContext()
+	defer cancel()
+
+	err := r.dbQueries.TruncateSandboxData(ctx)
+	if err != nil {
+		return 0, dbError(err, "repository: error to truncate the sandbox data")
+	}
+
+	deleted, err := r.dbQueries.DeleteAllShoppingLists(ctx)
+	if err != nil {
+		return 0, dbError(err, "repository: error to delete the sandbox lists")
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/sandbox_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/sandbox_repository.go -package repository -destination repository/sandbox_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSandboxRepository is a mock of SandboxRepository interface.
+type MockSandboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSandboxRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSandboxRepositoryMockRecorder is the mock recorder for MockSandboxRepository.
+type MockSandboxRepositoryMockRecorder struct {
+	mock *MockSandboxRepository
+}
+
+// NewMockSandboxRepository creates a new mock instance.
+func NewMockSandboxRepository(ctrl *gomock.Controller) *MockSandboxRepository {
+	mock := &MockSandboxRepository{ctrl: ctrl}
+	mock.recorder = &MockSandboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSandboxRepository) EXPECT() *MockSandboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Reset mocks base method.
+func (m *MockSandboxRepository) Reset() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockSandboxRepositoryMockRecorder) Reset() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockSandboxRepository)(nil).Reset))
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+)
+
+// SearchRepository reads the full-text index of the lists, the index is
+// kept up to date by a trigger of shopping_lists.
+type SearchRepository interface {
+	// SearchLists finds the lists of the owner that match the to_tsquery
+	// expression, the best matches first
+	SearchLists(owner string, query string, limit int32) ([]db_queries.SearchShoppingListsRow, error)
+	// Rebuild indexes every list again and removes the deleted ones, it
+	// returns the number of lists indexed
+	Rebuild() (int64, error)
+	// FindDrift returns the number of lists that must be indexed and the
+	// ones whose entry is missing, stale or outdated
+	FindDrift() (int64, []db_queries.FindShoppingListSearchDriftRow, error)
+}
+
+type SearchPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewSearchRepository(dbQueries *db_queries.Queries) SearchRepository {
+	return &SearchPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *SearchPostgresRepository) SearchLists(owner string, query string, limit int32) ([]db_queries.SearchShoppingListsRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.SearchShoppingLists(ctx, db_queries.SearchShoppingListsParams{
+		Query:      query,
+		Owner:      owner,
+		MaxResults: limit,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to search the lists of the user: %s", owner))
+	}
+
+	return rows, nil
+}
+
+func (r *SearchPostgresRepository) Rebuild() (int64, error) {
+	ctx, cancel := transactionContext()
+	defer cancel()
+
+	indexed, err := r.dbQueries.RebuildShoppingListSearch(ctx)
+	if err != nil {
+		return 0, dbError(err, "repository: error to rebuild the search index")
+	}
+
+	_, err = r.dbQueries.DeleteStaleShoppingListSearch(ctx)
+	if err != nil {
+		return 0, dbError(err, "repository: error to remove the deleted lists from the search index")
+	}
+
+	return indexed, nil
+}
+
+func (r *SearchPostgresRepository) FindDrift() (int64, []db_queries.FindShoppingListSearchDriftRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	count, err := r.dbQueries.CountIndexableShoppingLists(ctx)
+	if err != nil {
+		return 0, nil, dbError(err, "repository: error to count the lists of the search index")
+	}
+
+	rows, err := r.dbQueries.FindShoppingListSearchDrift(ctx)
+	if err != nil {
+		return 0, nil, dbError(err, "repository: error to compare the search index with the lists")
+	}
+
+	return count, rows, nil
+}
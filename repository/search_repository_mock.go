@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/search_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/search_repository.go -package repository -destination repository/search_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSearchRepository is a mock of SearchRepository interface.
+type MockSearchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSearchRepositoryMockRecorder is the mock recorder for MockSearchRepository.
+type MockSearchRepositoryMockRecorder struct {
+	mock *MockSearchRepository
+}
+
+// NewMockSearchRepository creates a new mock instance.
+func NewMockSearchRepository(ctrl *gomock.Controller) *MockSearchRepository {
+	mock := &MockSearchRepository{ctrl: ctrl}
+	mock.recorder = &MockSearchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearchRepository) EXPECT() *MockSearchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindDrift mocks base method.
+func (m *MockSearchRepository) FindDrift() (int64, []db_queries.FindShoppingListSearchDriftRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDrift")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].([]db_queries.FindShoppingListSearchDriftRow)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindDrift indicates an expected call of FindDrift.
+func (mr *MockSearchRepositoryMockRecorder) FindDrift() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDrift", reflect.TypeOf((*MockSearchRepository)(nil).FindDrift))
+}
+
+// Rebuild mocks base method.
+func (m *MockSearchRepository) Rebuild() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rebuild")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rebuild indicates an expected call of Rebuild.
+func (mr *MockSearchRepositoryMockRecorder) Rebuild() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rebuild", reflect.TypeOf((*MockSearchRepository)(nil).Rebuild))
+}
+
+// SearchLists mocks base method.
+func (m *MockSearchRepository) SearchLists(owner, query string, limit int32) ([]db_queries.SearchShoppingListsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchLists", owner, query, limit)
+	ret0, _ := ret[0].([]db_queries.SearchShoppingListsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchLists indicates an expected call of SearchLists.
+func (mr *MockSearchRepositoryMockRecorder) SearchLists(owner, query, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchLists", reflect.TypeOf((*MockSearchRepository)(nil).SearchLists), owner, query, limit)
+}
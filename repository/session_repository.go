@@ -1,19 +1,24 @@
 package repository
 
 import (
-	"context"
-	"math/rand"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	db_queries "shopping/database/queries"
-	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/rs/zerolog/log"
 )
 
 type SessionRepository interface {
 	AddSession(username string) (*db_queries.AddSessionRow, error)
 	GetSessionByToken(token string) (*db_queries.GetSessionByTokenRow, error)
+	DeleteSession(token string) error
+	// DeleteOtherSessions deletes the sessions of the user but the one of
+	// the token
+	DeleteOtherSessions(username string, token string) error
+	// EnsureSession creates the session with the token or extends it
+	EnsureSession(username string, token string, expiresAt time.Time) (*db_queries.UpsertSessionRow, error)
 }
 
 type SessionPostgresRepository struct {
@@ -26,11 +31,26 @@ func NewSessionRepository(dbQueries *db_queries.Queries) SessionRepository {
 	}
 }
 
+// newSessionToken returns 256 random bits, the tokens can't be guessed or
+// enumerated
+func newSessionToken() (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
 func (r *SessionPostgresRepository) AddSession(username string) (*db_queries.AddSessionRow, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := writeContext()
 	defer cancel()
 
-	token := strconv.Itoa(rand.Intn(100000000000))
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("repository: error to generate the session token: %w", err)
+	}
 
 	row, err := r.DBQueries.AddSession(ctx, db_queries.AddSessionParams{
 		Token: token,
@@ -42,21 +62,62 @@ func (r *SessionPostgresRepository) AddSession(username string) (*db_queries.Add
 	})
 
 	if err != nil {
-		log.Debug().Msgf("> add session error: %s", err.Error())
-		return nil, err
+		return nil, dbError(err, fmt.Sprintf("repository: error to add a session for the user: %s", username))
 	}
 
 	return &row, nil
 }
 
 func (r *SessionPostgresRepository) GetSessionByToken(token string) (*db_queries.GetSessionByTokenRow, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := readContext()
 	defer cancel()
 
 	row, err := r.DBQueries.GetSessionByToken(ctx, token)
 	if err != nil {
-		log.Debug().Msgf("> get session by token error: %s", err.Error())
-		return nil, err
+		return nil, dbError(err, "repository: error to get the session")
+	}
+
+	return &row, nil
+}
+
+func (r *SessionPostgresRepository) DeleteSession(token string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.DBQueries.DeleteSessionByToken(ctx, token)
+	if err != nil {
+		return dbError(err, "repository: error to delete the session")
+	}
+
+	return nil
+}
+
+func (r *SessionPostgresRepository) DeleteOtherSessions(username string, token string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	err := r.DBQueries.DeleteOtherSessions(ctx, db_queries.DeleteOtherSessionsParams{
+		Username: username,
+		Token:    token,
+	})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to delete the sessions of the user: %s", username))
+	}
+
+	return nil
+}
+
+func (r *SessionPostgresRepository) EnsureSession(username string, token string, expiresAt time.Time) (*db_queries.UpsertSessionRow, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.DBQueries.UpsertSession(ctx, db_queries.UpsertSessionParams{
+		Token:     token,
+		Username:  username,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to save the session of the user: %s", username))
 	}
 
 	return &row, nil
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: .\repository\session_repository.go
+// Source: repository/session_repository.go
 //
 // Generated by this command:
 //
-//	mockgen -source .\repository\session_repository.go -package repository -destination repository/session_repository_mock.go
+//	mockgen -source repository/session_repository.go -package repository -destination repository/session_repository_mock.go
 //
 
 // Package repository is a generated GoMock package.
@@ -12,6 +12,7 @@ package repository
 import (
 	reflect "reflect"
 	db_queries "shopping/database/queries"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -55,6 +56,49 @@ func (mr *MockSessionRepositoryMockRecorder) AddSession(username any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSession", reflect.TypeOf((*MockSessionRepository)(nil).AddSession), username)
 }
 
+// DeleteOtherSessions mocks base method.
+func (m *MockSessionRepository) DeleteOtherSessions(username, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOtherSessions", username, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOtherSessions indicates an expected call of DeleteOtherSessions.
+func (mr *MockSessionRepositoryMockRecorder) DeleteOtherSessions(username, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOtherSessions", reflect.TypeOf((*MockSessionRepository)(nil).DeleteOtherSessions), username, token)
+}
+
+// DeleteSession mocks base method.
+func (m *MockSessionRepository) DeleteSession(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockSessionRepositoryMockRecorder) DeleteSession(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSessionRepository)(nil).DeleteSession), token)
+}
+
+// EnsureSession mocks base method.
+func (m *MockSessionRepository) EnsureSession(username, token string, expiresAt time.Time) (*db_queries.UpsertSessionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureSession", username, token, expiresAt)
+	ret0, _ := ret[0].(*db_queries.UpsertSessionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureSession indicates an expected call of EnsureSession.
+func (mr *MockSessionRepositoryMockRecorder) EnsureSession(username, token, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureSession", reflect.TypeOf((*MockSessionRepository)(nil).EnsureSession), username, token, expiresAt)
+}
+
 // GetSessionByToken mocks base method.
 func (m *MockSessionRepository) GetSessionByToken(token string) (*db_queries.GetSessionByTokenRow, error) {
 	m.ctrl.T.Helper()
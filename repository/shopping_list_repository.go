@@ -1,25 +1,69 @@
 package repository
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	db_queries "shopping/database/queries"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 )
 
+// NewShoppingList is a list created by CreateShoppingLists
+type NewShoppingList struct {
+	Owner string
+	Name  string
+	Items []string
+	Tags  []string
+}
+
 type ShoppingListRepository interface {
 	GetShoppingListByID(id string) (*db_queries.ShoppingList, error)
-	CreateShoppingList(name string, items []string) (*db_queries.ShoppingList, error)
-	DeleteShoppingListByID(id string) error
-	GetAllShoppingLists() (*[]db_queries.ShoppingList, error)
+	CreateShoppingList(owner string, name string, items []string, tags []string) (*db_queries.ShoppingList, error)
+	// CreateShoppingLists creates the lists in one round trip, they are
+	// returned in the same order
+	CreateShoppingLists(lists []NewShoppingList) ([]db_queries.ShoppingList, error)
+	DeleteShoppingListByID(id string, deletedBy string) error
+	// RestoreShoppingList undoes the soft delete, ErrNotFound when the list
+	// isn't deleted
+	RestoreShoppingList(id string) (*db_queries.ShoppingList, error)
+	// GetAllShoppingLists returns the lists of the tenant, of every tenant
+	// when it's empty
+	GetAllShoppingLists(tenantID string) (*[]db_queries.ShoppingList, error)
+	GetShoppingListsByOwner(owner string) ([]db_queries.ShoppingList, error)
+	// CountShoppingListsByOwner counts the lists of the owner that are not
+	// deleted
+	CountShoppingListsByOwner(owner string) (int64, error)
+	// the IncludingDeleted variants also return the soft deleted lists
+	GetAllShoppingListsIncludingDeleted(tenantID string) ([]db_queries.ShoppingList, error)
+	GetShoppingListByIDIncludingDeleted(id string) (*db_queries.ShoppingList, error)
+	// GetShoppingListByIDForUpdate locks the list until the end of the unit
+	// of work, it's read with it when the list is written from what was read
+	GetShoppingListByIDForUpdate(id string) (*db_queries.ShoppingList, error)
+	// GetShoppingListSummaries returns the lists of the tenant with the
+	// number of their items instead of the items
+	GetShoppingListSummaries(tenantID string, includeDeleted bool) ([]db_queries.GetShoppingListSummariesRow, error)
+	// GetShoppingListsVersion changes with every change of the lists, it's
+	// the ETag of the collection
+	GetShoppingListsVersion(tenantID string) (*db_queries.GetShoppingListsVersionRow, error)
+	// GetShoppingListTenant returns the tenant of the list, deleted or not
+	GetShoppingListTenant(id string) (string, error)
+	// GetRecentlyUpdatedShoppingLists returns the last updated lists first,
+	// they warm the cache at startup
+	GetRecentlyUpdatedShoppingLists(limit int) ([]db_queries.ShoppingList, error)
 	PartialUpdate(id string, name *string, items *[]string) (*db_queries.ShoppingList, error)
 	UpdateShoppingListByID(id string, name string, items []string) (*db_queries.ShoppingList, error)
 	PushItemToShoppingList(id string, item string) (*db_queries.ShoppingList, error)
+	// MergeItemIntoShoppingList adds the quantity of the item to an item with
+	// the same name, without the case, or pushes it when there is none
+	MergeItemIntoShoppingList(id string, item string) (*db_queries.ShoppingList, error)
+	// RemoveShoppingListItem and RenameShoppingListItem change the item at
+	// the position from 1, they return ErrNotFound when it's no longer item
+	RemoveShoppingListItem(id string, position int, item string) (*db_queries.ShoppingList, error)
+	RenameShoppingListItem(id string, position int, item string, name string) (*db_queries.ShoppingList, error)
+	AppendItemsToShoppingList(id string, items []string) (*db_queries.ShoppingList, error)
+	FindListNameConflicts(owner string, name string) ([]db_queries.FindListNameConflictsRow, error)
 }
 
 type ShoppingListPostgresRepository struct {
@@ -32,11 +76,16 @@ func NewShoppingListRepository(dbQueries *db_queries.Queries) ShoppingListReposi
 	}
 }
 
-func (r *ShoppingListPostgresRepository) GetAllShoppingLists() (*[]db_queries.ShoppingList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (r *ShoppingListPostgresRepository) GetAllShoppingLists(tenantID string) (*[]db_queries.ShoppingList, error) {
+	ctx, cancel := readContext()
 	defer cancel()
 
-	rows, err := r.dbQueries.GetAllShoppingLists(ctx)
+	tenant, err := convertTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbQueries.GetAllShoppingLists(ctx, tenant)
 	if err != nil {
 		log.Err(err).Msg("repository: error to get all shopping lists")
 		return nil, errors.New("repository: error to get all the shopping lists")
@@ -45,38 +94,217 @@ func (r *ShoppingListPostgresRepository) GetAllShoppingLists() (*[]db_queries.Sh
 	return &rows, err
 }
 
-func (r *ShoppingListPostgresRepository) CreateShoppingList(name string, items []string) (*db_queries.ShoppingList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (r *ShoppingListPostgresRepository) GetShoppingListsByOwner(owner string) ([]db_queries.ShoppingList, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetShoppingListsByOwner(ctx, pgtype.Text{String: owner, Valid: true})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to get the shopping lists of the user: %s", owner)
+		return nil, errors.New("repository: error to get the shopping lists")
+	}
+
+	return rows, nil
+}
+
+func (r *ShoppingListPostgresRepository) CountShoppingListsByOwner(owner string) (int64, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	count, err := r.dbQueries.CountShoppingListsByOwner(ctx, pgtype.Text{String: owner, Valid: true})
+	if err != nil {
+		return 0, dbError(err, fmt.Sprintf("repository: error to count the shopping lists of the user: %s", owner))
+	}
+
+	return count, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetAllShoppingListsIncludingDeleted(tenantID string) ([]db_queries.ShoppingList, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	tenant, err := convertTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbQueries.GetAllShoppingListsIncludingDeleted(ctx, tenant)
+	if err != nil {
+		log.Err(err).Msg("repository: error to get all shopping lists including the deleted ones")
+		return nil, errors.New("repository: error to get all the shopping lists")
+	}
+
+	return rows, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetShoppingListSummaries(tenantID string, includeDeleted bool) ([]db_queries.GetShoppingListSummariesRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	tenant, err := convertTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbQueries.GetShoppingListSummaries(ctx, db_queries.GetShoppingListSummariesParams{
+		IncludeDeleted: includeDeleted,
+		TenantID:       tenant,
+	})
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the summaries of the shopping lists")
+		return nil, errors.New("repository: error to get all the shopping lists")
+	}
+
+	return rows, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetShoppingListsVersion(tenantID string) (*db_queries.GetShoppingListsVersionRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	tenant, err := convertTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.GetShoppingListsVersion(ctx, tenant)
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the version of the shopping lists")
+		return nil, errors.New("repository: error to get the version of the shopping lists")
+	}
+
+	return &row, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetShoppingListTenant(id string) (string, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return "", err
+	}
+
+	tenant, err := r.dbQueries.GetShoppingListTenant(ctx, uid)
+	if err != nil {
+		return "", dbError(err, fmt.Sprintf("repository: error to get the tenant of the shopping list: %s", id))
+	}
+
+	return tenant.String(), nil
+}
+
+func (r *ShoppingListPostgresRepository) GetRecentlyUpdatedShoppingLists(limit int) ([]db_queries.ShoppingList, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.GetRecentlyUpdatedShoppingLists(ctx, int32(limit))
+	if err != nil {
+		log.Err(err).Msg("repository: error to get the recently updated shopping lists")
+		return nil, errors.New("repository: error to get the recently updated shopping lists")
+	}
+
+	return rows, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetShoppingListByIDIncludingDeleted(id string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	shoppingList, err := r.dbQueries.GetShoppingListByIDIncludingDeleted(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the shopping list with id: %s", id))
+	}
+
+	return &shoppingList, nil
+}
+
+func (r *ShoppingListPostgresRepository) CreateShoppingList(owner string, name string, items []string, tags []string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
 	defer cancel()
 
+	// a nil slice is sent as NULL and the column is NOT NULL
+	if tags == nil {
+		tags = []string{}
+	}
+
 	row, err := r.dbQueries.CreateShoppingList(ctx, db_queries.CreateShoppingListParams{
 		Name:  name,
 		Items: items,
+		Tags:  tags,
+		Owner: pgtype.Text{String: owner, Valid: owner != ""},
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("error to create the new shopping list with name '%s' and items '%v'", name, items)
+		return nil, dbError(err, fmt.Sprintf("repository: error to create the shopping list with name '%s'", name))
 	}
 
-	return &row, err
+	return &row, nil
+}
+
+func (r *ShoppingListPostgresRepository) CreateShoppingLists(lists []NewShoppingList) ([]db_queries.ShoppingList, error) {
+	if len(lists) == 0 {
+		return []db_queries.ShoppingList{}, nil
+	}
+
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	params := make([]db_queries.CreateShoppingListsParams, len(lists))
+	for i, list := range lists {
+		// a nil slice is sent as NULL and the column is NOT NULL
+		tags := list.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		params[i] = db_queries.CreateShoppingListsParams{
+			Name:  list.Name,
+			Items: list.Items,
+			Tags:  tags,
+			Owner: pgtype.Text{String: list.Owner, Valid: list.Owner != ""},
+		}
+	}
+
+	created := make([]db_queries.ShoppingList, len(lists))
+	var batchErr error
+	results := r.dbQueries.CreateShoppingLists(ctx, params)
+	results.QueryRow(func(i int, row db_queries.ShoppingList, err error) {
+		if err != nil {
+			if batchErr == nil {
+				batchErr = dbError(err, fmt.Sprintf("repository: error to create the shopping list with name '%s'", lists[i].Name))
+			}
+			return
+		}
+		created[i] = row
+	})
+	if err := results.Close(); err != nil && batchErr == nil {
+		batchErr = dbError(err, "repository: error to create the shopping lists")
+	}
+	if batchErr != nil {
+		return nil, batchErr
+	}
+
+	return created, nil
 }
 
 func (r *ShoppingListPostgresRepository) PartialUpdate(id string, name *string, items *[]string) (
 	*db_queries.ShoppingList, error,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := writeContext()
 	defer cancel()
 
-	uid, err := uuid.Parse(id)
+	uid, err := convertStringToUUID(id)
 	if err != nil {
-		return nil, errors.New("invalid id value")
+		return nil, err
 	}
 
 	params := db_queries.ShoppingListPartialUpdateParams{
-		ID: pgtype.UUID{
-			Bytes: uid,
-			Valid: true,
-		},
+		ID: uid,
 	}
 
 	if name != nil && *name != "" {
@@ -96,61 +324,90 @@ func (r *ShoppingListPostgresRepository) PartialUpdate(id string, name *string,
 	)
 
 	if err != nil {
-		log.Debug().Msgf("shopping list partial update error: %s", err.Error())
-		return nil, err
+		return nil, dbError(err, fmt.Sprintf("repository: error to update the shopping list with id: %s", id))
 	}
 
 	return &row, nil
 }
 
 func (r *ShoppingListPostgresRepository) GetShoppingListByID(id string) (*db_queries.ShoppingList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := readContext()
 	defer cancel()
 
-	_uid, err := uuid.Parse(id)
+	uid, err := convertStringToUUID(id)
 	if err != nil {
-		return nil, errors.New("invalid uuid")
+		return nil, err
 	}
 
-	uid := pgtype.UUID{
-		Bytes: _uid,
-		Valid: true,
+	shoppingList, err := r.dbQueries.GetShoppingListByID(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the shopping list with id: %s", id))
 	}
 
-	shoppingList, err := r.dbQueries.GetShoppingListByID(ctx, uid)
+	return &shoppingList, nil
+}
+
+func (r *ShoppingListPostgresRepository) GetShoppingListByIDForUpdate(id string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &shoppingList, err
+	shoppingList, err := r.dbQueries.GetShoppingListByIDForUpdate(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to lock the shopping list with id: %s", id))
+	}
+
+	return &shoppingList, nil
 }
 
-func (r *ShoppingListPostgresRepository) DeleteShoppingListByID(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (r *ShoppingListPostgresRepository) DeleteShoppingListByID(id string, deletedBy string) error {
+	ctx, cancel := writeContext()
 	defer cancel()
 
-	_uid, err := uuid.Parse(id)
+	uid, err := convertStringToUUID(id)
 	if err != nil {
-		log.Err(err).Msg("invalid uuid when deleting a shopping list")
-		return errors.New("invalid uuid id")
+		return err
 	}
 
-	uid := pgtype.UUID{
-		Bytes: _uid,
-		Valid: true,
+	deleted, err := r.dbQueries.DeleteShoppingListByID(ctx, db_queries.DeleteShoppingListByIDParams{
+		ID:        uid,
+		DeletedBy: pgtype.Text{String: deletedBy, Valid: deletedBy != ""},
+	})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to delete the shopping list with id: %s", id))
 	}
 
-	err = r.dbQueries.DeleteShoppingListByID(ctx, uid)
-	if err != nil {
-		log.Err(err).Msgf("Error to delete the shopping list with uuid: '%s'", uid.String())
-		return errors.New(fmt.Sprintf("Error to delete the shopping list with the uuid: '%s'", uid.String()))
+	// the list doesn't exist or it was already deleted
+	if deleted == 0 {
+		return fmt.Errorf("repository: the shopping list with id %s doesn't exist: %w", id, ErrNotFound)
 	}
 
 	return nil
 }
 
+func (r *ShoppingListPostgresRepository) RestoreShoppingList(id string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := r.dbQueries.RestoreShoppingList(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to restore the shopping list with id: %s", id))
+	}
+
+	return &restored, nil
+}
+
 func (r *ShoppingListPostgresRepository) UpdateShoppingListByID(id string, name string, items []string) (*db_queries.ShoppingList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := writeContext()
 	defer cancel()
 
 	uid, err := convertStringToUUID(id)
@@ -164,16 +421,14 @@ func (r *ShoppingListPostgresRepository) UpdateShoppingListByID(id string, name
 		Items: items,
 	})
 	if err != nil {
-		msg := fmt.Sprintf("repository: error to update the shopping list wiht id: %s", id)
-		log.Err(err).Msg(msg)
-		return nil, errors.New(msg)
+		return nil, dbError(err, fmt.Sprintf("repository: error to update the shopping list with id: %s", id))
 	}
 
 	return &updated, nil
 }
 
 func (r *ShoppingListPostgresRepository) PushItemToShoppingList(id string, item string) (*db_queries.ShoppingList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := writeContext()
 	defer cancel()
 
 	uid, err := convertStringToUUID(id)
@@ -186,16 +441,131 @@ func (r *ShoppingListPostgresRepository) PushItemToShoppingList(id string, item
 		Items: []string{item},
 	})
 	if err != nil {
-		return nil, errors.New("error to push item")
+		return nil, dbError(err, fmt.Sprintf("repository: error to push an item to the shopping list with id: %s", id))
 	}
 
 	return &updated, nil
 }
 
+func (r *ShoppingListPostgresRepository) MergeItemIntoShoppingList(id string, item string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.dbQueries.MergeItemIntoShoppingList(ctx, db_queries.MergeItemIntoShoppingListParams{
+		Item: item,
+		ID:   uid,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to merge an item into the shopping list with id: %s", id))
+	}
+
+	return &updated, nil
+}
+
+func (r *ShoppingListPostgresRepository) RemoveShoppingListItem(id string, position int, item string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.dbQueries.RemoveShoppingListItem(ctx, db_queries.RemoveShoppingListItemParams{
+		Position: int32(position),
+		ID:       uid,
+		Item:     item,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to remove an item of the shopping list with id: %s", id))
+	}
+
+	return &updated, nil
+}
+
+func (r *ShoppingListPostgresRepository) RenameShoppingListItem(id string, position int, item string, name string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.dbQueries.RenameShoppingListItem(ctx, db_queries.RenameShoppingListItemParams{
+		Position: int32(position),
+		Name:     name,
+		ID:       uid,
+		Item:     item,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to rename an item of the shopping list with id: %s", id))
+	}
+
+	return &updated, nil
+}
+
+func (r *ShoppingListPostgresRepository) AppendItemsToShoppingList(id string, items []string) (*db_queries.ShoppingList, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.dbQueries.PushItemToShoppingList(ctx, db_queries.PushItemToShoppingListParams{
+		ID:    uid,
+		Items: items,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to append items to the shopping list with id: %s", id))
+	}
+
+	return &updated, nil
+}
+
+func (r *ShoppingListPostgresRepository) FindListNameConflicts(owner string, name string) ([]db_queries.FindListNameConflictsRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.FindListNameConflicts(ctx, db_queries.FindListNameConflictsParams{
+		Owner:      pgtype.Text{String: owner, Valid: true},
+		NamePrefix: likeEscaper.Replace(name),
+	})
+	if err != nil {
+		log.Err(err).Msgf("repository: error to find the lists named like '%s'", name)
+		return nil, errors.New("repository: error to find the lists by name")
+	}
+
+	return rows, nil
+}
+
+// convertTenantID converts the tenant of the queries scoped by tenant, the
+// empty id is null and selects every tenant
+func convertTenantID(value string) (pgtype.UUID, error) {
+	if value == "" {
+		return pgtype.UUID{Valid: false}, nil
+	}
+
+	v, err := uuid.Parse(value)
+	if err != nil {
+		return pgtype.UUID{Valid: false}, fmt.Errorf("repository: invalid tenant id '%s'", value)
+	}
+
+	return pgtype.UUID{Bytes: v, Valid: true}, nil
+}
+
 func convertStringToUUID(value string) (pgtype.UUID, error) {
 	v, err := uuid.Parse(value)
 	if err != nil {
-		return pgtype.UUID{Valid: false}, errors.New("invalid uuid")
+		// no list can have the id
+		return pgtype.UUID{Valid: false}, fmt.Errorf("repository: invalid id '%s': %w", value, ErrNotFound)
 	}
 
 	return pgtype.UUID{
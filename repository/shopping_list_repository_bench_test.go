@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"shopping/config"
+	"shopping/database"
+	"shopping/database/migrations"
+	db_queries "shopping/database/queries"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// BenchmarkGetShoppingListByID compares the query exec modes of pgx on the
+// read of a list, the hottest query of the API. It needs docker:
+//
+//	go test ./repository -run '^$' -bench GetShoppingListByID
+//
+// The cache modes save the parse (cache_statement) or the describe
+// (cache_describe) round trip of every query, the others are the ones that
+// work behind pgbouncer in transaction mode.
+func BenchmarkGetShoppingListByID(b *testing.B) {
+	ctx := context.Background()
+
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:17",
+		postgres.WithDatabase("shoppinglist"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForAll(
+				wait.ForLog("database system is ready to accept connections"),
+				wait.ForListeningPort("5432/tcp"),
+			).WithDeadline(30*time.Second),
+		),
+	)
+	if err != nil {
+		b.Fatalf("failed to start the container: %s", err)
+	}
+	b.Cleanup(func() {
+		if err := testcontainers.TerminateContainer(postgresContainer); err != nil {
+			b.Fatalf("failed to terminate the container: %s", err)
+		}
+	})
+
+	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	setup, err := database.NewDB(&config.Config{DBUrl: connStr})
+	if err != nil {
+		b.Fatalf("cannot connect to db: %s", err)
+	}
+	loaded, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, err = (&database.Migrator{Pool: setup, Migrations: loaded}).Up(ctx, 0)
+	if err != nil {
+		b.Fatalf("failed to migrate: %s", err)
+	}
+
+	list, err := NewShoppingListRepository(db_queries.New(setup)).CreateShoppingList("user", "Groceries", []string{"milk", "bread"}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	setup.Close()
+	id := list.ID.String()
+
+	for _, mode := range []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"} {
+		b.Run(mode, func(b *testing.B) {
+			dbpool, err := database.NewDB(&config.Config{DBUrl: connStr, DBQueryExecMode: mode, DBStatementCacheSize: 512})
+			if err != nil {
+				b.Fatalf("cannot connect to db: %s", err)
+			}
+			defer dbpool.Close()
+
+			repo := NewShoppingListRepository(db_queries.New(dbpool))
+			for b.Loop() {
+				_, err := repo.GetShoppingListByID(id)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: .\repository\shopping_list_repository.go
+// Source: repository/shopping_list_repository.go
 //
 // Generated by this command:
 //
-//	mockgen -source .\repository\shopping_list_repository.go -package repository -destination repository/shopping_list_repository_mock.go
+//	mockgen -source repository/shopping_list_repository.go -package repository -destination repository/shopping_list_repository_mock.go
 //
 
 // Package repository is a generated GoMock package.
@@ -40,48 +40,138 @@ func (m *MockShoppingListRepository) EXPECT() *MockShoppingListRepositoryMockRec
 	return m.recorder
 }
 
+// AppendItemsToShoppingList mocks base method.
+func (m *MockShoppingListRepository) AppendItemsToShoppingList(id string, items []string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendItemsToShoppingList", id, items)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendItemsToShoppingList indicates an expected call of AppendItemsToShoppingList.
+func (mr *MockShoppingListRepositoryMockRecorder) AppendItemsToShoppingList(id, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendItemsToShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).AppendItemsToShoppingList), id, items)
+}
+
+// CountShoppingListsByOwner mocks base method.
+func (m *MockShoppingListRepository) CountShoppingListsByOwner(owner string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountShoppingListsByOwner", owner)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountShoppingListsByOwner indicates an expected call of CountShoppingListsByOwner.
+func (mr *MockShoppingListRepositoryMockRecorder) CountShoppingListsByOwner(owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountShoppingListsByOwner", reflect.TypeOf((*MockShoppingListRepository)(nil).CountShoppingListsByOwner), owner)
+}
+
 // CreateShoppingList mocks base method.
-func (m *MockShoppingListRepository) CreateShoppingList(name string, items []string) (*db_queries.ShoppingList, error) {
+func (m *MockShoppingListRepository) CreateShoppingList(owner, name string, items, tags []string) (*db_queries.ShoppingList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateShoppingList", name, items)
+	ret := m.ctrl.Call(m, "CreateShoppingList", owner, name, items, tags)
 	ret0, _ := ret[0].(*db_queries.ShoppingList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateShoppingList indicates an expected call of CreateShoppingList.
-func (mr *MockShoppingListRepositoryMockRecorder) CreateShoppingList(name, items any) *gomock.Call {
+func (mr *MockShoppingListRepositoryMockRecorder) CreateShoppingList(owner, name, items, tags any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).CreateShoppingList), name, items)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).CreateShoppingList), owner, name, items, tags)
+}
+
+// CreateShoppingLists mocks base method.
+func (m *MockShoppingListRepository) CreateShoppingLists(lists []NewShoppingList) ([]db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShoppingLists", lists)
+	ret0, _ := ret[0].([]db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShoppingLists indicates an expected call of CreateShoppingLists.
+func (mr *MockShoppingListRepositoryMockRecorder) CreateShoppingLists(lists any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShoppingLists", reflect.TypeOf((*MockShoppingListRepository)(nil).CreateShoppingLists), lists)
 }
 
 // DeleteShoppingListByID mocks base method.
-func (m *MockShoppingListRepository) DeleteShoppingListByID(id string) error {
+func (m *MockShoppingListRepository) DeleteShoppingListByID(id, deletedBy string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteShoppingListByID", id)
+	ret := m.ctrl.Call(m, "DeleteShoppingListByID", id, deletedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteShoppingListByID indicates an expected call of DeleteShoppingListByID.
-func (mr *MockShoppingListRepositoryMockRecorder) DeleteShoppingListByID(id any) *gomock.Call {
+func (mr *MockShoppingListRepositoryMockRecorder) DeleteShoppingListByID(id, deletedBy any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShoppingListByID", reflect.TypeOf((*MockShoppingListRepository)(nil).DeleteShoppingListByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShoppingListByID", reflect.TypeOf((*MockShoppingListRepository)(nil).DeleteShoppingListByID), id, deletedBy)
+}
+
+// FindListNameConflicts mocks base method.
+func (m *MockShoppingListRepository) FindListNameConflicts(owner, name string) ([]db_queries.FindListNameConflictsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindListNameConflicts", owner, name)
+	ret0, _ := ret[0].([]db_queries.FindListNameConflictsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindListNameConflicts indicates an expected call of FindListNameConflicts.
+func (mr *MockShoppingListRepositoryMockRecorder) FindListNameConflicts(owner, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindListNameConflicts", reflect.TypeOf((*MockShoppingListRepository)(nil).FindListNameConflicts), owner, name)
 }
 
 // GetAllShoppingLists mocks base method.
-func (m *MockShoppingListRepository) GetAllShoppingLists() (*[]db_queries.ShoppingList, error) {
+func (m *MockShoppingListRepository) GetAllShoppingLists(tenantID string) (*[]db_queries.ShoppingList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllShoppingLists")
+	ret := m.ctrl.Call(m, "GetAllShoppingLists", tenantID)
 	ret0, _ := ret[0].(*[]db_queries.ShoppingList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllShoppingLists indicates an expected call of GetAllShoppingLists.
-func (mr *MockShoppingListRepositoryMockRecorder) GetAllShoppingLists() *gomock.Call {
+func (mr *MockShoppingListRepositoryMockRecorder) GetAllShoppingLists(tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllShoppingLists", reflect.TypeOf((*MockShoppingListRepository)(nil).GetAllShoppingLists), tenantID)
+}
+
+// GetAllShoppingListsIncludingDeleted mocks base method.
+func (m *MockShoppingListRepository) GetAllShoppingListsIncludingDeleted(tenantID string) ([]db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllShoppingListsIncludingDeleted", tenantID)
+	ret0, _ := ret[0].([]db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllShoppingListsIncludingDeleted indicates an expected call of GetAllShoppingListsIncludingDeleted.
+func (mr *MockShoppingListRepositoryMockRecorder) GetAllShoppingListsIncludingDeleted(tenantID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllShoppingLists", reflect.TypeOf((*MockShoppingListRepository)(nil).GetAllShoppingLists))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllShoppingListsIncludingDeleted", reflect.TypeOf((*MockShoppingListRepository)(nil).GetAllShoppingListsIncludingDeleted), tenantID)
+}
+
+// GetRecentlyUpdatedShoppingLists mocks base method.
+func (m *MockShoppingListRepository) GetRecentlyUpdatedShoppingLists(limit int) ([]db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentlyUpdatedShoppingLists", limit)
+	ret0, _ := ret[0].([]db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentlyUpdatedShoppingLists indicates an expected call of GetRecentlyUpdatedShoppingLists.
+func (mr *MockShoppingListRepositoryMockRecorder) GetRecentlyUpdatedShoppingLists(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentlyUpdatedShoppingLists", reflect.TypeOf((*MockShoppingListRepository)(nil).GetRecentlyUpdatedShoppingLists), limit)
 }
 
 // GetShoppingListByID mocks base method.
@@ -99,6 +189,111 @@ func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListByID(id any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListByID", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListByID), id)
 }
 
+// GetShoppingListByIDForUpdate mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListByIDForUpdate(id string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListByIDForUpdate", id)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListByIDForUpdate indicates an expected call of GetShoppingListByIDForUpdate.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListByIDForUpdate(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListByIDForUpdate", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListByIDForUpdate), id)
+}
+
+// GetShoppingListByIDIncludingDeleted mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListByIDIncludingDeleted(id string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListByIDIncludingDeleted", id)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListByIDIncludingDeleted indicates an expected call of GetShoppingListByIDIncludingDeleted.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListByIDIncludingDeleted(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListByIDIncludingDeleted", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListByIDIncludingDeleted), id)
+}
+
+// GetShoppingListSummaries mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListSummaries(tenantID string, includeDeleted bool) ([]db_queries.GetShoppingListSummariesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListSummaries", tenantID, includeDeleted)
+	ret0, _ := ret[0].([]db_queries.GetShoppingListSummariesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListSummaries indicates an expected call of GetShoppingListSummaries.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListSummaries(tenantID, includeDeleted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListSummaries", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListSummaries), tenantID, includeDeleted)
+}
+
+// GetShoppingListTenant mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListTenant(id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListTenant", id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListTenant indicates an expected call of GetShoppingListTenant.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListTenant(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListTenant", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListTenant), id)
+}
+
+// GetShoppingListsByOwner mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListsByOwner(owner string) ([]db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListsByOwner", owner)
+	ret0, _ := ret[0].([]db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListsByOwner indicates an expected call of GetShoppingListsByOwner.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListsByOwner(owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListsByOwner", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListsByOwner), owner)
+}
+
+// GetShoppingListsVersion mocks base method.
+func (m *MockShoppingListRepository) GetShoppingListsVersion(tenantID string) (*db_queries.GetShoppingListsVersionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShoppingListsVersion", tenantID)
+	ret0, _ := ret[0].(*db_queries.GetShoppingListsVersionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShoppingListsVersion indicates an expected call of GetShoppingListsVersion.
+func (mr *MockShoppingListRepositoryMockRecorder) GetShoppingListsVersion(tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShoppingListsVersion", reflect.TypeOf((*MockShoppingListRepository)(nil).GetShoppingListsVersion), tenantID)
+}
+
+// MergeItemIntoShoppingList mocks base method.
+func (m *MockShoppingListRepository) MergeItemIntoShoppingList(id, item string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeItemIntoShoppingList", id, item)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeItemIntoShoppingList indicates an expected call of MergeItemIntoShoppingList.
+func (mr *MockShoppingListRepositoryMockRecorder) MergeItemIntoShoppingList(id, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeItemIntoShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).MergeItemIntoShoppingList), id, item)
+}
+
 // PartialUpdate mocks base method.
 func (m *MockShoppingListRepository) PartialUpdate(id string, name *string, items *[]string) (*db_queries.ShoppingList, error) {
 	m.ctrl.T.Helper()
@@ -129,6 +324,51 @@ func (mr *MockShoppingListRepositoryMockRecorder) PushItemToShoppingList(id, ite
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushItemToShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).PushItemToShoppingList), id, item)
 }
 
+// RemoveShoppingListItem mocks base method.
+func (m *MockShoppingListRepository) RemoveShoppingListItem(id string, position int, item string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveShoppingListItem", id, position, item)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveShoppingListItem indicates an expected call of RemoveShoppingListItem.
+func (mr *MockShoppingListRepositoryMockRecorder) RemoveShoppingListItem(id, position, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveShoppingListItem", reflect.TypeOf((*MockShoppingListRepository)(nil).RemoveShoppingListItem), id, position, item)
+}
+
+// RenameShoppingListItem mocks base method.
+func (m *MockShoppingListRepository) RenameShoppingListItem(id string, position int, item, name string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameShoppingListItem", id, position, item, name)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameShoppingListItem indicates an expected call of RenameShoppingListItem.
+func (mr *MockShoppingListRepositoryMockRecorder) RenameShoppingListItem(id, position, item, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameShoppingListItem", reflect.TypeOf((*MockShoppingListRepository)(nil).RenameShoppingListItem), id, position, item, name)
+}
+
+// RestoreShoppingList mocks base method.
+func (m *MockShoppingListRepository) RestoreShoppingList(id string) (*db_queries.ShoppingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreShoppingList", id)
+	ret0, _ := ret[0].(*db_queries.ShoppingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreShoppingList indicates an expected call of RestoreShoppingList.
+func (mr *MockShoppingListRepositoryMockRecorder) RestoreShoppingList(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreShoppingList", reflect.TypeOf((*MockShoppingListRepository)(nil).RestoreShoppingList), id)
+}
+
 // UpdateShoppingListByID mocks base method.
 func (m *MockShoppingListRepository) UpdateShoppingListByID(id, name string, items []string) (*db_queries.ShoppingList, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SigningKeyRepository has the HMAC keys rotated by the admins, the keys of
+// the config aren't stored
+type SigningKeyRepository interface {
+	// ListKeys returns the keys of every purpose, the newest first
+	ListKeys() ([]db_queries.SigningKey, error)
+	AddKey(purpose string, id string, secret string, activeAt time.Time) (*db_queries.SigningKey, error)
+	// DeleteRetiredKeys deletes the keys of the purpose replaced by a key
+	// active since before retiredBefore, and returns how many were deleted
+	DeleteRetiredKeys(purpose string, retiredBefore time.Time) (int64, error)
+}
+
+type SigningKeyPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewSigningKeyRepository(dbQueries *db_queries.Queries) SigningKeyRepository {
+	return &SigningKeyPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *SigningKeyPostgresRepository) ListKeys() ([]db_queries.SigningKey, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, dbError(err, "repository: error to list the signing keys")
+	}
+
+	return rows, nil
+}
+
+func (r *SigningKeyPostgresRepository) AddKey(purpose string, id string, secret string, activeAt time.Time) (*db_queries.SigningKey, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.AddSigningKey(ctx, db_queries.AddSigningKeyParams{
+		Purpose:  purpose,
+		ID:       id,
+		Secret:   secret,
+		ActiveAt: pgtype.Timestamptz{Time: activeAt, Valid: true},
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to add the signing key %s of %s", id, purpose))
+	}
+
+	return &row, nil
+}
+
+func (r *SigningKeyPostgresRepository) DeleteRetiredKeys(purpose string, retiredBefore time.Time) (int64, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	deleted, err := r.dbQueries.DeleteRetiredSigningKeys(ctx, db_queries.DeleteRetiredSigningKeysParams{
+		Purpose:       purpose,
+		RetiredBefore: pgtype.Timestamptz{Time: retiredBefore, Valid: true},
+	})
+	if err != nil {
+		return 0, dbError(err, fmt.Sprintf("repository: error to delete the retired signing keys of %s", purpose))
+	}
+
+	return deleted, nil
+}
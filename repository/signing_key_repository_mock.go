@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/signing_key_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/signing_key_repository.go -package repository -destination repository/signing_key_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSigningKeyRepository is a mock of SigningKeyRepository interface.
+type MockSigningKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSigningKeyRepositoryMockRecorder is the mock recorder for MockSigningKeyRepository.
+type MockSigningKeyRepositoryMockRecorder struct {
+	mock *MockSigningKeyRepository
+}
+
+// NewMockSigningKeyRepository creates a new mock instance.
+func NewMockSigningKeyRepository(ctrl *gomock.Controller) *MockSigningKeyRepository {
+	mock := &MockSigningKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyRepository) EXPECT() *MockSigningKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddKey mocks base method.
+func (m *MockSigningKeyRepository) AddKey(purpose, id, secret string, activeAt time.Time) (*db_queries.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddKey", purpose, id, secret, activeAt)
+	ret0, _ := ret[0].(*db_queries.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddKey indicates an expected call of AddKey.
+func (mr *MockSigningKeyRepositoryMockRecorder) AddKey(purpose, id, secret, activeAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddKey", reflect.TypeOf((*MockSigningKeyRepository)(nil).AddKey), purpose, id, secret, activeAt)
+}
+
+// DeleteRetiredKeys mocks base method.
+func (m *MockSigningKeyRepository) DeleteRetiredKeys(purpose string, retiredBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRetiredKeys", purpose, retiredBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRetiredKeys indicates an expected call of DeleteRetiredKeys.
+func (mr *MockSigningKeyRepositoryMockRecorder) DeleteRetiredKeys(purpose, retiredBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRetiredKeys", reflect.TypeOf((*MockSigningKeyRepository)(nil).DeleteRetiredKeys), purpose, retiredBefore)
+}
+
+// ListKeys mocks base method.
+func (m *MockSigningKeyRepository) ListKeys() ([]db_queries.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys")
+	ret0, _ := ret[0].([]db_queries.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockSigningKeyRepositoryMockRecorder) ListKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockSigningKeyRepository)(nil).ListKeys))
+}
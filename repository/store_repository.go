@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	db_queries "shopping/database/queries"
+	"slices"
+)
+
+// StoreData is what the users write of a store, ItemAisles maps the items
+// to one of the Aisles
+type StoreData struct {
+	Name       string
+	Address    string
+	Aisles     []string
+	ItemAisles map[string]string
+}
+
+// StoreRepository has the stores of the users and the store of each list.
+// The stores can only be read and changed by their owner, except the store
+// of a list that is read with the list
+type StoreRepository interface {
+	CreateStore(owner string, data StoreData) (*db_queries.Store, error)
+	ListStores(owner string) ([]db_queries.Store, error)
+	GetStore(id string, owner string) (*db_queries.Store, error)
+	UpdateStore(id string, owner string, data StoreData) (*db_queries.Store, error)
+	// DeleteStore deletes the store, the lists shopped in it don't have a
+	// store anymore
+	DeleteStore(id string, owner string) error
+	SetListStore(listID string, storeID string) error
+	// UnsetListStore returns ErrNotFound when the list has no store
+	UnsetListStore(listID string) error
+	// GetListStore returns ErrNotFound when the list has no store
+	GetListStore(listID string) (*db_queries.Store, error)
+	// SetPrices adds or changes the prices in cents of the items of the
+	// store, the items are keys like in StoreData.ItemAisles
+	SetPrices(storeID string, prices map[string]int64) error
+	ListPrices(storeID string) ([]db_queries.StorePrice, error)
+	DeletePrice(storeID string, item string) error
+	// SumPrices returns the total of the items in each store of the owner
+	SumPrices(owner string, items []string) ([]db_queries.SumStorePricesRow, error)
+	// CheapestPrices returns the cheapest store of each item that has a
+	// price in a store of the owner
+	CheapestPrices(owner string, items []string) ([]db_queries.CheapestStorePricesRow, error)
+}
+
+type StorePostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewStoreRepository(dbQueries *db_queries.Queries) StoreRepository {
+	return &StorePostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *StorePostgresRepository) CreateStore(owner string, data StoreData) (*db_queries.Store, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	itemAisles, err := json.Marshal(data.ItemAisles)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.CreateStore(ctx, db_queries.CreateStoreParams{
+		Owner:      owner,
+		Name:       data.Name,
+		Address:    data.Address,
+		Aisles:     data.Aisles,
+		ItemAisles: itemAisles,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to create the store: %s", data.Name))
+	}
+
+	return &row, nil
+}
+
+func (r *StorePostgresRepository) ListStores(owner string) ([]db_queries.Store, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.ListStoresByOwner(ctx, owner)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to list the stores of: %s", owner))
+	}
+
+	return rows, nil
+}
+
+func (r *StorePostgresRepository) GetStore(id string, owner string) (*db_queries.Store, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.GetStore(ctx, db_queries.GetStoreParams{ID: uid, Owner: owner})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the store with id: %s", id))
+	}
+
+	return &row, nil
+}
+
+func (r *StorePostgresRepository) UpdateStore(id string, owner string, data StoreData) (*db_queries.Store, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	itemAisles, err := json.Marshal(data.ItemAisles)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.UpdateStore(ctx, db_queries.UpdateStoreParams{
+		ID:         uid,
+		Owner:      owner,
+		Name:       data.Name,
+		Address:    data.Address,
+		Aisles:     data.Aisles,
+		ItemAisles: itemAisles,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to update the store with id: %s", id))
+	}
+
+	return &row, nil
+}
+
+func (r *StorePostgresRepository) DeleteStore(id string, owner string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := r.dbQueries.DeleteStore(ctx, db_queries.DeleteStoreParams{ID: uid, Owner: owner})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to delete the store with id: %s", id))
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("repository: the store with id %s doesn't exist: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *StorePostgresRepository) SetListStore(listID string, storeID string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	listUID, err := convertStringToUUID(listID)
+	if err != nil {
+		return err
+	}
+	storeUID, err := convertStringToUUID(storeID)
+	if err != nil {
+		return err
+	}
+
+	err = r.dbQueries.SetListStore(ctx, db_queries.SetListStoreParams{ListID: listUID, StoreID: storeUID})
+	if err != nil {
+		// a foreign key violation when the list or the store doesn't exist
+		return dbError(err, fmt.Sprintf("repository: error to set the store of the list: %s", listID))
+	}
+
+	return nil
+}
+
+func (r *StorePostgresRepository) UnsetListStore(listID string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := r.dbQueries.UnsetListStore(ctx, uid)
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to unset the store of the list: %s", listID))
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("repository: the list with id %s has no store: %w", listID, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *StorePostgresRepository) GetListStore(listID string) (*db_queries.Store, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.GetListStore(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the store of the list: %s", listID))
+	}
+
+	return &row, nil
+}
+
+func (r *StorePostgresRepository) SetPrices(storeID string, prices map[string]int64) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(storeID)
+	if err != nil {
+		return err
+	}
+
+	// the rows are locked in the same order by the concurrent upserts
+	params := db_queries.UpsertStorePricesParams{StoreID: uid}
+	for _, item := range slices.Sorted(maps.Keys(prices)) {
+		params.Items = append(params.Items, item)
+		params.Prices = append(params.Prices, prices[item])
+	}
+
+	err = r.dbQueries.UpsertStorePrices(ctx, params)
+	if err != nil {
+		// a check violation when a price is negative
+		return dbError(err, fmt.Sprintf("repository: error to set the prices of the store: %s", storeID))
+	}
+
+	return nil
+}
+
+func (r *StorePostgresRepository) ListPrices(storeID string) ([]db_queries.StorePrice, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbQueries.ListStorePrices(ctx, uid)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to list the prices of the store: %s", storeID))
+	}
+
+	return rows, nil
+}
+
+func (r *StorePostgresRepository) DeletePrice(storeID string, item string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	uid, err := convertStringToUUID(storeID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := r.dbQueries.DeleteStorePrice(ctx, db_queries.DeleteStorePriceParams{StoreID: uid, Item: item})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to delete the price of %s in the store: %s", item, storeID))
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("repository: the store %s has no price of %s: %w", storeID, item, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *StorePostgresRepository) SumPrices(owner string, items []string) ([]db_queries.SumStorePricesRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.SumStorePrices(ctx, db_queries.SumStorePricesParams{Items: items, Owner: owner})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to sum the prices in the stores of: %s", owner))
+	}
+
+	return rows, nil
+}
+
+func (r *StorePostgresRepository) CheapestPrices(owner string, items []string) ([]db_queries.CheapestStorePricesRow, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	rows, err := r.dbQueries.CheapestStorePrices(ctx, db_queries.CheapestStorePricesParams{Owner: owner, Items: items})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to find the cheapest prices in the stores of: %s", owner))
+	}
+
+	return rows, nil
+}
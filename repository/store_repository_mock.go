@@ -0,0 +1,231 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/store_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/store_repository.go -package repository -destination repository/store_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStoreRepository is a mock of StoreRepository interface.
+type MockStoreRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStoreRepositoryMockRecorder is the mock recorder for MockStoreRepository.
+type MockStoreRepositoryMockRecorder struct {
+	mock *MockStoreRepository
+}
+
+// NewMockStoreRepository creates a new mock instance.
+func NewMockStoreRepository(ctrl *gomock.Controller) *MockStoreRepository {
+	mock := &MockStoreRepository{ctrl: ctrl}
+	mock.recorder = &MockStoreRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStoreRepository) EXPECT() *MockStoreRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CheapestPrices mocks base method.
+func (m *MockStoreRepository) CheapestPrices(owner string, items []string) ([]db_queries.CheapestStorePricesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheapestPrices", owner, items)
+	ret0, _ := ret[0].([]db_queries.CheapestStorePricesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheapestPrices indicates an expected call of CheapestPrices.
+func (mr *MockStoreRepositoryMockRecorder) CheapestPrices(owner, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheapestPrices", reflect.TypeOf((*MockStoreRepository)(nil).CheapestPrices), owner, items)
+}
+
+// CreateStore mocks base method.
+func (m *MockStoreRepository) CreateStore(owner string, data StoreData) (*db_queries.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStore", owner, data)
+	ret0, _ := ret[0].(*db_queries.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStore indicates an expected call of CreateStore.
+func (mr *MockStoreRepositoryMockRecorder) CreateStore(owner, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStore", reflect.TypeOf((*MockStoreRepository)(nil).CreateStore), owner, data)
+}
+
+// DeletePrice mocks base method.
+func (m *MockStoreRepository) DeletePrice(storeID, item string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePrice", storeID, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePrice indicates an expected call of DeletePrice.
+func (mr *MockStoreRepositoryMockRecorder) DeletePrice(storeID, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrice", reflect.TypeOf((*MockStoreRepository)(nil).DeletePrice), storeID, item)
+}
+
+// DeleteStore mocks base method.
+func (m *MockStoreRepository) DeleteStore(id, owner string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStore", id, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStore indicates an expected call of DeleteStore.
+func (mr *MockStoreRepositoryMockRecorder) DeleteStore(id, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStore", reflect.TypeOf((*MockStoreRepository)(nil).DeleteStore), id, owner)
+}
+
+// GetListStore mocks base method.
+func (m *MockStoreRepository) GetListStore(listID string) (*db_queries.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListStore", listID)
+	ret0, _ := ret[0].(*db_queries.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListStore indicates an expected call of GetListStore.
+func (mr *MockStoreRepositoryMockRecorder) GetListStore(listID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListStore", reflect.TypeOf((*MockStoreRepository)(nil).GetListStore), listID)
+}
+
+// GetStore mocks base method.
+func (m *MockStoreRepository) GetStore(id, owner string) (*db_queries.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStore", id, owner)
+	ret0, _ := ret[0].(*db_queries.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStore indicates an expected call of GetStore.
+func (mr *MockStoreRepositoryMockRecorder) GetStore(id, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStore", reflect.TypeOf((*MockStoreRepository)(nil).GetStore), id, owner)
+}
+
+// ListPrices mocks base method.
+func (m *MockStoreRepository) ListPrices(storeID string) ([]db_queries.StorePrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPrices", storeID)
+	ret0, _ := ret[0].([]db_queries.StorePrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPrices indicates an expected call of ListPrices.
+func (mr *MockStoreRepositoryMockRecorder) ListPrices(storeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPrices", reflect.TypeOf((*MockStoreRepository)(nil).ListPrices), storeID)
+}
+
+// ListStores mocks base method.
+func (m *MockStoreRepository) ListStores(owner string) ([]db_queries.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStores", owner)
+	ret0, _ := ret[0].([]db_queries.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStores indicates an expected call of ListStores.
+func (mr *MockStoreRepositoryMockRecorder) ListStores(owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockStoreRepository)(nil).ListStores), owner)
+}
+
+// SetListStore mocks base method.
+func (m *MockStoreRepository) SetListStore(listID, storeID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetListStore", listID, storeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetListStore indicates an expected call of SetListStore.
+func (mr *MockStoreRepositoryMockRecorder) SetListStore(listID, storeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetListStore", reflect.TypeOf((*MockStoreRepository)(nil).SetListStore), listID, storeID)
+}
+
+// SetPrices mocks base method.
+func (m *MockStoreRepository) SetPrices(storeID string, prices map[string]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrices", storeID, prices)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrices indicates an expected call of SetPrices.
+func (mr *MockStoreRepositoryMockRecorder) SetPrices(storeID, prices any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrices", reflect.TypeOf((*MockStoreRepository)(nil).SetPrices), storeID, prices)
+}
+
+// SumPrices mocks base method.
+func (m *MockStoreRepository) SumPrices(owner string, items []string) ([]db_queries.SumStorePricesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumPrices", owner, items)
+	ret0, _ := ret[0].([]db_queries.SumStorePricesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumPrices indicates an expected call of SumPrices.
+func (mr *MockStoreRepositoryMockRecorder) SumPrices(owner, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumPrices", reflect.TypeOf((*MockStoreRepository)(nil).SumPrices), owner, items)
+}
+
+// UnsetListStore mocks base method.
+func (m *MockStoreRepository) UnsetListStore(listID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsetListStore", listID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsetListStore indicates an expected call of UnsetListStore.
+func (mr *MockStoreRepositoryMockRecorder) UnsetListStore(listID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsetListStore", reflect.TypeOf((*MockStoreRepository)(nil).UnsetListStore), listID)
+}
+
+// UpdateStore mocks base method.
+func (m *MockStoreRepository) UpdateStore(id, owner string, data StoreData) (*db_queries.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStore", id, owner, data)
+	ret0, _ := ret[0].(*db_queries.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStore indicates an expected call of UpdateStore.
+func (mr *MockStoreRepositoryMockRecorder) UpdateStore(id, owner, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStore", reflect.TypeOf((*MockStoreRepository)(nil).UpdateStore), id, owner, data)
+}
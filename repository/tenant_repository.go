@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+)
+
+// TenantRepository has the organizations served by the deployment, they are
+// created with `shopping create-tenant`.
+type TenantRepository interface {
+	CreateTenant(slug string, name string) (*db_queries.Tenant, error)
+	GetTenantBySlug(slug string) (*db_queries.Tenant, error)
+}
+
+type TenantPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewTenantRepository(dbQueries *db_queries.Queries) TenantRepository {
+	return &TenantPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *TenantPostgresRepository) CreateTenant(slug string, name string) (*db_queries.Tenant, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.CreateTenant(ctx, db_queries.CreateTenantParams{
+		Slug: slug,
+		Name: name,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to create the tenant: %s", slug))
+	}
+
+	return &row, nil
+}
+
+func (r *TenantPostgresRepository) GetTenantBySlug(slug string) (*db_queries.Tenant, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the tenant: %s", slug))
+	}
+
+	return &row, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	db_queries "shopping/database/queries"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Repositories are the repositories of a unit of work, all of them use the
+// same transaction.
+type Repositories struct {
+	ShoppingLists   ShoppingListRepository
+	UserPreferences UserPreferencesRepository
+	Audit           AuditRepository
+	Outbox          OutboxRepository
+	Accounts        AccountRepository
+}
+
+// UnitOfWork runs fn in a single transaction, it's committed when fn returns
+// nil and rolled back when it returns an error. Use it when a handler must
+// change several tables atomically, e.g. update a list, record the audit
+// event and enqueue the outbox events.
+//
+// fn is called again when the transaction fails with a transient error, so
+// it must not keep state from a previous call.
+type UnitOfWork interface {
+	Do(fn func(repos Repositories) error) error
+}
+
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type UnitOfWorkPostgres struct {
+	db      TxBeginner
+	retrier *Retrier
+}
+
+// NewUnitOfWork returns a unit of work that retries the whole transaction
+// with the retrier, there are no retries when it's nil.
+func NewUnitOfWork(db TxBeginner, retrier *Retrier) UnitOfWork {
+	return &UnitOfWorkPostgres{
+		db:      db,
+		retrier: retrier,
+	}
+}
+
+// transientTxError marks the attempts that can be retried
+type transientTxError struct {
+	err error
+}
+
+func (e *transientTxError) Error() string {
+	return e.err.Error()
+}
+
+func (u *UnitOfWorkPostgres) Do(fn func(repos Repositories) error) error {
+	if u.retrier == nil {
+		return unwrapTransient(u.attempt(fn))
+	}
+
+	err := u.retrier.do(context.Background(), func() error {
+		return u.attempt(fn)
+	}, func(err error) bool {
+		var transient *transientTxError
+		return errors.As(err, &transient)
+	})
+
+	return unwrapTransient(err)
+}
+
+func unwrapTransient(err error) error {
+	var transient *transientTxError
+	if errors.As(err, &transient) {
+		return transient.err
+	}
+
+	return err
+}
+
+func (u *UnitOfWorkPostgres) attempt(fn func(repos Repositories) error) error {
+	ctx, cancel := transactionContext()
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		log.Err(err).Msg("repository: error to begin the transaction")
+		return transientIf(IsTransient(err), errors.New("repository: error to begin the transaction"))
+	}
+	// it's a no-op after the commit
+	defer tx.Rollback(context.Background())
+
+	observer := &transientObserver{db: tx}
+	dbQueries := db_queries.New(observer)
+	err = fn(Repositories{
+		ShoppingLists:   NewShoppingListRepository(dbQueries),
+		UserPreferences: NewUserPreferencesRepository(dbQueries),
+		Audit:           NewAuditRepository(dbQueries),
+		Outbox:          NewOutboxRepository(dbQueries),
+		Accounts:        NewAccountRepository(dbQueries),
+	})
+	if err != nil {
+		return transientIf(observer.sawTransient(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Err(err).Msg("repository: error to commit the transaction")
+		return transientIf(IsTransient(err), errors.New("repository: error to commit the transaction"))
+	}
+
+	return nil
+}
+
+func transientIf(transient bool, err error) error {
+	if transient {
+		return &transientTxError{err: err}
+	}
+
+	return err
+}
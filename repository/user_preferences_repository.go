@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	db_queries "shopping/database/queries"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type UserPreferencesRepository interface {
+	GetUserPreferences(username string) (*db_queries.UserPreference, error)
+	SaveUserPreferences(prefs db_queries.SaveUserPreferencesParams) (*db_queries.UserPreference, error)
+}
+
+// DefaultUserPreferences are used for the users that never saved their
+// preferences, they match the column defaults.
+func DefaultUserPreferences(username string) *db_queries.UserPreference {
+	return &db_queries.UserPreference{
+		Username:       username,
+		Timezone:       "UTC",
+		Locale:         "en-US",
+		FirstDayOfWeek: int16(time.Monday),
+	}
+}
+
+type UserPreferencesPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewUserPreferencesRepository(dbQueries *db_queries.Queries) UserPreferencesRepository {
+	return &UserPreferencesPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *UserPreferencesPostgresRepository) GetUserPreferences(username string) (*db_queries.UserPreference, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetUserPreferences(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultUserPreferences(username), nil
+	}
+
+	if err != nil {
+		log.Err(err).Msgf("repository: error to get the preferences of the user: %s", username)
+		return nil, errors.New("repository: error to get the user preferences")
+	}
+
+	return &row, nil
+}
+
+func (r *UserPreferencesPostgresRepository) SaveUserPreferences(prefs db_queries.SaveUserPreferencesParams) (*db_queries.UserPreference, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	row, err := r.dbQueries.SaveUserPreferences(ctx, prefs)
+	if err != nil {
+		log.Err(err).Msgf("repository: error to save the preferences of the user: %s", prefs.Username)
+		return nil, errors.New("repository: error to save the user preferences")
+	}
+
+	return &row, nil
+}
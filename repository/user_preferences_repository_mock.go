@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/user_preferences_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source repository/user_preferences_repository.go -package repository -destination repository/user_preferences_repository_mock.go
+//
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	reflect "reflect"
+	db_queries "shopping/database/queries"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserPreferencesRepository is a mock of UserPreferencesRepository interface.
+type MockUserPreferencesRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserPreferencesRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockUserPreferencesRepositoryMockRecorder is the mock recorder for MockUserPreferencesRepository.
+type MockUserPreferencesRepositoryMockRecorder struct {
+	mock *MockUserPreferencesRepository
+}
+
+// NewMockUserPreferencesRepository creates a new mock instance.
+func NewMockUserPreferencesRepository(ctrl *gomock.Controller) *MockUserPreferencesRepository {
+	mock := &MockUserPreferencesRepository{ctrl: ctrl}
+	mock.recorder = &MockUserPreferencesRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserPreferencesRepository) EXPECT() *MockUserPreferencesRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetUserPreferences mocks base method.
+func (m *MockUserPreferencesRepository) GetUserPreferences(username string) (*db_queries.UserPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPreferences", username)
+	ret0, _ := ret[0].(*db_queries.UserPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPreferences indicates an expected call of GetUserPreferences.
+func (mr *MockUserPreferencesRepositoryMockRecorder) GetUserPreferences(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPreferences", reflect.TypeOf((*MockUserPreferencesRepository)(nil).GetUserPreferences), username)
+}
+
+// SaveUserPreferences mocks base method.
+func (m *MockUserPreferencesRepository) SaveUserPreferences(prefs db_queries.SaveUserPreferencesParams) (*db_queries.UserPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserPreferences", prefs)
+	ret0, _ := ret[0].(*db_queries.UserPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveUserPreferences indicates an expected call of SaveUserPreferences.
+func (mr *MockUserPreferencesRepositoryMockRecorder) SaveUserPreferences(prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserPreferences", reflect.TypeOf((*MockUserPreferencesRepository)(nil).SaveUserPreferences), prefs)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	db_queries "shopping/database/queries"
+)
+
+// UserRepository has the users created with `shopping create-admin`, the
+// password is the hash of the passwords package.
+type UserRepository interface {
+	// CreateUser creates the user in the tenant, the lists and the sessions
+	// of the user belong to it
+	CreateUser(username string, role string, passwordHash string, tenantID string) (*db_queries.User, error)
+	GetUserByUsername(username string) (*db_queries.User, error)
+	// UpdatePassword returns ErrNotFound when the user doesn't exist
+	UpdatePassword(username string, passwordHash string) error
+}
+
+type UserPostgresRepository struct {
+	dbQueries *db_queries.Queries
+}
+
+func NewUserRepository(dbQueries *db_queries.Queries) UserRepository {
+	return &UserPostgresRepository{
+		dbQueries: dbQueries,
+	}
+}
+
+func (r *UserPostgresRepository) CreateUser(username string, role string, passwordHash string, tenantID string) (*db_queries.User, error) {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	tenant, err := convertTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.dbQueries.CreateUser(ctx, db_queries.CreateUserParams{
+		Username: username,
+		Role:     role,
+		Password: passwordHash,
+		TenantID: tenant,
+	})
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to create the user: %s", username))
+	}
+
+	return &row, nil
+}
+
+func (r *UserPostgresRepository) GetUserByUsername(username string) (*db_queries.User, error) {
+	ctx, cancel := readContext()
+	defer cancel()
+
+	row, err := r.dbQueries.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, dbError(err, fmt.Sprintf("repository: error to get the user: %s", username))
+	}
+
+	return &row, nil
+}
+
+func (r *UserPostgresRepository) UpdatePassword(username string, passwordHash string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+
+	updated, err := r.dbQueries.UpdateUserPassword(ctx, db_queries.UpdateUserPasswordParams{
+		Username: username,
+		Password: passwordHash,
+	})
+	if err != nil {
+		return dbError(err, fmt.Sprintf("repository: error to update the password of the user: %s", username))
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("repository: the user %s doesn't exist: %w", username, ErrNotFound)
+	}
+
+	return nil
+}
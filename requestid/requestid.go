@@ -0,0 +1,37 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the id of the request, the one of the client is kept when
+// it's valid so the logs of a proxy and of the API can be joined
+const Header = "X-Request-ID"
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+type contextKey struct{}
+
+// Middleware gives an id to every request, it's sent back in the header of
+// the response
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !validID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// FromContext returns the id of the request, empty out of Middleware
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,40 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{name: "keeps the id of the client", incoming: "proxy-42.a_b", kept: true},
+		{name: "generates one when missing", incoming: ""},
+		{name: "replaces an invalid one", incoming: "id\nwith a new line"},
+		{name: "replaces a long one", incoming: strings.Repeat("a", 129)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(Header, tc.incoming)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(Header))
+			assert.Equal(t, tc.kept, seen == tc.incoming)
+		})
+	}
+}
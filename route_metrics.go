@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"shopping/render"
+	"time"
+)
+
+// routeStats are published in /debug/vars as http_routes: a map for each
+// route keyed by its method and pattern, so there are as many as routes
+// in the table, with the requests, the responses by status class (2xx, 3xx,
+// 4xx and 5xx) and the total duration_ms
+var routeStats = expvar.NewMap("http_routes")
+
+// routeMetrics returns the stats of a route, the tests register the routes
+// many times
+func routeMetrics(key string) *expvar.Map {
+	if stats, ok := routeStats.Get(key).(*expvar.Map); ok {
+		return stats
+	}
+
+	stats := new(expvar.Map).Init()
+	routeStats.Set(key, stats)
+	return stats
+}
+
+// withRouteMetrics counts the requests of the route by the pattern instead
+// of the path, the panics are counted as 5xx
+func withRouteMetrics(key string, next http.HandlerFunc) http.HandlerFunc {
+	stats := routeMetrics(key)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			p := recover()
+			if p != nil {
+				sw.status = http.StatusInternalServerError
+			}
+
+			stats.Add("requests", 1)
+			stats.Add(fmt.Sprintf("%dxx", sw.status/100), 1)
+			stats.AddFloat("duration_ms", float64(time.Since(start).Microseconds())/1000)
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next(sw, r)
+	}
+}
+
+// statusWriter keeps the status of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap lets http.ResponseController flush the event streams
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type RouteResponse struct {
+	RegisteredRoute
+	// the http_routes stats of the route, null for the routes outside the
+	// table like the static files
+	Metrics json.RawMessage `json:"metrics"`
+}
+
+// handleListRoutes returns the routes of the mux with their stats, ?auth=
+// keeps the routes of an auth level, like the public ones
+func (app *App) handleListRoutes(w http.ResponseWriter, r *http.Request) {
+	auth := r.URL.Query().Get("auth")
+	if auth != "" && auth != authPublic && auth != authSession && auth != authPermission {
+		http.Error(w, fmt.Sprintf("'auth' must be %s, %s or %s", authPublic, authSession, authPermission), http.StatusBadRequest)
+		return
+	}
+
+	routes := []RouteResponse{}
+	for _, route := range app.routeMux.Routes() {
+		if auth != "" && route.Auth != auth {
+			continue
+		}
+
+		metrics := json.RawMessage("null")
+		if stats, ok := routeStats.Get(route.Method + " " + route.Pattern).(*expvar.Map); ok {
+			metrics = json.RawMessage(stats.String())
+		}
+		routes = append(routes, RouteResponse{RegisteredRoute: route, Metrics: metrics})
+	}
+
+	render.JSON(w, http.StatusOK, routes)
+}
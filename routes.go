@@ -0,0 +1,433 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"shopping/authz"
+	"shopping/clientip"
+	"shopping/loadshed"
+	"shopping/render"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxBodyBytes = 64 << 10
+
+// Route describes an endpoint of the API, the table is used to register the
+// handlers and to answer the OPTIONS requests so the metadata can't drift
+// from what the server enforces.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	// Action is the permission required to call the route, the route is
+	// public when it's empty
+	Action authz.Action
+	// Idempotent routes can be retried safely after a timeout
+	Idempotent bool
+	// MaxBodyBytes limits the request body of the POST, PUT and PATCH
+	// routes, defaultMaxBodyBytes is used when it's 0
+	MaxBodyBytes int64
+	// Timeout of the handler, REQUEST_TIMEOUT is used when it's 0 and
+	// requestTimeoutNone disables it
+	Timeout time.Duration
+	// MaxConcurrent caps the requests of the expensive routes served at the
+	// same time, under the limit of the whole server. No limit when it's 0
+	MaxConcurrent int
+	// RateLimit is the requests per minute of each API key to the route, on
+	// top of the rate quota of the user. No limit when it's 0
+	RateLimit int
+	// Signable GET routes can be served by the signed URLs of
+	// POST /v1/signed-urls without a session
+	Signable bool
+	// Session routes only need a session, like the logout. The routes with
+	// an Action need one too
+	Session bool
+	Handler http.HandlerFunc
+	// Middlewares are added around the authorization, the first one is the
+	// outermost
+	Middlewares []Middleware
+}
+
+// Middleware is a route specific middleware, its name is listed by the
+// routes introspection
+type Middleware struct {
+	Name string
+	Wrap func(next http.HandlerFunc) http.HandlerFunc
+}
+
+// RouteMetadata is the description of a method returned by OPTIONS
+type RouteMetadata struct {
+	Summary       string   `json:"summary"`
+	Idempotent    bool     `json:"idempotent"`
+	AuthRequired  bool     `json:"auth_required"`
+	Scopes        []string `json:"scopes"`
+	MaxBodyBytes  int64    `json:"max_body_bytes,omitempty"`
+	MaxConcurrent int      `json:"max_concurrent,omitempty"`
+	RateLimit     int      `json:"rate_limit,omitempty"`
+	Signable      bool     `json:"signable,omitempty"`
+}
+
+type PathMetadata struct {
+	Path    string                   `json:"path"`
+	Methods map[string]RouteMetadata `json:"methods"`
+}
+
+func (route Route) hasBody() bool {
+	return route.Method == http.MethodPost || route.Method == http.MethodPut || route.Method == http.MethodPatch
+}
+
+func (route Route) maxBodyBytes() int64 {
+	if !route.hasBody() {
+		return 0
+	}
+
+	if route.MaxBodyBytes == 0 {
+		return defaultMaxBodyBytes
+	}
+
+	return route.MaxBodyBytes
+}
+
+func (route Route) metadata() RouteMetadata {
+	scopes := []string{}
+	if route.Action != "" {
+		scopes = append(scopes, string(route.Action))
+	}
+
+	return RouteMetadata{
+		Summary:       route.Summary,
+		Idempotent:    route.Idempotent,
+		AuthRequired:  route.Action != "",
+		Scopes:        scopes,
+		MaxBodyBytes:  route.maxBodyBytes(),
+		MaxConcurrent: route.MaxConcurrent,
+		RateLimit:     route.RateLimit,
+		Signable:      route.Signable,
+	}
+}
+
+// the auth levels of the routes
+const (
+	authPublic     = "public"
+	authSession    = "session"
+	authPermission = "permission"
+)
+
+// auth is the credential the route requires, the public routes can still
+// check a token of their own like the share links
+func (route Route) auth() string {
+	switch {
+	case route.Action != "":
+		return authPermission
+	case route.Session:
+		return authSession
+	default:
+		return authPublic
+	}
+}
+
+func (app *App) routes() []Route {
+	return []Route{
+		{Method: "POST", Path: "/v1/lists", Summary: "Create a list", Action: authz.ActionListCreate, Handler: app.handleCreateList, Middlewares: []Middleware{{Name: "cache_headers", Wrap: app.addCacheHeaders}}},
+		{Method: "GET", Path: "/v1/lists", Summary: "Get all the lists", Action: authz.ActionListRead, Idempotent: true, Handler: app.handleGetLists},
+		{Method: "PUT", Path: "/v1/lists/{id}", Summary: "Replace a list", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handleUpdateList},
+		{Method: "DELETE", Path: "/v1/lists/{id}", Summary: "Delete a list", Action: authz.ActionListDelete, Idempotent: true, Handler: app.handleDeleteList},
+		// the fields of the patch are replaced, so it can be repeated
+		{Method: "PATCH", Path: "/v1/lists/{id}", Summary: "Update some fields of a list", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handlePatchList},
+		{Method: "GET", Path: "/v1/lists/{id}", Summary: "Get a list as json, csv or text", Action: authz.ActionListRead, Idempotent: true, Signable: true, Handler: app.handleGetList},
+		{Method: "POST", Path: "/v1/lists/{id}/push", Summary: "Add an item to a list", Action: authz.ActionListUpdate, Handler: app.handleListPush},
+		{Method: "POST", Path: "/v1/lists/{id}/undo", Summary: "Revert the last change of a list made in the UNDO_WINDOW", Action: authz.ActionListUpdate, Handler: app.handleUndoList},
+		{Method: "POST", Path: "/v1/lists/{id}/pop", Summary: "Remove the last item of a list", Action: authz.ActionListUpdate, Handler: app.handlePopItem},
+		{Method: "DELETE", Path: "/v1/lists/{id}/items/{itemId}", Summary: "Remove an item of a list by its position from 1 or its name", Action: authz.ActionListUpdate, Handler: app.handleRemoveItem},
+		{Method: "PATCH", Path: "/v1/lists/{id}/items/{itemId}", Summary: "Rename an item of a list by its position from 1 or its name", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handleRenameItem},
+		{Method: "POST", Path: "/v1/lists/{id}/items/by-barcode", Summary: "Add the product of a barcode to a list", Action: authz.ActionListUpdate, Handler: app.handleAddItemByBarcode},
+		{Method: "POST", Path: "/v1/lists/{id}/import-recipe", Summary: "Add the ingredients of a recipe page or text to a list", Action: authz.ActionListUpdate, RateLimit: 30, Handler: app.handleImportRecipe},
+		{Method: "PUT", Path: "/v1/lists/{id}/store", Summary: "Set the store a list is shopped in", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handleSetListStore},
+		{Method: "GET", Path: "/v1/lists/{id}/store", Summary: "Get the store of a list", Action: authz.ActionListRead, Idempotent: true, Handler: app.handleGetListStore},
+		{Method: "DELETE", Path: "/v1/lists/{id}/store", Summary: "Unset the store of a list", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handleUnsetListStore},
+		{Method: "GET", Path: "/v1/lists/{id}/price-comparison", Summary: "Total of a list in each store and the cheapest split", Action: authz.ActionListRead, Idempotent: true, MaxConcurrent: 10, Handler: app.handlePriceComparison},
+		// the uploads are also limited by PHOTO_MAX_BYTES
+		{Method: "POST", Path: "/v1/lists/{id}/items/{itemId}/photo", Summary: "Upload the photo of an item, multipart in the photo field", Action: authz.ActionListUpdate, MaxBodyBytes: 32 << 20, Timeout: time.Minute, Handler: app.handleUploadItemPhoto},
+		{Method: "GET", Path: "/v1/lists/{id}/items/{itemId}/photo", Summary: "Get the signed URLs of the photo of an item", Action: authz.ActionListRead, Idempotent: true, Handler: app.handleGetItemPhoto},
+		{Method: "GET", Path: "/v1/lists/{id}/items/{itemId}/photo/{variant}", Summary: "Download the original or the thumbnail of the photo of an item", Action: authz.ActionListRead, Idempotent: true, Signable: true, Handler: app.handleDownloadItemPhoto},
+		{Method: "DELETE", Path: "/v1/lists/{id}/items/{itemId}/photo", Summary: "Delete the photo of an item", Action: authz.ActionListUpdate, Idempotent: true, Handler: app.handleDeleteItemPhoto},
+		{Method: "GET", Path: "/v1/photos/{token}", Summary: "Download a photo with a signed URL", Idempotent: true, Handler: app.handleDownloadPhoto},
+		{Method: "POST", Path: "/v1/lists/{id}/complete", Summary: "Complete a list and record the purchase", Action: authz.ActionListComplete, Handler: app.handleCompleteList},
+		{Method: "GET", Path: "/v1/lists/{id}/export", Summary: "Export a list", Action: authz.ActionListExport, Idempotent: true, Signable: true, Handler: app.handleExportList},
+		{Method: "GET", Path: "/v1/export", Summary: "Export all the lists of the account", Action: authz.ActionListExport, Idempotent: true, Timeout: time.Minute, MaxConcurrent: 5, Signable: true, RateLimit: 10, Handler: app.handleExportAccount},
+		{Method: "GET", Path: "/v1/lists/{id}/portable", Summary: "Export a list in the portable format", Action: authz.ActionListExport, Idempotent: true, Signable: true, Handler: app.handleExportPortable},
+		{Method: "POST", Path: "/v1/lists/portable", Summary: "Import a list in the portable format", Action: authz.ActionListCreate, MaxBodyBytes: 1 << 20, Handler: app.handleImportPortable},
+		{Method: "GET", Path: "/v1/lists/portable/schema", Summary: "JSON schema of the portable format", Idempotent: true, Handler: app.handleGetPortableSchema},
+		{Method: "POST", Path: "/v1/lists/{id}/share-link", Summary: "Create a public link to a list", Action: authz.ActionListShare, Handler: app.handleCreateShareLink},
+		{Method: "POST", Path: "/v1/lists/{id}/reminders", Summary: "Remind the user of a list at a time", Action: authz.ActionListRemind, Handler: app.handleCreateReminder},
+		{Method: "GET", Path: "/v1/lists/{id}/reminders", Summary: "Reminders of the user in a list", Action: authz.ActionListRemind, Idempotent: true, Handler: app.handleListReminders},
+		{Method: "DELETE", Path: "/v1/lists/{id}/reminders/{reminderID}", Summary: "Cancel a reminder not delivered yet", Action: authz.ActionListRemind, Idempotent: true, Handler: app.handleCancelReminder},
+		{Method: "GET", Path: "/v1/shared/{token}", Summary: "Get a shared list", Idempotent: true, Handler: app.handleGetShared},
+		{Method: "GET", Path: "/v1/shared/{token}/embed", Summary: "Embeddable page of a shared list", Idempotent: true, Handler: app.handleGetSharedEmbed},
+		{Method: "GET", Path: "/v1/shared/{token}/events", Summary: "Server sent events of a shared list", Idempotent: true, Timeout: requestTimeoutNone, Handler: app.handleSharedEvents},
+
+		{Method: "GET", Path: "/v1/lists/search", Summary: "Search the lists of the user by name, items and tags", Action: authz.ActionListSearch, Idempotent: true, MaxConcurrent: 20, Handler: app.handleSearchLists},
+
+		{Method: "GET", Path: "/v1/items/suggest", Summary: "Suggest item names", Action: authz.ActionItemsSuggest, Idempotent: true, Handler: app.handleSuggestItems},
+		{Method: "POST", Path: "/v1/stores", Summary: "Create a store with its aisles", Action: authz.ActionStoresManage, Handler: app.handleCreateStore},
+		{Method: "GET", Path: "/v1/stores", Summary: "Get the stores of the user", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleListStores},
+		{Method: "GET", Path: "/v1/stores/{storeID}", Summary: "Get a store", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleGetStore},
+		{Method: "PUT", Path: "/v1/stores/{storeID}", Summary: "Replace a store", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleUpdateStore},
+		{Method: "DELETE", Path: "/v1/stores/{storeID}", Summary: "Delete a store", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleDeleteStore},
+		{Method: "GET", Path: "/v1/stores/{storeID}/prices", Summary: "Get the prices of the items in a store", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleListPrices},
+		// the prices of the body are set, the other ones are kept
+		{Method: "PATCH", Path: "/v1/stores/{storeID}/prices", Summary: "Set the prices of items in a store from json or csv", Action: authz.ActionStoresManage, Idempotent: true, MaxBodyBytes: 1 << 20, Handler: app.handleSetPrices},
+		{Method: "DELETE", Path: "/v1/stores/{storeID}/prices/{item}", Summary: "Delete the price of an item in a store", Action: authz.ActionStoresManage, Idempotent: true, Handler: app.handleDeletePrice},
+		{Method: "GET", Path: "/v1/products/lookup", Summary: "Find the product of a barcode", Action: authz.ActionProductsLookup, Idempotent: true, RateLimit: 60, Handler: app.handleLookupProduct},
+
+		{Method: "GET", Path: "/v1/stats/frequent-items", Summary: "Most purchased items", Action: authz.ActionStatsRead, Idempotent: true, MaxConcurrent: 10, Handler: app.handleFrequentItems},
+		{Method: "GET", Path: "/v1/stats/spend-by-month", Summary: "Spend by month", Action: authz.ActionStatsRead, Idempotent: true, MaxConcurrent: 10, Handler: app.handleSpendByMonth},
+		{Method: "GET", Path: "/v1/stats/lists-per-week", Summary: "Completed lists by week", Action: authz.ActionStatsRead, Idempotent: true, MaxConcurrent: 10, Handler: app.handleListsPerWeek},
+
+		{Method: "GET", Path: "/v1/users/me/preferences", Summary: "Get the preferences of the user", Action: authz.ActionPreferencesRead, Idempotent: true, Handler: app.handleGetPreferences},
+		{Method: "PATCH", Path: "/v1/users/me/preferences", Summary: "Update the preferences of the user", Action: authz.ActionPreferencesUpdate, Idempotent: true, Handler: app.handlePatchPreferences},
+		{Method: "GET", Path: "/v1/users/me/notifications", Summary: "Get the notification channels and digest of the user", Action: authz.ActionPreferencesRead, Idempotent: true, Handler: app.handleGetNotificationPreferences},
+		{Method: "PATCH", Path: "/v1/users/me/notifications", Summary: "Update the notification channels and digest of the user", Action: authz.ActionPreferencesUpdate, Idempotent: true, Handler: app.handlePatchNotificationPreferences},
+
+		{Method: "PUT", Path: "/v1/me/password", Summary: "Change the password and log out the other devices", Action: authz.ActionAccountPassword, Handler: app.handleChangePassword},
+		{Method: "GET", Path: "/v1/me/export", Summary: "Export everything stored about the user as a zip of JSON files and photos", Action: authz.ActionAccountData, Idempotent: true, Timeout: 2 * time.Minute, MaxConcurrent: 2, Signable: true, RateLimit: 5, Handler: app.handleExportMe},
+		{Method: "DELETE", Path: "/v1/me", Summary: "Erase the account and its data after the grace period of ACCOUNT_DELETION_GRACE", Action: authz.ActionAccountData, Idempotent: true, Handler: app.handleDeleteMe},
+		{Method: "GET", Path: "/v1/me/deletion", Summary: "Get the date the account will be erased", Action: authz.ActionAccountData, Idempotent: true, Handler: app.handleGetAccountDeletion},
+		{Method: "DELETE", Path: "/v1/me/deletion", Summary: "Cancel the deletion of the account during the grace period", Action: authz.ActionAccountData, Idempotent: true, Handler: app.handleCancelAccountDeletion},
+
+		{Method: "GET", Path: "/v1/account/bundle", Summary: "Export the signed bundle that moves the account to another deployment", Action: authz.ActionAccountMove, Idempotent: true, Timeout: 2 * time.Minute, MaxConcurrent: 2, RateLimit: 5, Handler: app.handleExportAccountBundle},
+		{Method: "POST", Path: "/v1/account/bundle", Summary: "Import the bundle of another deployment", Action: authz.ActionAccountMove, MaxBodyBytes: 16 << 20, Timeout: 2 * time.Minute, MaxConcurrent: 2, RateLimit: 5, Handler: app.handleImportAccountBundle},
+
+		{Method: "POST", Path: "/v1/admin/sandbox/reset", Summary: "Delete the data of every user and seed the demo data again, sandbox deployments only", Action: authz.ActionSandboxReset, Timeout: 2 * time.Minute, MaxConcurrent: 1, Handler: app.handleSandboxReset},
+
+		{Method: "POST", Path: "/v1/admin/search/rebuild", Summary: "Rebuild the search index in the background", Action: authz.ActionSearchRebuild, Handler: app.handleRebuildSearchIndex},
+		{Method: "GET", Path: "/v1/admin/search/rebuild", Summary: "Status of the last rebuild of the search index", Action: authz.ActionSearchRebuild, Idempotent: true, Handler: app.handleSearchIndexStatus},
+
+		{Method: "GET", Path: "/v1/admin/consistency", Summary: "Report of the last consistency check of the search index and the caches", Action: authz.ActionConsistencyCheck, Idempotent: true, Handler: app.handleConsistencyReport},
+		{Method: "POST", Path: "/v1/admin/consistency/check", Summary: "Run the consistency checks now, repair=true fixes the discrepancies", Action: authz.ActionConsistencyCheck, Timeout: 5 * time.Minute, MaxConcurrent: 1, Handler: app.handleRunConsistencyChecks},
+
+		{Method: "POST", Path: "/v1/admin/cache/flush", Summary: "Empty the caches of the instance, cache=lists or cache=stats only flushes one", Action: authz.ActionCacheFlush, Handler: app.handleFlushCaches},
+		{Method: "DELETE", Path: "/v1/admin/cache/lists/{id}", Summary: "Drop one list from the cache of the instance", Action: authz.ActionCacheFlush, Idempotent: true, Handler: app.handleInvalidateCachedList},
+
+		{Method: "GET", Path: "/v1/admin/requests", Summary: "Last requests of the access log, filtered by username, min_status and since", Action: authz.ActionRequestsRead, Idempotent: true, Handler: app.handleListRequests},
+
+		{Method: "POST", Path: "/v1/admin/backups", Summary: "Back up the data of every user to the bucket of BACKUP_S3_BUCKET in the background", Action: authz.ActionBackupsManage, MaxConcurrent: 1, Handler: app.handleCreateBackup},
+		{Method: "GET", Path: "/v1/admin/backups", Summary: "Status of the last backup of the instance", Action: authz.ActionBackupsManage, Idempotent: true, Handler: app.handleBackupStatus},
+		{Method: "POST", Path: "/v1/admin/restore", Summary: "Restore a backup of the bucket into an empty database in the background", Action: authz.ActionBackupsManage, Timeout: 5 * time.Minute, MaxConcurrent: 1, Handler: app.handleRestore},
+		{Method: "GET", Path: "/v1/admin/restore", Summary: "Progress of the last restore of the instance", Action: authz.ActionBackupsManage, Idempotent: true, Handler: app.handleRestoreStatus},
+
+		{Method: "GET", Path: "/v1/admin/signing-keys", Summary: "Ids of the keys of the share links and the signed URLs, and which one signs", Action: authz.ActionSigningKeysManage, Idempotent: true, Handler: app.handleListSigningKeys},
+		{Method: "POST", Path: "/v1/admin/signing-keys/rotate", Summary: "Add a new signing key, the previous ones keep verifying until what they signed expires", Action: authz.ActionSigningKeysManage, Handler: app.handleRotateSigningKeys},
+
+		{Method: "GET", Path: "/v1/admin/quotas", Summary: "Default quotas and the quotas of the users and the tenants", Action: authz.ActionQuotasManage, Idempotent: true, Handler: app.handleListQuotas},
+		{Method: "PUT", Path: "/v1/admin/quotas/{scope}/{subject}", Summary: "Set the quotas of users/{username} or tenants/{slug}, null keeps the wider quota", Action: authz.ActionQuotasManage, Idempotent: true, Handler: app.handleSetQuota},
+		{Method: "DELETE", Path: "/v1/admin/quotas/{scope}/{subject}", Summary: "Remove the quotas of a user or a tenant", Action: authz.ActionQuotasManage, Idempotent: true, Handler: app.handleDeleteQuota},
+
+		{Method: "GET", Path: "/v1/admin/runtime", Summary: "Build, listeners, database, caches and features of the instance", Action: authz.ActionRuntimeRead, Idempotent: true, Handler: app.handleRuntimeInfo},
+		{Method: "GET", Path: "/v1/admin/routes", Summary: "Routes of the instance with their auth, middlewares and metrics, filtered by auth", Action: authz.ActionRuntimeRead, Idempotent: true, Handler: app.handleListRoutes},
+
+		{Method: "GET", Path: "/debug/vars", Summary: "Runtime metrics, like the database retries and the saturation", Action: authz.ActionMetricsRead, Idempotent: true, Handler: expvar.Handler().ServeHTTP},
+
+		{Method: "POST", Path: "/v1/signed-urls", Summary: "Sign a URL that serves a signable route without a session until it expires", Action: authz.ActionSignedURLCreate, Handler: app.handleCreateSignedURL},
+		// the signed path is authorized for the user that signed it
+		{Method: "GET", Path: signedURLPrefix + "/{path...}", Summary: "Get a signable route with a signed URL", Idempotent: true, Handler: app.handleSigned},
+
+		{Method: "POST", Path: "/v1/login", Summary: "Create a session", Handler: app.handleLogin},
+		{Method: "POST", Path: "/v1/logout", Summary: "Delete the session and its cookies", Session: true, Handler: app.handleLogout},
+	}
+}
+
+// RegisteredRoute is a route as the mux serves it, with the middlewares
+// around its handler from the outermost
+type RegisteredRoute struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Summary string `json:"summary,omitempty"`
+	// public, session or permission
+	Auth        string   `json:"auth"`
+	Action      string   `json:"action,omitempty"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// RouteMux is the mux of the server, it remembers the routes added with
+// Register so they can be listed and measured by their pattern
+type RouteMux struct {
+	*http.ServeMux
+	mu     sync.Mutex
+	routes []RegisteredRoute
+}
+
+func NewRouteMux() *RouteMux {
+	return &RouteMux{ServeMux: http.NewServeMux()}
+}
+
+// Register serves the route with the handler and remembers it
+func (m *RouteMux) Register(route RegisteredRoute, handler http.Handler) {
+	m.ServeMux.Handle(route.Method+" "+route.Pattern, handler)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, route)
+}
+
+// Routes returns the registered routes in the order they were added
+func (m *RouteMux) Routes() []RegisteredRoute {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.routes)
+}
+
+// registerRoutes adds the routes to the mux and an OPTIONS handler for each
+// path that describes its methods. The Signable routes are also added to the
+// mux of the signed URLs.
+func (app *App) registerRoutes(mux *RouteMux, routes []Route) {
+	app.routeMux = mux
+	app.signedMux = http.NewServeMux()
+	paths := []string{}
+	byPath := map[string][]Route{}
+
+	for _, route := range routes {
+		handler := route.Handler
+		middlewares := []string{}
+		use := func(name string, middleware func(next http.HandlerFunc) http.HandlerFunc) {
+			handler = middleware(handler)
+			middlewares = append([]string{name}, middlewares...)
+		}
+
+		switch route.auth() {
+		case authPermission:
+			use("rate_limit", func(next http.HandlerFunc) http.HandlerFunc {
+				return app.rateLimited(route, next)
+			})
+			use("authorize", func(next http.HandlerFunc) http.HandlerFunc {
+				return app.authorized(route.Action, next)
+			})
+		case authSession:
+			use("authenticate", app.authRequired)
+		}
+		for _, middleware := range slices.Backward(route.Middlewares) {
+			use(middleware.Name, middleware.Wrap)
+		}
+		if route.admin() {
+			use("admin_ip_filter", app.adminIPFilter)
+		}
+		if route.hasBody() {
+			use("body_limit", func(next http.HandlerFunc) http.HandlerFunc {
+				return limitBody(route.maxBodyBytes(), next)
+			})
+		}
+		use("timeout", func(next http.HandlerFunc) http.HandlerFunc {
+			return app.withTimeout(route, next)
+		})
+		if route.MaxConcurrent > 0 {
+			use("concurrency_limit", func(next http.HandlerFunc) http.HandlerFunc {
+				return app.routeLimiter(route).Middleware(next).ServeHTTP
+			})
+		}
+		use("access_log_route", func(next http.HandlerFunc) http.HandlerFunc {
+			return withAccessLogRoute(route.Path, next)
+		})
+		use("metrics", func(next http.HandlerFunc) http.HandlerFunc {
+			return withRouteMetrics(route.Method+" "+route.Path, next)
+		})
+
+		mux.Register(RegisteredRoute{
+			Method:      route.Method,
+			Pattern:     route.Path,
+			Summary:     route.Summary,
+			Auth:        route.auth(),
+			Action:      string(route.Action),
+			Middlewares: middlewares,
+		}, handler)
+		if route.Signable && route.Method == http.MethodGet {
+			app.signedMux.HandleFunc(route.Method+" "+route.Path, handler)
+		}
+
+		if byPath[route.Path] == nil {
+			paths = append(paths, route.Path)
+		}
+		byPath[route.Path] = append(byPath[route.Path], route)
+	}
+
+	for _, path := range paths {
+		mux.HandleFunc("OPTIONS "+path, handleRouteOptions(path, byPath[path]))
+	}
+}
+
+func handleRouteOptions(path string, routes []Route) http.HandlerFunc {
+	metadata := PathMetadata{Path: path, Methods: map[string]RouteMetadata{}}
+	allow := []string{http.MethodOptions}
+	for _, route := range routes {
+		metadata.Methods[route.Method] = route.metadata()
+		allow = append(allow, route.Method)
+		if route.Method == http.MethodGet {
+			allow = append(allow, http.MethodHead)
+		}
+	}
+	slices.Sort(allow)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+
+		render.JSON(w, http.StatusOK, metadata)
+	}
+}
+
+// admin routes belong to the operators of the deployment
+func (route Route) admin() bool {
+	return strings.HasPrefix(route.Path, "/v1/admin/") || strings.HasPrefix(route.Path, "/debug/")
+}
+
+// adminIPFilter applies ADMIN_ALLOW_IPS and ADMIN_DENY_IPS, they are read on
+// every request so they can be reloaded
+func (app *App) adminIPFilter(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := app.settings()
+		if settings == nil {
+			next(w, r)
+			return
+		}
+
+		// the lists were validated with the config
+		filter, err := clientip.NewFilter(settings.AdminAllowIPs, settings.AdminDenyIPs)
+		if err != nil || !filter.Allowed(clientip.FromRequest(r)) {
+			http.Error(w, "the admin routes can't be called from this address", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func limitBody(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
+
+// routeLimiter caps the requests of the route, the ones over the limit wait
+// in a queue as long as the route limit and are shed with a 503
+func (app *App) routeLimiter(route Route) *loadshed.Limiter {
+	queueTimeout := time.Duration(0)
+	if app.Config != nil {
+		queueTimeout = app.Config.RequestQueueTimeout
+	}
+
+	return loadshed.NewLimiter(loadshed.Options{
+		Name:          route.Method + " " + route.Path,
+		MaxConcurrent: route.MaxConcurrent,
+		MaxQueue:      route.MaxConcurrent,
+		QueueTimeout:  queueTimeout,
+	})
+}
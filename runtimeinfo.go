@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"shopping/render"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// RuntimeInfo describes the running instance, it's logged at startup and
+// served in /v1/admin/runtime so the operators can check a deploy
+type RuntimeInfo struct {
+	Build     BuildInfo    `json:"build"`
+	StartedAt time.Time    `json:"started_at"`
+	Uptime    string       `json:"uptime"`
+	AppEnv    string       `json:"app_env"`
+	Listeners []string     `json:"listeners"`
+	Database  DatabaseInfo `json:"database"`
+	Caches    []CacheInfo  `json:"caches"`
+	Features  FeaturesInfo `json:"features"`
+}
+
+type BuildInfo struct {
+	GoVersion  string `json:"go_version"`
+	Version    string `json:"version"` // of the main module, (devel) for local builds
+	Revision   string `json:"revision,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified"` // built with uncommitted changes
+}
+
+type DatabaseInfo struct {
+	Host    string `json:"host"`
+	Port    uint16 `json:"port"`
+	Name    string `json:"name"`
+	User    string `json:"user"`
+	SSLMode string `json:"ssl_mode,omitempty"`
+	// DB_QUERY_EXEC_MODE, empty when it's the one of the url
+	QueryExecMode string `json:"query_exec_mode,omitempty"`
+	// the version of schema_migrations and the last migration built in the
+	// binary, they differ until the pending migrations are applied
+	MigrationVersion uint   `json:"migration_version"`
+	LatestMigration  uint   `json:"latest_migration"`
+	MigrationDirty   bool   `json:"migration_dirty"`
+	MigrationError   string `json:"migration_error,omitempty"`
+}
+
+type CacheInfo struct {
+	Name     string `json:"name"`
+	Backend  string `json:"backend"`
+	Entries  int    `json:"entries"`
+	Capacity int    `json:"capacity"`
+	TTL      string `json:"ttl,omitempty"`
+}
+
+type FeaturesInfo struct {
+	AuthzEngine           string `json:"authz_engine"`
+	SearchBackend         string `json:"search_backend"`
+	ProductsProvider      string `json:"products_provider"`
+	SwaggerAccess         string `json:"swagger_access"`
+	UniqueListNames       bool   `json:"unique_list_names"`
+	AccountMoves          bool   `json:"account_moves"`
+	Sandbox               bool   `json:"sandbox"`
+	AccessLog             bool   `json:"access_log"`
+	Notifications         bool   `json:"notifications"`
+	OutboxDispatcher      bool   `json:"outbox_dispatcher"`
+	OutboxWebhooks        int    `json:"outbox_webhooks"`
+	MaxConcurrentRequests int    `json:"max_concurrent_requests"`
+	MaxQueuedRequests     int    `json:"max_queued_requests"`
+	// 0s when disabled
+	ConsistencyCheckInterval string `json:"consistency_check_interval"`
+	SecretsRefreshInterval   string `json:"secrets_refresh_interval"`
+	// 0s when the backups are only made on demand
+	BackupInterval string `json:"backup_interval,omitempty"`
+}
+
+// the sizes of the caches, they are created in runServe
+const (
+	listsCacheSize        = 128
+	missingListsCacheSize = 1024
+	statsCacheSize        = 1024
+	statsCacheTTL         = 5 * time.Minute
+)
+
+func buildInfo() BuildInfo {
+	info := BuildInfo{Version: "unknown"}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = build.GoVersion
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// runtimeInfo collects the info of the instance, the migration version is
+// read from the database on every call
+func (app *App) runtimeInfo(ctx context.Context) RuntimeInfo {
+	config := app.settings()
+
+	info := RuntimeInfo{
+		Build:     buildInfo(),
+		StartedAt: app.startedAt,
+		Uptime:    time.Since(app.startedAt).Round(time.Second).String(),
+		AppEnv:    config.AppEnv,
+		Listeners: app.listeners,
+		Caches: []CacheInfo{
+			{Name: "lists", Backend: "memory-lru", Entries: app.ListsCache.Len(), Capacity: listsCacheSize},
+			{Name: "stats", Backend: "memory-lru", Entries: app.StatsCache.Len(), Capacity: statsCacheSize, TTL: statsCacheTTL.String()},
+		},
+		Features: FeaturesInfo{
+			AuthzEngine:              config.AuthzEngine,
+			SearchBackend:            config.SearchBackend,
+			ProductsProvider:         config.ProductsProvider,
+			SwaggerAccess:            config.SwaggerAccess,
+			UniqueListNames:          config.UniqueListNames,
+			AccountMoves:             app.secret("ACCOUNT_MOVE_SECRET", config.AccountMoveSecret) != "",
+			Sandbox:                  config.Sandbox,
+			AccessLog:                config.AccessLog,
+			Notifications:            config.NotificationsEnabled,
+			OutboxDispatcher:         config.OutboxDispatcher,
+			OutboxWebhooks:           len(config.OutboxWebhookURLs),
+			MaxConcurrentRequests:    config.MaxConcurrentRequests,
+			MaxQueuedRequests:        config.MaxQueuedRequests,
+			ConsistencyCheckInterval: config.ConsistencyCheckInterval.String(),
+			SecretsRefreshInterval:   config.SecretsRefreshInterval.String(),
+		},
+	}
+	if app.BackupStore != nil {
+		info.Features.BackupInterval = config.BackupInterval.String()
+	}
+	if info.Listeners == nil {
+		info.Listeners = []string{}
+	}
+	if config.ListsCacheTTL > 0 {
+		info.Caches[0].TTL = config.ListsCacheTTL.String()
+	}
+	if app.MissingLists != nil {
+		info.Caches = append(info.Caches, CacheInfo{
+			Name:     "missing_lists",
+			Backend:  "memory-lru",
+			Entries:  app.MissingLists.Len(),
+			Capacity: missingListsCacheSize,
+			TTL:      config.ListsCacheMissingTTL.String(),
+		})
+	}
+	if app.PriceComparisons != nil {
+		info.Caches = append(info.Caches, CacheInfo{
+			Name:     "price_comparisons",
+			Backend:  "memory-lru",
+			Entries:  app.PriceComparisons.Len(),
+			Capacity: priceComparisonsCacheSize,
+			TTL:      priceComparisonsCacheTTL.String(),
+		})
+	}
+
+	// only the address, the password is never shown
+	if connConfig, err := pgx.ParseConfig(app.secret("DATABASE_URL", config.DBUrl)); err == nil {
+		info.Database.Host = connConfig.Host
+		info.Database.Port = connConfig.Port
+		info.Database.Name = connConfig.Database
+		info.Database.User = connConfig.User
+	}
+	info.Database.SSLMode = config.DBSSLMode
+	info.Database.QueryExecMode = config.DBQueryExecMode
+
+	if app.Migrator != nil {
+		migrations := app.Migrator.Migrations
+		if len(migrations) > 0 {
+			info.Database.LatestMigration = migrations[len(migrations)-1].Version
+		}
+
+		version, dirty, err := app.Migrator.Version(ctx)
+		if err != nil {
+			info.Database.MigrationError = err.Error()
+		}
+		info.Database.MigrationVersion = version
+		info.Database.MigrationDirty = dirty
+	}
+
+	return info
+}
+
+// logStartup logs the summary of the instance, with a warning when the
+// schema doesn't match the migrations of the binary
+func (app *App) logStartup(info RuntimeInfo) {
+	log.Info().
+		Str("app_env", info.AppEnv).
+		Strs("listeners", info.Listeners).
+		Str("version", info.Build.Version).
+		Str("revision", info.Build.Revision).
+		Str("go_version", info.Build.GoVersion).
+		Str("db_host", info.Database.Host+":"+strconv.Itoa(int(info.Database.Port))).
+		Str("db_name", info.Database.Name).
+		Uint("migration_version", info.Database.MigrationVersion).
+		Interface("caches", info.Caches).
+		Interface("features", info.Features).
+		Msgf("> Server running on %s", strings.Join(info.Listeners, ", "))
+
+	db := info.Database
+	switch {
+	case db.MigrationError != "":
+		log.Warn().Msgf("unable to read the migration version: %s", db.MigrationError)
+	case db.MigrationDirty:
+		log.Warn().Msgf("the migration %d failed, fix the schema and run `shopping migrate force`", db.MigrationVersion)
+	case db.MigrationVersion < db.LatestMigration:
+		log.Warn().Msgf("the database is at the migration %d and the binary has up to %d, run `shopping migrate up`", db.MigrationVersion, db.LatestMigration)
+	}
+}
+
+func (app *App) handleRuntimeInfo(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, http.StatusOK, app.runtimeInfo(r.Context()))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"shopping/render"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type SandboxResetResponse struct {
+	ResetAt      time.Time `json:"reset_at"`
+	DeletedLists int64     `json:"deleted_lists"`
+	// NextResetAt is empty when the sandbox is only reset on demand
+	NextResetAt *time.Time `json:"next_reset_at,omitempty"`
+}
+
+// resetSandbox deletes the data of every user and seeds the demo data again,
+// the sessions of the seed are the only ones that survive.
+func (app *App) resetSandbox() (*SandboxResetResponse, error) {
+	app.sandboxMu.Lock()
+	defer app.sandboxMu.Unlock()
+
+	deleted, err := app.SandboxRepository.Reset()
+	if err != nil {
+		return nil, err
+	}
+
+	err = seedDemoData(io.Discard, app.ShoppingListRepository, app.SessionRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	app.purgeListsCache()
+	app.StatsCache.Purge()
+
+	// the lists were deleted and seeded without going through the outbox
+	if app.SearchIndex != nil {
+		_, err = app.SearchIndex.Rebuild(context.Background(), app.searchDocuments)
+		if err != nil {
+			log.Err(err).Msg("error to rebuild the search index of the sandbox")
+		}
+	}
+
+	res := &SandboxResetResponse{ResetAt: time.Now().UTC(), DeletedLists: deleted}
+	if interval := app.Config.SandboxResetInterval; interval > 0 {
+		next := res.ResetAt.Add(interval)
+		res.NextResetAt = &next
+	}
+
+	log.Info().Msgf("> sandbox reset, %d lists deleted", deleted)
+
+	return res, nil
+}
+
+// runSandboxResets resets the sandbox on every interval until the context is
+// done, every instance runs it so a reset may be repeated by the others.
+func (app *App) runSandboxResets(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := app.resetSandbox()
+			if err != nil {
+				log.Err(err).Msg("error to reset the sandbox")
+			}
+		}
+	}
+}
+
+func (app *App) handleSandboxReset(w http.ResponseWriter, r *http.Request) {
+	if app.SandboxRepository == nil {
+		http.Error(w, "this deployment is not a sandbox", http.StatusNotFound)
+		return
+	}
+
+	res, err := app.resetSandbox()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, res)
+}
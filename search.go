@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"shopping/outbox"
+	"shopping/render"
+	"shopping/search"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SearchRebuildStatus is the state of the last rebuild of the search index
+// made by this instance
+type SearchRebuildStatus struct {
+	Running    bool       `json:"running"`
+	Backend    string     `json:"backend"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Indexed    int        `json:"indexed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// searchRebuild runs a single rebuild of the index at a time
+type searchRebuild struct {
+	mu     sync.Mutex
+	status SearchRebuildStatus
+}
+
+func (sr *searchRebuild) Status() SearchRebuildStatus {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	return sr.status
+}
+
+// start runs the rebuild in the background, it returns false when another
+// one is running
+func (sr *searchRebuild) start(backend string, rebuild func(ctx context.Context) (int, error)) (SearchRebuildStatus, bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if sr.status.Running {
+		return sr.status, false
+	}
+
+	startedAt := time.Now().UTC()
+	sr.status = SearchRebuildStatus{Running: true, Backend: backend, StartedAt: &startedAt}
+
+	go func() {
+		indexed, err := rebuild(context.Background())
+
+		sr.mu.Lock()
+		defer sr.mu.Unlock()
+
+		finishedAt := time.Now().UTC()
+		sr.status.Running = false
+		sr.status.FinishedAt = &finishedAt
+		sr.status.Indexed = indexed
+		if err != nil {
+			log.Err(err).Msg("error to rebuild the search index")
+			sr.status.Error = err.Error()
+			return
+		}
+
+		log.Info().Msgf("> search index rebuilt, %d lists indexed", indexed)
+	}()
+
+	return sr.status, true
+}
+
+// searchDocuments is the source of the rebuilds, every list of every tenant
+// but the deleted ones
+func (app *App) searchDocuments() ([]search.Document, error) {
+	lists, err := app.ShoppingListRepository.GetAllShoppingLists("")
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]search.Document, 0, len(*lists))
+	for _, list := range *lists {
+		docs = append(docs, search.Document{
+			ID:    list.ID.String(),
+			Owner: list.Owner.String,
+			Name:  list.Name,
+			Items: list.Items,
+			Tags:  list.Tags,
+		})
+	}
+
+	return docs, nil
+}
+
+// searchPublisher applies the changes of the lists to the search index, the
+// external engines are only updated through the outbox
+func (app *App) searchPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, event outbox.Event) error {
+		if !strings.HasPrefix(event.Type, "list.") {
+			return nil
+		}
+
+		var listEvent ListEvent
+		err := json.Unmarshal(event.Payload, &listEvent)
+		if err != nil {
+			return err
+		}
+
+		list := listEvent.List
+		if event.Type == eventListDeleted || list == nil || list.DeletedAt != nil {
+			return app.SearchIndex.Delete(ctx, event.AggregateID)
+		}
+
+		owner := ""
+		if list.Owner != nil {
+			owner = *list.Owner
+		}
+
+		return app.SearchIndex.Put(ctx, search.Document{
+			ID:    event.AggregateID,
+			Owner: owner,
+			Name:  list.Name,
+			Items: list.Items,
+			Tags:  list.Tags,
+		})
+	})
+}
+
+func (app *App) handleSearchLists(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > 100 {
+		http.Error(w, "'q' is required and must have at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := intQueryParam(r, "limit", 20, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hits, err := app.SearchIndex.Search(r.Context(), user.Username, query, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, hits)
+}
+
+func (app *App) handleRebuildSearchIndex(w http.ResponseWriter, r *http.Request) {
+	status, started := app.searchRebuild.start(app.Config.SearchBackend, func(ctx context.Context) (int, error) {
+		return app.SearchIndex.Rebuild(ctx, app.searchDocuments)
+	})
+	if !started {
+		render.JSON(w, http.StatusConflict, status)
+		return
+	}
+
+	render.JSON(w, http.StatusAccepted, status)
+}
+
+func (app *App) handleSearchIndexStatus(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, http.StatusOK, app.searchRebuild.Status())
+}
@@ -0,0 +1,293 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// rebuildBatchSize is the number of documents sent in each request of a
+// rebuild
+const rebuildBatchSize = 1000
+
+// Meilisearch keeps the lists in an index of a Meilisearch server. The
+// server applies the changes in the background, in the order they were
+// sent, so they show up in the results after a moment.
+type Meilisearch struct {
+	url    string
+	apiKey string
+	index  string
+	client *http.Client
+	// the settings are sent once, before the first request
+	configured atomic.Bool
+}
+
+func NewMeilisearch(serverURL string, apiKey string, index string, client *http.Client) *Meilisearch {
+	if index == "" {
+		index = "shopping_lists"
+	}
+
+	return &Meilisearch{
+		url:    strings.TrimSuffix(serverURL, "/"),
+		apiKey: apiKey,
+		index:  index,
+		client: client,
+	}
+}
+
+type meilisearchSearchRequest struct {
+	Q                string `json:"q"`
+	Filter           string `json:"filter"`
+	Limit            int    `json:"limit"`
+	ShowRankingScore bool   `json:"showRankingScore"`
+}
+
+type meilisearchSearchResponse struct {
+	Hits []struct {
+		ID           string  `json:"id"`
+		Name         string  `json:"name"`
+		RankingScore float64 `json:"_rankingScore"`
+	} `json:"hits"`
+}
+
+type meilisearchSettings struct {
+	FilterableAttributes []string `json:"filterableAttributes"`
+	SearchableAttributes []string `json:"searchableAttributes"`
+}
+
+func (m *Meilisearch) Search(ctx context.Context, owner string, text string, limit int) ([]Hit, error) {
+	err := m.configure(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res meilisearchSearchResponse
+	err = m.do(ctx, http.MethodPost, "/search", meilisearchSearchRequest{
+		Q:                text,
+		Filter:           "owner = " + quoteFilterValue(owner),
+		Limit:            limit,
+		ShowRankingScore: true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		hits = append(hits, Hit{
+			ID:    hit.ID,
+			Name:  hit.Name,
+			Score: hit.RankingScore,
+		})
+	}
+
+	return hits, nil
+}
+
+func (m *Meilisearch) Put(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	err := m.configure(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.do(ctx, http.MethodPost, "/documents?primaryKey=id", docs, nil)
+}
+
+func (m *Meilisearch) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return m.do(ctx, http.MethodPost, "/documents/delete-batch", ids, nil)
+}
+
+// Rebuild empties the index and sends every document again, the searches
+// made in the meantime miss the documents that were not added back yet.
+func (m *Meilisearch) Rebuild(ctx context.Context, source Source) (int, error) {
+	docs, err := source()
+	if err != nil {
+		return 0, err
+	}
+
+	// the settings could have been changed in the server
+	m.configured.Store(false)
+	err = m.configure(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	err = m.do(ctx, http.MethodDelete, "/documents", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for start := 0; start < len(docs); start += rebuildBatchSize {
+		end := min(start+rebuildBatchSize, len(docs))
+		err = m.Put(ctx, docs[start:end]...)
+		if err != nil {
+			return start, err
+		}
+	}
+
+	return len(docs), nil
+}
+
+type meilisearchDocumentsResponse struct {
+	Results []Document `json:"results"`
+	Total   int        `json:"total"`
+}
+
+// Verify reads every document of the index, the differences are fixed by
+// sending or deleting those documents only.
+func (m *Meilisearch) Verify(ctx context.Context, source Source, repair bool) (Drift, error) {
+	docs, err := source()
+	if err != nil {
+		return Drift{}, err
+	}
+
+	indexed := map[string]Document{}
+	for offset := 0; ; offset += rebuildBatchSize {
+		var res meilisearchDocumentsResponse
+		path := fmt.Sprintf("/documents?fields=id,owner,name,items,tags&limit=%d&offset=%d", rebuildBatchSize, offset)
+		err = m.do(ctx, http.MethodGet, path, nil, &res)
+		if err != nil {
+			return Drift{}, err
+		}
+
+		for _, doc := range res.Results {
+			indexed[doc.ID] = doc
+		}
+		if len(res.Results) < rebuildBatchSize {
+			break
+		}
+	}
+
+	drift := Drift{Checked: len(docs), Missing: []string{}, Stale: []string{}, Outdated: []string{}}
+	changed := []Document{}
+	for _, doc := range docs {
+		current, ok := indexed[doc.ID]
+		delete(indexed, doc.ID)
+
+		switch {
+		case !ok:
+			drift.Missing = append(drift.Missing, doc.ID)
+		case !sameDocument(current, doc):
+			drift.Outdated = append(drift.Outdated, doc.ID)
+		default:
+			continue
+		}
+		changed = append(changed, doc)
+	}
+	for id := range indexed {
+		drift.Stale = append(drift.Stale, id)
+	}
+	slices.Sort(drift.Stale)
+
+	if !repair {
+		return drift, nil
+	}
+
+	for start := 0; start < len(changed); start += rebuildBatchSize {
+		err = m.Put(ctx, changed[start:min(start+rebuildBatchSize, len(changed))]...)
+		if err != nil {
+			return drift, err
+		}
+	}
+
+	err = m.Delete(ctx, drift.Stale...)
+	if err != nil {
+		return drift, err
+	}
+
+	return drift, nil
+}
+
+// sameDocument compares the documents, the empty and nil lists are the same
+func sameDocument(a Document, b Document) bool {
+	return a.ID == b.ID && a.Owner == b.Owner && a.Name == b.Name &&
+		slices.Equal(a.Items, b.Items) && slices.Equal(a.Tags, b.Tags)
+}
+
+// configure makes the owner filterable, the searches are always scoped to
+// the lists of a user
+func (m *Meilisearch) configure(ctx context.Context) error {
+	if m.configured.Load() {
+		return nil
+	}
+
+	err := m.do(ctx, http.MethodPatch, "/settings", meilisearchSettings{
+		FilterableAttributes: []string{"owner"},
+		SearchableAttributes: []string{"name", "items", "tags"},
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	m.configured.Store(true)
+
+	return nil
+}
+
+// do sends a request to a path of the index, out is decoded from the
+// response when it's not nil
+func (m *Meilisearch) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.url+"/indexes/"+url.PathEscape(m.index)+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("search: meilisearch request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("search: meilisearch responded with status %d", res.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("search: invalid meilisearch response: %w", err)
+	}
+
+	return nil
+}
+
+// quoteFilterValue quotes a value of a filter expression, the quotes and
+// backslashes in the value are escaped
+func quoteFilterValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type meilisearchCall struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// newFakeMeilisearch answers the paths of responses with their body and
+// the others with an accepted task
+func newFakeMeilisearch(t *testing.T, responses map[string]string) (*Meilisearch, func() []meilisearchCall) {
+	var mu sync.Mutex
+	calls := []meilisearchCall{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls = append(calls, meilisearchCall{Method: r.Method, Path: r.URL.RequestURI(), Body: string(body)})
+		mu.Unlock()
+
+		if res, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(res))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"taskUid":1}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewMeilisearch(server.URL+"/", "secret", "lists", server.Client()), func() []meilisearchCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func TestMeilisearchSearch(t *testing.T) {
+	index, calls := newFakeMeilisearch(t, map[string]string{
+		"/indexes/lists/search": `{"hits":[{"id":"a","name":"Weekly","owner":"u","_rankingScore":0.9}]}`,
+	})
+
+	hits, err := index.Search(context.Background(), `"quoted"`, "milk", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Hit{{ID: "a", Name: "Weekly", Score: 0.9}}, hits)
+
+	got := calls()
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, meilisearchCall{
+			Method: "PATCH",
+			Path:   "/indexes/lists/settings",
+			Body:   `{"filterableAttributes":["owner"],"searchableAttributes":["name","items","tags"]}`,
+		}, got[0])
+
+		var req meilisearchSearchRequest
+		assert.NoError(t, json.Unmarshal([]byte(got[1].Body), &req))
+		assert.Equal(t, `owner = "\"quoted\""`, req.Filter)
+		assert.Equal(t, "milk", req.Q)
+	}
+
+	// the settings are only sent once
+	_, err = index.Search(context.Background(), "u", "milk", 10)
+	assert.NoError(t, err)
+	assert.Len(t, calls(), 3)
+}
+
+func TestMeilisearchRebuild(t *testing.T) {
+	index, calls := newFakeMeilisearch(t, nil)
+
+	docs := make([]Document, rebuildBatchSize+1)
+	for i := range docs {
+		docs[i] = Document{ID: "id", Owner: "u", Name: "list"}
+	}
+
+	indexed, err := index.Rebuild(context.Background(), func() ([]Document, error) {
+		return docs, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(docs), indexed)
+
+	paths := []string{}
+	for _, call := range calls() {
+		paths = append(paths, call.Method+" "+call.Path)
+	}
+	assert.Equal(t, []string{
+		"PATCH /indexes/lists/settings",
+		"DELETE /indexes/lists/documents",
+		"POST /indexes/lists/documents?primaryKey=id",
+		"POST /indexes/lists/documents?primaryKey=id",
+	}, paths)
+}
+
+func TestMeilisearchVerify(t *testing.T) {
+	index, calls := newFakeMeilisearch(t, map[string]string{
+		"/indexes/lists/documents": `{"results":[
+			{"id":"same","owner":"u","name":"Weekly","items":["milk"],"tags":null},
+			{"id":"outdated","owner":"u","name":"Old name","items":[],"tags":[]},
+			{"id":"stale","owner":"u","name":"Deleted","items":[],"tags":[]}
+		],"total":3}`,
+	})
+	source := func() ([]Document, error) {
+		return []Document{
+			{ID: "same", Owner: "u", Name: "Weekly", Items: []string{"milk"}, Tags: []string{}},
+			{ID: "outdated", Owner: "u", Name: "New name"},
+			{ID: "missing", Owner: "u", Name: "Party"},
+		}, nil
+	}
+
+	drift, err := index.Verify(context.Background(), source, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Drift{
+		Checked:  3,
+		Missing:  []string{"missing"},
+		Stale:    []string{"stale"},
+		Outdated: []string{"outdated"},
+	}, drift)
+	assert.Len(t, calls(), 1)
+
+	_, err = index.Verify(context.Background(), source, true)
+
+	assert.NoError(t, err)
+	got := calls()[1:]
+	if assert.Len(t, got, 4) {
+		assert.Equal(t, "GET", got[0].Method)
+		assert.Equal(t, "/indexes/lists/settings", got[1].Path)
+		assert.Equal(t, "/indexes/lists/documents?primaryKey=id", got[2].Path)
+		assert.Contains(t, got[2].Body, `"id":"outdated"`)
+		assert.Contains(t, got[2].Body, `"id":"missing"`)
+		assert.Equal(t, meilisearchCall{Method: "POST", Path: "/indexes/lists/documents/delete-batch", Body: `["stale"]`}, got[3])
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"shopping/repository"
+	"strings"
+	"unicode"
+)
+
+// Postgres searches the full-text index of the database. The index is
+// updated by a trigger in the same transaction as the lists, so Put and
+// Delete have nothing to do.
+type Postgres struct {
+	repo repository.SearchRepository
+}
+
+func NewPostgres(repo repository.SearchRepository) *Postgres {
+	return &Postgres{
+		repo: repo,
+	}
+}
+
+func (p *Postgres) Search(ctx context.Context, owner string, text string, limit int) ([]Hit, error) {
+	query := prefixQuery(text)
+	if query == "" {
+		return []Hit{}, nil
+	}
+
+	rows, err := p.repo.SearchLists(owner, query, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, Hit{
+			ID:    row.ID.String(),
+			Name:  row.Name,
+			Score: float64(row.Score),
+		})
+	}
+
+	return hits, nil
+}
+
+func (p *Postgres) Put(ctx context.Context, docs ...Document) error {
+	return nil
+}
+
+func (p *Postgres) Delete(ctx context.Context, ids ...string) error {
+	return nil
+}
+
+// Rebuild doesn't need the source, the index is built from the table
+func (p *Postgres) Rebuild(ctx context.Context, source Source) (int, error) {
+	indexed, err := p.repo.Rebuild()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(indexed), nil
+}
+
+func (p *Postgres) Verify(ctx context.Context, source Source, repair bool) (Drift, error) {
+	checked, rows, err := p.repo.FindDrift()
+	if err != nil {
+		return Drift{}, err
+	}
+
+	drift := Drift{Checked: int(checked), Missing: []string{}, Stale: []string{}, Outdated: []string{}}
+	for _, row := range rows {
+		id := row.ID.String()
+		switch row.Problem {
+		case "missing":
+			drift.Missing = append(drift.Missing, id)
+		case "stale":
+			drift.Stale = append(drift.Stale, id)
+		default:
+			drift.Outdated = append(drift.Outdated, id)
+		}
+	}
+
+	if repair && drift.Len() > 0 {
+		_, err = p.repo.Rebuild()
+		if err != nil {
+			return drift, err
+		}
+	}
+
+	return drift, nil
+}
+
+// prefixQuery turns the text of the user into a to_tsquery expression that
+// matches the lists with every word, as a whole or as a prefix. Only
+// the letters and digits are kept so the text can't break the syntax.
+func prefixQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPrefixQuery(t *testing.T) {
+	tests := map[string]string{
+		"milk":             "milk:*",
+		"  Whole  Milk ":   "whole:* & milk:*",
+		"it's 2% & (fat)!": "it:* & s:* & 2:* & fat:*",
+		"café":             "café:*",
+		"&|!:*":            "",
+	}
+
+	for text, want := range tests {
+		assert.Equal(t, want, prefixQuery(text), text)
+	}
+}
+
+func TestPostgresSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := repository.NewMockSearchRepository(ctrl)
+	index := NewPostgres(repo)
+
+	repo.EXPECT().SearchLists("user", "mil:*", int32(5)).Return([]db_queries.SearchShoppingListsRow{
+		{ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, Name: "Weekly", Score: 0.5},
+	}, nil)
+
+	hits, err := index.Search(context.Background(), "user", "mil", 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Hit{{ID: "01000000-0000-0000-0000-000000000000", Name: "Weekly", Score: 0.5}}, hits)
+
+	// nothing to search, the database is not queried
+	hits, err = index.Search(context.Background(), "user", "!!", 5)
+
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestPostgresVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := repository.NewMockSearchRepository(ctrl)
+	index := NewPostgres(repo)
+
+	id := func(b byte) pgtype.UUID {
+		return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+	}
+	repo.EXPECT().FindDrift().Return(int64(10), []db_queries.FindShoppingListSearchDriftRow{
+		{ID: id(1), Problem: "missing"},
+		{ID: id(2), Problem: "stale"},
+		{ID: id(3), Problem: "outdated"},
+	}, nil).Times(2)
+
+	drift, err := index.Verify(context.Background(), nil, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, drift.Checked)
+	assert.Equal(t, 3, drift.Len())
+	assert.Equal(t, []string{id(2).String()}, drift.Stale)
+
+	// the repair rebuilds the whole index, it's a single statement
+	repo.EXPECT().Rebuild().Return(int64(10), nil)
+
+	_, err = index.Verify(context.Background(), nil, true)
+
+	assert.NoError(t, err)
+}
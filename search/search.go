@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"shopping/repository"
+	"time"
+)
+
+const (
+	BackendPostgres    = "postgres"
+	BackendMeilisearch = "meilisearch"
+)
+
+// Document is the indexed copy of a list
+type Document struct {
+	ID    string   `json:"id"`
+	Owner string   `json:"owner"`
+	Name  string   `json:"name"`
+	Items []string `json:"items"`
+	Tags  []string `json:"tags"`
+}
+
+type Hit struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"` // only comparable between the hits of the same search
+}
+
+// Source returns every document of the index, it's used to rebuild it
+type Source func() ([]Document, error)
+
+// Index finds the lists of a user by their name, items and tags. The
+// changes are applied with Put and Delete, they may be visible after a
+// while in the external engines.
+type Index interface {
+	Search(ctx context.Context, owner string, text string, limit int) ([]Hit, error)
+	// Put adds the documents or replaces the ones with the same id
+	Put(ctx context.Context, docs ...Document) error
+	Delete(ctx context.Context, ids ...string) error
+	// Rebuild indexes every document of the source again and drops the
+	// ones that are not there anymore, it returns the number indexed
+	Rebuild(ctx context.Context, source Source) (int, error)
+	// Verify compares the index with the documents of the source and
+	// fixes the differences when repair is true
+	Verify(ctx context.Context, source Source, repair bool) (Drift, error)
+}
+
+// Drift are the differences between an index and its source, by document id
+type Drift struct {
+	Checked int `json:"checked"`
+	// in the source but not in the index
+	Missing []string `json:"missing"`
+	// in the index but not in the source
+	Stale []string `json:"stale"`
+	// in both with a different content
+	Outdated []string `json:"outdated"`
+}
+
+func (d Drift) Len() int {
+	return len(d.Missing) + len(d.Stale) + len(d.Outdated)
+}
+
+type Options struct {
+	Backend string
+	// the full-text index of the database, used by the postgres backend
+	Repository repository.SearchRepository
+
+	MeilisearchURL    string
+	MeilisearchAPIKey string
+	MeilisearchIndex  string
+}
+
+var ErrUnknownBackend = errors.New("search: unknown backend")
+
+// New returns the index of the backend, when no backend is configured we
+// fallback to the full-text search of the database.
+func New(opts Options) (Index, error) {
+	switch opts.Backend {
+	case "", BackendPostgres:
+		return NewPostgres(opts.Repository), nil
+	case BackendMeilisearch:
+		if opts.MeilisearchURL == "" {
+			return nil, errors.New("search: the meilisearch backend requires an url")
+		}
+
+		return NewMeilisearch(opts.MeilisearchURL, opts.MeilisearchAPIKey, opts.MeilisearchIndex, &http.Client{Timeout: 5 * time.Second}), nil
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownBackend, opts.Backend)
+	}
+}
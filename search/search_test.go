@@ -0,0 +1,19 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	index, err := New(Options{})
+	assert.NoError(t, err)
+	assert.IsType(t, &Postgres{}, index)
+
+	_, err = New(Options{Backend: BackendMeilisearch})
+	assert.Error(t, err)
+
+	_, err = New(Options{Backend: "bleve"})
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
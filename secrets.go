@@ -0,0 +1,68 @@
+package main
+
+import (
+	"shopping/database"
+	"shopping/keyring"
+	"shopping/logging"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// secret returns the current value of a key read from a secret provider, or
+// the value of the config for the other keys
+func (app *App) secret(key string, configured string) string {
+	if app.Secrets != nil {
+		if value, ok := app.Secrets.Get(key); ok {
+			return value
+		}
+	}
+
+	return configured
+}
+
+// applySecret updates the components that keep a copy of a secret when a
+// refresh changes it, the others read it on every use with secret
+func (app *App) applySecret(key string, value string) {
+	if key != "DATABASE_URL" {
+		logging.AddSecret(value)
+	}
+
+	switch key {
+	case "DATABASE_URL":
+		err := database.SetPassword(value)
+		if err != nil {
+			log.Err(err).Msg("the refreshed DATABASE_URL is not valid, the new connections use the previous password")
+		}
+	case "SHARE_LINK_SECRET":
+		if value != "" {
+			ring := app.SigningKeys[keyPurposeShareLinks]
+			keys := slices.DeleteFunc(ring.Configured(), func(key keyring.Key) bool { return key.ID == "" })
+			ring.SetConfigured(append(keys, keyring.Key{Secret: []byte(value)}))
+		}
+	case "SHARE_LINK_KEYS", "SIGNED_URL_KEYS":
+		keys, err := keyring.Parse(strings.Split(value, ","))
+		if err != nil || len(keys) == 0 {
+			log.Error().Msgf("the refreshed %s are not valid, the previous keys are kept", key)
+			return
+		}
+		for _, k := range keys {
+			logging.AddSecret(string(k.Secret))
+		}
+
+		if key == "SIGNED_URL_KEYS" {
+			app.SigningKeys[keyPurposeSignedURLs].SetConfigured(keys)
+			return
+		}
+
+		// the key of SHARE_LINK_SECRET still verifies after the keys
+		ring := app.SigningKeys[keyPurposeShareLinks]
+		for _, configured := range ring.Configured() {
+			if configured.ID == "" {
+				keys = append(keys, configured)
+			}
+		}
+		ring.SetConfigured(keys)
+	}
+}
@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// AWSSecretsManager reads the secrets of AWS Secrets Manager, the references
+// are like shopping/production#database_url: the name or ARN of the secret
+// and, for the JSON secrets, the field. The credentials are the standard
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type AWSSecretsManager struct {
+	region   string
+	endpoint string
+	creds    awsCredentials
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewAWSSecretsManager(region string, client *http.Client) *AWSSecretsManager {
+	return &AWSSecretsManager{
+		region:   region,
+		endpoint: "https://secretsmanager." + region + ".amazonaws.com",
+		creds: awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		client: client,
+		now:    time.Now,
+	}
+}
+
+type awsGetSecretValueRequest struct {
+	SecretId string
+}
+
+type awsGetSecretValueResponse struct {
+	SecretString string
+	SecretBinary string // base64
+}
+
+func (a *AWSSecretsManager) Fetch(ctx context.Context, ref string) (string, error) {
+	if a.creds.AccessKeyID == "" || a.creds.SecretAccessKey == "" {
+		return "", errors.New("awssm: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+
+	id, field := splitField(ref)
+	payload, err := json.Marshal(awsGetSecretValueRequest{SecretId: id})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, payload, a.creds, a.region, "secretsmanager", a.now())
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("awssm: request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("awssm: responded with status %d", res.StatusCode)
+	}
+
+	var body awsGetSecretValueResponse
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("awssm: invalid response: %w", err)
+	}
+
+	secret := body.SecretString
+	if secret == "" && body.SecretBinary != "" {
+		data, err := base64.StdEncoding.DecodeString(body.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("awssm: invalid binary secret: %w", err)
+		}
+		secret = string(data)
+	}
+
+	if field == "" {
+		return secret, nil
+	}
+
+	var fields map[string]any
+	err = json.Unmarshal([]byte(secret), &fields)
+	if err != nil {
+		return "", fmt.Errorf("awssm: the secret is not a JSON object, it can't have the field '%s'", field)
+	}
+
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("awssm: the secret has no string field '%s'", field)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Provider reads a secret by its reference, the format of the reference
+// depends on the provider.
+type Provider interface {
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// the schemes of the references, like vault:secret/data/shopping#database_url
+const (
+	SchemeFile  = "file"
+	SchemeVault = "vault"
+	SchemeAWS   = "awssm"
+)
+
+var ErrUnknownProvider = errors.New("secrets: unknown provider")
+
+// Resolver reads the references of the config values with the providers of
+// their schemes
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a resolver with the file provider, the others are
+// added with Register when they are configured
+func NewResolver() *Resolver {
+	return &Resolver{
+		providers: map[string]Provider{SchemeFile: FileProvider{}},
+	}
+}
+
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// IsReference tells if the value is a reference to a secret, the values
+// that only look like one, like postgres://... or http://..., are not.
+func IsReference(value string) bool {
+	scheme, rest, ok := strings.Cut(value, ":")
+	if !ok || strings.HasPrefix(rest, "//") {
+		return false
+	}
+
+	switch scheme {
+	case SchemeFile, SchemeVault, SchemeAWS:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resolve returns the secret of the reference
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, path, _ := strings.Cut(ref, ":")
+
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s' is not configured", ErrUnknownProvider, scheme)
+	}
+
+	value, err := provider.Fetch(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("secrets: unable to read %s: %w", ref, err)
+	}
+
+	return value, nil
+}
+
+// FileProvider reads the secret from a file, like the Docker and Kubernetes
+// secrets mounted in /run/secrets. The trailing new line is dropped.
+type FileProvider struct{}
+
+func (FileProvider) Fetch(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// splitField splits a reference like <path>#<field>, the field is empty when
+// the reference has none
+func splitField(ref string) (string, string) {
+	path, field, _ := strings.Cut(ref, "#")
+	return path, field
+}
@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Store keeps the current value of the secrets read from a provider, the
+// refreshes update them and notify the components that can't read them on
+// every use, like the database pool.
+type Store struct {
+	resolver *Resolver
+	refs     map[string]string // key -> reference
+
+	mu       sync.RWMutex
+	values   map[string]string
+	onChange []func(key string, value string)
+}
+
+// NewStore keeps the values of the keys read from the references
+func NewStore(resolver *Resolver, refs map[string]string, values map[string]string) *Store {
+	current := make(map[string]string, len(values))
+	for key, value := range values {
+		current[key] = value
+	}
+
+	return &Store{
+		resolver: resolver,
+		refs:     refs,
+		values:   current,
+	}
+}
+
+// Get returns the current value of a key, ok is false when the key isn't
+// read from a provider
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// OnChange registers a function called after a refresh changes a key, it
+// must not block
+func (s *Store) OnChange(fn func(key string, value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onChange = append(s.onChange, fn)
+}
+
+// Refresh reads every reference again, a key that can't be read keeps its
+// value. It returns the keys that changed.
+func (s *Store) Refresh(ctx context.Context) []string {
+	changed := []string{}
+	for key, ref := range s.refs {
+		value, err := s.resolver.Resolve(ctx, ref)
+		if err != nil {
+			log.Err(err).Msgf("secrets: unable to refresh %s, the current value is kept", key)
+			continue
+		}
+
+		s.mu.Lock()
+		if s.values[key] != value {
+			s.values[key] = value
+			changed = append(changed, key)
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.RLock()
+	onChange := s.onChange
+	s.mu.RUnlock()
+
+	for _, key := range changed {
+		value, _ := s.Get(key)
+		log.Info().Msgf("> secret %s changed", key)
+		for _, fn := range onChange {
+			fn(key, value)
+		}
+	}
+
+	return changed
+}
+
+// Run refreshes the secrets on every interval until the context is done
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	if len(s.refs) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Vault reads the secrets of a HashiCorp Vault KV engine, the references are
+// like secret/data/shopping#database_url: the API path of the secret and the
+// field. Both versions of the engine are supported.
+type Vault struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+func NewVault(addr string, token string, client *http.Client) *Vault {
+	return &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		client: client,
+	}
+}
+
+type vaultResponse struct {
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+func (v *Vault) Fetch(ctx context.Context, ref string) (string, error) {
+	path, field := splitField(ref)
+	if field == "" {
+		return "", errors.New("vault: the reference must have a field, like secret/data/shopping#database_url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: responded with status %d", res.StatusCode)
+	}
+
+	var body vaultResponse
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("vault: invalid response: %w", err)
+	}
+
+	// the version 2 of the engine nests the fields in data.data
+	fields := body.Data
+	if nested, ok := body.Data["data"]; ok && field != "data" {
+		err = json.Unmarshal(nested, &fields)
+		if err != nil {
+			return "", fmt.Errorf("vault: invalid response: %w", err)
+		}
+	}
+
+	raw, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("vault: the secret has no field '%s'", field)
+	}
+
+	var value string
+	err = json.Unmarshal(raw, &value)
+	if err != nil {
+		return "", fmt.Errorf("vault: the field '%s' is not a string", field)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"shopping/config"
+	"shopping/database"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+type demoList struct {
+	Owner string
+	Name  string
+	Items []string
+	Tags  []string
+}
+
+// demoLists are the lists of the seed, the users are the ones of allUsers
+var demoLists = []demoList{
+	{Owner: "user", Name: "Groceries", Items: []string{"milk", "bread", "eggs", "apples"}, Tags: []string{"weekly"}},
+	{Owner: "user", Name: "Hardware store", Items: []string{"screws", "wood glue", "sandpaper"}},
+	{Owner: "user", Name: "Birthday party", Items: []string{"cake", "candles", "balloons"}, Tags: []string{"party"}},
+	{Owner: "admin", Name: "Office supplies", Items: []string{"paper", "pens", "stapler"}, Tags: []string{"work"}},
+}
+
+// demoSessions are known tokens so the tools and the tests can skip the
+// login, they only exist in the databases that were seeded
+var demoSessions = map[string]string{
+	"admin": "seed-admin-token",
+	"user":  "seed-user-token",
+}
+
+const demoSessionTTL = 365 * 24 * time.Hour
+
+// runSeed runs `shopping seed`, it fills the database of the config with the
+// demo data and returns the exit code.
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+	force := flags.Bool("force", false, "seed even when APP_ENV is production")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	config := config.SetupConfig(configFlags)
+	if config.AppEnv == "production" && !*force {
+		fmt.Fprintln(os.Stderr, "seed: APP_ENV is production, use --force to seed anyway")
+		return 1
+	}
+
+	dbpool, err := database.NewDB(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "seed: cannot connect to the database")
+		return 1
+	}
+	defer dbpool.Close()
+
+	dbQueries := db_queries.New(dbpool)
+	err = seedDemoData(os.Stdout, repository.NewShoppingListRepository(dbQueries), repository.NewSessionRepository(dbQueries))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// seedDemoData creates the demo lists and sessions through the repositories,
+// it can run again: the lists are found by owner and name and their items
+// are reset, and the sessions are extended.
+func seedDemoData(out io.Writer, lists repository.ShoppingListRepository, sessions repository.SessionRepository) error {
+	for _, demo := range demoLists {
+		existing, err := findListByName(lists, demo.Owner, demo.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			created, err := lists.CreateShoppingList(demo.Owner, demo.Name, demo.Items, demo.Tags)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "created list '%s' of %s (%s)\n", demo.Name, demo.Owner, created.ID.String())
+			continue
+		}
+
+		if slices.Equal(existing.Items, demo.Items) {
+			fmt.Fprintf(out, "list '%s' of %s is up to date\n", demo.Name, demo.Owner)
+			continue
+		}
+
+		_, err = lists.UpdateShoppingListByID(existing.ID.String(), demo.Name, demo.Items)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "reset the items of the list '%s' of %s\n", demo.Name, demo.Owner)
+	}
+
+	usernames := make([]string, 0, len(demoSessions))
+	for username := range demoSessions {
+		usernames = append(usernames, username)
+	}
+	slices.Sort(usernames)
+
+	for _, username := range usernames {
+		session, err := sessions.EnsureSession(username, demoSessions[username], time.Now().Add(demoSessionTTL))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "session of %s: %s\n", username, session.Token)
+	}
+
+	return nil
+}
+
+func findListByName(lists repository.ShoppingListRepository, owner string, name string) (*db_queries.ShoppingList, error) {
+	similar, err := lists.FindListNameConflicts(owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range similar {
+		if strings.EqualFold(row.Name, name) {
+			return lists.GetShoppingListByID(row.ID.String())
+		}
+	}
+
+	return nil, nil
+}
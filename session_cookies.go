@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"shopping/config"
+	"shopping/render"
+	"strings"
+	"time"
+)
+
+const (
+	// the login asks for a cookie session with "session": "cookie", the
+	// other clients keep the bearer tokens
+	sessionModeCookie = "cookie"
+
+	sessionCookieName = "session"
+	// csrfCookieName is readable by the scripts of the page, they send it
+	// back in csrfHeader
+	csrfCookieName = "csrf_token"
+	csrfHeader     = "X-CSRF-Token"
+)
+
+// csrfToken is derived from the session so a cookie set by another site or
+// subdomain can't be paired with a header it chose
+func csrfToken(sessionToken string) string {
+	sum := sha256.Sum256([]byte("csrf:" + sessionToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// sessionToken returns the bearer token of the request, or the token of
+// the session cookie when there's no Authorization header
+func sessionToken(r *http.Request) (token string, fromCookie bool) {
+	header := r.Header.Get("Authorization")
+	if header != "" {
+		if !strings.HasPrefix(header, "Bearer ") {
+			return "", false
+		}
+		return header[7:], false
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+
+	return cookie.Value, true
+}
+
+// validCSRF checks the double submitted token of the requests that change
+// data, the safe methods don't need it
+func validCSRF(r *http.Request, sessionToken string) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	header := r.Header.Get(csrfHeader)
+	cookie, err := r.Cookie(csrfCookieName)
+	if header == "" || err != nil || cookie.Value != header {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(header), []byte(csrfToken(sessionToken))) == 1
+}
+
+func (app *App) cookieSessions() bool {
+	return app.Config != nil && app.Config.SessionCookies
+}
+
+func (app *App) sameSite() http.SameSite {
+	switch app.Config.SessionCookieSameSite {
+	case config.SameSiteStrict:
+		return http.SameSiteStrictMode
+	case config.SameSiteNone:
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// setSessionCookies sends the session in an HttpOnly cookie and its CSRF
+// token in one the page can read, both expire with the session
+func (app *App) setSessionCookies(w http.ResponseWriter, token string, expires time.Time) string {
+	csrf := csrfToken(token)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Domain:   app.Config.SessionCookieDomain,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   app.Config.SessionCookieSecure,
+		SameSite: app.sameSite(),
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    csrf,
+		Path:     "/",
+		Domain:   app.Config.SessionCookieDomain,
+		Expires:  expires,
+		Secure:   app.Config.SessionCookieSecure,
+		SameSite: app.sameSite(),
+	})
+
+	return csrf
+}
+
+func (app *App) clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{sessionCookieName, csrfCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     "/",
+			Domain:   app.Config.SessionCookieDomain,
+			MaxAge:   -1,
+			Secure:   app.Config.SessionCookieSecure,
+			SameSite: app.sameSite(),
+		})
+	}
+}
+
+// handleLogout deletes the session of the request, the cookies too when it
+// came from them
+func (app *App) handleLogout(w http.ResponseWriter, r *http.Request) {
+	token, fromCookie := sessionToken(r)
+
+	err := app.SessionRepository.DeleteSession(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if fromCookie && app.cookieSessions() {
+		app.clearSessionCookies(w)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loginWithCookie answers the login of a cookie session, the token stays in
+// the cookie and the page gets the CSRF token
+func (app *App) loginWithCookie(w http.ResponseWriter, token string, expires time.Time) {
+	csrf := app.setSessionCookies(w, token, expires)
+
+	render.JSON(w, http.StatusOK, map[string]string{"csrf_token": csrf})
+}
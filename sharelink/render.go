@@ -0,0 +1,112 @@
+package sharelink
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+	FormatHTML = "html"
+	FormatICS  = "ics"
+)
+
+// List is the public view of a shared list, it doesn't expose the owner
+type List struct {
+	Name      string    `json:"name"`
+	Items     []string  `json:"items"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatText:
+		return "text/plain; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+func RenderText(w io.Writer, list List) error {
+	var b strings.Builder
+
+	b.WriteString(list.Name + "\n\n")
+	for _, item := range list.Items {
+		b.WriteString("[ ] " + item + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Name}}</title>
+<style>
+  body { font-family: sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; }
+  ul { list-style: none; padding: 0; }
+  li { padding: .4rem 0; border-bottom: 1px solid #ddd; }
+  li::before { content: "\2610"; margin-right: .6rem; }
+  footer { color: #777; font-size: .8rem; margin-top: 2rem; }
+  @media print { footer { display: none; } li { border: 0; } }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<ul>
+{{- range .Items}}
+  <li>{{.}}</li>
+{{- end}}
+</ul>
+<footer>This link expires on {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}</footer>
+</body>
+</html>
+`))
+
+func RenderHTML(w io.Writer, list List) error {
+	return htmlTemplate.Execute(w, list)
+}
+
+// RenderICS writes the list as a calendar with one VTODO per item so it can
+// be imported in the reminders or tasks app of the phone.
+func RenderICS(w io.Writer, listID string, list List) error {
+	stamp := list.UpdatedAt.UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//shopping//shared list//EN\r\n")
+	b.WriteString("X-WR-CALNAME:" + escapeICS(list.Name) + "\r\n")
+	for i, item := range list.Items {
+		b.WriteString("BEGIN:VTODO\r\n")
+		b.WriteString(fmt.Sprintf("UID:%s-%d@shopping\r\n", listID, i+1))
+		b.WriteString("DTSTAMP:" + stamp + "\r\n")
+		b.WriteString("SUMMARY:" + escapeICS(item) + "\r\n")
+		b.WriteString("CATEGORIES:" + escapeICS(list.Name) + "\r\n")
+		b.WriteString("STATUS:NEEDS-ACTION\r\n")
+		b.WriteString("END:VTODO\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
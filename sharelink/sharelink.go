@@ -0,0 +1,102 @@
+package sharelink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"shopping/keyring"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("sharelink: invalid token")
+	ErrExpired      = errors.New("sharelink: the link has expired")
+)
+
+// Signer creates the tokens of the public share links. The token carries the
+// list id and the expiration, nothing is stored so a link can't be revoked
+// before it expires except by removing its key from the ring.
+type Signer struct {
+	keys *keyring.Ring
+}
+
+func NewSigner(keys *keyring.Ring) *Signer {
+	return &Signer{keys: keys}
+}
+
+// Sign returns a URL safe token like <payload>.<signature>.<kid>, the key
+// without id of SHARE_LINK_SECRET signs the tokens without kid of the
+// previous versions
+func (s *Signer) Sign(listID string, expiresAt time.Time) string {
+	payload := listID + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	key := s.keys.Signing(time.Now())
+	token := encoded + "." + base64.RawURLEncoding.EncodeToString(mac(key, encoded))
+	if key.ID != "" {
+		token += "." + key.ID
+	}
+
+	return token
+}
+
+// Verify checks the signature and the expiration of the token and returns
+// the id of the shared list and when the link expires.
+func (s *Signer) Verify(token string, now time.Time) (string, time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 && len(parts) != 3 {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	kid := ""
+	if len(parts) == 3 {
+		kid = parts[2]
+	}
+	key, ok := s.keys.Lookup(kid)
+	if !ok {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	encoded := parts[0]
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, mac(key, encoded)) {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	listID, exp, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	expires := time.Unix(expiresAt, 0)
+	if !now.Before(expires) {
+		return "", time.Time{}, ErrExpired
+	}
+
+	return listID, expires, nil
+}
+
+// mac signs the kid with the payload, so a token can't be moved to another
+// key
+func mac(key keyring.Key, payload string) []byte {
+	h := hmac.New(sha256.New, key.Secret)
+	if key.ID != "" {
+		h.Write([]byte(key.ID + "."))
+	}
+	h.Write([]byte(payload))
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,88 @@
+package sharelink
+
+import (
+	"bytes"
+	"shopping/keyring"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signer := NewSigner(keyring.NewRing(keyring.Key{Secret: []byte("secret")}))
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	token := signer.Sign("123e4567-e89b-12d3-a456-426614174000", now.Add(time.Hour))
+
+	listID, expiresAt, err := signer.Verify(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", listID)
+	assert.True(t, now.Add(time.Hour).Equal(expiresAt))
+
+	_, _, err = signer.Verify(token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	_, _, err = NewSigner(keyring.NewRing(keyring.Key{Secret: []byte("other")})).Verify(token, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// a different payload with the original signature
+	_, sig, _ := strings.Cut(token, ".")
+	forged := strings.Split(signer.Sign("another-list", now.Add(time.Hour)), ".")[0] + "." + sig
+	_, _, err = signer.Verify(forged, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, _, err = signer.Verify("garbage", now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeyRotation(t *testing.T) {
+	// the links signed before the keys had an id
+	ring := keyring.NewRing(keyring.Key{Secret: []byte("secret")})
+	signer := NewSigner(ring)
+	now := time.Now()
+	legacy := signer.Sign("123e4567-e89b-12d3-a456-426614174000", now.Add(time.Hour))
+	assert.Equal(t, 1, strings.Count(legacy, "."))
+
+	ring.SetRotated([]keyring.Key{{ID: "k2", Secret: []byte("rotated")}})
+	token := signer.Sign("123e4567-e89b-12d3-a456-426614174000", now.Add(time.Hour))
+	assert.True(t, strings.HasSuffix(token, ".k2"))
+
+	for _, token := range []string{legacy, token} {
+		_, _, err := signer.Verify(token, now)
+		assert.NoError(t, err)
+	}
+
+	// the kid is signed, the signature of a key doesn't verify with another
+	parts := strings.Split(token, ".")
+	_, _, err := signer.Verify(parts[0]+"."+parts[1], now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// removing a key invalidates its links only
+	ring.SetConfigured(nil)
+	_, _, err = signer.Verify(legacy, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, _, err = signer.Verify(token, now)
+	assert.NoError(t, err)
+}
+
+func TestRenderEscapesItems(t *testing.T) {
+	list := List{
+		Name:      "Party, food; drinks",
+		Items:     []string{"<script>alert(1)</script>", "chips\nsalsa"},
+		UpdatedAt: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		ExpiresAt: time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC),
+	}
+
+	var html bytes.Buffer
+	assert.NoError(t, RenderHTML(&html, list))
+	assert.NotContains(t, html.String(), "<script>")
+	assert.Contains(t, html.String(), "&lt;script&gt;")
+
+	var ics bytes.Buffer
+	assert.NoError(t, RenderICS(&ics, "1", list))
+	assert.Contains(t, ics.String(), "X-WR-CALNAME:Party\\, food\\; drinks\r\n")
+	assert.Contains(t, ics.String(), "SUMMARY:chips\\nsalsa\r\n")
+	assert.Equal(t, 2, strings.Count(ics.String(), "BEGIN:VTODO"))
+}
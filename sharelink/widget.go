@@ -0,0 +1,31 @@
+package sharelink
+
+import (
+	"embed"
+	"html/template"
+	"io"
+)
+
+// Widget has the static files of the embeddable widget, they are served
+// from our origin so the page works with a strict CSP (no inline code).
+//
+//go:embed widget/widget.js widget/widget.css
+var Widget embed.FS
+
+//go:embed widget/embed.html
+var embedHTML string
+
+var embedTemplate = template.Must(template.New("embed").Parse(embedHTML))
+
+// EmbedCSP allows the page to be framed by any site, but it can only load
+// our own scripts and styles and only connect to our events stream.
+const EmbedCSP = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; frame-ancestors *; base-uri 'none'; form-action 'none'"
+
+type EmbedPage struct {
+	List
+	EventsURL string
+}
+
+func RenderEmbed(w io.Writer, page EmbedPage) error {
+	return embedTemplate.Execute(w, page)
+}
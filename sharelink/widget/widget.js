@@ -0,0 +1,58 @@
+// Live view of a shared list. The page is server rendered so it works
+// without javascript, this only keeps it up to date with the events stream.
+(function () {
+  "use strict";
+
+  var root = document.querySelector(".shopping-widget");
+  if (!root || !window.EventSource) {
+    return;
+  }
+
+  var name = root.querySelector(".shopping-widget-name");
+  var items = root.querySelector(".shopping-widget-items");
+  var status = root.querySelector(".shopping-widget-status");
+
+  function showStatus(text) {
+    status.textContent = text;
+    status.hidden = false;
+  }
+
+  // textContent only, the list is user content
+  function render(list) {
+    name.textContent = list.name;
+    document.title = list.name;
+
+    var fragment = document.createDocumentFragment();
+    list.items.forEach(function (item) {
+      var li = document.createElement("li");
+      li.textContent = item;
+      fragment.appendChild(li);
+    });
+
+    items.replaceChildren(fragment);
+    status.hidden = true;
+  }
+
+  var source = new EventSource(root.dataset.events);
+
+  source.addEventListener("list", function (event) {
+    render(JSON.parse(event.data));
+  });
+
+  source.addEventListener("deleted", function () {
+    source.close();
+    showStatus("This list is no longer available.");
+  });
+
+  source.addEventListener("expired", function () {
+    source.close();
+    showStatus("This link has expired.");
+  });
+
+  source.onerror = function () {
+    // the browser reconnects by itself while the stream is open
+    if (source.readyState === EventSource.CLOSED) {
+      showStatus("Live updates are not available.");
+    }
+  };
+})();
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"shopping/export"
+	"shopping/render"
+	"shopping/sharelink"
+	"shopping/static"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const maxShareLinkTTL = 30 * 24 * time.Hour
+
+type CreateShareLinkRequest struct {
+	// seconds until the link expires, the configured default when empty
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+type ShareLinkResponse struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (app *App) handleCreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var data CreateShareLinkRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	ttl := app.Config.ShareLinkTTL
+	if data.ExpiresIn != 0 {
+		ttl = time.Duration(data.ExpiresIn) * time.Second
+	}
+
+	if ttl <= 0 || ttl > maxShareLinkTTL {
+		http.Error(w, fmt.Sprintf("'expires_in' must be between 1 and %d seconds", int64(maxShareLinkTTL.Seconds())), http.StatusBadRequest)
+		return
+	}
+
+	list, err := app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	token := app.ShareLinks.Sign(list.ID.String(), expiresAt)
+
+	render.JSON(w, http.StatusCreated, ShareLinkResponse{
+		URL:       app.publicURL(r) + "/v1/shared/" + token,
+		Token:     token,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// handleGetShared serves a shared list without authentication, the token is
+// the only credential so it must never end up in a Referer header.
+func (app *App) handleGetShared(w http.ResponseWriter, r *http.Request) {
+	listID, expiresAt, ok := app.verifySharedToken(w, r)
+	if !ok {
+		return
+	}
+
+	format, ok := sharedFormat(r)
+	if !ok {
+		http.Error(w, "'format' must be json, text, html or ics", http.StatusBadRequest)
+		return
+	}
+
+	shared, err := app.loadSharedList(listID, expiresAt)
+	if err != nil {
+		// the list was deleted after sharing it
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", sharelink.ContentType(format))
+	setSharedHeaders(w)
+
+	switch format {
+	case sharelink.FormatText:
+		err = sharelink.RenderText(w, shared)
+	case sharelink.FormatHTML:
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		err = sharelink.RenderHTML(w, shared)
+	case sharelink.FormatICS:
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(shared.Name, sharelink.FormatICS)))
+		err = sharelink.RenderICS(w, listID, shared)
+	default:
+		render.JSON(w, http.StatusOK, shared)
+	}
+
+	if err != nil {
+		log.Err(err).Msgf("failed to render the shared list with id: %s", listID)
+		return
+	}
+}
+
+// handleGetSharedEmbed serves the widget page meant to be used in an
+// iframe, the script keeps it updated with the events stream.
+func (app *App) handleGetSharedEmbed(w http.ResponseWriter, r *http.Request) {
+	listID, expiresAt, ok := app.verifySharedToken(w, r)
+	if !ok {
+		return
+	}
+
+	shared, err := app.loadSharedList(listID, expiresAt)
+	if err != nil {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", sharelink.ContentType(sharelink.FormatHTML))
+	w.Header().Set("Content-Security-Policy", sharelink.EmbedCSP)
+	setSharedHeaders(w)
+
+	err = sharelink.RenderEmbed(w, sharelink.EmbedPage{
+		List:      shared,
+		EventsURL: "/v1/shared/" + r.PathValue("token") + "/events",
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to render the embedded list with id: %s", listID)
+		return
+	}
+}
+
+// sseHeartbeat keeps the connection open through proxies that close idle
+// connections
+const sseHeartbeat = 25 * time.Second
+
+// handleSharedEvents streams the shared list as server sent events: a `list`
+// event with the current state on connect and after every change, and a
+// final `deleted` or `expired` event when the list can't be seen anymore.
+func (app *App) handleSharedEvents(w http.ResponseWriter, r *http.Request) {
+	listID, expiresAt, ok := app.verifySharedToken(w, r)
+	if !ok {
+		return
+	}
+
+	// subscribe before reading the list so no change is missed
+	changes, unsubscribe := app.ListEvents.Subscribe(listID)
+	defer unsubscribe()
+
+	shared, err := app.loadSharedList(listID, expiresAt)
+	if err != nil {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	setSharedHeaders(w)
+
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	err = writeEvent(w, "list", shared)
+	for err == nil {
+		err = rc.Flush()
+		if err != nil {
+			break
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired.C:
+			_ = writeEvent(w, "expired", nil)
+			_ = rc.Flush()
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		case <-changes:
+			shared, err = app.loadSharedList(listID, expiresAt)
+			if errors.Is(err, pgx.ErrNoRows) {
+				_ = writeEvent(w, "deleted", nil)
+				_ = rc.Flush()
+				return
+			}
+
+			if err != nil {
+				// the client keeps the previous state until the next change
+				log.Err(err).Msgf("failed to load the shared list with id: %s", listID)
+				err = nil
+				continue
+			}
+
+			err = writeEvent(w, "list", shared)
+		}
+	}
+
+	log.Debug().Err(err).Msgf("closing the events stream of the list with id: %s", listID)
+}
+
+func writeEvent(w io.Writer, event string, data any) error {
+	payload := []byte("{}")
+	if data != nil {
+		var err error
+		payload, err = json.Marshal(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// widgetAssets serves the script and the styles of the embeddable widget
+func widgetAssets() http.Handler {
+	assets, err := fs.Sub(sharelink.Widget, "widget")
+	if err != nil {
+		panic(err)
+	}
+
+	return static.Handler("/v1/embed/", assets, time.Hour)
+}
+
+// verifySharedToken writes the error response when the token is not valid
+func (app *App) verifySharedToken(w http.ResponseWriter, r *http.Request) (string, time.Time, bool) {
+	listID, expiresAt, err := app.ShareLinks.Verify(r.PathValue("token"), time.Now())
+	if errors.Is(err, sharelink.ErrExpired) {
+		http.Error(w, "the link has expired", http.StatusGone)
+		return "", time.Time{}, false
+	}
+
+	if err != nil {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return "", time.Time{}, false
+	}
+
+	return listID, expiresAt, true
+}
+
+func (app *App) loadSharedList(listID string, expiresAt time.Time) (sharelink.List, error) {
+	list, err := app.ShoppingListRepository.GetShoppingListByID(listID)
+	if err != nil {
+		return sharelink.List{}, err
+	}
+
+	shared := sharelink.List{
+		Name:      list.Name,
+		Items:     list.Items,
+		UpdatedAt: list.UpdatedAt.Time,
+		ExpiresAt: expiresAt.UTC(),
+	}
+
+	if shared.Items == nil {
+		shared.Items = []string{}
+	}
+
+	return shared, nil
+}
+
+func setSharedHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+}
+
+// sharedFormat uses the `format` query param and falls back to the Accept
+// header, so the same link opens as a page in the browser.
+func sharedFormat(r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case sharelink.FormatJSON, sharelink.FormatText, sharelink.FormatHTML, sharelink.FormatICS:
+		return format, true
+	case "":
+	default:
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/html"):
+		return sharelink.FormatHTML, true
+	case strings.Contains(accept, "text/calendar"):
+		return sharelink.FormatICS, true
+	case strings.Contains(accept, "text/plain"):
+		return sharelink.FormatText, true
+	default:
+		return sharelink.FormatJSON, true
+	}
+}
+
+// publicURL is the base of the links handed out to people without an account
+func (app *App) publicURL(r *http.Request) string {
+	if app.Config.PublicURL != "" {
+		return strings.TrimSuffix(app.Config.PublicURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
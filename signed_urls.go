@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"shopping/render"
+	"shopping/signedurl"
+	"strings"
+	"time"
+)
+
+const signedURLPrefix = "/v1/signed"
+
+type CreateSignedURLRequest struct {
+	// the path of a signable route with its query, like
+	// /v1/lists/<id>/export?format=csv
+	Path string `json:"path"`
+	// seconds until the URL expires, SIGNED_URL_TTL when empty
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleCreateSignedURL signs a GET of a Signable route for the user, the
+// route is authorized again for the user when the URL is used
+func (app *App) handleCreateSignedURL(w http.ResponseWriter, r *http.Request) {
+	var data CreateSignedURLRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	ttl := app.Config.SignedURLTTL
+	if data.ExpiresIn != 0 {
+		ttl = time.Duration(data.ExpiresIn) * time.Second
+	}
+
+	if ttl <= 0 || ttl > app.Config.SignedURLMaxTTL {
+		http.Error(w, fmt.Sprintf("'expires_in' must be between 1 and %d seconds", int64(app.Config.SignedURLMaxTTL.Seconds())), http.StatusBadRequest)
+		return
+	}
+
+	target, err := url.ParseRequestURI(data.Path)
+	if err != nil || target.Host != "" || !app.signable(target.Path) {
+		http.Error(w, "'path' must be the path of a route that can be signed, like /v1/lists/<id>/export", http.StatusUnprocessableEntity)
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	query := app.SignedURLs.Sign(target.Path, target.Query(), currentUser(r).Username, expiresAt)
+
+	render.JSON(w, http.StatusCreated, SignedURLResponse{
+		URL:       app.publicURL(r) + signedURLPrefix + target.EscapedPath() + "?" + query.Encode(),
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// signable tells if a GET of the path is served by a Signable route, the
+// paths the mux would redirect can't be signed
+func (app *App) signable(p string) bool {
+	if app.signedMux == nil || path.Clean(p) != p {
+		return false
+	}
+
+	_, pattern := app.signedMux.Handler(&http.Request{Method: http.MethodGet, URL: &url.URL{Path: p}})
+	return pattern != ""
+}
+
+// handleSigned serves the path of a signed URL as the user that signed it,
+// the signature is the only credential like the share links
+func (app *App) handleSigned(w http.ResponseWriter, r *http.Request) {
+	signedPath := strings.TrimPrefix(r.URL.Path, signedURLPrefix)
+	if !app.signable(signedPath) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	username, expiresAt, err := app.SignedURLs.Verify(signedPath, r.URL.Query(), time.Now())
+	if errors.Is(err, signedurl.ErrExpired) {
+		http.Error(w, "the link has expired", http.StatusGone)
+		return
+	}
+	if err != nil {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	user, err := app.findUser(username)
+	if err != nil {
+		http.Error(w, "authorization error", http.StatusInternalServerError)
+		return
+	}
+	// the user was deleted after signing it
+	if user == nil {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+
+	inner := r.Clone(context.WithValue(r.Context(), signedUserContextKey, user))
+	inner.URL.Path = signedPath
+	inner.URL.RawPath = ""
+	inner.URL.RawQuery = signedurl.Strip(r.URL.Query()).Encode()
+	inner.RequestURI = inner.URL.RequestURI()
+
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", max(int(time.Until(expiresAt).Seconds()), 0)))
+
+	app.signedMux.ServeHTTP(w, inner)
+}
+
+const signedUserContextKey contextKey = "signed_user"
+
+// signedUser returns the user of the signed URL of the request, nil when the
+// request has a session instead
+func signedUser(r *http.Request) *User {
+	user, _ := r.Context().Value(signedUserContextKey).(*User)
+	return user
+}
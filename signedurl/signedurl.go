@@ -0,0 +1,116 @@
+// Package signedurl signs the paths of the API for a user and a time, the
+// URLs like /v1/signed/v1/lists/<id>/export?exp=...&kid=...&sub=...&sig=...
+// give access to that path alone without a session until they expire.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"shopping/keyring"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalid = errors.New("signedurl: invalid signature")
+	ErrExpired = errors.New("signedurl: the URL has expired")
+)
+
+// the parameters added to the query of the signed paths
+const (
+	ParamExpires   = "exp"
+	ParamKeyID     = "kid"
+	ParamSubject   = "sub"
+	ParamSignature = "sig"
+)
+
+// Signer signs with the keys of a ring, the URLs carry the kid of their key
+// so the previous keys still verify them after a rotation
+type Signer struct {
+	keys *keyring.Ring
+}
+
+func NewSigner(keys *keyring.Ring) *Signer {
+	return &Signer{keys: keys}
+}
+
+// Sign returns the query of the signed path, query is copied with the
+// parameters of the signature. Every parameter is signed, so none of them
+// can be changed or added
+func (s *Signer) Sign(path string, query url.Values, subject string, expiresAt time.Time) url.Values {
+	key := s.keys.Signing(time.Now())
+
+	signed := url.Values{}
+	for name, values := range query {
+		signed[name] = append([]string{}, values...)
+	}
+	signed.Set(ParamExpires, strconv.FormatInt(expiresAt.Unix(), 10))
+	signed.Set(ParamKeyID, key.ID)
+	signed.Set(ParamSubject, subject)
+	signed.Del(ParamSignature)
+
+	signed.Set(ParamSignature, base64.RawURLEncoding.EncodeToString(mac(key.Secret, path, signed)))
+	return signed
+}
+
+// Verify checks the signature and the expiration of the signed path, it
+// returns the user that signed it and when the URL expires
+func (s *Signer) Verify(path string, query url.Values, now time.Time) (string, time.Time, error) {
+	sig, err := base64.RawURLEncoding.DecodeString(query.Get(ParamSignature))
+	if err != nil || len(query[ParamSignature]) != 1 {
+		return "", time.Time{}, ErrInvalid
+	}
+
+	key, ok := s.keys.Lookup(query.Get(ParamKeyID))
+	if !ok {
+		return "", time.Time{}, ErrInvalid
+	}
+
+	signed := url.Values{}
+	for name, values := range query {
+		signed[name] = values
+	}
+	signed.Del(ParamSignature)
+	if !hmac.Equal(sig, mac(key.Secret, path, signed)) {
+		return "", time.Time{}, ErrInvalid
+	}
+
+	exp, err := strconv.ParseInt(query.Get(ParamExpires), 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInvalid
+	}
+
+	expiresAt := time.Unix(exp, 0)
+	if !now.Before(expiresAt) {
+		return "", time.Time{}, ErrExpired
+	}
+
+	return query.Get(ParamSubject), expiresAt, nil
+}
+
+// Strip returns the query without the parameters of the signature, as the
+// handlers of the path expect it
+func Strip(query url.Values) url.Values {
+	stripped := url.Values{}
+	for name, values := range query {
+		switch name {
+		case ParamExpires, ParamKeyID, ParamSubject, ParamSignature:
+		default:
+			stripped[name] = values
+		}
+	}
+
+	return stripped
+}
+
+// mac signs the path and the query sorted by Encode, only the GET requests
+// are signed
+func mac(secret []byte, path string, query url.Values) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("GET\n" + path + "\n" + query.Encode()))
+
+	return h.Sum(nil)
+}
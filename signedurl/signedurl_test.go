@@ -0,0 +1,63 @@
+package signedurl
+
+import (
+	"net/url"
+	"shopping/keyring"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Now()
+	signer := NewSigner(keyring.NewRing(keyring.Key{ID: "k1", Secret: []byte("first-secret")}))
+
+	query := signer.Sign("/v1/lists/1/export", url.Values{"format": {"csv"}}, "alice", now.Add(time.Minute))
+	assert.Equal(t, "k1", query.Get(ParamKeyID))
+	assert.Equal(t, url.Values{"format": {"csv"}}, Strip(query))
+
+	subject, expiresAt, err := signer.Verify("/v1/lists/1/export", query, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", subject)
+	assert.Equal(t, now.Add(time.Minute).Unix(), expiresAt.Unix())
+
+	_, _, err = signer.Verify("/v1/lists/1/export", query, now.Add(2*time.Minute))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	// another path, a changed or added parameter
+	_, _, err = signer.Verify("/v1/lists/2/export", query, now)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	for name, value := range map[string]string{"format": "json", ParamSubject: "admin", ParamExpires: "9999999999", "extra": "1"} {
+		tampered, _ := url.ParseQuery(query.Encode())
+		tampered.Set(name, value)
+		_, _, err = signer.Verify("/v1/lists/1/export", tampered, now)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+
+	_, _, err = signer.Verify("/v1/lists/1/export", url.Values{}, now)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	ring := keyring.NewRing(keyring.Key{ID: "k1", Secret: []byte("first-secret")})
+	signer := NewSigner(ring)
+	old := signer.Sign("/v1/export", nil, "alice", now.Add(time.Minute))
+
+	// the new key signs and the previous one still verifies
+	ring.SetRotated([]keyring.Key{{ID: "k2", Secret: []byte("second-secret")}})
+	_, _, err := signer.Verify("/v1/export", old, now)
+	assert.NoError(t, err)
+
+	fresh := signer.Sign("/v1/export", nil, "alice", now.Add(time.Minute))
+	assert.Equal(t, "k2", fresh.Get(ParamKeyID))
+
+	// until it's removed
+	ring.SetConfigured(nil)
+	_, _, err = signer.Verify("/v1/export", old, now)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, _, err = signer.Verify("/v1/export", fresh, now)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"shopping/config"
+	"shopping/keyring"
+	"shopping/logging"
+	"shopping/render"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// the purposes of the rings the admins can rotate. The account bundles and
+// the webhooks are verified by other parties, their secrets are only
+// rotated in the config
+const (
+	keyPurposeShareLinks = "share_links"
+	keyPurposeSignedURLs = "signed_urls"
+)
+
+type RotateSigningKeysRequest struct {
+	// the purposes to rotate, all of them when empty
+	Purposes []string `json:"purposes"`
+}
+
+type SigningKeyResponse struct {
+	Purpose string `json:"purpose"`
+	// the key of SHARE_LINK_SECRET has no id
+	ID string `json:"id"`
+	// rotated or config
+	Source   string     `json:"source"`
+	ActiveAt *time.Time `json:"active_at,omitempty"`
+	Signing  bool       `json:"signing"`
+}
+
+// newKeyRings reads the keys of the config, a random key is generated for
+// the purposes without one so what they sign stops working after a restart
+func newKeyRings(cfg *config.Config) (map[string]*keyring.Ring, error) {
+	shareLinkKeys, err := keyring.Parse(cfg.ShareLinkKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	shareLinkSecret := cfg.ShareLinkSecret
+	if shareLinkSecret == "" && len(shareLinkKeys) == 0 {
+		log.Warn().Msg("SHARE_LINK_SECRET is empty, the share links will stop working after a restart")
+
+		shareLinkSecret, err = randomSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if shareLinkSecret != "" {
+		shareLinkKeys = append(shareLinkKeys, keyring.Key{Secret: []byte(shareLinkSecret)})
+	}
+
+	signedURLKeys, err := keyring.Parse(cfg.SignedURLKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signedURLKeys) == 0 {
+		log.Warn().Msg("SIGNED_URL_KEYS is empty, the signed URLs will stop working after a restart")
+
+		secret, err := randomSecret()
+		if err != nil {
+			return nil, err
+		}
+		signedURLKeys = []keyring.Key{{ID: "random", Secret: []byte(secret)}}
+	}
+
+	return map[string]*keyring.Ring{
+		keyPurposeShareLinks: keyring.NewRing(shareLinkKeys...),
+		keyPurposeSignedURLs: keyring.NewRing(signedURLKeys...),
+	}, nil
+}
+
+func randomSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// maxSignedLifetime is the longest a purpose signs for, a key replaced for
+// longer than that can't have signed anything still valid
+func (app *App) maxSignedLifetime(purpose string) time.Duration {
+	if purpose == keyPurposeSignedURLs {
+		return app.Config.SignedURLMaxTTL
+	}
+
+	// the photos share the keys of the share links
+	return max(maxShareLinkTTL, app.Config.PhotoURLTTL)
+}
+
+// reloadSigningKeys deletes the retired keys and loads the rotated ones of
+// every purpose, the rings keep their keys when the database fails
+func (app *App) reloadSigningKeys() {
+	if app.SigningKeyRepository == nil {
+		return
+	}
+
+	now := time.Now()
+	for purpose := range app.SigningKeys {
+		deleted, err := app.SigningKeyRepository.DeleteRetiredKeys(purpose, now.Add(-app.maxSignedLifetime(purpose)))
+		if err != nil {
+			log.Err(err).Msgf("error to delete the retired signing keys of %s", purpose)
+		}
+		if deleted > 0 {
+			log.Info().Msgf("%d retired signing keys of %s deleted", deleted, purpose)
+		}
+	}
+
+	rows, err := app.SigningKeyRepository.ListKeys()
+	if err != nil {
+		log.Err(err).Msg("error to load the signing keys, the previous ones are kept")
+		return
+	}
+
+	rotated := map[string][]keyring.Key{}
+	for _, row := range rows {
+		logging.AddSecret(row.Secret)
+		rotated[row.Purpose] = append(rotated[row.Purpose], keyring.Key{ID: row.ID, Secret: []byte(row.Secret), NotBefore: row.ActiveAt.Time})
+	}
+
+	for purpose, ring := range app.SigningKeys {
+		ring.SetRotated(rotated[purpose])
+	}
+}
+
+func (app *App) runSigningKeyRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.reloadSigningKeys()
+		}
+	}
+}
+
+// handleRotateSigningKeys adds a new key to the purposes, the previous keys
+// keep verifying until what they signed has expired. The new keys sign after
+// two refresh intervals, once every instance has loaded them
+func (app *App) handleRotateSigningKeys(w http.ResponseWriter, r *http.Request) {
+	var data RotateSigningKeysRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	purposes := data.Purposes
+	if len(purposes) == 0 {
+		purposes = []string{keyPurposeShareLinks, keyPurposeSignedURLs}
+	}
+	for _, purpose := range purposes {
+		if app.SigningKeys[purpose] == nil {
+			http.Error(w, fmt.Sprintf("the purposes must be %s or %s", keyPurposeShareLinks, keyPurposeSignedURLs), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	now := time.Now()
+	activeAt := now.Add(2 * app.Config.SigningKeysRefreshInterval).Truncate(time.Second)
+	for _, purpose := range slices.Compact(slices.Sorted(slices.Values(purposes))) {
+		key, err := keyring.Generate(now, activeAt)
+		if err != nil {
+			http.Error(w, "error to generate the key", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = app.SigningKeyRepository.AddKey(purpose, key.ID, string(key.Secret), activeAt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info().Msgf("signing key %s of %s added, it signs from %s", key.ID, purpose, activeAt.UTC().Format(time.RFC3339))
+	}
+
+	app.reloadSigningKeys()
+	render.JSON(w, http.StatusCreated, app.signingKeysResponse(now))
+}
+
+// handleListSigningKeys returns the keys of every purpose without their
+// secrets
+func (app *App) handleListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, http.StatusOK, app.signingKeysResponse(time.Now()))
+}
+
+func (app *App) signingKeysResponse(now time.Time) []SigningKeyResponse {
+	keys := []SigningKeyResponse{}
+	for _, purpose := range slices.Sorted(maps.Keys(app.SigningKeys)) {
+		ring := app.SigningKeys[purpose]
+		signing := ring.Signing(now).ID
+
+		for _, key := range ring.Rotated() {
+			activeAt := key.NotBefore.UTC()
+			keys = append(keys, SigningKeyResponse{Purpose: purpose, ID: key.ID, Source: "rotated", ActiveAt: &activeAt, Signing: key.ID == signing})
+		}
+		for _, key := range ring.Configured() {
+			keys = append(keys, SigningKeyResponse{Purpose: purpose, ID: key.ID, Source: "config", Signing: key.ID == signing})
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"shopping/smoke"
+	"time"
+)
+
+// runSmoke runs `shopping smoke`, the scripted checks of a live deployment,
+// and returns the exit code.
+func runSmoke(args []string) int {
+	flags := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	baseURL := flags.String("base-url", "", "URL of the deployment, like https://shopping.example.com")
+	token := flags.String("token", "", "session token, the steps log in with --username and --password when it's empty")
+	username := flags.String("username", "", "user that logs in, it must be able to update and delete lists")
+	password := flags.String("password", "", "password of the user")
+	timeout := flags.Duration("timeout", time.Minute, "timeout of the whole run")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if *baseURL == "" {
+		fmt.Fprintln(os.Stderr, "smoke: --base-url is required")
+		flags.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := smoke.Run(ctx, smoke.Options{
+		BaseURL:  *baseURL,
+		Token:    *token,
+		Username: *username,
+		Password: *password,
+	})
+	report.Print(os.Stdout)
+
+	if !report.Passed() {
+		return 1
+	}
+
+	return 0
+}
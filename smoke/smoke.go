@@ -0,0 +1,339 @@
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options of a smoke run, the token is used when it's set and the steps log
+// in with the username and password otherwise. The user must be able to
+// update and delete lists.
+type Options struct {
+	BaseURL  string
+	Token    string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+type Result struct {
+	Step     string
+	Passed   bool
+	Skipped  bool
+	Message  string
+	Duration time.Duration
+}
+
+type Report struct {
+	Results []Result
+}
+
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed && !result.Skipped {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Print writes a line per step and the summary
+func (r *Report) Print(w io.Writer) {
+	passed, failed, skipped := 0, 0, 0
+	for _, result := range r.Results {
+		status := "PASS"
+		switch {
+		case result.Skipped:
+			status = "SKIP"
+			skipped++
+		case result.Passed:
+			passed++
+		default:
+			status = "FAIL"
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s  %-20s %6dms  %s\n", status, result.Step, result.Duration.Milliseconds(), result.Message)
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+}
+
+// runner keeps the state shared by the steps
+type runner struct {
+	opts   Options
+	client *http.Client
+	token  string
+	listID string
+	etag   string
+}
+
+type step struct {
+	name string
+	run  func(r *runner, ctx context.Context) (string, error)
+}
+
+// steps run in order, a failure skips the rest because they depend on the
+// state left by the previous ones
+var steps = []step{
+	{"login", (*runner).login},
+	{"create list", (*runner).createList},
+	{"get list", (*runner).getList},
+	{"cache headers", (*runner).checkCacheHeaders},
+	{"patch list", (*runner).patchList},
+	{"push item", (*runner).pushItem},
+	{"delete list", (*runner).deleteList},
+	{"list is gone", (*runner).checkDeleted},
+}
+
+// Run runs every step against the deployment, the list created by the run is
+// deleted by the run itself.
+func Run(ctx context.Context, opts Options) *Report {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	r := &runner{
+		opts:   opts,
+		client: client,
+		token:  opts.Token,
+	}
+
+	report := &Report{}
+	failed := false
+	for _, s := range steps {
+		if failed {
+			report.Results = append(report.Results, Result{Step: s.name, Skipped: true, Message: "a previous step failed"})
+			continue
+		}
+
+		start := time.Now()
+		message, err := s.run(r, ctx)
+		result := Result{Step: s.name, Passed: err == nil, Message: message, Duration: time.Since(start)}
+		if err != nil {
+			result.Message = err.Error()
+			failed = true
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	// don't leave the list behind when a step in the middle failed
+	if failed && r.listID != "" {
+		res, err := r.do(ctx, "DELETE", "/v1/lists/"+r.listID, nil, nil)
+		if err == nil {
+			res.Body.Close()
+		}
+	}
+
+	return report
+}
+
+func (r *runner) login(ctx context.Context) (string, error) {
+	if r.opts.Token != "" {
+		return "using the given token", nil
+	}
+
+	if r.opts.Username == "" {
+		return "", fmt.Errorf("a token or a username and password are required")
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	_, err := r.json(ctx, "POST", "/v1/login", map[string]string{"username": r.opts.Username, "password": r.opts.Password}, http.StatusOK, &body)
+	if err != nil {
+		return "", err
+	}
+
+	if body.Token == "" {
+		return "", fmt.Errorf("the response has no token")
+	}
+
+	r.token = body.Token
+	return "logged in as " + r.opts.Username, nil
+}
+
+type list struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Items []string `json:"items"`
+}
+
+func (r *runner) createList(ctx context.Context) (string, error) {
+	name := fmt.Sprintf("smoke test %d", time.Now().UnixNano())
+
+	var created list
+	// rename so an old list of a failed run can't make it fail
+	_, err := r.json(ctx, "POST", "/v1/lists?on_conflict=rename", map[string]any{"name": name, "items": []string{"milk"}}, http.StatusCreated, &created)
+	if err != nil {
+		return "", err
+	}
+
+	if created.ID == "" {
+		return "", fmt.Errorf("the created list has no id")
+	}
+
+	r.listID = created.ID
+	return "created " + created.ID, nil
+}
+
+func (r *runner) getList(ctx context.Context) (string, error) {
+	var got list
+	res, err := r.json(ctx, "GET", "/v1/lists/"+r.listID, nil, http.StatusOK, &got)
+	if err != nil {
+		return "", err
+	}
+
+	if got.ID != r.listID || len(got.Items) != 1 || got.Items[0] != "milk" {
+		return "", fmt.Errorf("unexpected list %+v", got)
+	}
+
+	r.etag = res.Header.Get("ETag")
+	return "", nil
+}
+
+func (r *runner) checkCacheHeaders(ctx context.Context) (string, error) {
+	if r.etag == "" {
+		return "", fmt.Errorf("the list has no ETag")
+	}
+
+	res, err := r.do(ctx, "GET", "/v1/lists/"+r.listID, nil, map[string]string{"If-None-Match": r.etag})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNotModified {
+		return "", fmt.Errorf("expected %d with If-None-Match, got %d", http.StatusNotModified, res.StatusCode)
+	}
+
+	if cc := res.Header.Get("Cache-Control"); cc != "" && !strings.Contains(cc, "no-cache") {
+		return "", fmt.Errorf("the list must be revalidated, got Cache-Control '%s'", cc)
+	}
+
+	return "ETag " + r.etag, nil
+}
+
+func (r *runner) patchList(ctx context.Context) (string, error) {
+	var patched list
+	_, err := r.json(ctx, "PATCH", "/v1/lists/"+r.listID, map[string]any{"items": []string{"milk", "bread"}}, http.StatusOK, &patched)
+	if err != nil {
+		return "", err
+	}
+
+	if len(patched.Items) != 2 {
+		return "", fmt.Errorf("expected 2 items after the patch, got %v", patched.Items)
+	}
+
+	return "", nil
+}
+
+func (r *runner) pushItem(ctx context.Context) (string, error) {
+	var pushed list
+	_, err := r.json(ctx, "POST", "/v1/lists/"+r.listID+"/push", map[string]string{"item": "eggs"}, http.StatusOK, &pushed)
+	if err != nil {
+		return "", err
+	}
+
+	if len(pushed.Items) != 3 || pushed.Items[2] != "eggs" {
+		return "", fmt.Errorf("expected eggs at the end of the list, got %v", pushed.Items)
+	}
+
+	// the cached copy must have been dropped
+	var got list
+	_, err = r.json(ctx, "GET", "/v1/lists/"+r.listID, nil, http.StatusOK, &got)
+	if err != nil {
+		return "", err
+	}
+
+	if len(got.Items) != 3 {
+		return "", fmt.Errorf("the list was served stale after the push: %v", got.Items)
+	}
+
+	return "", nil
+}
+
+func (r *runner) deleteList(ctx context.Context) (string, error) {
+	res, err := r.do(ctx, "DELETE", "/v1/lists/"+r.listID, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return "", fmt.Errorf("expected %d, got %d", http.StatusNoContent, res.StatusCode)
+	}
+
+	return "", nil
+}
+
+func (r *runner) checkDeleted(ctx context.Context) (string, error) {
+	res, err := r.do(ctx, "GET", "/v1/lists/"+r.listID, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("the deleted list is still returned")
+	}
+
+	r.listID = ""
+	return fmt.Sprintf("answered %d", res.StatusCode), nil
+}
+
+// json sends the body as JSON, checks the status and decodes the response
+func (r *runner) json(ctx context.Context, method string, path string, body any, status int, out any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	res, err := r.do(ctx, method, path, reader, map[string]string{"Content-Type": "application/json", "Accept": "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != status {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("%s %s: expected %d, got %d: %s", method, path, status, res.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+
+	return res, nil
+}
+
+func (r *runner) do(ctx context.Context, method string, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.opts.BaseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	return r.client.Do(req)
+}
@@ -0,0 +1,132 @@
+package smoke
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDeployment serves a single list like the API does
+type fakeDeployment struct {
+	mu       sync.Mutex
+	items    []string
+	deleted  bool
+	noETag   bool
+	requests []string
+}
+
+func (d *fakeDeployment) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.requests = append(d.requests, r.Method+" "+r.URL.Path)
+
+	if r.URL.Path == "/v1/login" {
+		json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	respond := func(status int) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(list{ID: "1", Name: "smoke", Items: d.items})
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "POST /v1/lists":
+		d.items = []string{"milk"}
+		respond(http.StatusCreated)
+	case "GET /v1/lists/1":
+		if d.deleted {
+			http.Error(w, "list not found", http.StatusNotFound)
+			return
+		}
+		etag := `"` + strings.Join(d.items, ",") + `"`
+		if !d.noETag {
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		respond(http.StatusOK)
+	case "PATCH /v1/lists/1":
+		var patch list
+		json.NewDecoder(r.Body).Decode(&patch)
+		d.items = patch.Items
+		respond(http.StatusOK)
+	case "POST /v1/lists/1/push":
+		var push struct {
+			Item string `json:"item"`
+		}
+		json.NewDecoder(r.Body).Decode(&push)
+		d.items = append(d.items, push.Item)
+		respond(http.StatusOK)
+	case "DELETE /v1/lists/1":
+		d.deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("passes against a healthy deployment", func(t *testing.T) {
+		server := httptest.NewServer(&fakeDeployment{})
+		defer server.Close()
+
+		report := Run(context.Background(), Options{BaseURL: server.URL, Username: "admin", Password: "password"})
+
+		var out strings.Builder
+		report.Print(&out)
+		assert.True(t, report.Passed(), out.String())
+		assert.Len(t, report.Results, len(steps))
+		assert.Contains(t, out.String(), "8 passed, 0 failed, 0 skipped")
+	})
+
+	t.Run("skips the login with a token", func(t *testing.T) {
+		deployment := &fakeDeployment{}
+		server := httptest.NewServer(deployment)
+		defer server.Close()
+
+		report := Run(context.Background(), Options{BaseURL: server.URL, Token: "secret"})
+
+		assert.True(t, report.Passed())
+		assert.NotContains(t, deployment.requests, "POST /v1/login")
+	})
+
+	t.Run("fails and cleans up when a step fails", func(t *testing.T) {
+		deployment := &fakeDeployment{noETag: true}
+		server := httptest.NewServer(deployment)
+		defer server.Close()
+
+		report := Run(context.Background(), Options{BaseURL: server.URL, Token: "secret"})
+
+		assert.False(t, report.Passed())
+		assert.False(t, report.Results[3].Passed)
+		assert.Equal(t, "the list has no ETag", report.Results[3].Message)
+		assert.True(t, report.Results[4].Skipped)
+		assert.True(t, deployment.deleted)
+	})
+
+	t.Run("fails without credentials", func(t *testing.T) {
+		server := httptest.NewServer(&fakeDeployment{})
+		defer server.Close()
+
+		report := Run(context.Background(), Options{BaseURL: server.URL})
+
+		assert.False(t, report.Passed())
+		assert.False(t, report.Results[0].Passed)
+	})
+}
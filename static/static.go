@@ -0,0 +1,45 @@
+package static
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+)
+
+//go:embed public
+var embedded embed.FS
+
+// FS returns the static files built in the binary, or the files of dir when
+// it's set so a deployment can replace them without a rebuild.
+func FS(dir string) (fs.FS, error) {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrInvalid}
+		}
+
+		return os.DirFS(dir), nil
+	}
+
+	return fs.Sub(embedded, "public")
+}
+
+// Handler serves the files under the prefix. Nothing is fetched from a CDN,
+// so the docs and the widget work in air-gapped deployments.
+func Handler(prefix string, fsys fs.FS, maxAge time.Duration) http.Handler {
+	files := http.StripPrefix(prefix, http.FileServerFS(fsys))
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package static
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerServesEmbeddedFiles(t *testing.T) {
+	fsys, err := FS("")
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	Handler("/static/", fsys, time.Hour).ServeHTTP(rec, httptest.NewRequest("GET", "/static/robots.txt", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+	assert.Contains(t, rec.Body.String(), "Disallow: /v1/")
+}
+
+func TestFSOverride(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logo.svg"), []byte("<svg/>"), 0o644))
+
+	fsys, err := FS(dir)
+	assert.NoError(t, err)
+
+	data, err := fs.ReadFile(fsys, "logo.svg")
+	assert.NoError(t, err)
+	assert.Equal(t, "<svg/>", string(data))
+
+	_, err = FS(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	_, err = FS(filepath.Join(dir, "logo.svg"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/recipe"
+	"shopping/render"
+	"shopping/repository"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxStoreNameLength = 255
+	maxStoreAisles     = 100
+	maxStoreItems      = 1000
+)
+
+type StoreRequest struct {
+	Name    string   `json:"name"`
+	Address string   `json:"address"`
+	Aisles  []string `json:"aisles"`
+	// Items maps the items to their aisle
+	Items map[string]string `json:"items"`
+}
+
+type StoreResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Address   string            `json:"address"`
+	Aisles    []string          `json:"aisles"`
+	Items     map[string]string `json:"items"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type SetListStoreRequest struct {
+	StoreID string `json:"store_id"`
+}
+
+// storeData validates the store of a request, the items are stored by their
+// recipe.Key so "Eggs" also matches "eggs (6)" in a list
+func storeData(data StoreRequest) (repository.StoreData, error) {
+	store := repository.StoreData{
+		Name:       strings.TrimSpace(data.Name),
+		Address:    strings.TrimSpace(data.Address),
+		Aisles:     []string{},
+		ItemAisles: map[string]string{},
+	}
+
+	if store.Name == "" || utf8.RuneCountInString(store.Name) > maxStoreNameLength {
+		return store, fmt.Errorf("'name' is required and can't be longer than %d characters", maxStoreNameLength)
+	}
+	if len(data.Aisles) > maxStoreAisles {
+		return store, fmt.Errorf("a store can't have more than %d aisles", maxStoreAisles)
+	}
+	if len(data.Items) > maxStoreItems {
+		return store, fmt.Errorf("a store can't have more than %d items", maxStoreItems)
+	}
+
+	for _, aisle := range data.Aisles {
+		aisle = strings.TrimSpace(aisle)
+		if aisle == "" || slices.Contains(store.Aisles, aisle) {
+			return store, fmt.Errorf("the aisles must have a name and be different, got '%s'", aisle)
+		}
+		store.Aisles = append(store.Aisles, aisle)
+	}
+
+	for item, aisle := range data.Items {
+		aisle = strings.TrimSpace(aisle)
+		if !slices.Contains(store.Aisles, aisle) {
+			return store, fmt.Errorf("the aisle '%s' of the item '%s' isn't in 'aisles'", aisle, item)
+		}
+
+		key := recipe.Key(item)
+		if key == "" {
+			return store, fmt.Errorf("the item '%s' has no name", item)
+		}
+		store.ItemAisles[key] = aisle
+	}
+
+	return store, nil
+}
+
+func storeResponse(row db_queries.Store) StoreResponse {
+	store := StoreResponse{
+		ID:        row.ID.String(),
+		Name:      row.Name,
+		Address:   row.Address,
+		Aisles:    row.Aisles,
+		Items:     storeItemAisles(row),
+		CreatedAt: row.CreatedAt.Time.UTC(),
+		UpdatedAt: row.UpdatedAt.Time.UTC(),
+	}
+	if store.Aisles == nil {
+		store.Aisles = []string{}
+	}
+
+	return store
+}
+
+// storeItemAisles maps the keys of the items to their aisle
+func storeItemAisles(row db_queries.Store) map[string]string {
+	itemAisles := map[string]string{}
+	// the column is always written from a map, it's a valid object
+	_ = json.Unmarshal(row.ItemAisles, &itemAisles)
+
+	return itemAisles
+}
+
+// orderByAisle sorts the items in the walking order of the aisles of the
+// store, the items of an aisle keep their order and the unknown ones go last
+func orderByAisle(items []string, store db_queries.Store) []string {
+	itemAisles := storeItemAisles(store)
+	rank := func(item string) int {
+		if index := slices.Index(store.Aisles, itemAisles[recipe.Key(item)]); index >= 0 {
+			return index
+		}
+		return len(store.Aisles)
+	}
+
+	ordered := slices.Clone(items)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+
+	return ordered
+}
+
+func (app *App) handleCreateStore(w http.ResponseWriter, r *http.Request) {
+	var data StoreRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	store, err := storeData(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	created, err := app.StoreRepository.CreateStore(currentUser(r).Username, store)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	render.JSON(w, http.StatusCreated, storeResponse(*created))
+}
+
+func (app *App) handleListStores(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.StoreRepository.ListStores(currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	stores := make([]StoreResponse, 0, len(rows))
+	for _, row := range rows {
+		stores = append(stores, storeResponse(row))
+	}
+
+	render.JSON(w, http.StatusOK, stores)
+}
+
+func (app *App) handleGetStore(w http.ResponseWriter, r *http.Request) {
+	store, err := app.StoreRepository.GetStore(r.PathValue("storeID"), currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, storeResponse(*store))
+}
+
+func (app *App) handleUpdateStore(w http.ResponseWriter, r *http.Request) {
+	var data StoreRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	store, err := storeData(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	updated, err := app.StoreRepository.UpdateStore(r.PathValue("storeID"), currentUser(r).Username, store)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	// a store renamed or with other aisles
+	app.invalidatePriceComparisons(currentUser(r).Username)
+
+	render.JSON(w, http.StatusOK, storeResponse(*updated))
+}
+
+func (app *App) handleDeleteStore(w http.ResponseWriter, r *http.Request) {
+	err := app.StoreRepository.DeleteStore(r.PathValue("storeID"), currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	app.invalidatePriceComparisons(currentUser(r).Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetListStore shops the list in one of the stores of the user
+func (app *App) handleSetListStore(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var data SetListStoreRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	// the deleted lists can't be changed
+	_, err = app.ShoppingListRepository.GetShoppingListByID(id)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	store, err := app.StoreRepository.GetStore(data.StoreID, currentUser(r).Username)
+	if err != nil {
+		repositoryError(w, err, "store not found")
+		return
+	}
+
+	err = app.StoreRepository.SetListStore(id, data.StoreID)
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, storeResponse(*store))
+}
+
+func (app *App) handleGetListStore(w http.ResponseWriter, r *http.Request) {
+	store, err := app.StoreRepository.GetListStore(r.PathValue("id"))
+	if err != nil {
+		repositoryError(w, err, "the list has no store")
+		return
+	}
+
+	render.JSON(w, http.StatusOK, storeResponse(*store))
+}
+
+func (app *App) handleUnsetListStore(w http.ResponseWriter, r *http.Request) {
+	err := app.StoreRepository.UnsetListStore(r.PathValue("id"))
+	if err != nil {
+		repositoryError(w, err, "the list has no store")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// orderList applies the ?order of the request to a copy of the list, it
+// writes the error response itself and returns false when it can't
+func (app *App) orderList(w http.ResponseWriter, r *http.Request, list ShoppingListResponse) (ShoppingListResponse, bool) {
+	switch r.URL.Query().Get("order") {
+	case "":
+		return list, true
+	case "aisle":
+	default:
+		http.Error(w, "'order' can only be aisle", http.StatusBadRequest)
+		return list, false
+	}
+
+	store, err := app.StoreRepository.GetListStore(r.PathValue("id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "the list has no store, set it with PUT /v1/lists/{id}/store", http.StatusUnprocessableEntity)
+		return list, false
+	}
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return list, false
+	}
+
+	// orderByAisle copies the items, the ones of the cache are shared
+	list.Items = orderByAisle(list.Items, *store)
+
+	return list, true
+}
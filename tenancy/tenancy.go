@@ -0,0 +1,173 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultID is the tenant created by the migration, the existing users,
+// lists and sessions and the built-in users belong to it
+const DefaultID = "00000000-0000-0000-0000-000000000001"
+
+// DefaultSlug selects the default tenant, it's also the tenant of the
+// requests without subdomain nor header
+const DefaultSlug = "default"
+
+// Default is the tenant of a deployment without multi-tenancy
+var Default = Tenant{ID: DefaultID, Slug: DefaultSlug, Name: "Default"}
+
+// ErrUnknown is returned by the lookup of a slug without tenant
+var ErrUnknown = errors.New("tenancy: unknown tenant")
+
+var validSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidSlug tells if the slug can name a tenant, it's a DNS label
+func ValidSlug(slug string) bool {
+	return validSlug.MatchString(slug)
+}
+
+// Tenant is an organization served by the deployment, its users only see
+// its lists
+type Tenant struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// IsDefault tells if it's the tenant of the instance, the one that keeps the
+// admin routes
+func (t Tenant) IsDefault() bool {
+	return t.ID == DefaultID
+}
+
+type Options struct {
+	// Header carries the slug of the tenant when the host has none, like
+	// for the clients behind a single domain. It's X-Tenant by default
+	Header string
+	// BaseDomain is the domain of the tenants: the slug is the subdomain,
+	// acme.shopping.example.com for shopping.example.com. The subdomain
+	// isn't read when it's empty
+	BaseDomain string
+	// CacheTTL is how long a tenant is remembered, the unknown slugs too
+	CacheTTL time.Duration
+	// Lookup finds the tenant of the slug, it returns ErrUnknown when
+	// there's none
+	Lookup func(slug string) (*Tenant, error)
+}
+
+// Resolver finds the tenant of the requests
+type Resolver struct {
+	opts  Options
+	cache *expirable.LRU[string, *Tenant]
+}
+
+func NewResolver(opts Options) *Resolver {
+	if opts.Header == "" {
+		opts.Header = "X-Tenant"
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = time.Minute
+	}
+	opts.BaseDomain = strings.ToLower(strings.TrimPrefix(opts.BaseDomain, "."))
+
+	return &Resolver{
+		opts:  opts,
+		cache: expirable.NewLRU[string, *Tenant](1024, nil, opts.CacheTTL),
+	}
+}
+
+// Header is the header read for the slug of the tenant
+func (res *Resolver) Header() string {
+	return res.opts.Header
+}
+
+// Slug returns the slug requested by r, the subdomain first and then the
+// header. It's empty when the request names none
+func (res *Resolver) Slug(r *http.Request) string {
+	if res.opts.BaseDomain != "" {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		host = strings.ToLower(host)
+
+		if sub, ok := strings.CutSuffix(host, "."+res.opts.BaseDomain); ok && !strings.Contains(sub, ".") {
+			return sub
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(r.Header.Get(res.opts.Header)))
+}
+
+// Resolve returns the tenant of the slug, the default one for an empty slug
+func (res *Resolver) Resolve(slug string) (*Tenant, error) {
+	if slug == "" || slug == DefaultSlug {
+		return &Default, nil
+	}
+	if !ValidSlug(slug) {
+		return nil, ErrUnknown
+	}
+
+	if tenant, ok := res.cache.Get(slug); ok {
+		if tenant == nil {
+			return nil, ErrUnknown
+		}
+		return tenant, nil
+	}
+
+	tenant, err := res.opts.Lookup(slug)
+	if errors.Is(err, ErrUnknown) {
+		res.cache.Add(slug, nil)
+		return nil, ErrUnknown
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.cache.Add(slug, tenant)
+	return tenant, nil
+}
+
+type contextKey struct{}
+
+// Middleware puts the tenant of the request in its context, the requests
+// for an unknown tenant get a 404 as there's nothing to serve for them
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := res.Resolve(res.Slug(r))
+		if errors.Is(err, ErrUnknown) {
+			http.Error(w, "unknown tenant", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Err(err).Msg("tenancy: error to resolve the tenant")
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), *tenant)))
+	})
+}
+
+// NewContext returns a copy of ctx with the tenant
+func NewContext(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant of the request, the default one out of
+// Middleware
+func FromContext(ctx context.Context) Tenant {
+	tenant, ok := ctx.Value(contextKey{}).(Tenant)
+	if !ok {
+		return Default
+	}
+	return tenant
+}
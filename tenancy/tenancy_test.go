@@ -0,0 +1,72 @@
+package tenancy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	lookups := 0
+	acme := &Tenant{ID: "7f2d3e4a-0000-4000-8000-000000000002", Slug: "acme", Name: "Acme"}
+	resolver := NewResolver(Options{
+		BaseDomain: "shopping.example.com",
+		Lookup: func(slug string) (*Tenant, error) {
+			lookups++
+			if slug == "acme" {
+				return acme, nil
+			}
+			return nil, ErrUnknown
+		},
+	})
+
+	var seen Tenant
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		host   string
+		header string
+		status int
+		tenant Tenant
+	}{
+		{name: "default without subdomain nor header", host: "shopping.example.com", status: 200, tenant: Default},
+		{name: "subdomain", host: "acme.shopping.example.com:8080", status: 200, tenant: *acme},
+		{name: "subdomain before the header", host: "acme.shopping.example.com", header: "default", status: 200, tenant: *acme},
+		{name: "header", host: "localhost:8080", header: "Acme", status: 200, tenant: *acme},
+		{name: "default slug", host: "localhost", header: "default", status: 200, tenant: Default},
+		{name: "unknown subdomain", host: "globex.shopping.example.com", status: 404},
+		{name: "unknown header", host: "localhost", header: "globex", status: 404},
+		{name: "invalid slug", host: "localhost", header: "../acme", status: 404},
+		{name: "nested subdomain is ignored", host: "a.acme.shopping.example.com", status: 200, tenant: Default},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			seen = Tenant{}
+			req := httptest.NewRequest("GET", "/v1/lists", nil)
+			req.Host = tc.host
+			if tc.header != "" {
+				req.Header.Set("X-Tenant", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.tenant, seen)
+		})
+	}
+
+	// acme and globex were looked up once, the unknown slugs are cached too
+	assert.Equal(t, 2, lookups)
+}
+
+func TestFromContextOutOfMiddleware(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	assert.Equal(t, Default, FromContext(req.Context()))
+	assert.True(t, FromContext(req.Context()).IsDefault())
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"shopping/config"
+	"shopping/database"
+	db_queries "shopping/database/queries"
+	"shopping/repository"
+	"shopping/tenancy"
+	"strings"
+)
+
+// lookupTenant finds the tenant of the slug for the resolver
+func (app *App) lookupTenant(slug string) (*tenancy.Tenant, error) {
+	row, err := app.TenantRepository.GetTenantBySlug(slug)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, tenancy.ErrUnknown
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenancy.Tenant{ID: row.ID.String(), Slug: row.Slug, Name: row.Name}, nil
+}
+
+// tenantScope is the tenant of the queries of the request, empty when
+// MULTI_TENANCY is disabled so the queries read every tenant
+func (app *App) tenantScope(r *http.Request) string {
+	if app.TenantResolver == nil {
+		return ""
+	}
+
+	return tenancy.FromContext(r.Context()).ID
+}
+
+// inTenant tells if the data of the tenant can be served to the request, it
+// always can when MULTI_TENANCY is disabled
+func (app *App) inTenant(r *http.Request, tenantID string) bool {
+	scope := app.tenantScope(r)
+	return scope == "" || scope == tenantID
+}
+
+// tenantGuard answers 404 for the lists of another tenant, the route of a
+// list that doesn't exist answers the same so the ids don't leak
+func (app *App) tenantGuard(w http.ResponseWriter, r *http.Request) bool {
+	if app.TenantResolver == nil {
+		return true
+	}
+
+	// the admin routes belong to the operators of the deployment
+	if strings.HasPrefix(r.URL.Path, "/v1/admin/") && !tenancy.FromContext(r.Context()).IsDefault() {
+		http.NotFound(w, r)
+		return false
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		return true
+	}
+
+	tenantID, err := app.ShoppingListRepository.GetShoppingListTenant(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		// the handler answers it
+		return true
+	}
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return false
+	}
+
+	if !app.inTenant(r, tenantID) {
+		http.Error(w, "list not found", http.StatusNotFound)
+		return false
+	}
+
+	return true
+}
+
+// runCreateTenant runs `shopping create-tenant`, the users are added to it
+// with `shopping create-admin --tenant <slug>`.
+func runCreateTenant(args []string) int {
+	flags := flag.NewFlagSet("create-tenant", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+	slug := flags.String("slug", "", "subdomain or X-Tenant value of the tenant, like acme")
+	name := flags.String("name", "", "name of the organization, the slug when empty")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	*slug = strings.ToLower(*slug)
+	if *slug == "" {
+		fmt.Fprintln(os.Stderr, "create-tenant: --slug is required")
+		return 2
+	}
+	if *slug == tenancy.DefaultSlug || !tenancy.ValidSlug(*slug) {
+		fmt.Fprintf(os.Stderr, "create-tenant: '%s' is not a valid slug, use lowercase letters, digits and dashes\n", *slug)
+		return 2
+	}
+	if *name == "" {
+		*name = *slug
+	}
+
+	config := config.SetupConfig(configFlags)
+	dbpool, err := database.NewDB(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create-tenant: cannot connect to the database")
+		return 1
+	}
+	defer dbpool.Close()
+
+	tenants := repository.NewTenantRepository(db_queries.New(dbpool))
+	tenant, err := tenants.CreateTenant(*slug, *name)
+	if errors.Is(err, repository.ErrConflict) {
+		fmt.Fprintf(os.Stderr, "create-tenant: the tenant '%s' already exists\n", *slug)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-tenant: %s\n", err)
+		return 1
+	}
+
+	fmt.Printf("created the tenant '%s' (%s)\n", tenant.Slug, tenant.ID.String())
+	return 0
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// timeoutStats are published in /debug/vars as http_timeouts: the requests
+// answered with a 504 by route
+var timeoutStats = expvar.NewMap("http_timeouts")
+
+// requestTimeoutNone disables the timeout of the routes that stay open, like
+// the event streams
+const requestTimeoutNone time.Duration = -1
+
+// withTimeout answers 504 when the handler takes longer than the timeout of
+// the route, or REQUEST_TIMEOUT when it's 0. The context of the request is
+// canceled, so the queries that use it stop. The response is buffered until
+// the handler returns, like http.TimeoutHandler does.
+func (app *App) withTimeout(route Route, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeout := route.Timeout
+		if timeout == 0 {
+			if settings := app.settings(); settings != nil {
+				timeout = settings.RequestTimeout
+			}
+		}
+		if timeout <= 0 {
+			next(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: http.Header{}}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			// the recovery middleware answers it
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			maps.Copy(w.Header(), tw.header)
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+			timeoutStats.Add(route.Method+" "+route.Path, 1)
+			log.Warn().Msgf("the request %s %s timed out after %s", r.Method, r.URL.Path, timeout)
+			http.Error(w, "the request timed out", http.StatusGatewayTimeout)
+		}
+	}
+}
+
+// timeoutWriter keeps the response until the handler returns, the writes
+// after the timeout fail with http.ErrHandlerTimeout
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+
+	return tw.body.Write(data)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
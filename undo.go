@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db_queries "shopping/database/queries"
+	"shopping/render"
+	"shopping/repository"
+	"time"
+)
+
+// the type of the audit and outbox events of the undone changes
+const eventListUndone = "list.undone"
+
+// errUndoForbidden is returned when the last change of the list was made by
+// another user, only the admins can undo it
+var errUndoForbidden = errors.New("the last change of the list was made by another user")
+
+// handleUndoList reverts the last change of the list when it's recent. The
+// audit log is the journal of the changes: the state before the change is
+// the list of the previous event, so the undo is written in the same unit of
+// work that reads them.
+func (app *App) handleUndoList(w http.ResponseWriter, r *http.Request) {
+	window := app.Config.UndoWindow
+	if window <= 0 {
+		http.Error(w, "the undo is disabled, set UNDO_WINDOW", http.StatusNotFound)
+		return
+	}
+
+	id := r.PathValue("id")
+	user := currentUser(r)
+
+	list, err := app.writeList(user.Username, eventListUndone, id, func(repos repository.Repositories) (*db_queries.ShoppingList, error) {
+		events, err := repos.Audit.Last("list", id, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		return undoListChange(repos, user, id, events, window)
+	})
+	if errors.Is(err, errUndoForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		repositoryError(w, err, "list not found")
+		return
+	}
+
+	// the undone creation deletes the list
+	if list == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, newShoppingListResponse(*list))
+}
+
+// undoListChange reverts the first of the events, the last ones first
+func undoListChange(repos repository.Repositories, user *User, id string, events []db_queries.AuditEvent, window time.Duration) (*db_queries.ShoppingList, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("the list has no changes to undo: %w", repository.ErrConflict)
+	}
+
+	last := events[0]
+	if last.Action == eventListUndone {
+		return nil, fmt.Errorf("the last change of the list was already undone: %w", repository.ErrConflict)
+	}
+	if time.Since(last.CreatedAt.Time) > window {
+		return nil, fmt.Errorf("the last change of the list is older than %s and can't be undone: %w", window, repository.ErrConflict)
+	}
+	if last.Actor != user.Username && user.Role != "admin" {
+		return nil, errUndoForbidden
+	}
+
+	switch last.Action {
+	case eventListDeleted:
+		return repos.ShoppingLists.RestoreShoppingList(id)
+	case eventListCreated:
+		return nil, repos.ShoppingLists.DeleteShoppingListByID(id, user.Username)
+	case eventListUpdated, eventListItemAdded, eventListItemRemoved, eventListItemRenamed:
+		var previous ListEvent
+		if len(events) > 1 {
+			err := json.Unmarshal(events[1].Data, &previous)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// the lists changed before the audit log have no previous state
+		if previous.List == nil {
+			return nil, fmt.Errorf("the state of the list before the last change is unknown: %w", repository.ErrConflict)
+		}
+
+		return repos.ShoppingLists.UpdateShoppingListByID(id, previous.List.Name, previous.List.Items)
+	default:
+		return nil, fmt.Errorf("the last change of the list (%s) can't be undone: %w", last.Action, repository.ErrConflict)
+	}
+}
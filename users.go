@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"shopping/config"
+	"shopping/database"
+	db_queries "shopping/database/queries"
+	"shopping/passwords"
+	"shopping/repository"
+	"shopping/tenancy"
+	"strings"
+	"sync"
+)
+
+// findUser returns the built-in user or the one created in the database with
+// `shopping create-admin`, nil when there's none.
+func (app *App) findUser(username string) (*User, error) {
+	if user := allUsers[username]; user != nil {
+		return user, nil
+	}
+
+	if app.UserRepository == nil {
+		return nil, nil
+	}
+
+	row, err := app.UserRepository.GetUserByUsername(username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{Role: row.Role, Username: row.Username, TenantID: row.TenantID.String()}, nil
+}
+
+// checkCredentials returns the user when the password is right, nil
+// otherwise. It takes the same time whether the user exists or not, so the
+// usernames can't be found by timing the logins.
+func (app *App) checkCredentials(username string, password string) (*User, error) {
+	if user := allUsers[username]; user != nil {
+		expected := sha256.Sum256([]byte(user.Password))
+		given := sha256.Sum256([]byte(password))
+		if subtle.ConstantTimeCompare(expected[:], given[:]) != 1 {
+			return nil, nil
+		}
+		return user, nil
+	}
+
+	if app.UserRepository == nil {
+		return nil, nil
+	}
+
+	row, err := app.UserRepository.GetUserByUsername(username)
+	if errors.Is(err, repository.ErrNotFound) {
+		passwords.Verify(dummyPasswordHash(), password)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := passwords.Verify(row.Password, password)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &User{Role: row.Role, Username: row.Username, TenantID: row.TenantID.String()}, nil
+}
+
+// dummyPasswordHash is verified for the unknown users, it has the cost of
+// the real hashes
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := passwords.Hash("dummy password of the unknown users")
+	return hash
+})
+
+// newPasswordPolicy reads PASSWORD_BLOCKLIST_FILE, the passwords of the
+// built-in list are always refused
+func newPasswordPolicy(cfg *config.Config) (*passwords.Policy, error) {
+	blocklist := []string{}
+	if cfg.PasswordBlocklistFile != "" {
+		file, err := os.Open(cfg.PasswordBlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		blocklist, err = passwords.ReadBlocklist(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return passwords.NewPolicy(cfg.PasswordMinLength, cfg.PasswordMinScore, blocklist), nil
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// handleChangePassword replaces the password of a user of the database and
+// logs out the other devices, the new one must follow the password policy
+func (app *App) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	var data ChangePasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "invalid data", http.StatusBadRequest)
+		return
+	}
+
+	if allUsers[user.Username] != nil {
+		http.Error(w, "the built-in users have a fixed password", http.StatusConflict)
+		return
+	}
+
+	current, err := app.checkCredentials(user.Username, data.CurrentPassword)
+	if err != nil {
+		http.Error(w, "error to check the credentials", http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "'current_password' is wrong", http.StatusForbidden)
+		return
+	}
+
+	var policyErr *passwords.PolicyError
+	err = app.PasswordPolicy.Check(data.NewPassword, user.Username)
+	if errors.As(err, &policyErr) {
+		http.Error(w, policyErr.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	hash, err := passwords.Hash(data.NewPassword)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = app.UserRepository.UpdatePassword(user.Username, hash)
+	if err != nil {
+		repositoryError(w, err, "user not found")
+		return
+	}
+
+	// whoever had the old password is logged out
+	token, _ := sessionToken(r)
+	err = app.SessionRepository.DeleteOtherSessions(user.Username, token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runCreateAdmin runs `shopping create-admin`, the password is read from the
+// standard input when the flag is empty so it doesn't end in the history of
+// the shell.
+func runCreateAdmin(args []string) int {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	configFlags := config.AddFlags(flags)
+	username := flags.String("username", "", "username of the admin")
+	password := flags.String("password", "", "password of the admin, read from the standard input when empty")
+	tenantSlug := flags.String("tenant", "", "slug of the tenant of the admin, the default tenant when empty")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "create-admin: --username is required")
+		return 2
+	}
+
+	if allUsers[*username] != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: '%s' is a built-in user\n", *username)
+		return 1
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintf(os.Stderr, "create-admin: %s\n", err)
+			return 1
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	config := config.SetupConfig(configFlags)
+	policy, err := newPasswordPolicy(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: error to read PASSWORD_BLOCKLIST_FILE: %s\n", err)
+		return 1
+	}
+
+	err = policy.Check(*password, *username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %s\n", err)
+		return 1
+	}
+
+	hash, err := passwords.Hash(*password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %s\n", err)
+		return 1
+	}
+
+	dbpool, err := database.NewDB(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create-admin: cannot connect to the database")
+		return 1
+	}
+	defer dbpool.Close()
+
+	dbQueries := db_queries.New(dbpool)
+
+	tenantID := ""
+	if *tenantSlug != "" && *tenantSlug != tenancy.DefaultSlug {
+		tenant, err := repository.NewTenantRepository(dbQueries).GetTenantBySlug(*tenantSlug)
+		if errors.Is(err, repository.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "create-admin: the tenant '%s' doesn't exist, create it with `shopping create-tenant`\n", *tenantSlug)
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "create-admin: %s\n", err)
+			return 1
+		}
+		tenantID = tenant.ID.String()
+	}
+
+	users := repository.NewUserRepository(dbQueries)
+	_, err = users.CreateUser(*username, "admin", hash, tenantID)
+	if errors.Is(err, repository.ErrConflict) {
+		fmt.Fprintf(os.Stderr, "create-admin: the user '%s' already exists\n", *username)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create-admin: %s\n", err)
+		return 1
+	}
+
+	fmt.Printf("created the admin '%s'\n", *username)
+	return 0
+}